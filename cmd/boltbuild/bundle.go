@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"boltbuild/pkg/protocol"
+)
+
+// runBundle implements "boltbuild bundle export|import"
+func runBundle(args []string) {
+	usage := func() {
+		fmt.Println("Usage: boltbuild bundle export [--client URL] [--source archive|disk] [-o file] <build-id>")
+		fmt.Println("       boltbuild bundle import [--client URL] [--env name] [--server host:port] [--out dir] <file.boltbuild>")
+		os.Exit(1)
+	}
+	if len(args) < 1 {
+		usage()
+	}
+
+	var err error
+	switch args[0] {
+	case "export":
+		err = exportBundle(args[1:])
+	case "import":
+		err = importBundle(args[1:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportBundle downloads a build of a client's history as a .boltbuild archive
+func exportBundle(args []string) error {
+	flags := flag.NewFlagSet("bundle export", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	source := flags.String("source", "", "Take the files from the build's archive or the project directory on disk (default: archive if the files were archived)")
+	output := flags.String("o", "", "File to write (default: <build-id>.boltbuild)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one build ID")
+	}
+	buildID := flags.Arg(0)
+	if *output == "" {
+		*output = buildID + ".boltbuild"
+	}
+
+	base := strings.TrimRight(*clientURL, "/")
+	useCLIAPIKey(base)
+	query := url.Values{}
+	if *source != "" {
+		query.Set("source", *source)
+	}
+	resp, err := http.Get(base + "/api/v1/builds/" + url.PathEscape(buildID) + "/export?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(message)))
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported build %s to %s\n", buildID, *output)
+	return nil
+}
+
+// importBundle submits a .boltbuild archive through a client and saves the returned artifacts
+func importBundle(args []string) error {
+	flags := flag.NewFlagSet("bundle import", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	environment := flags.String("env", "", "Build environment (default: the bundle's)")
+	server := flags.String("server", "", "Build server address (default: any available server)")
+	out := flags.String("out", "", "Directory to save artifacts into (default: do not save them)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one .boltbuild file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	base := strings.TrimRight(*clientURL, "/")
+	useCLIAPIKey(base)
+	query := url.Values{}
+	query.Set("environment", *environment)
+	query.Set("server", *server)
+	resp, err := http.Post(base+"/api/v1/build/bundle?"+query.Encode(), "application/gzip", file)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("build rejected: %s", strings.TrimSpace(string(message)))
+	}
+	var response protocol.BuildResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+
+	fmt.Print(response.Output)
+	for _, warning := range response.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if *out != "" {
+		saved, err := saveRemoteArtifacts(&response, *out)
+		for _, target := range saved {
+			fmt.Printf("Saved %s\n", target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := response.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("Build %s succeeded in %v\n", response.ID, response.Duration)
+	return nil
+}
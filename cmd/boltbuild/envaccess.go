@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+
+	"boltbuild/pkg/client"
+)
+
+// apiKeyEnv holds the API key the command line tools send to the client
+const apiKeyEnv = "BOLTBUILD_API_KEY"
+
+// apiKeyTransport adds the API key to the command line tools' requests to the client
+type apiKeyTransport struct {
+	host string
+	key  string
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request with the API key when it goes to the client
+func (t *apiKeyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Host == t.host {
+		r = r.Clone(r.Context())
+		r.Header.Set(client.APIKeyHeader, t.key)
+	}
+	return t.next.RoundTrip(r)
+}
+
+// useCLIAPIKey makes the command line tools send the key of $BOLTBUILD_API_KEY to the client at base
+func useCLIAPIKey(base string) {
+	key := os.Getenv(apiKeyEnv)
+	target, err := url.Parse(base)
+	if key == "" || err != nil {
+		return
+	}
+	http.DefaultClient.Transport = &apiKeyTransport{host: target.Host, key: key, next: http.DefaultTransport}
+}
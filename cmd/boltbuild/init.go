@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"boltbuild/pkg/client"
+	"boltbuild/pkg/config"
+)
+
+// environmentTemplates are ready-made environment definitions used by "boltbuild init"
+var environmentTemplates = map[string]config.BuildEnvironment{
+	"go": {
+		Name:         "go",
+		Command:      "go build -trimpath -o bin/ ./...",
+		ProjectDir:   ".",
+		ExecutionDir: ".",
+		OutputPaths:  []string{"./bin/*"},
+		EnvVars:      map[string]string{"CGO_ENABLED": "0"},
+	},
+	"cmake-ninja": {
+		Name:               "cmake-ninja",
+		Command:            "ctest --build-and-test . build --build-generator Ninja --build-options -DCMAKE_BUILD_TYPE=Release",
+		ProjectDir:         ".",
+		ExecutionDir:       ".",
+		OutputPaths:        []string{"./build/*"}, // Top-level build outputs (executables have no extension on Unix)
+		AlwaysCollectPaths: []string{"./build/CMakeFiles/CMakeError.log", "./build/CMakeFiles/CMakeOutput.log", "./build/CMakeFiles/CMakeConfigureLog.yaml"},
+	},
+	"msbuild": {
+		Name:         "msbuild",
+		Command:      "msbuild -restore -m -p:Configuration=Release",
+		ProjectDir:   ".",
+		ExecutionDir: ".",
+		OutputPaths:  []string{"./bin/Release/*", "./bin/Release/*/*"},
+	},
+	"cargo": {
+		Name:         "cargo",
+		Command:      "cargo build --release",
+		ProjectDir:   ".",
+		ExecutionDir: ".",
+		OutputPaths:  []string{"./target/release/*"},
+	},
+	"npm": {
+		Name:         "npm",
+		Command:      "npm run build",
+		ProjectDir:   ".",
+		ExecutionDir: ".",
+		OutputPaths:  []string{"./dist/*", "./build/*"},
+		EnvVars:      map[string]string{"NODE_ENV": "production"},
+	},
+}
+
+// templatesForKind maps detected project kinds to the template that builds them
+var templatesForKind = map[string]string{
+	"go":     "go",
+	"cmake":  "cmake-ninja",
+	"dotnet": "msbuild",
+	"rust":   "cargo",
+	"node":   "npm",
+}
+
+// runInit scaffolds a configuration file with built-in environment templates
+func runInit(args []string) {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	templates := flags.String("templates", "", "Comma separated templates to include (default: detected from the current directory, or all)")
+	force := flags.Bool("force", false, "Overwrite an existing configuration file")
+	list := flags.Bool("list", false, "List available templates and exit")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild init [--templates go,cargo] [--force] [config.yaml]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	names := templateNames()
+	if *list {
+		for _, name := range names {
+			fmt.Printf("  %-12s %s\n", name, environmentTemplates[name].Command)
+		}
+		return
+	}
+
+	configPath := "config.yaml"
+	if flags.NArg() > 0 {
+		configPath = flags.Arg(0)
+	}
+
+	if _, err := os.Stat(configPath); err == nil && !*force {
+		fmt.Printf("%s already exists, use --force to overwrite it\n", configPath)
+		os.Exit(1)
+	}
+
+	// Pick templates: explicit list, detected project kinds, or everything
+	var selected []string
+	if *templates != "" {
+		for _, name := range strings.Split(*templates, ",") {
+			name = strings.TrimSpace(name)
+			if _, exists := environmentTemplates[name]; !exists {
+				fmt.Printf("Unknown template: %s (available: %s)\n", name, strings.Join(names, ", "))
+				os.Exit(1)
+			}
+			selected = append(selected, name)
+		}
+	} else {
+		detected, _ := client.DetectProjectKinds(".")
+		for _, found := range detected {
+			if name, exists := templatesForKind[found.Kind]; exists {
+				selected = append(selected, name)
+			}
+		}
+		if len(selected) == 0 {
+			selected = names
+		}
+	}
+
+	cfg := config.Default()
+	for _, name := range selected {
+		cfg.Build.Environments[name] = environmentTemplates[name]
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("Generated configuration is invalid: %v\n", err)
+		os.Exit(1)
+	}
+	if err := config.Save(cfg, configPath); err != nil {
+		fmt.Printf("Failed to write %s: %v\n", configPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Created %s with environments: %s\n", configPath, strings.Join(selected, ", "))
+}
+
+// templateNames returns the sorted names of all built-in templates
+func templateNames() []string {
+	names := make([]string, 0, len(environmentTemplates))
+	for name := range environmentTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
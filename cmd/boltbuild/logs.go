@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"boltbuild/pkg/client"
+)
+
+// runLogs implements "boltbuild logs", printing the log of a build from a running client
+func runLogs(args []string) {
+	flags := flag.NewFlagSet("logs", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	follow := flags.Bool("f", false, "Stream the output of a running build until it finishes (exits 1 if it failed)")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild logs [--client URL] [-f] <build-id>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	// Flags may also follow the build ID
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	buildID := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	base := strings.TrimRight(*clientURL, "/")
+	useCLIAPIKey(base)
+	var err error
+	if *follow {
+		err = followBuildLog(base, buildID)
+	} else {
+		err = printBuildLog(base, buildID)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printBuildLog prints the stored log of a finished build
+func printBuildLog(base, buildID string) error {
+	resp, err := http.Get(base + "/api/v1/builds/" + url.PathEscape(buildID) + "/log")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Running builds have no stored log yet
+		if state, err := buildState(base, buildID); err == nil && state == "running" {
+			return fmt.Errorf("build %s is still running, use -f to follow its output", buildID)
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+// buildState asks the client whether a build is running, succeeded or failed
+func buildState(base, buildID string) (string, error) {
+	var response struct {
+		Result struct {
+			State string `json:"state"`
+		} `json:"result"`
+		Error *client.RPCError `json:"error"`
+	}
+	if err := callRPC(base, "build.status", buildID, func(decoder *json.Decoder) error {
+		return decoder.Decode(&response)
+	}); err != nil {
+		return "", err
+	}
+	if response.Error != nil {
+		return "", fmt.Errorf("%s", response.Error.Message)
+	}
+	return response.Result.State, nil
+}
+
+// followBuildLog streams the output of a build through the build.subscribe RPC until it
+// finishes; finished builds print their stored log
+func followBuildLog(base, buildID string) error {
+	success, failure, err := subscribeBuildLog(base, buildID)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("build %s failed: %s", buildID, failure)
+	}
+	return nil
+}
+
+// subscribeBuildLog prints the output of a build as it arrives until the build finishes and
+// returns whether it succeeded, and its error if not
+func subscribeBuildLog(base, buildID string) (bool, string, error) {
+	var success bool
+	var failure string
+	err := callRPC(base, "build.subscribe", buildID, func(decoder *json.Decoder) error {
+		for {
+			var message struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+				Params struct {
+					Data    string `json:"data"`
+					Success bool   `json:"success"`
+					Error   string `json:"error"`
+				} `json:"params"`
+				Result struct {
+					Success bool `json:"success"`
+				} `json:"result"`
+				Error *client.RPCError `json:"error"`
+			}
+			if err := decoder.Decode(&message); err != nil {
+				if err == io.EOF {
+					return fmt.Errorf("connection to the client closed before build %s finished", buildID)
+				}
+				return err
+			}
+
+			switch {
+			case message.Error != nil:
+				return fmt.Errorf("%s", message.Error.Message)
+			case message.Method == "build.log":
+				fmt.Print(message.Params.Data)
+			case message.Method == "build.finished":
+				success, failure = message.Params.Success, message.Params.Error
+				if !success {
+					return nil
+				}
+			case len(message.ID) > 0:
+				success = message.Result.Success
+				return nil
+			}
+		}
+	})
+	return success, failure, err
+}
+
+// callRPC sends a JSON-RPC request about a build to the client and hands the response stream to read
+func callRPC(base, method, buildID string, read func(decoder *json.Decoder) error) error {
+	body, err := json.Marshal(client.RPCRequest{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage("1"),
+		Method:  method,
+		Params:  json.RawMessage(fmt.Sprintf(`{"build_id":%q}`, buildID)),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(base+"/rpc", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("client returned %s", resp.Status)
+	}
+	return read(json.NewDecoder(resp.Body))
+}
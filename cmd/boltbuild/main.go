@@ -0,0 +1,227 @@
+// Command boltbuild runs a build server, client coordinator or relay, and provides the command
+// line tools that submit builds to them. Run it without arguments for usage.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/relay"
+	"boltbuild/pkg/client"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+	"boltbuild/pkg/server"
+)
+
+func main() {
+	// Started by "boltbuild make" as the recipe shell
+	if isMakeShell() {
+		runMakeShell(os.Args[1], os.Args[2])
+		return
+	}
+
+	// Simple argument parsing
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// Commands that do not need a loaded configuration
+	switch os.Args[1] {
+	case "init":
+		runInit(os.Args[2:])
+		return
+	case "remote":
+		runRemote(os.Args[2:])
+		return
+	case "make":
+		runMake(os.Args[2:])
+		return
+	case "ninja-exec":
+		runNinjaExec(os.Args[2:])
+		return
+	case "logs":
+		runLogs(os.Args[2:])
+		return
+	case "replay":
+		runReplay(os.Args[2:])
+		return
+	case "bundle":
+		runBundle(os.Args[2:])
+		return
+	case "simulate":
+		runSimulate(os.Args[2:])
+		return
+	}
+
+	// Options of the server command come before the configuration file
+	args := os.Args[2:]
+	instances := 0
+	if os.Args[1] == "server" {
+		flags := flag.NewFlagSet("server", flag.ExitOnError)
+		flags.IntVar(&instances, "instances", 0, "Logical servers sharing the capacity (overrides server.instances)")
+		flags.Parse(args)
+		args = flags.Args()
+	}
+
+	// Load configuration
+	configPath := "config.yaml"
+	if len(args) > 0 {
+		configPath = args[0]
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if instances > 0 {
+		cfg.Server.Instances = instances
+	}
+
+	// Initialize logger with config
+	logging.Initialize(cfg)
+	logging.Infof("Configuration loaded from %s", configPath)
+
+	// Setup signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	mode := os.Args[1]
+	switch mode {
+	case "server":
+		runServer(cfg, sigChan)
+	case "client":
+		runClient(cfg, sigChan)
+	case "relay":
+		runRelay(cfg, sigChan)
+	case "projects":
+		runProjects(cfg)
+	default:
+		fmt.Printf("Invalid mode: %s\n", mode)
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// printUsage prints the command line help
+func printUsage() {
+	fmt.Println("Usage: boltbuild <command> [options] [config.yaml]")
+	fmt.Println("  server - Start build server (--instances N splits the machine into N logical servers)")
+	fmt.Println("  client - Start build client with web interface")
+	fmt.Println("  relay  - Start a relay that forwards build traffic between clients and servers in other networks")
+	fmt.Println("  init   - Create a configuration file with environment templates")
+	fmt.Println("  projects - List the configured projects")
+	fmt.Println("  remote - Build through a client running on another machine (see boltbuild remote --help)")
+	fmt.Println("  make   - Run make with compile steps dispatched to build servers (see boltbuild make --help)")
+	fmt.Println("  ninja-exec - Run a single Ninja action on a build server (see boltbuild ninja-exec --help)")
+	fmt.Println("  logs   - Print the log of a build, -f follows a running build (see boltbuild logs --help)")
+	fmt.Println("  replay - Run a build of the history again, e.g. to reproduce a failure (see boltbuild replay --help)")
+	fmt.Println("  bundle - Export a build as a .boltbuild archive or import one to build it (see boltbuild bundle)")
+	fmt.Println("  simulate - Load-test the farm through a client and report throughput (see boltbuild simulate --help)")
+	fmt.Println("  config.yaml - Optional path to configuration file (default: config.yaml)")
+}
+
+// runServer starts a build server that accepts client connections
+func runServer(cfg *config.Config, sigChan chan os.Signal) {
+	logging.Eventf(logging.EventInfo, "Starting BoltBuild %s - Server Mode", protocol.Version)
+	if cfg.WANMode {
+		if err := cfg.ValidateWAN(config.WANRoleServer); err != nil {
+			logging.Fatalf("WAN mode: %v", err)
+		}
+	}
+	if cfg.Server.Capacity == 0 {
+		cfg.Server.Capacity = server.AutoCapacity(cfg.Server.AutoCapacity)
+	}
+
+	// Create the servers (build workers), one unless the machine is split into several instances
+	servers, err := server.NewInstances(cfg, cfg.Server.Instances)
+	if err != nil {
+		logging.Fatalf("Server failed: %v", err)
+	}
+	for _, instance := range servers {
+		logging.Infof("Build server %s will listen on port %d with capacity %d", instance.ID(), instance.Port(), instance.Capacity())
+		go func(instance *server.Server) {
+			if err := instance.Start(); err != nil {
+				logging.Fatalf("Server failed: %v", err)
+			}
+		}(instance)
+	}
+
+	// Start the status endpoint of the first instance if configured
+	if port := cfg.Server.AdminPort; port != 0 {
+		go func() {
+			if err := servers[0].StartAdmin(port); err != nil {
+				logging.Fatalf("Server admin endpoint failed: %v", err)
+			}
+		}()
+	}
+
+	// Wait for shutdown signal
+	<-sigChan
+	logging.Eventf(logging.EventInfo, "Shutting down server...")
+}
+
+// runRelay starts a relay that clients and servers connect out to
+func runRelay(cfg *config.Config, sigChan chan os.Signal) {
+	logging.Eventf(logging.EventInfo, "Starting BoltBuild %s - Relay Mode", protocol.Version)
+	if cfg.WANMode {
+		if err := cfg.ValidateWAN(config.WANRoleRelay); err != nil {
+			logging.Fatalf("WAN mode: %v", err)
+		}
+	}
+
+	if len(cfg.Relay.Tenants) == 0 {
+		logging.Fatalf("No relay tenants configured")
+	}
+
+	forwarder := relay.New(cfg.Relay, cfg.TLS)
+	go func() {
+		if err := forwarder.Start(); err != nil {
+			logging.Fatalf("Relay failed: %v", err)
+		}
+	}()
+
+	// Wait for shutdown signal
+	<-sigChan
+	logging.Eventf(logging.EventInfo, "Shutting down relay...")
+}
+
+// runClient starts a client with web interface that discovers and connects to servers
+func runClient(cfg *config.Config, sigChan chan os.Signal) {
+	logging.Eventf(logging.EventInfo, "Starting BoltBuild %s - Client Mode", protocol.Version)
+	if cfg.WANMode {
+		if err := cfg.ValidateWAN(config.WANRoleClient); err != nil {
+			logging.Fatalf("WAN mode: %v", err)
+		}
+	}
+
+	// Create client (build coordinator)
+	coordinator := client.NewCoordinator(cfg)
+
+	// Create web server
+	webServer := client.NewWebServer(coordinator, cfg.Web.Port)
+
+	// Start web server in goroutine
+	go func() {
+		if err := webServer.Start(); err != nil {
+			logging.Fatalf("Web server failed: %v", err)
+		}
+	}()
+
+	// Start client in goroutine
+	go func() {
+		if err := coordinator.Start(); err != nil {
+			logging.Fatalf("Client failed: %v", err)
+		}
+	}()
+
+	// Wait for shutdown signal
+	<-sigChan
+	logging.Eventf(logging.EventInfo, "Shutting down client...")
+}
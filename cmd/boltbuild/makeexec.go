@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Environment variables handing the "boltbuild make" settings to the recipe shell
+const (
+	makeShellEnv  = "BOLTBUILD_MAKE_SHELL"
+	makeClientEnv = "BOLTBUILD_MAKE_CLIENT"
+	makeEnvEnv    = "BOLTBUILD_MAKE_ENV"
+	makeRootEnv   = "BOLTBUILD_MAKE_ROOT"
+	makeLocalEnv  = "BOLTBUILD_MAKE_LOCAL"
+	makeRemoteEnv = "BOLTBUILD_MAKE_REMOTE"
+)
+
+// runMake implements "boltbuild make": runs make with its recipes dispatched to the build farm
+func runMake(args []string) {
+	flags := flag.NewFlagSet("make", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	environment := flags.String("env", "", "Environment used for remote recipes (must set allow_command_override)")
+	root := flags.String("root", ".", "Directory uploaded with every remote recipe")
+	jobs := flags.Int("j", 0, "Parallel jobs (default: available remote capacity, ignored inside a parent make)")
+	var local, remote stringList
+	flags.Var(&local, "local", "Regular expression for recipes that always run locally (repeatable)")
+	flags.Var(&remote, "remote", "Regular expression for recipes that always run remotely (repeatable)")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild make --env X [--client URL] [--root DIR] [-j N] [-- make arguments]")
+		fmt.Println("Compile steps (-c ... -o file) are built remotely, link and other steps locally.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *environment == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	useCLIAPIKey(*clientURL)
+
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	rootDir, err := filepath.Abs(*root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	makeArgs := []string{"SHELL=" + self}
+
+	// Inside a parent make the jobserver from MAKEFLAGS already limits parallelism
+	if !strings.Contains(os.Getenv("MAKEFLAGS"), "jobserver-auth") {
+		if *jobs <= 0 {
+			*jobs = remoteCapacity(*clientURL)
+		}
+		makeArgs = append(makeArgs, "-j"+strconv.Itoa(*jobs))
+	}
+	makeArgs = append(makeArgs, flags.Args()...)
+
+	cmd := exec.Command("make", makeArgs...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(),
+		makeShellEnv+"=1",
+		makeClientEnv+"="+strings.TrimRight(*clientURL, "/"),
+		makeEnvEnv+"="+*environment,
+		makeRootEnv+"="+rootDir,
+		makeLocalEnv+"="+strings.Join(local, "\n"),
+		makeRemoteEnv+"="+strings.Join(remote, "\n"),
+	)
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// remoteCapacity sums the capacity of available servers, with the local CPU count as lower bound
+func remoteCapacity(clientURL string) int {
+	capacity := 0
+
+	resp, err := http.Get(strings.TrimRight(clientURL, "/") + "/api/v1/servers")
+	if err == nil {
+		defer resp.Body.Close()
+		var servers map[string]struct {
+			Capacity  int  `json:"capacity"`
+			Available bool `json:"available"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&servers) == nil {
+			for _, server := range servers {
+				if server.Available {
+					capacity += server.Capacity
+				}
+			}
+		}
+	}
+
+	if capacity < runtime.NumCPU() {
+		capacity = runtime.NumCPU()
+	}
+	return capacity
+}
+
+// isMakeShell reports whether boltbuild was started by make as the recipe shell
+func isMakeShell() bool {
+	return os.Getenv(makeShellEnv) != "" && len(os.Args) >= 3 && strings.HasPrefix(os.Args[1], "-") && strings.Contains(os.Args[1], "c")
+}
+
+// runMakeShell runs a single make recipe locally or on the build farm
+func runMakeShell(shellFlags, recipe string) {
+	executor := &remoteExecutor{
+		client:      os.Getenv(makeClientEnv),
+		environment: os.Getenv(makeEnvEnv),
+		root:        os.Getenv(makeRootEnv),
+		local:       splitPatterns(os.Getenv(makeLocalEnv)),
+		remote:      splitPatterns(os.Getenv(makeRemoteEnv)),
+	}
+	useCLIAPIKey(executor.client)
+
+	if outputs, remote := executor.remoteOutputs(recipe); remote {
+		ok, err := executor.run(recipe, outputs)
+		if err == nil {
+			if !ok {
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Fprintf(os.Stderr, "boltbuild: remote execution failed (%v), running locally\n", err)
+	}
+
+	cmd := exec.Command("/bin/sh", shellFlags, recipe)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		os.Exit(1)
+	}
+}
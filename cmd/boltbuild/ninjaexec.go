@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+)
+
+// envOrDefault returns an environment variable or a fallback value
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// runNinjaExec implements "boltbuild ninja-exec", a per-action wrapper for Ninja builds.
+// It is used as a compiler launcher, e.g. CMAKE_CXX_COMPILER_LAUNCHER="boltbuild;ninja-exec;--"
+// or in build.ninja rules: command = boltbuild ninja-exec -- $cxx -c $in -o $out
+func runNinjaExec(args []string) {
+	flags := flag.NewFlagSet("ninja-exec", flag.ExitOnError)
+	clientURL := flags.String("client", envOrDefault("BOLTBUILD_CLIENT", "http://localhost:8081"), "URL of the boltbuild client web interface ($BOLTBUILD_CLIENT)")
+	environment := flags.String("env", os.Getenv("BOLTBUILD_ENV"), "Environment used for remote actions, must set allow_command_override ($BOLTBUILD_ENV)")
+	root := flags.String("root", envOrDefault("BOLTBUILD_ROOT", "."), "Directory uploaded with every action ($BOLTBUILD_ROOT)")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild ninja-exec [--client URL] [--env X] [--root DIR] -- <command...>")
+		fmt.Println("Compile actions run on a build server, everything else runs locally.")
+		fmt.Println("$BOLTBUILD_LOCAL / $BOLTBUILD_REMOTE hold newline separated patterns forcing actions local or remote.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	command := flags.Args()
+	if len(command) == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if *environment != "" {
+		rootDir, err := buildutil.CanonicalPath(*root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "boltbuild: invalid root %s: %v\n", *root, err)
+			os.Exit(1)
+		}
+		executor := &remoteExecutor{
+			client:      strings.TrimRight(*clientURL, "/"),
+			environment: *environment,
+			root:        rootDir,
+			local:       splitPatterns(os.Getenv("BOLTBUILD_LOCAL")),
+			remote:      splitPatterns(os.Getenv("BOLTBUILD_REMOTE")),
+		}
+		useCLIAPIKey(executor.client)
+
+		step := buildutil.QuoteArgs(command)
+
+		if outputs, remote := executor.remoteOutputs(step); remote {
+			ok, err := executor.run(step, outputs)
+			if err == nil {
+				if !ok {
+					os.Exit(1)
+				}
+				return
+			}
+			fmt.Fprintf(os.Stderr, "boltbuild: remote execution failed (%v), running locally\n", err)
+		}
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "boltbuild: %v\n", err)
+		os.Exit(1)
+	}
+}
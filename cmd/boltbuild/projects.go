@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"boltbuild/pkg/config"
+)
+
+// runProjects prints the configured projects sorted by name
+func runProjects(cfg *config.Config) {
+	if len(cfg.Projects) == 0 {
+		fmt.Println("No projects configured")
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Projects))
+	for name := range cfg.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		project := cfg.Projects[name]
+		labels := make([]string, 0, len(project.Labels))
+		for key, value := range project.Labels {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+
+		fmt.Printf("%-20s %-15s %s", name, project.Environment, project.Dir)
+		if len(labels) > 0 {
+			fmt.Printf("  [%s]", strings.Join(labels, ", "))
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/client"
+	"boltbuild/pkg/protocol"
+)
+
+// stringList collects repeated command line flags
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runRemote implements "boltbuild remote", a thin CLI for a client running elsewhere
+func runRemote(args []string) {
+	flags := flag.NewFlagSet("remote", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild remote [--client URL] <command> [options]")
+		fmt.Println("Commands:")
+		fmt.Println("  build         Upload a directory, build it and download the artifacts")
+		fmt.Println("  environments  List the environments of the client")
+		fmt.Println("  builds        List recent builds")
+		fmt.Println("Set $BOLTBUILD_API_KEY to send an API key of web.auth.api_keys.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	base := strings.TrimRight(*clientURL, "/")
+	useCLIAPIKey(base)
+	var err error
+	switch flags.Arg(0) {
+	case "build":
+		err = remoteBuild(base, flags.Args()[1:])
+	case "environments":
+		err = remoteList(base + "/api/v1/environments")
+	case "builds":
+		err = remoteList(base + "/api/v1/builds")
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// remoteBuild uploads a directory to the client and saves the returned artifacts
+func remoteBuild(base string, args []string) error {
+	flags := flag.NewFlagSet("remote build", flag.ExitOnError)
+	environment := flags.String("env", "", "Build environment")
+	project := flags.String("project", "", "Named project (provides the default environment)")
+	dir := flags.String("dir", "", "Directory to upload (default: . unless --project is given, which builds the project's directory on the client)")
+	server := flags.String("server", "", "Build server address (default: any available server)")
+	out := flags.String("out", "", "Directory to save artifacts into (default: --dir)")
+	dryRun := flags.Bool("dry-run", false, "Show the files, command and server the build would use without running it")
+	var params stringList
+	flags.Var(&params, "param", "Build parameter as name=value (repeatable)")
+	flags.Parse(args)
+
+	if *environment == "" && *project == "" {
+		return fmt.Errorf("--env or --project is required")
+	}
+
+	values := make(map[string]string)
+	for _, param := range params {
+		name, value, found := strings.Cut(param, "=")
+		if !found {
+			return fmt.Errorf("invalid parameter %s, expected name=value", param)
+		}
+		values[name] = value
+	}
+
+	// A named project without --dir is built in its configured directory on the client
+	if *dir == "" && *project != "" {
+		spec := client.BuildSpec{Environment: *environment, Project: *project, Parameters: values, Server: *server}
+		return remoteProjectBuild(base, spec, *dryRun)
+	}
+	if *dir == "" {
+		*dir = "."
+	}
+	if *out == "" {
+		*out = *dir
+	}
+
+	query := url.Values{}
+	query.Set("environment", *environment)
+	query.Set("project", *project)
+	query.Set("server", *server)
+	if len(values) > 0 {
+		data, _ := json.Marshal(values)
+		query.Set("parameters", string(data))
+	}
+
+	if *dryRun {
+		query.Set("dry_run", "true")
+		var report client.DryRunReport
+		if err := uploadArchive(base, query, *dir, nil, &report); err != nil {
+			return err
+		}
+		printDryRun(report)
+		return nil
+	}
+
+	fmt.Printf("Uploading %s to %s...\n", *dir, base)
+	query.Set("follow", "true")
+	resp, err := postArchive(base, query, *dir, nil)
+	if err != nil {
+		return err
+	}
+	response, err := followBuildResponse(base, resp)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range response.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	saved, err := saveRemoteArtifacts(response, *out)
+	for _, target := range saved {
+		fmt.Printf("Saved %s\n", target)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := response.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("Build %s succeeded in %v\n", response.ID, response.Duration)
+	return nil
+}
+
+// remoteProjectBuild builds a named project in its directory on the client, which also saves the
+// artifacts there
+func remoteProjectBuild(base string, spec client.BuildSpec, dryRun bool) error {
+	body, err := json.Marshal(struct {
+		client.BuildSpec
+		DryRun bool `json:"dry_run,omitempty"`
+		Follow bool `json:"follow"`
+	}{spec, dryRun, !dryRun})
+	if err != nil {
+		return err
+	}
+
+	if !dryRun {
+		fmt.Printf("Building project %s on %s...\n", spec.Project, base)
+	}
+	resp, err := http.Post(base+"/api/v1/build", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := checkBuildAnswer(resp); err != nil {
+		return err
+	}
+
+	if dryRun {
+		defer resp.Body.Close()
+		var report client.DryRunReport
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			return fmt.Errorf("invalid response: %v", err)
+		}
+		printDryRun(report)
+		return nil
+	}
+
+	response, err := followBuildResponse(base, resp)
+	if err != nil {
+		return err
+	}
+	for _, warning := range response.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if err := response.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("Build %s succeeded in %v\n", response.ID, response.Duration)
+	return nil
+}
+
+// followBuildResponse prints the output of a build submitted with follow=true while it runs,
+// through build.subscribe, and returns its result. Clients that do not name the build in the
+// response headers have their output printed once the build finished.
+func followBuildResponse(base string, resp *http.Response) (*protocol.BuildResponse, error) {
+	defer resp.Body.Close()
+
+	streamed := make(chan error, 1)
+	buildID := resp.Header.Get(client.BuildIDHeader)
+	if buildID != "" {
+		go func() {
+			_, _, err := subscribeBuildLog(base, buildID)
+			streamed <- err
+		}()
+	} else {
+		streamed <- fmt.Errorf("the client did not name the build")
+	}
+
+	var response protocol.BuildResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+
+	// The subscription ends with the build; fall back to the collected output if it failed
+	if err := <-streamed; err != nil {
+		logging.Debugf("Live output of build %s unavailable: %v", response.ID, err)
+		fmt.Print(response.Output)
+	}
+	return &response, nil
+}
+
+// uploadBuild streams a directory (plus extra generated files) to a client's upload API
+func uploadBuild(base string, query url.Values, dir string, extra map[string][]byte) (*protocol.BuildResponse, error) {
+	var response protocol.BuildResponse
+	if err := uploadArchive(base, query, dir, extra, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// uploadArchive streams a directory to a client's upload API and decodes the JSON answer into target
+func uploadArchive(base string, query url.Values, dir string, extra map[string][]byte, target interface{}) error {
+	resp, err := postArchive(base, query, dir, extra)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	return nil
+}
+
+// postArchive streams a directory to a client's upload API and returns the accepted response
+func postArchive(base string, query url.Values, dir string, extra map[string][]byte) (*http.Response, error) {
+	// Stream the archive while it is being created
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(client.WriteArchive(writer, dir, extra))
+	}()
+
+	resp, err := http.Post(base+"/api/v1/build/upload?"+query.Encode(), "application/gzip", reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBuildAnswer(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// checkBuildAnswer turns a refused build submission into an error, closing its body
+func checkBuildAnswer(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("build rejected: %s", strings.TrimSpace(string(message)))
+}
+
+// printDryRun shows a dry run report
+func printDryRun(report client.DryRunReport) {
+	fmt.Printf("Environment:   %s\n", report.Environment)
+	fmt.Printf("Command:       %s\n", report.Command)
+	fmt.Printf("Execution dir: %s\n", report.ExecutionDir)
+	fmt.Printf("Output paths:  %s\n", strings.Join(report.OutputPaths, ", "))
+	if report.Image != "" {
+		fmt.Printf("Image:         %s\n", report.Image)
+	}
+	fmt.Printf("Files:         %d (%s)\n", report.Files, report.TotalSize)
+	for _, file := range report.LargestFiles {
+		fmt.Printf("  %10s  %s\n", file.Size, file.Path)
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Printf("Skipped:       %d\n", len(report.Skipped))
+		for _, file := range report.Skipped {
+			fmt.Printf("  %s (%s)\n", file.Path, file.Reason)
+		}
+	}
+	if report.ServerError != "" {
+		fmt.Printf("Server:        none - %s\n", report.ServerError)
+	} else {
+		fmt.Printf("Server:        %s (%s)\n", report.Server, report.ServerAddr)
+	}
+}
+
+// saveRemoteArtifacts writes the artifacts of an upload build below out and returns the saved paths
+func saveRemoteArtifacts(response *protocol.BuildResponse, out string) ([]string, error) {
+	names := make([]string, 0, len(response.OutputFiles))
+	for name := range response.OutputFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var saved []string
+	for _, name := range names {
+		content, err := base64.StdEncoding.DecodeString(response.OutputFiles[name])
+		if err != nil {
+			return saved, fmt.Errorf("failed to decode %s: %v", name, err)
+		}
+		if expected, ok := response.Checksums[name]; ok && expected != buildutil.ArtifactChecksum(content) {
+			return saved, fmt.Errorf("checksum mismatch for %s", name)
+		}
+		relPath, err := buildutil.CleanUploadPath(name)
+		if err != nil {
+			return saved, err
+		}
+		target := filepath.Join(out, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return saved, err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return saved, err
+		}
+		saved = append(saved, target)
+	}
+	return saved, nil
+}
+
+// remoteList prints a JSON API response
+func remoteList(endpoint string) error {
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var data interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	formatted, _ := json.MarshalIndent(data, "", "  ")
+	fmt.Println(string(formatted))
+	return nil
+}
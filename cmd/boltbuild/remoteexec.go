@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+)
+
+// recipeScript is the uploaded file that runs a single build step on the build server
+const recipeScript = buildutil.BuildMetadataDir + "/recipe.sh"
+
+// defaultLocalPatterns keep link, archive, recursive make and compound shell steps on the
+// local machine (outputs of steps that change directory cannot be located reliably)
+var defaultLocalPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|[\s/])(make|gmake|ninja|ld|ld\.\w+|lld|ar|ranlib|strip|install)(\s|$)`),
+	regexp.MustCompile(`(^|\s)-shared(\s|$)`),
+	regexp.MustCompile(`(^|\s)cd\s|&&|\|\||;`),
+}
+
+// stepOutputs finds the files written by a compile step: the object file and an optional depfile
+var stepOutputs = regexp.MustCompile(`(?:^|\s)-(?:o|MF)\s*(\S+)`)
+
+// compileFlag detects compile-only steps
+var compileFlag = regexp.MustCompile(`(^|\s)-c(\s|$)`)
+
+// remoteExecutor runs individual build steps (make recipes, ninja commands) on the build farm
+// through a client's upload API
+type remoteExecutor struct {
+	client      string // Client web interface URL
+	environment string // Environment with allow_command_override
+	root        string // Local directory uploaded with every step
+	local       []*regexp.Regexp
+	remote      []*regexp.Regexp
+}
+
+// splitPatterns compiles newline separated regular expressions, ignoring invalid ones
+func splitPatterns(patterns string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, pattern := range strings.Split(patterns, "\n") {
+		if pattern == "" {
+			continue
+		}
+		if re, err := regexp.Compile(pattern); err == nil {
+			compiled = append(compiled, re)
+		} else {
+			fmt.Fprintf(os.Stderr, "boltbuild: ignoring invalid pattern %s: %v\n", pattern, err)
+		}
+	}
+	return compiled
+}
+
+// matchesAny reports whether step matches one of the patterns
+func matchesAny(patterns []*regexp.Regexp, step string) bool {
+	for _, re := range patterns {
+		if re.MatchString(step) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteOutputs applies the locality rules and returns the outputs of a step that may run remotely
+func (e *remoteExecutor) remoteOutputs(step string) ([]string, bool) {
+	var outputs []string
+	for _, match := range stepOutputs.FindAllStringSubmatch(step, -1) {
+		outputs = append(outputs, match[1])
+	}
+	if len(outputs) == 0 {
+		return nil, false // Outputs must be known to fetch them back
+	}
+
+	if matchesAny(e.remote, step) {
+		return outputs, true
+	}
+	if matchesAny(e.local, step) || matchesAny(defaultLocalPatterns, step) {
+		return nil, false
+	}
+	if !compileFlag.MatchString(step) {
+		return nil, false // Not a compile step, most likely a link
+	}
+	return outputs, true
+}
+
+// run uploads the root directory, runs the step in the current directory's counterpart
+// on a build server and saves the outputs locally
+func (e *remoteExecutor) run(step string, outputs []string) (bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	relDir, err := filepath.Rel(e.root, cwd)
+	if err != nil || relDir == ".." || strings.HasPrefix(relDir, ".."+string(filepath.Separator)) {
+		return false, fmt.Errorf("%s is outside the upload root %s", cwd, e.root)
+	}
+	relDir = filepath.ToSlash(relDir)
+
+	// Output patterns are "./"-prefixed paths relative to the workspace root
+	var outputPaths []string
+	for _, output := range outputs {
+		if !filepath.IsAbs(output) {
+			outputPaths = append(outputPaths, "./"+filepath.ToSlash(filepath.Join(relDir, output)))
+		} else if rel, err := filepath.Rel(e.root, output); err == nil && !strings.HasPrefix(rel, "..") {
+			outputPaths = append(outputPaths, "./"+filepath.ToSlash(rel))
+		} else {
+			return false, fmt.Errorf("output %s is outside the upload root", output)
+		}
+	}
+
+	script := fmt.Sprintf("cd %s || exit 1\n%s\n", buildutil.ShellQuote(relDir), step)
+	query := url.Values{}
+	query.Set("environment", e.environment)
+	query.Set("command", "sh "+recipeScript)
+	query.Set("outputs", strings.Join(outputPaths, ","))
+
+	response, err := uploadBuild(e.client, query, e.root, map[string][]byte{recipeScript: []byte(script)})
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Print(response.Output)
+	if _, err := saveRemoteArtifacts(response, e.root); err != nil {
+		return false, err
+	}
+	return response.Success, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"boltbuild/pkg/client"
+	"boltbuild/pkg/protocol"
+)
+
+// runReplay implements "boltbuild replay", re-running a build of a client's history
+func runReplay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	source := flags.String("source", "", "Take the files from the build's archive or the project directory on disk (default: archive if the files were archived)")
+	server := flags.String("server", "", "Build server address (default: any available server)")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild replay [--client URL] [--source archive|disk] [--server host:port] <build-id>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	// Flags may also follow the build ID
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	buildID := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	base := strings.TrimRight(*clientURL, "/")
+	useCLIAPIKey(base)
+	response, err := replayBuild(base, buildID, client.ReplayRequest{Source: *source, Server: *server})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(response.Output)
+	if !response.Success {
+		fmt.Fprintf(os.Stderr, "Replay %s of build %s failed: %s\n", response.ID, buildID, response.Error)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Replay %s of build %s succeeded in %v\n", response.ID, buildID, response.Duration)
+}
+
+// replayBuild asks the client to replay a build and waits for its result
+func replayBuild(base, buildID string, request client.ReplayRequest) (*protocol.BuildResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(base+"/api/v1/build/"+url.PathEscape(buildID)+"/replay", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(message)))
+	}
+	var response protocol.BuildResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	return &response, nil
+}
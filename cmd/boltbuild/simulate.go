@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/client"
+)
+
+// simulationOutcome is the result of one simulated build
+type simulationOutcome struct {
+	id       string
+	latency  time.Duration // From sending the request until the result arrived
+	duration time.Duration // Reported by the server
+	success  bool
+	rejected string // Why the client refused the build, e.g. no available servers
+}
+
+// LatencySummary describes a distribution of durations
+type LatencySummary struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	Max time.Duration `json:"max"`
+}
+
+// summarizeLatencies returns the percentiles of a list of durations (zero when empty)
+func summarizeLatencies(durations []time.Duration) LatencySummary {
+	if len(durations) == 0 {
+		return LatencySummary{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return LatencySummary{P50: buildutil.Percentile(durations, 0.50), P95: buildutil.Percentile(durations, 0.95), Max: durations[len(durations)-1]}
+}
+
+// SimulationReport summarizes a simulation run
+type SimulationReport struct {
+	Builds        int            `json:"builds"`
+	Concurrency   int            `json:"concurrency"`
+	Elapsed       time.Duration  `json:"elapsed"`
+	BuildsPerMin  float64        `json:"builds_per_minute"` // Finished builds, rejected ones excluded
+	Succeeded     int            `json:"succeeded"`
+	Failed        int            `json:"failed"`
+	Rejected      int            `json:"rejected"`
+	FailureRate   float64        `json:"failure_rate"`   // Failed and rejected builds per submitted build
+	RejectReasons map[string]int `json:"reject_reasons"` // Rejection message -> count
+	Latency       LatencySummary `json:"latency"`        // Submission until result
+	BuildDuration LatencySummary `json:"build_duration"` // On the server
+	Overhead      LatencySummary `json:"overhead"`       // Latency minus build duration: queueing and transfers
+	QueueWait     LatencySummary `json:"queue_wait"`     // Until the server received the build, from the client's history
+	Servers       int            `json:"servers"`        // Servers connected to the client at the start
+}
+
+// runSimulate implements "boltbuild simulate", a load test that submits many builds to a
+// running client and reports throughput, latencies and failure rates
+func runSimulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	clientURL := flags.String("client", "http://localhost:8081", "URL of the boltbuild client web interface")
+	builds := flags.Int("builds", 100, "Number of builds to submit")
+	concurrency := flags.Int("concurrency", 4, "Builds submitted at the same time")
+	environment := flags.String("env", "", "Environment of the synthetic builds")
+	project := flags.String("project", "", "Named project of the synthetic builds (instead of --env)")
+	replay := flags.Int("replay", 0, "Replay the environments, projects and parameters of the last N builds in the history instead")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	var params stringList
+	flags.Var(&params, "param", "Build parameter as name=value for synthetic builds (repeatable)")
+	flags.Usage = func() {
+		fmt.Println("Usage: boltbuild simulate [--client URL] --builds N --concurrency N (--env X | --project X | --replay N)")
+		fmt.Println("Every build runs on the farm, use an environment whose builds are safe to repeat.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *builds < 1 || *concurrency < 1 || (*environment == "" && *project == "" && *replay < 1) {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	base := strings.TrimRight(*clientURL, "/")
+	useCLIAPIKey(base)
+
+	var templates []client.BuildSpec
+	var err error
+	if *replay > 0 {
+		templates, err = recordedBuilds(base, *replay)
+	} else {
+		template := client.BuildSpec{Environment: *environment, Project: *project, Parameters: map[string]string{}}
+		for _, param := range params {
+			name, value, found := strings.Cut(param, "=")
+			if !found {
+				err = fmt.Errorf("invalid parameter %s, expected name=value", param)
+				break
+			}
+			template.Parameters[name] = value
+		}
+		templates = []client.BuildSpec{template}
+	}
+	if err == nil {
+		var report *SimulationReport
+		if report, err = simulate(client.New(base), base, templates, *builds, *concurrency); err == nil {
+			if *asJSON {
+				data, _ := json.MarshalIndent(report, "", "  ")
+				fmt.Println(string(data))
+			} else {
+				printSimulation(report)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// recordedBuilds returns the requests of the last builds in the client's history, oldest first
+func recordedBuilds(base string, count int) ([]client.BuildSpec, error) {
+	var records []client.BuildRecord
+	if err := getJSON(base+"/api/v1/builds", &records); err != nil {
+		return nil, fmt.Errorf("failed to read the build history: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the build history is empty, nothing to replay")
+	}
+	if len(records) > count {
+		records = records[:count]
+	}
+	builds := make([]client.BuildSpec, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		build := client.BuildSpec{Project: record.Project, Parameters: record.Parameters}
+		if record.Project == "" {
+			build.Environment = record.Environment
+		}
+		builds = append(builds, build)
+	}
+	return builds, nil
+}
+
+// getJSON decodes the response of a client API endpoint
+func getJSON(endpoint string, target interface{}) error {
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// simulate submits count builds, cycling through the templates, with at most concurrency at a time
+func simulate(api *client.Client, base string, templates []client.BuildSpec, count, concurrency int) (*SimulationReport, error) {
+	servers, err := api.Servers(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach client: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Submitting %d builds, %d at a time, to %s (%d servers connected)...\n", count, concurrency, base, len(servers))
+
+	jobs := make(chan client.BuildSpec)
+	outcomes := make([]simulationOutcome, 0, count)
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for build := range jobs {
+				outcome := submitSimulated(api, build)
+				mux.Lock()
+				outcomes = append(outcomes, outcome)
+				if done := len(outcomes); done%10 == 0 || done == count {
+					fmt.Fprintf(os.Stderr, "  %d/%d finished\n", done, count)
+				}
+				mux.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		jobs <- templates[i%len(templates)]
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	// Queue waits are only recorded in the client's history
+	queueWaits := make(map[string]time.Duration)
+	var records []client.BuildRecord
+	if err := getJSON(base+"/api/v1/builds", &records); err == nil {
+		for _, record := range records {
+			queueWaits[record.ID] = record.QueueWait
+		}
+	}
+
+	report := &SimulationReport{Builds: count, Concurrency: concurrency, Elapsed: elapsed, RejectReasons: map[string]int{}, Servers: len(servers)}
+	var latencies, durations, overheads, waits []time.Duration
+	for _, outcome := range outcomes {
+		if outcome.rejected != "" {
+			report.Rejected++
+			report.RejectReasons[outcome.rejected]++
+			continue
+		}
+		if outcome.success {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+		latencies = append(latencies, outcome.latency)
+		durations = append(durations, outcome.duration)
+		if overhead := outcome.latency - outcome.duration; overhead > 0 {
+			overheads = append(overheads, overhead)
+		}
+		if wait, exists := queueWaits[outcome.id]; exists {
+			waits = append(waits, wait)
+		}
+	}
+	if minutes := elapsed.Minutes(); minutes > 0 {
+		report.BuildsPerMin = float64(report.Succeeded+report.Failed) / minutes
+	}
+	report.FailureRate = float64(report.Failed+report.Rejected) / float64(count)
+	report.Latency = summarizeLatencies(latencies)
+	report.BuildDuration = summarizeLatencies(durations)
+	report.Overhead = summarizeLatencies(overheads)
+	report.QueueWait = summarizeLatencies(waits)
+	return report, nil
+}
+
+// submitSimulated sends one build to the client and waits for its result
+func submitSimulated(api *client.Client, build client.BuildSpec) simulationOutcome {
+	start := time.Now()
+	response, err := api.Build(context.Background(), build)
+	var refused *client.APIError
+	if errors.As(err, &refused) {
+		return simulationOutcome{rejected: refused.Message}
+	}
+	if err != nil {
+		return simulationOutcome{rejected: err.Error()}
+	}
+	return simulationOutcome{id: response.ID, latency: time.Since(start), duration: response.Duration, success: response.Success}
+}
+
+// printSimulation shows a simulation report
+func printSimulation(report *SimulationReport) {
+	percent := func(n int) float64 { return 100 * float64(n) / float64(report.Builds) }
+	summary := func(s LatencySummary) string {
+		return fmt.Sprintf("p50 %v, p95 %v, max %v", s.P50.Round(time.Millisecond), s.P95.Round(time.Millisecond), s.Max.Round(time.Millisecond))
+	}
+
+	fmt.Printf("Builds:         %d, %d at a time, on %d servers\n", report.Builds, report.Concurrency, report.Servers)
+	fmt.Printf("Elapsed:        %v\n", report.Elapsed.Round(time.Millisecond))
+	fmt.Printf("Throughput:     %.1f builds/min\n", report.BuildsPerMin)
+	fmt.Printf("Succeeded:      %d (%.1f%%)\n", report.Succeeded, percent(report.Succeeded))
+	fmt.Printf("Failed:         %d (%.1f%%)\n", report.Failed, percent(report.Failed))
+	fmt.Printf("Rejected:       %d (%.1f%%)\n", report.Rejected, percent(report.Rejected))
+
+	reasons := make([]string, 0, len(report.RejectReasons))
+	for reason := range report.RejectReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool { return report.RejectReasons[reasons[i]] > report.RejectReasons[reasons[j]] })
+	for _, reason := range reasons {
+		fmt.Printf("  %4d × %s\n", report.RejectReasons[reason], reason)
+	}
+
+	if report.Succeeded+report.Failed > 0 {
+		fmt.Printf("Latency:        %s\n", summary(report.Latency))
+		fmt.Printf("Build duration: %s\n", summary(report.BuildDuration))
+		fmt.Printf("Overhead:       %s\n", summary(report.Overhead))
+		fmt.Printf("Queue wait:     %s\n", summary(report.QueueWait))
+	}
+}
@@ -3,6 +3,16 @@ module boltbuild
 go 1.21
 
 require (
+	github.com/go-ldap/ldap/v3 v3.4.6
 	github.com/gorilla/mux v1.8.0
+	golang.org/x/crypto v0.33.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+require (
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
+	github.com/go-asn1-ber/asn1-ber v1.5.5 // indirect
+	github.com/google/uuid v1.3.1 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+)
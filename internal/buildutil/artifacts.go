@@ -0,0 +1,45 @@
+package buildutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Supported artifact compression algorithms
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
+// SupportedCompressions lists the algorithms this build understands, in order of preference
+var SupportedCompressions = []string{CompressionGzip}
+
+// EncodeArtifact compresses (if requested) and base64-encodes artifact content
+func EncodeArtifact(content []byte, compression string) (string, error) {
+	switch compression {
+	case CompressionNone:
+		return base64.StdEncoding.EncodeToString(content), nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(content); err != nil {
+			return "", err
+		}
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	default:
+		return "", fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// ArtifactChecksum returns the hex SHA-256 of uncompressed artifact content
+func ArtifactChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,38 @@
+// Package buildutil holds the small helpers the boltbuild client and server share: build IDs,
+// workspace paths, shell quoting, artifact encoding and project detection.
+package buildutil
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// GenerateID creates a random ID for build requests
+func GenerateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// ContainsString reports whether list contains value
+func ContainsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Percentile returns the nearest-rank percentile of sorted durations
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,26 @@
+package buildutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// ProjectHash is the root of a two-level hash tree over the project: every file is hashed and
+// the sorted path/hash list is hashed again, so identical projects hash identically on any machine
+func ProjectHash(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	root := sha256.New()
+	for _, path := range paths {
+		leaf := sha256.Sum256([]byte(files[path]))
+		root.Write([]byte(path))
+		root.Write([]byte{0})
+		root.Write(leaf[:])
+	}
+	return hex.EncodeToString(root.Sum(nil))
+}
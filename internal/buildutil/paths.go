@@ -0,0 +1,58 @@
+package buildutil
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BuildMetadataDir holds files boltbuild itself writes into a workspace
+const BuildMetadataDir = ".boltbuild"
+
+// BuildLogPath is the workspace-relative location of the full build log
+const BuildLogPath = BuildMetadataDir + "/build.log"
+
+// StepLogDir holds the logs of the single steps of a multi-step build
+const StepLogDir = BuildMetadataDir + "/steps"
+
+// DebugBundlePath is the workspace-relative location of the debug bundle of a failed build
+const DebugBundlePath = BuildMetadataDir + "/debug-bundle.tar.gz"
+
+// ProvenancePath is the workspace-relative location of the generated provenance document
+const ProvenancePath = BuildMetadataDir + "/provenance.json"
+
+// ProjectDirPrefix starts the name of every build's temp directory (project_<build id>)
+const ProjectDirPrefix = "project_"
+
+// CanonicalPath returns name as an absolute path with symlinks resolved
+func CanonicalPath(name string) (string, error) {
+	absPath, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(absPath)
+}
+
+// CleanUploadPath normalizes an uploaded file name and rejects paths leaving the workspace
+func CleanUploadPath(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid file path in upload: %s", name)
+	}
+	return cleaned, nil
+}
+
+// SafeName replaces everything but letters, digits, dots, dashes and underscores
+func SafeName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(safe, ".") == "" {
+		return "_"
+	}
+	return safe
+}
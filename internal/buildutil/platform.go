@@ -0,0 +1,20 @@
+package buildutil
+
+import (
+	"strings"
+)
+
+// MatchesPlatform reports whether a platform ("linux/amd64") is one of a list of operating
+// systems and OS/architecture pairs; an empty list matches every platform
+func MatchesPlatform(platforms []string, platform string) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	goos, _, _ := strings.Cut(platform, "/")
+	for _, candidate := range platforms {
+		if candidate == platform || candidate == goos {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package buildutil
+
+import (
+	"sort"
+)
+
+// ProjectKind describes a recognizable project type and the tools that build it
+type ProjectKind struct {
+	Kind    string   // e.g. "go", "cmake"
+	Markers []string // glob patterns of files in the project root
+	Tools   []string // executables commonly used to build this kind of project
+}
+
+// KnownProjectKinds lists the detection rules, most specific first
+var KnownProjectKinds = []ProjectKind{
+	{Kind: "go", Markers: []string{"go.mod"}, Tools: []string{"go"}},
+	{Kind: "cmake", Markers: []string{"CMakeLists.txt"}, Tools: []string{"cmake", "ninja"}},
+	{Kind: "dotnet", Markers: []string{"*.csproj", "*.fsproj", "*.vbproj", "*.sln"}, Tools: []string{"dotnet", "msbuild"}},
+	{Kind: "rust", Markers: []string{"Cargo.toml"}, Tools: []string{"cargo", "rustc"}},
+	{Kind: "node", Markers: []string{"package.json", "tsconfig.json"}, Tools: []string{"npm", "npx", "yarn", "pnpm", "node", "tsc"}},
+	{Kind: "java", Markers: []string{"pom.xml", "build.gradle", "build.gradle.kts"}, Tools: []string{"mvn", "gradle", "gradlew", "javac"}},
+	{Kind: "python", Markers: []string{"pyproject.toml", "setup.py", "requirements.txt"}, Tools: []string{"python", "python3", "pip"}},
+	{Kind: "make", Markers: []string{"Makefile", "makefile", "GNUmakefile"}, Tools: []string{"make"}},
+}
+
+// ProbedTools lists the executables whose versions servers report: the build tools of the
+// known project kinds plus the common compilers
+func ProbedTools() []string {
+	seen := map[string]bool{}
+	var tools []string
+	for _, kind := range KnownProjectKinds {
+		for _, tool := range append(kind.Tools, "gcc", "g++", "clang") {
+			if !seen[tool] {
+				seen[tool] = true
+				tools = append(tools, tool)
+			}
+		}
+	}
+	sort.Strings(tools)
+	return tools
+}
@@ -0,0 +1,22 @@
+package buildutil
+
+import (
+	"strings"
+)
+
+// ShellQuote quotes an argument for a POSIX shell where it needs it
+func ShellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\$`&|;<>()*?[]{}~#!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// QuoteArgs joins arguments with POSIX shell quoting where they need it
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package buildutil
+
+import (
+	"context"
+	"os/exec"
+)
+
+// ShellCommand runs a command line through the platform shell, sh -c
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
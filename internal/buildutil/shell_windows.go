@@ -0,0 +1,21 @@
+package buildutil
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ShellCommand runs a command line through the platform shell, cmd /C. The command line is
+// passed unchanged, as cmd does not understand the argument quoting Go applies; /S keeps the
+// outer quotes from being parsed, so commands may contain quotes of their own.
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	comspec := os.Getenv("COMSPEC")
+	if comspec == "" {
+		comspec = "cmd.exe"
+	}
+	cmd := exec.CommandContext(ctx, comspec)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd.exe /S /C "` + command + `"`}
+	return cmd
+}
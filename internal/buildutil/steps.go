@@ -0,0 +1,25 @@
+package buildutil
+
+import (
+	"boltbuild/pkg/protocol"
+)
+
+// StepRequest returns the request one step runs as: its command, directory and variables
+func StepRequest(request protocol.BuildRequest, step protocol.BuildStep) protocol.BuildRequest {
+	request.Command, request.Args, request.Shell = step.Command, step.Args, step.Shell
+	request.Steps = nil
+	if step.Dir != "" {
+		request.ExecutionDir = step.Dir
+	}
+	if len(step.EnvVars) > 0 {
+		vars := make(map[string]string, len(request.EnvVars)+len(step.EnvVars))
+		for key, value := range request.EnvVars {
+			vars[key] = value
+		}
+		for key, value := range step.EnvVars {
+			vars[key] = value
+		}
+		request.EnvVars = vars
+	}
+	return request
+}
@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"fmt"
+)
+
+// EventKind is the severity of a Windows Event Log entry
+type EventKind int
+
+// Event Log entry types
+const (
+	EventInfo EventKind = iota
+	EventWarning
+	EventError
+)
+
+// defaultEventSource is the Event Log source name when logging.event_source is not set
+const defaultEventSource = "boltbuild"
+
+// Eventf logs a significant event (startup, shutdown, refused logins) at info level and
+// also writes it to the Windows Event Log when logging.event_log is enabled
+func Eventf(kind EventKind, format string, v ...interface{}) {
+	Infof(format, v...)
+	ReportEventf(kind, format, v...)
+}
+
+// ReportEventf writes an event only to the Windows Event Log, for events the regular log
+// already covers at another level, e.g. failed builds
+func ReportEventf(kind EventKind, format string, v ...interface{}) {
+	if EventLogEnabled() {
+		logger.eventLog.report(kind, fmt.Sprintf(format, v...))
+	}
+}
+
+// EventLogEnabled reports whether events are written to the Windows Event Log
+func EventLogEnabled() bool {
+	return logger != nil && logger.eventLog != nil
+}
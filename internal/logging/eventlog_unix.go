@@ -0,0 +1,18 @@
+//go:build !windows
+
+package logging
+
+import (
+	"fmt"
+)
+
+// eventLog is only available on Windows
+type eventLog struct{}
+
+// openEventLog fails outside of Windows
+func openEventLog(source string) (*eventLog, error) {
+	return nil, fmt.Errorf("the Windows Event Log is not available on this platform")
+}
+
+// report does nothing
+func (e *eventLog) report(kind EventKind, message string) {}
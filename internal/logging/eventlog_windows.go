@@ -0,0 +1,65 @@
+//go:build windows
+
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                 = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW = advapi32.NewProc("RegisterEventSourceW")
+	procReportEventW         = advapi32.NewProc("ReportEventW")
+)
+
+// Win32 event types of ReportEventW
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+// eventLogID is the event ID of every entry; the message is passed as its only insertion string
+const eventLogID = 1
+
+// eventLog writes entries to the Application log under a registered event source
+type eventLog struct {
+	handle uintptr
+	mux    sync.Mutex
+}
+
+// openEventLog registers the event source for reporting
+func openEventLog(source string) (*eventLog, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	handle, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return nil, fmt.Errorf("failed to register event source %s: %v", source, err)
+	}
+	return &eventLog{handle: handle}, nil
+}
+
+// report writes one entry; failures are ignored as there is nowhere left to report them
+func (e *eventLog) report(kind EventKind, message string) {
+	text, err := syscall.UTF16PtrFromString(message)
+	if err != nil {
+		return
+	}
+	eventType := uintptr(eventlogInformationType)
+	switch kind {
+	case EventWarning:
+		eventType = eventlogWarningType
+	case EventError:
+		eventType = eventlogErrorType
+	}
+	inserts := [1]*uint16{text}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	procReportEventW.Call(e.handle, eventType, 0, eventLogID, 0, 1, 0, uintptr(unsafe.Pointer(&inserts[0])), 0)
+}
@@ -0,0 +1,219 @@
+// Package logging writes the boltbuild log, the per-build event log and the optional syslog
+// and protocol trace outputs. Until Initialize applies the configuration, messages go to the
+// standard logger at info level.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"boltbuild/pkg/config"
+)
+
+// Level represents the logging level
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelDebug
+)
+
+// Logger provides structured logging with configurable levels
+type Logger struct {
+	level    atomic.Int32  // Level, changed at runtime through SetLevel
+	syslog   *syslogWriter // Also ships records to a syslog server (nil = disabled)
+	eventLog *eventLog     // Receives significant events on Windows (nil = disabled)
+}
+
+// ParseLevel returns the level of a name, "info" or "debug"
+func ParseLevel(levelStr string) (Level, error) {
+	switch strings.ToLower(levelStr) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	}
+	return LevelInfo, fmt.Errorf("invalid log level %q (use info or debug)", levelStr)
+}
+
+// String returns the name of a level
+func (l Level) String() string {
+	if l >= LevelDebug {
+		return "debug"
+	}
+	return "info"
+}
+
+// NewLogger creates a new logger with the specified level
+func NewLogger(levelStr string) *Logger {
+	level, _ := ParseLevel(levelStr) // Default to info
+
+	l := &Logger{}
+	l.level.Store(int32(level))
+	return l
+}
+
+// Level returns the current level
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// SetLevel changes the level while the process runs
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// Info logs messages at info level (always shown)
+func (l *Logger) Info(v ...interface{}) {
+	l.output(syslogInformational, fmt.Sprint(v...))
+}
+
+// Infof logs formatted messages at info level (always shown)
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.output(syslogInformational, fmt.Sprintf(format, v...))
+}
+
+// Debug logs messages at debug level (only shown when debug is enabled)
+func (l *Logger) Debug(v ...interface{}) {
+	if l.Level() >= LevelDebug {
+		l.output(syslogDebug, fmt.Sprint(v...))
+	}
+}
+
+// Debugf logs formatted messages at debug level (only shown when debug is enabled)
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	if l.Level() >= LevelDebug {
+		l.output(syslogDebug, fmt.Sprintf(format, v...))
+	}
+}
+
+// Fatal logs fatal messages and exits (always shown)
+func (l *Logger) Fatal(v ...interface{}) {
+	l.fatal(fmt.Sprint(v...))
+}
+
+// Fatalf logs formatted fatal messages and exits (always shown)
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.fatal(fmt.Sprintf(format, v...))
+}
+
+// output writes a message to the standard error output and the syslog server
+func (l *Logger) output(severity int, message string) {
+	log.Output(3, message)
+	if l.syslog != nil {
+		l.syslog.write(severity, message)
+	}
+}
+
+// fatal logs a message, gives the syslog server a moment to receive it and exits
+func (l *Logger) fatal(message string) {
+	l.output(syslogCritical, message)
+	if l.syslog != nil {
+		l.syslog.flush(time.Second)
+	}
+	os.Exit(1)
+}
+
+// Global logger instance
+var logger *Logger
+
+// Initialize initializes the global logger with config
+func Initialize(cfg *config.Config) {
+	logger = NewLogger(cfg.Logging.Level)
+	logger.syslog = newSyslogWriter(cfg.Logging.Syslog)
+	if cfg.Logging.ProtocolTrace != "" {
+		tracer, err := openProtocolTrace(cfg.Logging.ProtocolTrace, cfg.Server.BuildEnv.Secret)
+		if err != nil {
+			log.Printf("Warning: Protocol trace disabled: %v", err)
+		} else {
+			log.Printf("Tracing protocol messages to %s", cfg.Logging.ProtocolTrace)
+			protocolTracer = tracer
+		}
+	}
+	if cfg.Logging.EventLog {
+		source := cfg.Logging.EventSource
+		if source == "" {
+			source = defaultEventSource
+		}
+		eventLog, err := openEventLog(source)
+		if err != nil {
+			log.Printf("Warning: Not writing to the Event Log: %v", err)
+		}
+		logger.eventLog = eventLog
+	}
+}
+
+// Convenience functions for global logger
+func Info(v ...interface{}) {
+	if logger != nil {
+		logger.Info(v...)
+	} else {
+		log.Print(v...)
+	}
+}
+
+func Infof(format string, v ...interface{}) {
+	if logger != nil {
+		logger.Infof(format, v...)
+	} else {
+		log.Printf(format, v...)
+	}
+}
+
+func Debug(v ...interface{}) {
+	if logger != nil {
+		logger.Debug(v...)
+	}
+}
+
+func Debugf(format string, v ...interface{}) {
+	if logger != nil {
+		logger.Debugf(format, v...)
+	}
+}
+
+func Fatal(v ...interface{}) {
+	if logger != nil {
+		logger.Fatal(v...)
+	} else {
+		log.Fatal(v...)
+	}
+}
+
+func Fatalf(format string, v ...interface{}) {
+	if logger != nil {
+		logger.Fatalf(format, v...)
+	} else {
+		log.Fatalf(format, v...)
+	}
+}
+
+// SetLevel switches the global logger to a level by name, e.g. to capture a debug trace
+// of a misbehaving build without restarting
+func SetLevel(levelStr string) error {
+	level, err := ParseLevel(levelStr)
+	if err != nil {
+		return err
+	}
+	if logger == nil {
+		logger = NewLogger(levelStr)
+	}
+	previous := logger.Level()
+	logger.SetLevel(level)
+	if previous != level {
+		Infof("Log level changed from %s to %s", previous, level)
+	}
+	return nil
+}
+
+// CurrentLevel returns the name of the global logger's level
+func CurrentLevel() string {
+	if logger == nil {
+		return LevelInfo.String()
+	}
+	return logger.Level().String()
+}
@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/pkg/config"
+)
+
+// traceElideLength is the length above which strings (file contents, large logs) are replaced
+// by their size in the protocol trace
+const traceElideLength = 256
+
+// ProtocolTracer writes every message of client/server connections to a trace file
+type ProtocolTracer struct {
+	file   *os.File
+	secret func(name string) bool // Environment variables whose values are not traced
+	mux    sync.Mutex
+}
+
+// protocolTracer is set when logging.protocol_trace names a trace file
+var protocolTracer *ProtocolTracer
+
+// openProtocolTrace opens the trace file for appending
+func openProtocolTrace(path string, secret func(name string) bool) (*ProtocolTracer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &ProtocolTracer{file: file, secret: secret}, nil
+}
+
+// record writes one line: time, peer, direction (-> sent, <- received, -- connection event) and text
+func (t *ProtocolTracer) record(peer, direction, text string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	fmt.Fprintf(t.file, "%s %s %s %s\n", time.Now().UTC().Format("2006-01-02T15:04:05.000000Z"), peer, direction, text)
+}
+
+// message writes a protocol line with long strings elided; lines that are not JSON are
+// shown as quoted text, which is what framing problems between versions look like
+func (t *ProtocolTracer) message(peer, direction string, line []byte) {
+	var value interface{}
+	if err := json.Unmarshal(line, &value); err != nil {
+		text := line
+		if len(text) > traceElideLength {
+			text = text[:traceElideLength]
+		}
+		t.record(peer, direction, fmt.Sprintf("[not JSON, %d bytes: %v] %s", len(line), err, strconv.Quote(string(text))))
+		return
+	}
+	var elided bytes.Buffer
+	encoder := json.NewEncoder(&elided)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(t.elideStrings(value))
+	t.record(peer, direction, strings.TrimSuffix(elided.String(), "\n"))
+}
+
+// elideStrings replaces long strings in a decoded JSON value by their size
+func (t *ProtocolTracer) elideStrings(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if len(v) > traceElideLength {
+			return fmt.Sprintf("<%d bytes>", len(v))
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = t.elideStrings(item)
+		}
+		// Build requests carry the environment variables of builds
+		if vars, ok := v["env_vars"].(map[string]interface{}); ok {
+			for name := range vars {
+				if t.secret(name) {
+					vars[name] = config.ScrubbedValue
+				}
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = t.elideStrings(item)
+		}
+	}
+	return value
+}
+
+// tracedConn copies the newline-delimited messages of a connection to the protocol trace
+type tracedConn struct {
+	net.Conn
+	peer    string
+	sent    []byte // Partial message written so far
+	read    []byte // Partial message received so far
+	sentMux sync.Mutex
+	closed  sync.Once
+}
+
+// TraceConn wraps a client/server connection when protocol tracing is enabled
+func TraceConn(conn net.Conn, peer string) net.Conn {
+	if protocolTracer == nil {
+		return conn
+	}
+	protocolTracer.record(peer, "--", "connected")
+	return &tracedConn{Conn: conn, peer: peer}
+}
+
+// Read traces every complete message received
+func (c *tracedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read = c.traceLines(c.read, p[:n], "<-")
+	if err != nil && len(c.read) > 0 {
+		protocolTracer.message(c.peer, "<-", c.read)
+		c.read = nil
+	}
+	return n, err
+}
+
+// Write traces every complete message sent
+func (c *tracedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.sentMux.Lock()
+	c.sent = c.traceLines(c.sent, p[:n], "->")
+	c.sentMux.Unlock()
+	return n, err
+}
+
+// Close traces the end of the connection
+func (c *tracedConn) Close() error {
+	c.closed.Do(func() { protocolTracer.record(c.peer, "--", "closed") })
+	return c.Conn.Close()
+}
+
+// traceLines appends data to a partial message, traces the complete ones and returns the rest
+func (c *tracedConn) traceLines(pending, data []byte, direction string) []byte {
+	pending = append(pending, data...)
+	for {
+		end := bytes.IndexByte(pending, '\n')
+		if end < 0 {
+			return pending
+		}
+		if line := bytes.TrimSpace(pending[:end]); len(line) > 0 {
+			protocolTracer.message(c.peer, direction, line)
+		}
+		pending = pending[end+1:]
+	}
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/pkg/config"
+)
+
+// Syslog severities of the log levels (RFC 5424)
+const (
+	syslogCritical      = 2
+	syslogInformational = 6
+	syslogDebug         = 7
+)
+
+// syslogBuffer is the number of records queued for an unreachable syslog server before records are dropped
+const syslogBuffer = 1024
+
+// syslogRetry is how long records are dropped after the syslog server could not be reached
+const syslogRetry = 10 * time.Second
+
+// syslogWriter sends RFC 5424 records in the background, so an unreachable syslog server
+// never delays logging. TCP connections use octet counting framing (RFC 6587) and are
+// redialed after errors.
+type syslogWriter struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+	queue    chan string
+	conn     net.Conn
+	retryAt  time.Time
+}
+
+// newSyslogWriter starts shipping records to the configured server (nil when disabled)
+func newSyslogWriter(cfg config.SyslogConfig) *syslogWriter {
+	if cfg.Address == "" {
+		return nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	s := &syslogWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: config.SyslogFacilities["daemon"],
+		hostname: hostname,
+		appName:  cfg.AppName,
+		queue:    make(chan string, syslogBuffer),
+	}
+	if s.network == "" {
+		s.network = "udp"
+	}
+	if facility, exists := config.SyslogFacilities[cfg.Facility]; exists {
+		s.facility = facility
+	}
+	if s.appName == "" {
+		s.appName = "boltbuild"
+	}
+	go s.run()
+	return s
+}
+
+// write queues a record; records are dropped while the queue is full
+func (s *syslogWriter) write(severity int, message string) {
+	record := fmt.Sprintf("<%d>1 %s %s %s %d - - %s", s.facility*8+severity,
+		time.Now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"), s.hostname, s.appName, os.Getpid(),
+		strings.TrimRight(message, "\n"))
+	select {
+	case s.queue <- record:
+	default:
+	}
+}
+
+// run sends queued records, reconnecting after errors. Failures are reported on the standard
+// error output only, as logging them would queue more records.
+func (s *syslogWriter) run() {
+	for record := range s.queue {
+		if s.conn == nil {
+			if time.Now().Before(s.retryAt) {
+				continue
+			}
+			conn, err := net.DialTimeout(s.network, s.address, syslogRetry)
+			if err != nil {
+				log.Printf("Warning: Failed to reach syslog server %s: %v", s.address, err)
+				s.retryAt = time.Now().Add(syslogRetry)
+				continue
+			}
+			s.conn = conn
+		}
+
+		frame := record
+		if s.network == "tcp" {
+			frame = strconv.Itoa(len(record)) + " " + record
+		}
+		if _, err := s.conn.Write([]byte(frame)); err != nil {
+			log.Printf("Warning: Failed to send to syslog server %s: %v", s.address, err)
+			s.conn.Close()
+			s.conn = nil
+		}
+	}
+}
+
+// flush waits until the queued records were handed to the connection, at most for timeout
+func (s *syslogWriter) flush(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for len(s.queue) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
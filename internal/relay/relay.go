@@ -0,0 +1,323 @@
+// Package relay implements the relay that connects servers and clients that cannot reach each
+// other directly, and the handshake both sides use to register with it.
+package relay
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+)
+
+// Relay roles announced in the hello message
+const (
+	RelayRoleServer     = "server"      // Control connection of a server, receives open requests
+	RelayRoleClient     = "client"      // Control connection of a client, receives the server list
+	RelayRoleClientData = "client-data" // Tunnel to ServerID requested by a client
+	RelayRoleServerData = "server-data" // Tunnel answering open request Session
+)
+
+// Relay message types
+const (
+	RelayMessageHello   = "hello"   // party -> relay: Role, Tenant, Token and ServerID or Session
+	RelayMessageWelcome = "welcome" // relay -> party: control connection accepted
+	RelayMessageError   = "error"   // relay -> party: Error, the connection is closed
+	RelayMessageServers = "servers" // relay -> client: Servers of the tenant (sent on every change)
+	RelayMessageOpen    = "open"    // relay -> server: open a data connection for Session
+)
+
+// RelayOpenTimeout is how long a client tunnel waits for the server's data connection
+const RelayOpenTimeout = 10 * time.Second
+
+// RelayMessage is exchanged on relay control connections and opens data connections
+type RelayMessage struct {
+	Type     string   `json:"type"`
+	Role     string   `json:"role,omitempty"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Token    string   `json:"token,omitempty"`
+	ServerID string   `json:"server_id,omitempty"`
+	Session  string   `json:"session,omitempty"`
+	Servers  []string `json:"servers,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// relayPeer is a control connection registered at the relay
+type relayPeer struct {
+	encoder  *json.Encoder
+	writeMux sync.Mutex
+}
+
+// send writes a message to the peer; safe for concurrent use
+func (p *relayPeer) send(msg RelayMessage) error {
+	p.writeMux.Lock()
+	defer p.writeMux.Unlock()
+	return p.encoder.Encode(msg)
+}
+
+// relayStream is a data connection together with what its hello decoder already read
+type relayStream struct {
+	conn   net.Conn
+	reader io.Reader
+}
+
+// Relay forwards build traffic between clients and servers that both connect out to it
+type Relay struct {
+	port     int
+	tls      config.TLSConfig
+	tenants  map[string]string
+	servers  map[string]map[string]*relayPeer // Tenant -> server ID -> control connection
+	clients  map[string]map[*relayPeer]bool   // Tenant -> client control connections
+	sessions map[string]chan relayStream      // Open requests waiting for the server's data connection
+	mux      sync.Mutex
+}
+
+// New creates a relay for the configured tenants
+func New(cfg config.RelayConfig, tlsConfig config.TLSConfig) *Relay {
+	return &Relay{
+		port:     cfg.Port,
+		tls:      tlsConfig,
+		tenants:  cfg.Tenants,
+		servers:  make(map[string]map[string]*relayPeer),
+		clients:  make(map[string]map[*relayPeer]bool),
+		sessions: make(map[string]chan relayStream),
+	}
+}
+
+// Start accepts connections from clients and servers
+func (r *Relay) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", r.port))
+	if err != nil {
+		return fmt.Errorf("failed to start relay: %v", err)
+	}
+	defer listener.Close()
+
+	if r.tls.Enabled {
+		certificates, err := transport.NewCertificateStore(r.tls)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go certificates.Watch(done)
+		listener = tls.NewListener(listener, certificates.ServerTLSConfig())
+	}
+
+	logging.Infof("Relay listening on port %d for %d tenants", r.port, len(r.tenants))
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logging.Debugf("Failed to accept relay connection: %v", err)
+			continue
+		}
+		go r.handleConnection(conn)
+	}
+}
+
+// authenticate checks the tenant token of a hello message
+func (r *Relay) authenticate(hello RelayMessage) bool {
+	token, exists := r.tenants[hello.Tenant]
+	return exists && subtle.ConstantTimeCompare([]byte(token), []byte(hello.Token)) == 1
+}
+
+// handleConnection reads the hello of a new connection and handles it according to its role
+func (r *Relay) handleConnection(conn net.Conn) {
+	remote := conn.RemoteAddr().String()
+	decoder := json.NewDecoder(conn)
+	conn.SetReadDeadline(time.Now().Add(RelayOpenTimeout))
+	var hello RelayMessage
+	if err := decoder.Decode(&hello); err != nil || hello.Type != RelayMessageHello {
+		logging.Debugf("Closing relay connection from %s without hello", remote)
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if !r.authenticate(hello) {
+		logging.Eventf(logging.EventWarning, "Relay refused %s from %s: invalid credentials for tenant %q", hello.Role, remote, hello.Tenant)
+		json.NewEncoder(conn).Encode(RelayMessage{Type: RelayMessageError, Error: "invalid tenant credentials"})
+		conn.Close()
+		return
+	}
+
+	stream := relayStream{conn: conn, reader: io.MultiReader(decoder.Buffered(), conn)}
+	switch hello.Role {
+	case RelayRoleServer:
+		r.serveServer(hello, stream)
+	case RelayRoleClient:
+		r.serveClient(hello, stream)
+	case RelayRoleClientData:
+		r.openTunnel(hello, stream)
+	case RelayRoleServerData:
+		r.mux.Lock()
+		waiting, exists := r.sessions[hello.Session]
+		delete(r.sessions, hello.Session)
+		r.mux.Unlock()
+		if !exists {
+			conn.Close()
+			return
+		}
+		waiting <- stream
+	default:
+		logging.Debugf("Closing relay connection from %s with unknown role %q", remote, hello.Role)
+		conn.Close()
+	}
+}
+
+// serverIDs lists the servers of a tenant; callers hold the lock
+func (r *Relay) serverIDs(tenant string) []string {
+	ids := []string{}
+	for id := range r.servers[tenant] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// announceServers sends the server list of a tenant to all its clients
+func (r *Relay) announceServers(tenant string) {
+	r.mux.Lock()
+	message := RelayMessage{Type: RelayMessageServers, Servers: r.serverIDs(tenant)}
+	peers := make([]*relayPeer, 0, len(r.clients[tenant]))
+	for peer := range r.clients[tenant] {
+		peers = append(peers, peer)
+	}
+	r.mux.Unlock()
+
+	for _, peer := range peers {
+		peer.send(message)
+	}
+}
+
+// serveServer registers a server control connection until it closes
+func (r *Relay) serveServer(hello RelayMessage, stream relayStream) {
+	defer stream.conn.Close()
+	peer := &relayPeer{encoder: json.NewEncoder(stream.conn)}
+
+	r.mux.Lock()
+	if r.servers[hello.Tenant] == nil {
+		r.servers[hello.Tenant] = make(map[string]*relayPeer)
+	}
+	r.servers[hello.Tenant][hello.ServerID] = peer
+	r.mux.Unlock()
+	logging.Infof("Relay: server %s of tenant %s registered from %s", hello.ServerID, hello.Tenant, stream.conn.RemoteAddr())
+
+	peer.send(RelayMessage{Type: RelayMessageWelcome})
+	r.announceServers(hello.Tenant)
+
+	// Servers send nothing else; reading detects the disconnect
+	io.Copy(io.Discard, stream.reader)
+
+	r.mux.Lock()
+	if r.servers[hello.Tenant][hello.ServerID] == peer {
+		delete(r.servers[hello.Tenant], hello.ServerID)
+	}
+	r.mux.Unlock()
+	logging.Infof("Relay: server %s of tenant %s disconnected", hello.ServerID, hello.Tenant)
+	r.announceServers(hello.Tenant)
+}
+
+// serveClient sends the tenant's server list to a client control connection until it closes
+func (r *Relay) serveClient(hello RelayMessage, stream relayStream) {
+	defer stream.conn.Close()
+	peer := &relayPeer{encoder: json.NewEncoder(stream.conn)}
+
+	r.mux.Lock()
+	if r.clients[hello.Tenant] == nil {
+		r.clients[hello.Tenant] = make(map[*relayPeer]bool)
+	}
+	r.clients[hello.Tenant][peer] = true
+	servers := r.serverIDs(hello.Tenant)
+	r.mux.Unlock()
+	logging.Infof("Relay: client of tenant %s connected from %s", hello.Tenant, stream.conn.RemoteAddr())
+
+	peer.send(RelayMessage{Type: RelayMessageWelcome})
+	peer.send(RelayMessage{Type: RelayMessageServers, Servers: servers})
+
+	io.Copy(io.Discard, stream.reader)
+
+	r.mux.Lock()
+	delete(r.clients[hello.Tenant], peer)
+	r.mux.Unlock()
+}
+
+// openTunnel asks a server for a data connection and forwards traffic between it and the client
+func (r *Relay) openTunnel(hello RelayMessage, client relayStream) {
+	defer client.conn.Close()
+
+	session := buildutil.GenerateID()
+	waiting := make(chan relayStream, 1)
+	r.mux.Lock()
+	peer, exists := r.servers[hello.Tenant][hello.ServerID]
+	if exists {
+		r.sessions[session] = waiting
+	}
+	r.mux.Unlock()
+	if !exists {
+		logging.Debugf("Relay: tenant %s requested unknown server %s", hello.Tenant, hello.ServerID)
+		return
+	}
+
+	if err := peer.send(RelayMessage{Type: RelayMessageOpen, Session: session}); err != nil {
+		return
+	}
+
+	var server relayStream
+	select {
+	case server = <-waiting:
+	case <-time.After(RelayOpenTimeout):
+		r.mux.Lock()
+		delete(r.sessions, session)
+		r.mux.Unlock()
+		logging.Infof("Relay: server %s did not open session %s in time", hello.ServerID, session)
+		return
+	}
+	defer server.conn.Close()
+
+	logging.Debugf("Relay: tunnel %s between %s and server %s", session, client.conn.RemoteAddr(), hello.ServerID)
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(server.conn, client.reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client.conn, server.reader)
+		done <- struct{}{}
+	}()
+	<-done // Either side closing ends the tunnel
+}
+
+// DialRelay opens a connection to the relay and introduces it with hello
+func DialRelay(cfg config.RelayConnection, hello RelayMessage, timeout time.Duration, tlsSettings config.TLSConfig) (net.Conn, *json.Decoder, error) {
+	conn, err := transport.DialServer(cfg.Address, timeout, tlsSettings)
+	if err != nil {
+		return nil, nil, err
+	}
+	hello.Type = RelayMessageHello
+	hello.Tenant = cfg.Tenant
+	hello.Token = cfg.Token
+	if err := json.NewEncoder(conn).Encode(hello); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, json.NewDecoder(conn), nil
+}
+
+// ExpectWelcome waits for the relay to accept a control connection
+func ExpectWelcome(decoder *json.Decoder) error {
+	var reply RelayMessage
+	if err := decoder.Decode(&reply); err != nil {
+		return err
+	}
+	if reply.Type != RelayMessageWelcome {
+		return fmt.Errorf("relay refused the connection: %s", reply.Error)
+	}
+	return nil
+}
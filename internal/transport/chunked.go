@@ -0,0 +1,169 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"boltbuild/pkg/protocol"
+)
+
+// uploadTimeout bounds how long a server waits for the chunks of a build and keeps unclaimed ones
+const uploadTimeout = 5 * time.Minute
+
+// maxPendingUploads bounds how many chunked uploads a server reassembles at once
+const maxPendingUploads = 64
+
+// pendingUpload collects the chunks of one build
+type pendingUpload struct {
+	chunks   map[int][]byte
+	size     protocol.ByteSize // Bytes of all received chunks
+	expected int               // 0 until the build request arrived
+	created  time.Time
+	complete chan struct{}
+	closed   bool
+	err      error // Why the upload was refused; its chunks are dropped
+}
+
+// checkComplete signals the waiting build once every chunk arrived; the store's mux must be held
+func (p *pendingUpload) checkComplete() {
+	if p.closed || p.expected == 0 {
+		return
+	}
+	for i := 0; i < p.expected; i++ {
+		if _, exists := p.chunks[i]; !exists {
+			return
+		}
+	}
+	p.closed = true
+	close(p.complete)
+}
+
+// fail refuses the upload, drops its chunks and wakes the waiting build; the store's mux must be held
+func (p *pendingUpload) fail(err error) {
+	p.err = err
+	p.chunks = make(map[int][]byte)
+	p.size = 0
+	if !p.closed {
+		p.closed = true
+		close(p.complete)
+	}
+}
+
+// UploadStore reassembles chunked uploads, whose chunks may arrive on any connection before
+// or after the build request
+type UploadStore struct {
+	mux     sync.Mutex
+	uploads map[string]*pendingUpload
+	maxSize protocol.ByteSize // Limit for the chunks of one upload (0 = unlimited)
+}
+
+// NewUploadStore creates an empty upload store accepting up to maxSize bytes per upload
+// (0 = unlimited)
+func NewUploadStore(maxSize protocol.ByteSize) *UploadStore {
+	return &UploadStore{uploads: make(map[string]*pendingUpload), maxSize: maxSize}
+}
+
+// get returns the upload of a build, creating it unless too many are pending; mux must be held
+func (u *UploadStore) get(buildID string) (*pendingUpload, error) {
+	upload, exists := u.uploads[buildID]
+	if !exists {
+		if len(u.uploads) >= maxPendingUploads {
+			return nil, fmt.Errorf("too many pending uploads (%d)", maxPendingUploads)
+		}
+		upload = &pendingUpload{chunks: make(map[int][]byte), created: time.Now(), complete: make(chan struct{})}
+		u.uploads[buildID] = upload
+	}
+	return upload, nil
+}
+
+// Add stores a received chunk. Chunks beyond the size limit refuse the whole upload.
+func (u *UploadStore) Add(buildID string, chunk protocol.UploadChunk) error {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	upload, err := u.get(buildID)
+	if err != nil {
+		return err
+	}
+	if upload.err != nil {
+		return upload.err
+	}
+
+	upload.size += protocol.ByteSize(len(chunk.Data) - len(upload.chunks[chunk.Index]))
+	if u.maxSize > 0 && upload.size > u.maxSize {
+		upload.fail(fmt.Errorf("upload exceeds the workspace size limit of %s", u.maxSize))
+		return upload.err
+	}
+	upload.chunks[chunk.Index] = chunk.Data
+	upload.checkComplete()
+	return nil
+}
+
+// Expire drops uploads whose build request did not arrive within the upload timeout
+func (u *UploadStore) Expire() {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	for id, upload := range u.uploads {
+		if upload.expected == 0 && time.Since(upload.created) > uploadTimeout {
+			delete(u.uploads, id)
+		}
+	}
+}
+
+// Assemble waits for all chunks of a request and restores its files, unless ctx is done first
+// or the upload was refused
+func (u *UploadStore) Assemble(ctx context.Context, request *protocol.BuildRequest) error {
+	u.mux.Lock()
+	upload, err := u.get(request.ID)
+	if err != nil {
+		u.mux.Unlock()
+		return err
+	}
+	upload.expected = request.Upload.Chunks
+	if size := protocol.ByteSize(request.Upload.Size); u.maxSize > 0 && size > u.maxSize {
+		upload.fail(fmt.Errorf("upload of %s exceeds the workspace size limit of %s", size, u.maxSize))
+	}
+	upload.checkComplete()
+	u.mux.Unlock()
+
+	defer func() {
+		u.mux.Lock()
+		delete(u.uploads, request.ID)
+		u.mux.Unlock()
+	}()
+
+	select {
+	case <-upload.complete:
+		if upload.err != nil {
+			return upload.err
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("upload cancelled: %v", ctx.Err())
+	case <-time.After(uploadTimeout):
+		u.mux.Lock()
+		received := len(upload.chunks)
+		u.mux.Unlock()
+		return fmt.Errorf("upload incomplete: received %d of %d chunks", received, request.Upload.Chunks)
+	}
+
+	u.mux.Lock()
+	var data []byte
+	for i := 0; i < request.Upload.Chunks; i++ {
+		data = append(data, upload.chunks[i]...)
+	}
+	u.mux.Unlock()
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != request.Upload.SHA256 {
+		return fmt.Errorf("upload checksum mismatch")
+	}
+	if err := json.Unmarshal(data, &request.Files); err != nil {
+		return fmt.Errorf("invalid upload: %v", err)
+	}
+	request.Upload = nil
+	return nil
+}
@@ -0,0 +1,109 @@
+package transport
+
+import (
+	"encoding/base64"
+	"errors"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// defaultFaultDelay is the longest injected delay when faults.max_delay is not set
+const defaultFaultDelay = 5 * time.Second
+
+// ErrFaultInjected is returned by sends that fault injection dropped
+var ErrFaultInjected = errors.New("connection dropped by fault injection")
+
+// FaultInjector decides which of the configured faults happen; a nil injector injects none
+type FaultInjector struct {
+	config config.FaultConfig
+	random *rand.Rand
+	mux    sync.Mutex
+}
+
+// NewFaultInjector creates the injector of a client or server, nil when no faults are configured
+func NewFaultInjector(cfg config.FaultConfig) *FaultInjector {
+	if cfg.Disconnect <= 0 && cfg.Delay <= 0 && cfg.Corrupt <= 0 {
+		return nil
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &FaultInjector{config: cfg, random: rand.New(rand.NewSource(seed))}
+}
+
+// inject reports whether a fault with the given probability happens now
+func (f *FaultInjector) inject(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.random.Float64() < rate
+}
+
+// Disconnect drops a connection instead of sending a message, with the configured probability
+func (f *FaultInjector) Disconnect(conn net.Conn, peer string) bool {
+	if f == nil || !f.inject(f.config.Disconnect) {
+		return false
+	}
+	logging.Infof("Fault injection: dropping the connection to %s", peer)
+	conn.Close()
+	return true
+}
+
+// Delay holds back the result of a build, with the configured probability
+func (f *FaultInjector) Delay(buildID string) {
+	if f == nil || !f.inject(f.config.Delay) {
+		return
+	}
+	maxDelay := f.config.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultFaultDelay
+	}
+	f.mux.Lock()
+	delay := time.Duration(f.random.Int63n(int64(maxDelay)) + 1)
+	f.mux.Unlock()
+
+	logging.Infof("Fault injection: delaying the result of build %s by %v", buildID, delay.Round(time.Millisecond))
+	time.Sleep(delay)
+}
+
+// Corrupt alters one output file of a result after its checksum was computed, with the
+// configured probability
+func (f *FaultInjector) Corrupt(response *protocol.BuildResponse) {
+	if f == nil || len(response.OutputFiles) == 0 || !f.inject(f.config.Corrupt) {
+		return
+	}
+	paths := make([]string, 0, len(response.OutputFiles))
+	for path, encoded := range response.OutputFiles {
+		if len(encoded) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	sort.Strings(paths)
+
+	f.mux.Lock()
+	path := paths[f.random.Intn(len(paths))]
+	f.mux.Unlock()
+	content, err := base64.StdEncoding.DecodeString(response.OutputFiles[path])
+	if err != nil || len(content) == 0 {
+		return
+	}
+	f.mux.Lock()
+	content[f.random.Intn(len(content))] ^= 0xff
+	f.mux.Unlock()
+
+	logging.Infof("Fault injection: corrupting artifact %s of build %s", path, response.ID)
+	response.OutputFiles[path] = base64.StdEncoding.EncodeToString(content)
+}
@@ -0,0 +1,8 @@
+package transport
+
+import (
+	"time"
+)
+
+// ReverseRetryInterval is how long a server waits before dialing a client again
+const ReverseRetryInterval = 10 * time.Second
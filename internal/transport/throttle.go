@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// minBurst keeps the bucket large enough for efficient socket writes at low rates
+const minBurst = 4 * protocol.KB
+
+// TokenBucket is a rate limiter shared by all connections of a component
+type TokenBucket struct {
+	rate       float64 // Bytes per second
+	burst      float64
+	start, end int  // Active minutes of the day
+	always     bool // No limit hours configured
+	tokens     float64
+	last       time.Time
+	mux        sync.Mutex
+}
+
+// NewTokenBucket creates the limiter for a configuration, nil when unlimited
+func NewTokenBucket(cfg config.BandwidthConfig) *TokenBucket {
+	if cfg.MaxBandwidth <= 0 {
+		return nil
+	}
+	bucket := &TokenBucket{rate: float64(cfg.MaxBandwidth), always: cfg.LimitHours == "", last: time.Now()}
+	bucket.burst = bucket.rate / 10
+	if bucket.burst < float64(minBurst) {
+		bucket.burst = float64(minBurst)
+	}
+	bucket.tokens = bucket.burst
+	if !bucket.always {
+		// The configuration was validated, so the hours parse
+		bucket.start, bucket.end, _ = config.ParseLimitHours(cfg.LimitHours)
+	}
+	return bucket
+}
+
+// active reports whether the limit applies at the given time
+func (b *TokenBucket) active(now time.Time) bool {
+	if b.always {
+		return true
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if b.start <= b.end {
+		return minute >= b.start && minute < b.end
+	}
+	return minute >= b.start || minute < b.end // Overnight range
+}
+
+// wait blocks until n bytes (at most burst) may be written
+func (b *TokenBucket) wait(n int) {
+	for {
+		b.mux.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		if b.tokens >= float64(n) {
+			b.tokens -= float64(n)
+			b.mux.Unlock()
+			return
+		}
+		missing := float64(n) - b.tokens
+		b.mux.Unlock()
+		time.Sleep(time.Duration(missing / b.rate * float64(time.Second)))
+	}
+}
+
+// throttledConn limits the writes of a connection through a shared token bucket
+type throttledConn struct {
+	net.Conn
+	bucket *TokenBucket
+}
+
+// Throttle wraps a connection when a bandwidth limit is configured
+func Throttle(conn net.Conn, bucket *TokenBucket) net.Conn {
+	if bucket == nil {
+		return conn
+	}
+	return &throttledConn{Conn: conn, bucket: bucket}
+}
+
+// Write sends p in pieces no larger than the bucket's burst, waiting for tokens before each
+func (c *throttledConn) Write(p []byte) (int, error) {
+	if !c.bucket.active(time.Now()) {
+		return c.Conn.Write(p)
+	}
+
+	written := 0
+	for written < len(p) {
+		size := len(p) - written
+		if size > int(c.bucket.burst) {
+			size = int(c.bucket.burst)
+		}
+		c.bucket.wait(size)
+		n, err := c.Conn.Write(p[written : written+size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
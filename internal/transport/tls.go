@@ -0,0 +1,301 @@
+// Package transport provides the connection plumbing clients and servers share: TLS setup,
+// WebSocket tunnels, bandwidth throttling, chunked uploads and fault injection for testing.
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// caValidity is the lifetime of a CA created for auto_rotate
+const caValidity = 10 * 365 * 24 * time.Hour
+
+// certificateStore serves the current server certificate, reloading it when the files change
+// and issuing a new one before expiry with auto_rotate. Connections keep the certificate they
+// were established with, new connections get the current one.
+type certificateStore struct {
+	config  config.TLSConfig
+	cert    *tls.Certificate
+	modTime time.Time // Of the loaded certificate file
+	mux     sync.RWMutex
+}
+
+// NewCertificateStore loads (or with auto_rotate creates) the server certificate
+func NewCertificateStore(cfg config.TLSConfig) (*certificateStore, error) {
+	store := &certificateStore{config: cfg}
+	if err := store.refresh(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// getCertificate implements tls.Config.GetCertificate
+func (s *certificateStore) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.cert, nil
+}
+
+// refresh rotates the certificate if it is due and reloads it if the files changed
+func (s *certificateStore) refresh() error {
+	if s.config.AutoRotate && rotationDue(s.config) {
+		if err := issueServerCertificate(s.config); err != nil {
+			return fmt.Errorf("failed to rotate certificate: %v", err)
+		}
+		logging.Infof("Issued a new TLS certificate %s", s.config.CertFile)
+	}
+
+	info, err := os.Stat(s.config.CertFile)
+	if err != nil {
+		return err
+	}
+	s.mux.RLock()
+	unchanged := !info.ModTime().After(s.modTime)
+	s.mux.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		// A half-replaced pair is picked up on the next check
+		return fmt.Errorf("failed to load certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %v", err)
+	}
+
+	s.mux.Lock()
+	s.cert = &cert
+	s.modTime = info.ModTime()
+	s.mux.Unlock()
+	logging.Infof("Loaded TLS certificate %s (expires %s)", s.config.CertFile, leaf.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+// rotationDue reports whether the certificate on disk is missing or expires within renew_before
+func rotationDue(cfg config.TLSConfig) bool {
+	data, err := os.ReadFile(cfg.CertFile)
+	if err != nil {
+		return true
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return true
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	return time.Until(leaf.NotAfter) < cfg.RenewBefore
+}
+
+// Watch checks the certificate files periodically until done is closed
+func (s *certificateStore) Watch(done <-chan struct{}) {
+	ticker := time.NewTicker(s.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.refresh(); err != nil {
+				logging.Infof("Warning: TLS certificate refresh failed, keeping the current one: %v", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// ServerTLSConfig returns the TLS configuration for the build server listener
+func (s *certificateStore) ServerTLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: s.getCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// ClientTLSConfig returns the TLS configuration for connecting to a server. The CA file is read
+// for every connection, so a CA bundle updated for a rollover is used without a restart.
+func ClientTLSConfig(cfg config.TLSConfig, host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" && !cfg.InsecureSkipVerify {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// DialServer connects to a build server, over TLS when enabled in config; ws:// and wss:// URLs
+// select the WebSocket transport instead
+func DialServer(addr string, timeout time.Duration, cfg config.TLSConfig) (net.Conn, error) {
+	if config.IsWebSocketURL(addr) {
+		conn, err := dialWebSocket(addr, timeout, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return logging.TraceConn(conn, addr), nil
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return logging.TraceConn(conn, addr), nil
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+	tlsConfig, err := ClientTLSConfig(cfg, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return logging.TraceConn(tlsConn, addr), nil
+}
+
+// issueServerCertificate signs a new server certificate for this machine's names and addresses,
+// creating the CA first if it does not exist yet
+func issueServerCertificate(cfg config.TLSConfig) error {
+	ca, caKey, err := loadOrCreateCA(cfg)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: hostname, Organization: []string{"BoltBuild"}},
+		NotBefore:    time.Now().Add(-time.Hour), // Tolerate clock skew between machines
+		NotAfter:     time.Now().Add(cfg.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{hostname, "localhost"},
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				template.IPAddresses = append(template.IPAddresses, ipNet.IP)
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	// Key first: the certificate's modification time triggers the reload of both
+	if err := writePEMFile(cfg.KeyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return err
+	}
+	return writePEMFile(cfg.CertFile, "CERTIFICATE", der, 0644)
+}
+
+// loadOrCreateCA reads the CA used by auto_rotate, creating a self-signed one on first use
+func loadOrCreateCA(cfg config.TLSConfig) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	if pair, err := tls.LoadX509KeyPair(cfg.CAFile, cfg.CAKeyFile); err == nil {
+		ca, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("CA key %s is not an ECDSA key", cfg.CAKeyFile)
+		}
+		return ca, key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to load CA: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "BoltBuild CA", Organization: []string{"BoltBuild"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEMFile(cfg.CAKeyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return nil, nil, err
+	}
+	if err := writePEMFile(cfg.CAFile, "CERTIFICATE", der, 0644); err != nil {
+		return nil, nil, err
+	}
+	logging.Infof("Created TLS CA %s, distribute it to clients as tls.ca_file", cfg.CAFile)
+
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+// randomSerial returns a random certificate serial number
+func randomSerial() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	return serial
+}
+
+// writePEMFile replaces a PEM file atomically so readers never see a partial file
+func writePEMFile(path, blockType string, der []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
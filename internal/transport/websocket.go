@@ -0,0 +1,299 @@
+package transport
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// WebSocket settings
+const (
+	webSocketGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11" // RFC 6455 handshake constant
+	WebSocketDefaultPath  = "/boltbuild"
+	webSocketMaxFrameSize = 1 << 30 // Larger frames are refused rather than allocated
+)
+
+// WebSocket opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// wsConn carries the build protocol in binary WebSocket messages. Every Write is sent as one
+// message and Read returns message payloads as a continuous stream, so the JSON encoders on both
+// sides work unchanged.
+type wsConn struct {
+	net.Conn
+	reader    *bufio.Reader
+	client    bool // Clients mask their frames, servers must not
+	remaining int64
+	masked    bool
+	mask      [4]byte
+	maskPos   int
+	writeMux  sync.Mutex
+	closeOnce sync.Once
+}
+
+// Read returns payload bytes of data messages, answering pings on the way
+func (c *wsConn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if err := c.nextFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.reader.Read(p)
+	if c.masked {
+		for i := 0; i < n; i++ {
+			p[i] ^= c.mask[c.maskPos%4]
+			c.maskPos++
+		}
+	}
+	c.remaining -= int64(n)
+	return n, err
+}
+
+// nextFrame reads frame headers until a data frame starts, handling control frames in between
+func (c *wsConn) nextFrame() error {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return err
+	}
+	opcode := header[0] & 0x0F
+	c.masked = header[1]&0x80 != 0
+	if c.masked == c.client {
+		return fmt.Errorf("websocket frame masking violates the protocol")
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return err
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return err
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]))
+	}
+	if length < 0 || length > webSocketMaxFrameSize {
+		return fmt.Errorf("websocket frame of %d bytes is too large", length)
+	}
+	if c.masked {
+		if _, err := io.ReadFull(c.reader, c.mask[:]); err != nil {
+			return err
+		}
+	}
+	c.maskPos = 0
+
+	switch opcode {
+	case wsOpBinary, wsOpText, wsOpContinuation:
+		c.remaining = length
+		return nil
+	}
+
+	// Control frames are small and handled here
+	if length > 125 {
+		return fmt.Errorf("websocket control frame of %d bytes", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return err
+	}
+	if c.masked {
+		for i := range payload {
+			payload[i] ^= c.mask[i%4]
+		}
+	}
+	switch opcode {
+	case wsOpPing:
+		return c.writeFrame(wsOpPong, payload)
+	case wsOpClose:
+		c.writeFrame(wsOpClose, nil)
+		return io.EOF
+	}
+	return nil // Unsolicited pongs
+}
+
+// Write sends p as one binary message
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(wsOpBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeFrame sends a single final frame, masked when this is the client side
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | opcode
+	switch {
+	case len(payload) < 126:
+		header[1] = byte(len(payload))
+	case len(payload) <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	frame := payload
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		header[1] |= 0x80
+		header = append(header, mask[:]...)
+		frame = make([]byte, len(payload))
+		for i, b := range payload {
+			frame[i] = b ^ mask[i%4]
+		}
+	}
+
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	if _, err := c.Conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.Conn.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the underlying connection
+func (c *wsConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.writeFrame(wsOpClose, nil)
+	})
+	return c.Conn.Close()
+}
+
+// webSocketAccept computes the Sec-WebSocket-Accept value for a handshake key
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// dialWebSocket connects to a server's WebSocket endpoint given as ws:// or wss:// URL
+func dialWebSocket(rawURL string, timeout time.Duration, cfg config.TLSConfig) (net.Conn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket URL %s: %v", rawURL, err)
+	}
+	host := target.Host
+	if target.Port() == "" {
+		if target.Scheme == "wss" {
+			host = net.JoinHostPort(target.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(target.Hostname(), "80")
+		}
+	}
+	path := target.RequestURI()
+	if target.Path == "" {
+		path = WebSocketDefaultPath
+	}
+
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// wss uses the configured CA when there is one and the system roots otherwise
+	if target.Scheme == "wss" {
+		tlsConfig, err := ClientTLSConfig(cfg, target.Hostname())
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	request := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\nUser-Agent: boltbuild/%s\r\n\r\n", path, target.Host, key, protocol.Version)
+	if _, err := io.WriteString(conn, request); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake with %s failed: %v", rawURL, err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake with %s failed: %s", rawURL, response.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return &wsConn{Conn: conn, reader: reader, client: true}, nil
+}
+
+// AcceptWebSocket upgrades a request to a WebSocket connection. Requests that are no valid
+// upgrade are answered with an error status and nil is returned.
+func AcceptWebSocket(w http.ResponseWriter, r *http.Request) net.Conn {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "WebSocket upgrade required", http.StatusUpgradeRequired)
+		return nil
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		logging.Debugf("WebSocket hijack failed: %v", err)
+		return nil
+	}
+	conn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n\r\n"
+	if _, err := io.WriteString(conn, response); err != nil {
+		conn.Close()
+		return nil
+	}
+	return &wsConn{Conn: conn, reader: rw.Reader}
+}
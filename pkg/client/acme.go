@@ -0,0 +1,28 @@
+package client
+
+import (
+	"boltbuild/pkg/config"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// defaultACMECacheDir holds the account key and certificates obtained by ACME
+const defaultACMECacheDir = "boltbuild-acme"
+
+// acmeManager creates the certificate manager, accepting the CA's terms of service on the user's behalf
+func acmeManager(c config.ACMEConfig) *autocert.Manager {
+	cacheDir := c.CacheDir
+	if cacheDir == "" {
+		cacheDir = defaultACMECacheDir
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(c.Hosts...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      c.Email,
+	}
+	if c.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: c.DirectoryURL}
+	}
+	return manager
+}
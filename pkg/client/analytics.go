@@ -0,0 +1,566 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/internal/buildutil"
+)
+
+// exportColumns are the CSV columns of a build history export
+var exportColumns = []string{"id", "environment", "project", "server_id", "server_addr", "success", "error",
+	"submitted_at", "duration_ms", "queue_wait_ms", "parameters", "output_files", "warnings"}
+
+// StatsSummary aggregates a group of build records
+type StatsSummary struct {
+	Builds       int           `json:"builds"`
+	Succeeded    int           `json:"succeeded"`
+	SuccessRate  float64       `json:"success_rate"` // 0..1
+	P50Duration  time.Duration `json:"p50_duration"`
+	P95Duration  time.Duration `json:"p95_duration"`
+	AvgQueueWait time.Duration `json:"avg_queue_wait"`
+	MaxQueueWait time.Duration `json:"max_queue_wait"`
+}
+
+// StatsBucket is the summary of one time interval
+type StatsBucket struct {
+	Start time.Time `json:"start"`
+	StatsSummary
+}
+
+// BuildStats is returned by /api/stats
+type BuildStats struct {
+	Since        time.Time               `json:"since"`
+	Bucket       time.Duration           `json:"bucket"`
+	Total        StatsSummary            `json:"total"`
+	Environments map[string]StatsSummary `json:"environments"`
+	Servers      map[string]StatsSummary `json:"servers"` // By server address
+	Trend        []StatsBucket           `json:"trend"`   // Oldest first, empty intervals included
+}
+
+// summarize computes success rate, duration percentiles and queue waits of records
+func summarize(records []BuildRecord) StatsSummary {
+	summary := StatsSummary{Builds: len(records)}
+	if len(records) == 0 {
+		return summary
+	}
+
+	var durations []time.Duration
+	var totalWait time.Duration
+	for _, record := range records {
+		if record.Success {
+			summary.Succeeded++
+			durations = append(durations, record.Duration)
+		}
+		totalWait += record.QueueWait
+		if record.QueueWait > summary.MaxQueueWait {
+			summary.MaxQueueWait = record.QueueWait
+		}
+	}
+	summary.SuccessRate = float64(summary.Succeeded) / float64(len(records))
+	summary.AvgQueueWait = totalWait / time.Duration(len(records))
+
+	// Durations of failed builds say little about build time, only successful ones count
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		summary.P50Duration = buildutil.Percentile(durations, 0.50)
+		summary.P95Duration = buildutil.Percentile(durations, 0.95)
+	}
+	return summary
+}
+
+// computeStats groups the records submitted since a point in time by environment, server and interval
+func computeStats(records []BuildRecord, since time.Time, bucket time.Duration) BuildStats {
+	stats := BuildStats{Since: since, Bucket: bucket}
+
+	var selected []BuildRecord
+	byBucket := make(map[int][]BuildRecord)
+	for _, record := range records {
+		if record.SubmittedAt.Before(since) {
+			continue
+		}
+		selected = append(selected, record)
+		index := int(record.SubmittedAt.Sub(since) / bucket)
+		byBucket[index] = append(byBucket[index], record)
+	}
+
+	stats.Total = summarize(selected)
+	stats.Environments, stats.Servers = groupSummaries(selected)
+
+	for start, index := since, 0; start.Before(time.Now()); start, index = start.Add(bucket), index+1 {
+		stats.Trend = append(stats.Trend, StatsBucket{Start: start, StatsSummary: summarize(byBucket[index])})
+	}
+	return stats
+}
+
+// groupSummaries summarizes records per environment and per server address
+func groupSummaries(records []BuildRecord) (map[string]StatsSummary, map[string]StatsSummary) {
+	byEnvironment := make(map[string][]BuildRecord)
+	byServer := make(map[string][]BuildRecord)
+	for _, record := range records {
+		byEnvironment[record.Environment] = append(byEnvironment[record.Environment], record)
+		byServer[record.ServerAddr] = append(byServer[record.ServerAddr], record)
+	}
+
+	environments := make(map[string]StatsSummary)
+	for name, group := range byEnvironment {
+		environments[name] = summarize(group)
+	}
+	servers := make(map[string]StatsSummary)
+	for addr, group := range byServer {
+		servers[addr] = summarize(group)
+	}
+	return environments, servers
+}
+
+// EnvironmentOverview is the at-a-glance summary of an environment shown on the dashboard
+type EnvironmentOverview struct {
+	BuildsToday      int           `json:"builds_today"`
+	FailedToday      int           `json:"failed_today"`
+	AvgDuration      time.Duration `json:"avg_duration"`               // Successful builds in the history
+	PreviousAvg      time.Duration `json:"previous_avg,omitempty"`     // Average before today, to spot regressions
+	LastFailure      *BuildRecord  `json:"last_failure,omitempty"`     // Most recent failed build
+	MostUsedServer   string        `json:"most_used_server,omitempty"` // Server address
+	MostUsedBuilds   int           `json:"most_used_builds,omitempty"` // Builds of the environment on that server
+	MostUsedServerID string        `json:"most_used_server_id,omitempty"`
+}
+
+// environmentOverviews summarizes the history per environment (records newest first)
+func environmentOverviews(records []BuildRecord, now time.Time) map[string]*EnvironmentOverview {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	overviews := make(map[string]*EnvironmentOverview)
+	type durations struct {
+		total, previousTotal time.Duration
+		count, previousCount int
+	}
+	sums := make(map[string]*durations)
+	serverUse := make(map[string]map[string]int)
+	serverIDs := make(map[string]string)
+
+	for i := range records {
+		record := records[i]
+		overview, exists := overviews[record.Environment]
+		if !exists {
+			overview = &EnvironmentOverview{}
+			overviews[record.Environment] = overview
+			sums[record.Environment] = &durations{}
+			serverUse[record.Environment] = make(map[string]int)
+		}
+
+		if !record.SubmittedAt.Before(today) {
+			overview.BuildsToday++
+			if !record.Success {
+				overview.FailedToday++
+			}
+		}
+		if !record.Success && overview.LastFailure == nil {
+			overview.LastFailure = &record
+		}
+		if record.Success {
+			sum := sums[record.Environment]
+			sum.total += record.Duration
+			sum.count++
+			if record.SubmittedAt.Before(today) {
+				sum.previousTotal += record.Duration
+				sum.previousCount++
+			}
+		}
+		if record.ServerAddr != "" {
+			serverUse[record.Environment][record.ServerAddr]++
+			serverIDs[record.ServerAddr] = record.ServerID
+		}
+	}
+
+	for name, overview := range overviews {
+		sum := sums[name]
+		if sum.count > 0 {
+			overview.AvgDuration = sum.total / time.Duration(sum.count)
+		}
+		if sum.previousCount > 0 {
+			overview.PreviousAvg = sum.previousTotal / time.Duration(sum.previousCount)
+		}
+		for addr, count := range serverUse[name] {
+			if count > overview.MostUsedBuilds || (count == overview.MostUsedBuilds && addr < overview.MostUsedServer) {
+				overview.MostUsedServer, overview.MostUsedBuilds = addr, count
+				overview.MostUsedServerID = serverIDs[addr]
+			}
+		}
+	}
+	return overviews
+}
+
+// handleEnvironmentStatsAPI returns the dashboard summary of every environment with builds
+func (ws *WebServer) handleEnvironmentStatsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(environmentOverviews(ws.client.history.List(), time.Now()))
+}
+
+// parseStatsPeriod parses "7d", "24h" or "90m" style periods
+func parseStatsPeriod(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid period: %s", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("invalid period: %s", value)
+	}
+	return period, nil
+}
+
+// handleStatsAPI returns build analytics (?period=7d&bucket=1d&environment=X&project=Y)
+func (ws *WebServer) handleStatsAPI(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	period, err := parseStatsPeriod(query.Get("period"), 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bucket, err := parseStatsPeriod(query.Get("bucket"), 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if period/bucket > 1000 {
+		http.Error(w, "too many buckets, use a larger bucket", http.StatusBadRequest)
+		return
+	}
+
+	records := ws.client.history.List()
+	if environment, project := query.Get("environment"), query.Get("project"); environment != "" || project != "" {
+		filtered := records[:0]
+		for _, record := range records {
+			if (environment == "" || record.Environment == environment) && (project == "" || record.Project == project) {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	// Align buckets to the bucket size so consecutive requests show the same intervals
+	since := time.Now().Add(-period).Truncate(bucket)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(computeStats(records, since, bucket))
+}
+
+// handleStatsPage serves the analytics dashboard
+func (ws *WebServer) handleStatsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>BoltBuild - Build Analytics</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: 'Inter', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            background: #031C26;
+            color: #A4FFF0;
+            min-height: 100vh;
+            padding: 20px;
+        }
+        .container { max-width: 1400px; margin: 0 auto; }
+        .header { display: flex; justify-content: space-between; align-items: center; padding: 20px 0 30px; }
+        .header h1 { font-size: 2rem; letter-spacing: -1px; }
+        .header a { color: #A4FFF0; }
+        .controls select { background: #062B38; color: #A4FFF0; border: 1px solid rgba(164, 255, 240, 0.3); padding: 6px 10px; border-radius: 6px; }
+        .card {
+            background: rgba(164, 255, 240, 0.05);
+            border: 1px solid rgba(164, 255, 240, 0.2);
+            border-radius: 12px;
+            padding: 20px;
+            margin-bottom: 24px;
+        }
+        .card h2 { font-size: 1.1rem; margin-bottom: 15px; }
+        .totals { display: grid; grid-template-columns: repeat(auto-fit, minmax(160px, 1fr)); gap: 15px; }
+        .total .value { font-size: 1.8rem; font-weight: 700; color: #fff; }
+        .total .label { font-size: 0.8rem; opacity: 0.7; }
+        .charts { display: grid; grid-template-columns: 1fr 1fr; gap: 24px; }
+        @media (max-width: 900px) { .charts { grid-template-columns: 1fr; } }
+        canvas { width: 100%; height: 220px; }
+        table { width: 100%; border-collapse: collapse; font-size: 0.9rem; }
+        th, td { text-align: left; padding: 8px; border-bottom: 1px solid rgba(164, 255, 240, 0.1); }
+        th { opacity: 0.7; font-weight: 600; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>📈 Build Analytics</h1>
+            <div class="controls">
+                <select id="period">
+                    <option value="1d|1h">Last 24 hours</option>
+                    <option value="7d|1d" selected>Last 7 days</option>
+                    <option value="30d|1d">Last 30 days</option>
+                </select>
+                &nbsp;<a href="#" id="export-csv">⬇ CSV</a>
+                &nbsp;<a href="#" id="export-json">⬇ JSON</a>
+                &nbsp;<a href="./">← Dashboard</a>
+            </div>
+        </div>
+
+        <div class="card"><div class="totals" id="totals"></div></div>
+
+        <div class="charts">
+            <div class="card"><h2>Builds and success rate</h2><canvas id="builds-chart"></canvas></div>
+            <div class="card"><h2>Duration p50 / p95 and queue wait</h2><canvas id="duration-chart"></canvas></div>
+        </div>
+
+        <div class="card"><h2>Environments</h2><table id="environments"></table></div>
+        <div class="card"><h2>Servers</h2><table id="servers"></table></div>
+    </div>
+
+    <script>
+        function formatDuration(nanoseconds) {
+            const ms = nanoseconds / 1000000;
+            if (ms < 1000) return Math.round(ms) + 'ms';
+            const seconds = ms / 1000;
+            if (seconds < 60) return seconds.toFixed(1) + 's';
+            return Math.floor(seconds / 60) + 'm ' + Math.round(seconds % 60) + 's';
+        }
+
+        function percent(rate) {
+            return Math.round(rate * 100) + '%';
+        }
+
+        // Draws series as bars (first) and lines (others) scaled to their own maximum
+        function drawChart(canvas, labels, series) {
+            const ratio = window.devicePixelRatio || 1;
+            canvas.width = canvas.clientWidth * ratio;
+            canvas.height = canvas.clientHeight * ratio;
+            const ctx = canvas.getContext('2d');
+            ctx.scale(ratio, ratio);
+            const width = canvas.clientWidth, height = canvas.clientHeight - 30;
+            const step = width / Math.max(labels.length, 1);
+
+            series.forEach((s, index) => {
+                const max = Math.max(...s.values, s.min || 0) || 1;
+                ctx.strokeStyle = ctx.fillStyle = s.color;
+                if (index === 0) {
+                    s.values.forEach((v, i) => {
+                        const h = v / max * height;
+                        ctx.fillRect(i * step + step * 0.15, height - h, step * 0.7, h);
+                    });
+                } else {
+                    ctx.lineWidth = 2;
+                    ctx.beginPath();
+                    s.values.forEach((v, i) => {
+                        const x = i * step + step / 2, y = height - v / max * height;
+                        i === 0 ? ctx.moveTo(x, y) : ctx.lineTo(x, y);
+                    });
+                    ctx.stroke();
+                }
+            });
+
+            ctx.fillStyle = 'rgba(164, 255, 240, 0.7)';
+            ctx.font = '11px sans-serif';
+            const every = Math.ceil(labels.length / 8);
+            labels.forEach((label, i) => {
+                if (i % every === 0) ctx.fillText(label, i * step + 2, height + 18);
+            });
+            let x = 0;
+            series.forEach(s => {
+                ctx.fillStyle = s.color;
+                ctx.fillText('■ ' + s.name, width - 320 + x, 12);
+                x += 110;
+            });
+        }
+
+        function renderTable(table, rows, keyTitle) {
+            const names = Object.keys(rows).sort();
+            if (names.length === 0) {
+                table.innerHTML = '<tr><td>No builds in this period</td></tr>';
+                return;
+            }
+            table.innerHTML = '<tr><th>' + keyTitle + '</th><th>Builds</th><th>Success rate</th><th>p50</th><th>p95</th><th>Avg queue wait</th></tr>' +
+                names.map(name => {
+                    const s = rows[name];
+                    return '<tr><td>' + (name || '-') + '</td><td>' + s.builds + '</td><td>' + percent(s.success_rate) + '</td><td>' +
+                        formatDuration(s.p50_duration) + '</td><td>' + formatDuration(s.p95_duration) + '</td><td>' + formatDuration(s.avg_queue_wait) + '</td></tr>';
+                }).join('');
+        }
+
+        function loadStats() {
+            const [period, bucket] = document.getElementById('period').value.split('|');
+            fetch('api/v1/stats?period=' + period + '&bucket=' + bucket)
+                .then(response => response.json())
+                .then(stats => {
+                    const t = stats.total;
+                    document.getElementById('totals').innerHTML = [
+                        ['Builds', t.builds], ['Success rate', percent(t.success_rate)],
+                        ['p50 duration', formatDuration(t.p50_duration)], ['p95 duration', formatDuration(t.p95_duration)],
+                        ['Avg queue wait', formatDuration(t.avg_queue_wait)]
+                    ].map(([label, value]) => '<div class="total"><div class="value">' + value + '</div><div class="label">' + label + '</div></div>').join('');
+
+                    const hourly = bucket.endsWith('h');
+                    const labels = stats.trend.map(b => {
+                        const d = new Date(b.start);
+                        return hourly ? d.getHours() + ':00' : (d.getMonth() + 1) + '/' + d.getDate();
+                    });
+                    drawChart(document.getElementById('builds-chart'), labels, [
+                        { name: 'builds', color: 'rgba(164, 255, 240, 0.35)', values: stats.trend.map(b => b.builds) },
+                        { name: 'success rate', color: '#7CFC9A', min: 1, values: stats.trend.map(b => b.success_rate) }
+                    ]);
+                    const durationMax = Math.max(...stats.trend.map(b => b.p95_duration), 1);
+                    drawChart(document.getElementById('duration-chart'), labels, [
+                        { name: 'p95', color: 'rgba(164, 255, 240, 0.35)', values: stats.trend.map(b => b.p95_duration) },
+                        { name: 'p50', color: '#ffffff', min: durationMax, values: stats.trend.map(b => b.p50_duration) },
+                        { name: 'queue wait', color: '#ffd166', min: durationMax, values: stats.trend.map(b => b.avg_queue_wait) }
+                    ]);
+
+                    renderTable(document.getElementById('environments'), stats.environments, 'Environment');
+                    renderTable(document.getElementById('servers'), stats.servers, 'Server');
+                })
+                .catch(error => console.error('Error loading statistics:', error));
+        }
+
+        // Exports cover the selected period
+        ['csv', 'json'].forEach(format => {
+            document.getElementById('export-' + format).addEventListener('click', e => {
+                e.preventDefault();
+                const period = document.getElementById('period').value.split('|')[0];
+                window.location = 'api/v1/builds/export?format=' + format + '&since=' + period;
+            });
+        });
+
+        document.getElementById('period').addEventListener('change', loadStats);
+        loadStats();
+        setInterval(loadStats, 30000);
+    </script>
+</body>
+</html>`))
+}
+
+// parseExportTime accepts an RFC 3339 time, a date (2006-01-02) or a period back from now ("7d", "12h")
+func parseExportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	period, err := parseStatsPeriod(value, 0)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s (use RFC 3339, YYYY-MM-DD or a period like 7d)", value)
+	}
+	return time.Now().Add(-period), nil
+}
+
+// handleBuildsExportAPI produces a downloadable build report
+// (?format=csv|json&since=...&until=...&environment=X&project=Y), oldest build first
+func (ws *WebServer) handleBuildsExportAPI(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	var since, until time.Time
+	var err error
+	if value := query.Get("since"); value != "" {
+		if since, err = parseExportTime(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("until"); value != "" {
+		if until, err = parseExportTime(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	environment, project := query.Get("environment"), query.Get("project")
+	history := ws.client.history.List()
+	var records []BuildRecord
+	for i := len(history) - 1; i >= 0; i-- {
+		record := history[i]
+		if record.SubmittedAt.Before(since) || (!until.IsZero() && !record.SubmittedAt.Before(until)) {
+			continue
+		}
+		if (environment != "" && record.Environment != environment) || (project != "" && record.Project != project) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	filename := "boltbuild-builds-" + time.Now().Format("20060102-150405") + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if format == "json" {
+		report := struct {
+			GeneratedAt  time.Time               `json:"generated_at"`
+			Since        *time.Time              `json:"since,omitempty"`
+			Until        *time.Time              `json:"until,omitempty"`
+			Builds       []BuildRecord           `json:"builds"`
+			Summary      StatsSummary            `json:"summary"`
+			Environments map[string]StatsSummary `json:"environments"`
+			Servers      map[string]StatsSummary `json:"servers"`
+		}{GeneratedAt: time.Now(), Builds: records, Summary: summarize(records)}
+		if !since.IsZero() {
+			report.Since = &since
+		}
+		if !until.IsZero() {
+			report.Until = &until
+		}
+		if report.Builds == nil {
+			report.Builds = []BuildRecord{}
+		}
+		report.Environments, report.Servers = groupSummaries(records)
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	writer.Write(exportColumns)
+	for _, record := range records {
+		parameters := make([]string, 0, len(record.Parameters))
+		for name, value := range record.Parameters {
+			parameters = append(parameters, name+"="+value)
+		}
+		sort.Strings(parameters)
+
+		writer.Write([]string{
+			record.ID,
+			record.Environment,
+			record.Project,
+			record.ServerID,
+			record.ServerAddr,
+			strconv.FormatBool(record.Success),
+			record.Error,
+			record.SubmittedAt.Format(time.RFC3339),
+			strconv.FormatInt(record.Duration.Milliseconds(), 10),
+			strconv.FormatInt(record.QueueWait.Milliseconds(), 10),
+			strings.Join(parameters, ";"),
+			strconv.Itoa(len(record.OutputFiles)),
+			strconv.Itoa(len(record.Warnings)),
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// handleServerAnnounceAPI connects to a server that announced itself, remembering its address
+// for later discovery scans
+func (ws *WebServer) handleServerAnnounceAPI(w http.ResponseWriter, r *http.Request) {
+	if ws.config.WANMode {
+		http.Error(w, "Servers register at the relay in WAN mode", http.StatusForbidden)
+		return
+	}
+	var announcement protocol.ServerAnnouncement
+	if err := json.NewDecoder(r.Body).Decode(&announcement); err != nil || announcement.Port <= 0 || announcement.Port > 65535 {
+		http.Error(w, "Invalid announcement", http.StatusBadRequest)
+		return
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		http.Error(w, "Unknown remote address", http.StatusBadRequest)
+		return
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(announcement.Port))
+	logging.Infof("Server %s announced itself at %s", announcement.ID, addr)
+	ws.client.discoveryMux.Lock()
+	ws.client.announced[addr] = true
+	ws.client.discoveryMux.Unlock()
+	go ws.client.connectToServer(addr)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// announcedServers returns the addresses of servers that announced themselves
+func (c *Coordinator) announcedServers() []string {
+	c.discoveryMux.RLock()
+	defer c.discoveryMux.RUnlock()
+	addrs := make([]string, 0, len(c.announced))
+	for addr := range c.announced {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// currentAPIVersion is the web API version the dashboard and the CLI commands use.
+// The unversioned /api routes are aliases for it.
+const currentAPIVersion = "v1"
+
+// apiVersions lists every web API version served, oldest first
+var apiVersions = []string{"v1"}
+
+// apiDeprecation announces that a version or a route is going away
+type apiDeprecation struct {
+	Since     time.Time // When it was deprecated (Deprecation header)
+	Sunset    time.Time // When it stops working, zero if not decided (Sunset header)
+	Successor string    // Path of the replacement below the base path, if any
+	Info      string    // URL documenting the migration, if any
+}
+
+// apiDeprecations marks versions ("v1") or route templates ("/api/v1/builds/{id}") as deprecated.
+// Breaking changes go into a new version; the old one is listed here until it is removed.
+var apiDeprecations = map[string]apiDeprecation{}
+
+// setHeaders adds the Deprecation, Sunset and Link headers to a response
+func (d apiDeprecation) setHeaders(header http.Header, r *http.Request) {
+	header.Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+	if !d.Sunset.IsZero() {
+		header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Successor != "" {
+		header.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", externalPath(r, d.Successor)))
+	}
+	if d.Info != "" {
+		header.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", d.Info))
+	}
+}
+
+// apiVersionMiddleware labels responses with the API version that served them and adds
+// deprecation headers for deprecated versions and routes
+func apiVersionMiddleware(version string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Boltbuild-API-Version", version)
+
+			deprecation, deprecated := apiDeprecations[version]
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					// Unversioned aliases share the deprecations of the version they stand for
+					template = "/api/" + version + strings.TrimPrefix(strings.TrimPrefix(template, "/api/"+version), "/api")
+					if routeDeprecation, exists := apiDeprecations[template]; exists {
+						deprecation, deprecated = routeDeprecation, true
+					}
+				}
+			}
+			if deprecated {
+				deprecation.setHeaders(w.Header(), r)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleAPIVersionsAPI lists the web API versions served
+func (ws *WebServer) handleAPIVersionsAPI(w http.ResponseWriter, r *http.Request) {
+	type versionInfo struct {
+		Version    string `json:"version"`
+		Path       string `json:"path"`
+		URL        string `json:"url"` // As seen by the browser, behind a reverse proxy too
+		Deprecated bool   `json:"deprecated,omitempty"`
+	}
+	versions := make([]versionInfo, 0, len(apiVersions))
+	for _, version := range apiVersions {
+		_, deprecated := apiDeprecations[version]
+		path := externalPath(r, "/api/"+version)
+		versions = append(versions, versionInfo{Version: version, Path: path, URL: externalURL(r) + path, Deprecated: deprecated})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"current":  currentAPIVersion,
+		"versions": versions,
+	})
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// negotiateCompression picks the preferred algorithm that the server also supports
+func negotiateCompression(serverInfo protocol.ServerInfo) string {
+	for _, preferred := range buildutil.SupportedCompressions {
+		for _, offered := range serverInfo.Compression {
+			if preferred == offered {
+				return preferred
+			}
+		}
+	}
+	return buildutil.CompressionNone
+}
+
+// decodeArtifact reverses buildutil.EncodeArtifact and returns the raw artifact content
+func decodeArtifact(encoded string, compression string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	switch compression {
+	case buildutil.CompressionNone:
+		return data, nil
+	case buildutil.CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// ArtifactInfo identifies the content of an output file
+type ArtifactInfo struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	Verified  bool   `json:"verified"`             // Matched the checksum sent by the server (older servers send none)
+	LocalPath string `json:"local_path,omitempty"` // Where the client saved the file ("" = not saved, e.g. upload builds)
+}
+
+// verifyArtifacts decodes the output files of a response, checks them against the server's checksums
+// and returns their sizes and hashes sorted by path. Files that fail to decode or verify are removed
+// from the response so they are never saved, and reported in the returned error.
+func verifyArtifacts(response *protocol.BuildResponse) ([]ArtifactInfo, error) {
+	artifacts := make([]ArtifactInfo, 0, len(response.OutputFiles))
+	var corrupted []string
+	for path, encoded := range response.OutputFiles {
+		content, err := decodeArtifact(encoded, response.Compression)
+		if err != nil {
+			logging.Debugf("Warning: Failed to decode file %s: %v", path, err)
+			corrupted = append(corrupted, path)
+			continue
+		}
+
+		info := ArtifactInfo{Path: path, Size: int64(len(content)), SHA256: buildutil.ArtifactChecksum(content)}
+		if expected, ok := response.Checksums[path]; ok {
+			if expected != info.SHA256 {
+				logging.Infof("Warning: Artifact %s of build %s has checksum %s, server sent %s", path, response.ID, info.SHA256, expected)
+				corrupted = append(corrupted, path)
+				continue
+			}
+			info.Verified = true
+		}
+		artifacts = append(artifacts, info)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+
+	if len(corrupted) == 0 {
+		return artifacts, nil
+	}
+	sort.Strings(corrupted)
+	for _, path := range corrupted {
+		delete(response.OutputFiles, path)
+	}
+	return artifacts, fmt.Errorf("artifact verification failed for %s", strings.Join(corrupted, ", "))
+}
+
+// Artifact change states in a build comparison
+const (
+	ArtifactAdded     = "added"
+	ArtifactRemoved   = "removed"
+	ArtifactChanged   = "changed"
+	ArtifactUnchanged = "unchanged"
+)
+
+// ArtifactChange compares one output file between two builds
+type ArtifactChange struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	OldSize int64  `json:"old_size,omitempty"`
+	NewSize int64  `json:"new_size,omitempty"`
+	OldHash string `json:"old_sha256,omitempty"`
+	NewHash string `json:"new_sha256,omitempty"`
+}
+
+// diffArtifacts compares the artifacts of two builds by hash, ignoring boltbuild's own metadata files
+func diffArtifacts(base, head []ArtifactInfo) []ArtifactChange {
+	baseByPath := make(map[string]ArtifactInfo)
+	for _, artifact := range base {
+		baseByPath[artifact.Path] = artifact
+	}
+
+	isMetadata := func(path string) bool {
+		return strings.HasPrefix(strings.TrimPrefix(path, "./"), buildutil.BuildMetadataDir+"/")
+	}
+
+	changes := []ArtifactChange{}
+	for _, artifact := range head {
+		if isMetadata(artifact.Path) {
+			continue
+		}
+		old, exists := baseByPath[artifact.Path]
+		delete(baseByPath, artifact.Path)
+
+		change := ArtifactChange{Path: artifact.Path, NewSize: artifact.Size, NewHash: artifact.SHA256}
+		switch {
+		case !exists:
+			change.Status = ArtifactAdded
+		case old.SHA256 != artifact.SHA256:
+			change.Status = ArtifactChanged
+			change.OldSize, change.OldHash = old.Size, old.SHA256
+		default:
+			change.Status = ArtifactUnchanged
+			change.OldSize, change.OldHash = old.Size, old.SHA256
+		}
+		changes = append(changes, change)
+	}
+	for _, artifact := range baseByPath {
+		if !isMetadata(artifact.Path) {
+			changes = append(changes, ArtifactChange{Path: artifact.Path, Status: ArtifactRemoved, OldSize: artifact.Size, OldHash: artifact.SHA256})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
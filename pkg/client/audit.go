@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+)
+
+// maxAuditEvents is how many audit events are kept in memory for the API
+const maxAuditEvents = 500
+
+// AuditEvent is a security relevant decision, such as a refused build
+type AuditEvent struct {
+	Time        time.Time `json:"time"`
+	User        string    `json:"user,omitempty"`   // Empty for anonymous requests
+	Method      string    `json:"method,omitempty"` // How the user authenticated
+	Remote      string    `json:"remote"`
+	Action      string    `json:"action"` // e.g. "build"
+	Environment string    `json:"environment,omitempty"`
+	Allowed     bool      `json:"allowed"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// AuditLog appends audit events to audit.jsonl next to the build history and keeps the latest in memory
+type AuditLog struct {
+	events []AuditEvent
+	path   string // Empty = memory only
+	mux    sync.Mutex
+}
+
+// NewAuditLog creates an audit log writing to dir
+func NewAuditLog(dir string) *AuditLog {
+	a := &AuditLog{}
+	if dir != "" {
+		a.path = filepath.Join(dir, "audit.jsonl")
+	}
+	return a
+}
+
+// Record stores an event and logs it
+func (a *AuditLog) Record(event AuditEvent) {
+	event.Time = time.Now().UTC()
+	user := event.User
+	if user == "" {
+		user = "anonymous"
+	}
+	if event.Allowed {
+		logging.Infof("Audit: %s %s by %s from %s allowed: %s", event.Action, event.Environment, user, event.Remote, event.Reason)
+	} else {
+		logging.Eventf(logging.EventWarning, "Audit: %s %s by %s from %s denied: %s", event.Action, event.Environment, user, event.Remote, event.Reason)
+	}
+
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	a.events = append(a.events, event)
+	if len(a.events) > maxAuditEvents {
+		a.events = a.events[len(a.events)-maxAuditEvents:]
+	}
+	if a.path == "" {
+		return
+	}
+	data, _ := json.Marshal(event)
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		logging.Infof("Failed to write audit log %s: %v", a.path, err)
+		return
+	}
+	defer file.Close()
+	file.Write(append(data, '\n'))
+}
+
+// Recent returns the events kept in memory, newest first
+func (a *AuditLog) Recent() []AuditEvent {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	events := make([]AuditEvent, len(a.events))
+	for i, event := range a.events {
+		events[len(a.events)-1-i] = event
+	}
+	return events
+}
+
+// handleAuditAPI returns the recent audit events
+func (ws *WebServer) handleAuditAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.client.audit.Recent())
+}
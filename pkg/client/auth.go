@@ -0,0 +1,308 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// sessionCookie holds the session ID of a signed in dashboard user
+const sessionCookie = "boltbuild_session"
+
+// Identity is an authenticated user of the dashboard or API
+type Identity struct {
+	User   string    `json:"user"`
+	Email  string    `json:"email,omitempty"`
+	Groups []string  `json:"groups,omitempty"`
+	Roles  []string  `json:"roles,omitempty"` // From web.auth.roles
+	Method string    `json:"method"`          // How the user signed in: "oidc", "ldap" or "api_key"
+	Since  time.Time `json:"since"`
+}
+
+// session is a signed in browser
+type session struct {
+	identity Identity
+	expires  time.Time
+}
+
+// authenticator checks the sessions of dashboard and API requests and runs the login flows
+type authenticator struct {
+	config   config.AuthConfig
+	oidc     *oidcProvider
+	ldap     *ldapProvider
+	sessions map[string]*session
+	mux      sync.Mutex
+}
+
+// newAuthenticator creates the authentication layer, nil when neither a login nor API keys are configured
+func newAuthenticator(cfg config.AuthConfig) *authenticator {
+	if !cfg.Enabled() && len(cfg.APIKeys) == 0 {
+		return nil
+	}
+	a := &authenticator{config: cfg, sessions: make(map[string]*session)}
+	if cfg.OIDC != nil {
+		a.oidc = newOIDCProvider(*cfg.OIDC)
+	}
+	if cfg.LDAP != nil {
+		a.ldap = &ldapProvider{config: *cfg.LDAP}
+	}
+	return a
+}
+
+// assignRoles grants the roles mapped to the user's groups, comparing group names without case
+func (a *authenticator) assignRoles(identity Identity) Identity {
+	granted := make(map[string]bool)
+	identity.Roles = nil
+	for group, roles := range a.config.Roles {
+		for _, member := range identity.Groups {
+			if !strings.EqualFold(group, member) {
+				continue
+			}
+			for _, role := range roles {
+				if !granted[role] {
+					granted[role] = true
+					identity.Roles = append(identity.Roles, role)
+				}
+			}
+		}
+	}
+	sort.Strings(identity.Roles)
+	return identity
+}
+
+// identityKey stores the Identity of an authenticated request in its context
+type identityKey struct{}
+
+// requestIdentity returns the authenticated user of a request
+func requestIdentity(r *http.Request) (Identity, bool) {
+	identity, ok := r.Context().Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// randomToken returns a random hex string for session IDs and login state
+func randomToken() string {
+	buffer := make([]byte, 32)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
+
+// startSession signs a user in, setting the session cookie
+func (a *authenticator) startSession(w http.ResponseWriter, r *http.Request, identity Identity) {
+	identity = a.assignRoles(identity)
+	identity.Since = time.Now().UTC()
+	id := randomToken()
+
+	a.mux.Lock()
+	now := time.Now()
+	for key, existing := range a.sessions {
+		if now.After(existing.expires) {
+			delete(a.sessions, key)
+		}
+	}
+	a.sessions[id] = &session{identity: identity, expires: now.Add(a.config.SessionTTL)}
+	a.mux.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     externalPath(r, "/"),
+		MaxAge:   int(a.config.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || forwardedValue(r, "X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	logging.Infof("User %s signed in (%s)", identity.User, identity.Method)
+}
+
+// lookupSession returns the identity of a request's session cookie
+func (a *authenticator) lookupSession(r *http.Request) (Identity, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return Identity{}, false
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	existing, exists := a.sessions[cookie.Value]
+	if !exists {
+		return Identity{}, false
+	}
+	if time.Now().After(existing.expires) {
+		delete(a.sessions, cookie.Value)
+		return Identity{}, false
+	}
+	return existing.identity, true
+}
+
+// middleware lets authenticated requests through with their identity: API keys, signed in browsers
+// and, with LDAP, API clients sending their password by HTTP basic authentication. Without a login
+// method other requests pass anonymously; otherwise they are sent to the login page (pages) or
+// refused with 401 (API).
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/auth/") || r.URL.Path == protocol.AnnouncePath || r.URL.Path == "/api/servers/announce" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		identity, present, ok := a.apiKeyIdentity(r)
+		if present && !ok {
+			logging.Eventf(logging.EventWarning, "Invalid API key from %s", r.RemoteAddr)
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if !ok {
+			identity, ok = a.lookupSession(r)
+		}
+		if username, password, basic := r.BasicAuth(); !ok && basic && a.ldap != nil {
+			var err error
+			if identity, err = a.ldap.authenticate(username, password); err != nil {
+				logging.Eventf(logging.EventWarning, "API login of %s from %s failed: %v", username, r.RemoteAddr, err)
+			} else {
+				identity, ok = a.assignRoles(identity), true
+				identity.Since = time.Now().UTC()
+			}
+		}
+		if !ok && !a.config.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok {
+			if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api") {
+				http.Redirect(w, r, externalPath(r, "/auth/login")+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+				return
+			}
+			if a.ldap != nil {
+				w.Header().Set("WWW-Authenticate", `Basic realm="boltbuild"`)
+			}
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, identity)))
+	})
+}
+
+// handleLogin shows the LDAP login form, or starts the single sign-on flow without LDAP
+func (a *authenticator) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := safeRedirectPath(r.URL.Query().Get("next"))
+	if a.ldap == nil {
+		a.oidc.startLogin(w, r, next)
+		return
+	}
+	a.writeLoginPage(w, next, "")
+}
+
+// handleOIDCLogin starts the single sign-on flow from the login form
+func (a *authenticator) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	a.oidc.startLogin(w, r, safeRedirectPath(r.URL.Query().Get("next")))
+}
+
+// handleLDAPLogin checks the login form's user name and password against the directory
+func (a *authenticator) handleLDAPLogin(w http.ResponseWriter, r *http.Request) {
+	next := safeRedirectPath(r.FormValue("next"))
+	username := strings.TrimSpace(r.FormValue("username"))
+	identity, err := a.ldap.authenticate(username, r.FormValue("password"))
+	if err != nil {
+		logging.Eventf(logging.EventWarning, "Login of %s from %s failed: %v", username, r.RemoteAddr, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		a.writeLoginPage(w, next, "Invalid user name or password")
+		return
+	}
+	a.startSession(w, r, identity)
+	http.Redirect(w, r, externalPath(r, next), http.StatusFound)
+}
+
+// writeLoginPage renders the user name and password form, with a single sign-on link if configured
+func (a *authenticator) writeLoginPage(w http.ResponseWriter, next, message string) {
+	w.Header().Set("Content-Type", "text/html")
+	sso := ""
+	if a.oidc != nil {
+		sso = `<p style="margin-top: 20px;"><a href="oidc?next=` + html.EscapeString(url.QueryEscape(next)) + `" style="color: #A4FFF0;">Sign in with single sign-on</a></p>`
+	}
+	if message != "" {
+		message = `<p style="color: #ff6b6b; margin-bottom: 15px;">` + html.EscapeString(message) + `</p>`
+	}
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>BoltBuild - Sign in</title>
+    <style>
+        body { font-family: 'Inter', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #031C26; color: #A4FFF0; display: flex; justify-content: center; padding-top: 100px; }
+        form { background: rgba(164, 255, 240, 0.05); border: 1px solid rgba(164, 255, 240, 0.2); border-radius: 12px; padding: 30px; width: 320px; }
+        h1 { margin: 0 0 20px; font-size: 1.6rem; }
+        h1 span { color: #7BFFF0; }
+        label { display: block; margin-bottom: 5px; font-size: 0.9rem; }
+        input { width: 100%%; box-sizing: border-box; padding: 10px; margin-bottom: 15px; border-radius: 8px; border: 1px solid rgba(164, 255, 240, 0.3); background: #052833; color: #A4FFF0; }
+        button { width: 100%%; padding: 10px; border: none; border-radius: 8px; background: #A4FFF0; color: #031C26; font-weight: 600; cursor: pointer; }
+    </style>
+</head>
+<body>
+    <form method="POST" action="login">
+        <h1>bolt<span>build</span></h1>
+        %s
+        <input type="hidden" name="next" value="%s">
+        <label for="username">User name</label>
+        <input id="username" name="username" autocomplete="username" autofocus required>
+        <label for="password">Password</label>
+        <input id="password" name="password" type="password" autocomplete="current-password" required>
+        <button type="submit">Sign in</button>
+        %s
+    </form>
+</body>
+</html>`, message, html.EscapeString(next), sso)
+}
+
+// handleCallback completes a login and starts the session
+func (a *authenticator) handleCallback(w http.ResponseWriter, r *http.Request) {
+	identity, next, err := a.oidc.finishLogin(r)
+	if err != nil {
+		logging.Eventf(logging.EventWarning, "Login failed: %v", err)
+		http.Error(w, fmt.Sprintf("Login failed: %v", err), http.StatusForbidden)
+		return
+	}
+	a.startSession(w, r, identity)
+	http.Redirect(w, r, externalPath(r, next), http.StatusFound)
+}
+
+// handleLogout ends the session
+func (a *authenticator) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		a.mux.Lock()
+		delete(a.sessions, cookie.Value)
+		a.mux.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: externalPath(r, "/"), MaxAge: -1, HttpOnly: true})
+	http.Redirect(w, r, externalPath(r, "/auth/login"), http.StatusFound)
+}
+
+// handleSessionAPI returns the signed in user, or enabled=false without a login
+func (ws *WebServer) handleSessionAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	identity, ok := requestIdentity(r)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]interface{}{"enabled": false})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"enabled": true, "identity": identity})
+}
+
+// safeRedirectPath keeps post-login redirects on this site
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") || strings.HasPrefix(path, "/auth/") {
+		return "/"
+	}
+	return path
+}
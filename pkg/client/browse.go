@@ -0,0 +1,130 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+)
+
+// DirEntry is a directory shown in the web interface directory picker
+type DirEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// DirListing is the content of a browsed directory
+type DirListing struct {
+	Path    string     `json:"path,omitempty"`   // Empty when listing the allowed roots
+	Parent  string     `json:"parent,omitempty"` // Empty at an allowed root
+	Kinds   []string   `json:"kinds,omitempty"`  // Detected project types of this directory
+	Entries []DirEntry `json:"entries"`
+}
+
+// browseRoots returns the directories that may be browsed and built from the web interface.
+// Without explicit configuration these are the environment and project directories.
+func (c *Coordinator) browseRoots() []string {
+	var candidates []string
+	if len(c.config.Web.BrowseRoots) > 0 {
+		candidates = c.config.Web.BrowseRoots
+	} else {
+		for _, env := range c.allEnvironments() {
+			candidates = append(candidates, env.PrimaryDir())
+		}
+		for _, project := range c.config.Projects {
+			candidates = append(candidates, project.Dir)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var roots []string
+	for _, candidate := range candidates {
+		root, err := buildutil.CanonicalPath(candidate)
+		if err != nil || seen[root] {
+			continue
+		}
+		seen[root] = true
+		roots = append(roots, root)
+	}
+
+	sort.Strings(roots)
+	return roots
+}
+
+// withinRoot reports whether path is root or lies below it
+func withinRoot(root, path string) bool {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// resolveBrowsePath validates that path lies inside an allowed root and returns its canonical form
+func (c *Coordinator) resolveBrowsePath(path string) (string, string, error) {
+	resolved, err := buildutil.CanonicalPath(path)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid path %s: %v", path, err)
+	}
+
+	// Prefer the most specific root so that nested roots get the right parent
+	var matchedRoot string
+	for _, root := range c.browseRoots() {
+		if withinRoot(root, resolved) && len(root) > len(matchedRoot) {
+			matchedRoot = root
+		}
+	}
+	if matchedRoot == "" {
+		return "", "", fmt.Errorf("path %s is outside the allowed directories", path)
+	}
+
+	return resolved, matchedRoot, nil
+}
+
+// listDirectory lists the subdirectories of an allowed directory, or the allowed roots if path is empty
+func (c *Coordinator) listDirectory(path string) (*DirListing, error) {
+	listing := &DirListing{Entries: []DirEntry{}}
+
+	if path == "" {
+		for _, root := range c.browseRoots() {
+			listing.Entries = append(listing.Entries, DirEntry{Name: root, Path: root})
+		}
+		return listing, nil
+	}
+
+	dir, root, err := c.resolveBrowsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	listing.Path = dir
+	if dir != root {
+		listing.Parent = filepath.Dir(dir)
+	}
+	if detected, err := DetectProjectKinds(dir); err == nil {
+		for _, found := range detected {
+			listing.Kinds = append(listing.Kinds, found.Kind)
+		}
+	}
+
+	for _, entry := range entries {
+		// Hidden directories are never useful build roots
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		listing.Entries = append(listing.Entries, DirEntry{
+			Name: entry.Name(),
+			Path: filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	return listing, nil
+}
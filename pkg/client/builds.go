@@ -0,0 +1,275 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// Build event types delivered to subscribers of a running build
+const (
+	BuildEventLog      = "log"
+	BuildEventFinished = "finished"
+)
+
+// BuildEvent is a notification about a running build
+type BuildEvent struct {
+	Type     string                  `json:"type"`
+	BuildID  string                  `json:"build_id"`
+	Data     string                  `json:"data,omitempty"`     // Output chunk for log events
+	Response *protocol.BuildResponse `json:"response,omitempty"` // Result for finished events (nil on timeout)
+	Error    string                  `json:"error,omitempty"`
+}
+
+// RunningBuild describes a build that has been dispatched and not yet finished
+type RunningBuild struct {
+	ID          string         `json:"id"`
+	Environment string         `json:"environment"`
+	Project     string         `json:"project,omitempty"`
+	ServerID    string         `json:"server_id"`
+	ServerAddr  string         `json:"server_addr"`
+	StartedAt   time.Time      `json:"started_at"`
+	Estimate    *BuildEstimate `json:"estimate,omitempty"` // Expected duration from earlier builds
+}
+
+// activeBuild tracks the live state of a running build
+type activeBuild struct {
+	info        RunningBuild
+	server      *ServerConnection
+	backup      *ServerConnection // Server running the speculative copy, if any
+	cancel      func()            // Stops a build running on this machine (local fallback)
+	log         strings.Builder
+	subscribers map[chan BuildEvent]bool
+	mux         sync.Mutex
+}
+
+// subscriberBuffer is the number of events buffered per subscriber before events are dropped
+const subscriberBuffer = 1024
+
+// trackBuild registers a dispatched build (again registering a tracked build has no effect)
+func (c *Coordinator) trackBuild(info RunningBuild, server *ServerConnection) {
+	c.activeMux.Lock()
+	defer c.activeMux.Unlock()
+
+	if _, exists := c.activeBuilds[info.ID]; exists {
+		return
+	}
+	c.activeBuilds[info.ID] = &activeBuild{
+		info:        info,
+		server:      server,
+		subscribers: make(map[chan BuildEvent]bool),
+	}
+}
+
+// trackBackup records the server running the speculative copy of a build
+func (c *Coordinator) trackBackup(id string, server *ServerConnection) {
+	c.activeMux.Lock()
+	defer c.activeMux.Unlock()
+
+	if build, exists := c.activeBuilds[id]; exists {
+		build.backup = server
+	}
+}
+
+// trackCancel records how to stop a build running on this machine
+func (c *Coordinator) trackCancel(id string, cancel func()) {
+	c.activeMux.Lock()
+	defer c.activeMux.Unlock()
+
+	if build, exists := c.activeBuilds[id]; exists {
+		build.cancel = cancel
+	}
+}
+
+// failServerBuilds ends the builds waiting for a server whose connection dropped, so they fail
+// right away instead of at the build timeout and can be retried on another server
+func (c *Coordinator) failServerBuilds(server *ServerConnection, reason error) {
+	c.activeMux.RLock()
+	var ids []string
+	for id, build := range c.activeBuilds {
+		if build.server == server {
+			ids = append(ids, id)
+		}
+		if build.backup == server {
+			ids = append(ids, backupID(id))
+		}
+	}
+	c.activeMux.RUnlock()
+
+	for _, id := range ids {
+		c.deliverResponse(&protocol.BuildResponse{ID: id, Error: fmt.Sprintf("server %s disconnected: %v", server.info.ID, reason)})
+	}
+}
+
+// finishBuild removes a build from tracking and notifies its subscribers
+func (c *Coordinator) finishBuild(id string, response *protocol.BuildResponse, err error) {
+	c.activeMux.Lock()
+	build, exists := c.activeBuilds[id]
+	delete(c.activeBuilds, id)
+	c.activeMux.Unlock()
+	if !exists {
+		return
+	}
+
+	event := BuildEvent{Type: BuildEventFinished, BuildID: id, Response: response}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	build.mux.Lock()
+	defer build.mux.Unlock()
+	for subscriber := range build.subscribers {
+		build.publish(subscriber, event)
+		close(subscriber)
+	}
+	build.subscribers = nil
+}
+
+// appendBuildLog records streamed output of a running build
+func (c *Coordinator) appendBuildLog(id, data string) {
+	c.activeMux.RLock()
+	build, exists := c.activeBuilds[id]
+	c.activeMux.RUnlock()
+	if !exists {
+		return
+	}
+
+	build.mux.Lock()
+	build.log.WriteString(data)
+	for subscriber := range build.subscribers {
+		build.publish(subscriber, BuildEvent{Type: BuildEventLog, BuildID: id, Data: data})
+	}
+	received := int64(build.log.Len())
+	build.mux.Unlock()
+
+	c.bus.Publish(BusEvent{
+		Type:        BusBuildOutput,
+		BuildID:     id,
+		Environment: build.info.Environment,
+		Project:     build.info.Project,
+		ServerID:    build.info.ServerID,
+		ServerAddr:  build.info.ServerAddr,
+		Output:      data,
+		OutputBytes: received,
+	})
+}
+
+// publish delivers an event without blocking the connection reader (caller holds the lock)
+func (b *activeBuild) publish(subscriber chan BuildEvent, event BuildEvent) {
+	select {
+	case subscriber <- event:
+	default:
+		logging.Debugf("Warning: Dropping %s event of build %s for a slow subscriber", event.Type, event.BuildID)
+	}
+}
+
+// partialLog returns the output a running build has produced so far
+func (c *Coordinator) partialLog(id string) string {
+	c.activeMux.RLock()
+	build, exists := c.activeBuilds[id]
+	c.activeMux.RUnlock()
+	if !exists {
+		return ""
+	}
+
+	build.mux.Lock()
+	defer build.mux.Unlock()
+	return build.log.String()
+}
+
+// SubscribeBuild returns the output produced so far and a channel with further events.
+// The channel is closed after the finished event.
+func (c *Coordinator) SubscribeBuild(id string) (string, <-chan BuildEvent, bool) {
+	c.activeMux.RLock()
+	build, exists := c.activeBuilds[id]
+	c.activeMux.RUnlock()
+	if !exists {
+		return "", nil, false
+	}
+
+	build.mux.Lock()
+	defer build.mux.Unlock()
+	subscriber := make(chan BuildEvent, subscriberBuffer)
+	build.subscribers[subscriber] = true
+	return build.log.String(), subscriber, true
+}
+
+// UnsubscribeBuild stops event delivery to a subscriber
+func (c *Coordinator) UnsubscribeBuild(id string, events <-chan BuildEvent) {
+	c.activeMux.RLock()
+	build, exists := c.activeBuilds[id]
+	c.activeMux.RUnlock()
+	if !exists {
+		return
+	}
+
+	build.mux.Lock()
+	defer build.mux.Unlock()
+	for subscriber := range build.subscribers {
+		if subscriber == events {
+			delete(build.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// RunningBuild returns a running build by ID
+func (c *Coordinator) RunningBuild(id string) (RunningBuild, bool) {
+	c.activeMux.RLock()
+	defer c.activeMux.RUnlock()
+
+	build, exists := c.activeBuilds[id]
+	if !exists {
+		return RunningBuild{}, false
+	}
+	return build.info, true
+}
+
+// RunningBuilds lists running builds, oldest first
+func (c *Coordinator) RunningBuilds() []RunningBuild {
+	c.activeMux.RLock()
+	defer c.activeMux.RUnlock()
+
+	builds := make([]RunningBuild, 0, len(c.activeBuilds))
+	for _, build := range c.activeBuilds {
+		builds = append(builds, build.info)
+	}
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].StartedAt.Before(builds[j].StartedAt)
+	})
+	return builds
+}
+
+// CancelBuild asks the executing server to stop a running build
+func (c *Coordinator) CancelBuild(id string) error {
+	c.activeMux.RLock()
+	build, exists := c.activeBuilds[id]
+	c.activeMux.RUnlock()
+	if !exists {
+		return fmt.Errorf("build %s is not running", id)
+	}
+
+	if build.cancel != nil {
+		logging.Infof("Cancelling local build %s", id)
+		build.cancel()
+		return nil
+	}
+	if build.server == nil {
+		return fmt.Errorf("build %s was forwarded to %s and can only be cancelled there", id, build.info.ServerAddr)
+	}
+	logging.Infof("Cancelling build %s on server %s", id, build.info.ServerID)
+	if err := build.server.send(protocol.Message{Type: protocol.MessageCancel, BuildID: id}); err != nil {
+		return fmt.Errorf("failed to send cancel request to %s: %v", build.info.ServerAddr, err)
+	}
+	if build.backup != nil {
+		if err := build.backup.send(protocol.Message{Type: protocol.MessageCancel, BuildID: backupID(id)}); err != nil {
+			logging.Debugf("Warning: Failed to cancel speculative copy of build %s: %v", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"github.com/gorilla/mux"
+)
+
+// bundleFormat is the version of the .boltbuild archive layout
+const bundleFormat = 1
+
+// Entries of a .boltbuild archive: the metadata and the project files below bundleFilesDir
+const (
+	bundleMetadataFile = "bundle.json"
+	bundleFilesDir     = "files/"
+)
+
+// BundleMetadata describes the build a .boltbuild archive was exported from
+type BundleMetadata struct {
+	Format     int         `json:"format"`
+	ExportedAt time.Time   `json:"exported_at"`
+	Source     string      `json:"source"` // Where the files were taken from: archive or disk
+	Build      BuildRecord `json:"build"`  // Environment, sub-project, parameters and overrides, and how it ended
+}
+
+// ExportBundle packs a build of the history into a .boltbuild archive: its record and its project
+// files, taken from the archive made when it ran or from its project directory as it is now
+func (c *Coordinator) ExportBundle(w io.Writer, record BuildRecord, source string) error {
+	source = replaySource(record, source)
+	var files map[string]string
+	var err error
+	switch source {
+	case ReplayArchive:
+		files, err = c.archivedFiles(record)
+	case ReplayDisk:
+		files, err = c.projectFiles(record)
+	default:
+		err = fmt.Errorf("invalid bundle source: %s (use %s or %s)", source, ReplayArchive, ReplayDisk)
+	}
+	if err != nil {
+		return err
+	}
+
+	metadata, err := json.MarshalIndent(BundleMetadata{Format: bundleFormat, ExportedAt: time.Now(), Source: source, Build: record}, "", "  ")
+	if err != nil {
+		return err
+	}
+	entries := map[string]string{bundleMetadataFile: string(metadata)}
+	for name, content := range files {
+		entries[bundleFilesDir+name] = content
+	}
+	return writeFilesTarGz(w, entries)
+}
+
+// projectFiles reads the files of a build from its project directory as it is now
+func (c *Coordinator) projectFiles(record BuildRecord) (map[string]string, error) {
+	if record.ProjectDir == "" {
+		return nil, fmt.Errorf("%w on disk: build %s was uploaded", errFilesUnavailable, record.ID)
+	}
+	env, exists := c.lookupEnvironment(record.Environment)
+	if !exists {
+		return nil, fmt.Errorf("environment %s not found in client configuration", record.Environment)
+	}
+	env, err := c.prepareEnvironment(env, record.Environment, record.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	roots, _, err := resolveSourceRoots(env, record.ProjectDir, record.Subpath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := c.readSourceRoots(roots, env.Transfer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project files: %v", err)
+	}
+	return files, nil
+}
+
+// readBundle unpacks a .boltbuild archive
+func readBundle(body io.Reader) (*BundleMetadata, map[string]string, error) {
+	entries, err := readTarFiles(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, exists := entries[bundleMetadataFile]
+	if !exists {
+		return nil, nil, fmt.Errorf("not a build bundle: %s is missing", bundleMetadataFile)
+	}
+	var metadata BundleMetadata
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %v", bundleMetadataFile, err)
+	}
+	if metadata.Format != bundleFormat {
+		return nil, nil, fmt.Errorf("unsupported bundle format %d", metadata.Format)
+	}
+
+	files := make(map[string]string, len(entries))
+	for name, content := range entries {
+		if relPath, found := strings.CutPrefix(name, bundleFilesDir); found {
+			files[relPath] = content
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("bundle contains no project files")
+	}
+	return &metadata, files, nil
+}
+
+// handleBundleExportAPI downloads a build of the history as a .boltbuild archive
+func (ws *WebServer) handleBundleExportAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	record, exists := ws.client.history.Get(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Unknown build: %s", id), http.StatusNotFound)
+		return
+	}
+	source := r.URL.Query().Get("source")
+	if source != "" && source != ReplayArchive && source != ReplayDisk {
+		http.Error(w, fmt.Sprintf("Invalid bundle source: %s", source), http.StatusBadRequest)
+		return
+	}
+
+	// Build the archive first so errors can still be reported with a status
+	var bundle bytes.Buffer
+	if err := ws.client.ExportBundle(&bundle, record, source); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errFilesUnavailable) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".boltbuild"))
+	w.Write(bundle.Bytes())
+}
+
+// handleBundleImportAPI submits the build of an uploaded .boltbuild archive. Query parameters:
+// environment (default: the bundle's), server, and project (default: the bundle's if configured here).
+func (ws *WebServer) handleBundleImportAPI(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, ws.uploadLimit())
+	metadata, files, err := readBundle(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read bundle: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	build := metadata.Build
+	environment := build.Environment
+	if query.Get("environment") != "" {
+		environment = query.Get("environment")
+	}
+	project := build.Project
+	if query.Has("project") {
+		project = query.Get("project")
+	} else if _, exists := ws.client.lookupProject(project); !exists {
+		project = ""
+	}
+	if err := ws.authorizeBuild(r, environment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	opts := BuildOptions{
+		Project:     project,
+		Subpath:     build.Subpath,
+		Parameters:  build.Parameters,
+		Files:       files,
+		Command:     build.Command,
+		OutputPaths: build.OutputPaths,
+		ReplayOf:    build.ID,
+	}
+	opts.User, opts.Teams = ws.buildUser(r)
+
+	logging.Infof("Importing bundle of build %s for environment %s with %d files", build.ID, environment, len(files))
+	response, err := ws.client.SubmitBuildToServer(r.Context(), environment, "", "", "", []string{}, query.Get("server"), opts)
+	if err != nil {
+		writeBuildError(w, err)
+		return
+	}
+
+	// External callers get plain base64 artifacts
+	if err := decompressArtifacts(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
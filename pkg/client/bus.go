@@ -0,0 +1,148 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// Event types published on the client's event bus
+const (
+	BusBuildSubmitted  = "build.submitted"  // Submit hooks passed, the build is about to be sent
+	BusBuildDispatched = "build.dispatched" // The request reached a server or federation peer
+	BusBuildOutput     = "build.output"     // A chunk of output arrived from the server
+	BusBuildFinished   = "build.finished"   // The build succeeded, failed, timed out or was cancelled
+	BusServerJoined    = "server.joined"    // A build server connected
+	BusServerLeft      = "server.left"      // A build server disconnected
+)
+
+// BusEvent is something that happened to a build or a server connection
+type BusEvent struct {
+	Type        string
+	BuildID     string
+	Environment string
+	Project     string
+	ServerID    string
+	ServerAddr  string
+	Record      *BuildRecord         // build.finished: the history record; earlier subscribers may fill in fields
+	Output      string               // build.output: the new chunk; build.finished: the complete output
+	OutputBytes int64                // build.output: output received so far
+	Server      *protocol.ServerInfo // server.* events
+	Err         error                // server.left: why the connection ended
+	Time        time.Time
+}
+
+// buildEvent describes the build of a hook event
+func buildEvent(kind string, event HookEvent) BusEvent {
+	return BusEvent{
+		Type:        kind,
+		BuildID:     event.BuildID,
+		Environment: event.Environment,
+		Project:     event.Project,
+		ServerID:    event.ServerID,
+		ServerAddr:  event.ServerAddr,
+	}
+}
+
+// finishedEvent describes a finished build and its output
+func finishedEvent(record *BuildRecord, output string) BusEvent {
+	return BusEvent{
+		Type:        BusBuildFinished,
+		BuildID:     record.ID,
+		Environment: record.Environment,
+		Project:     record.Project,
+		ServerID:    record.ServerID,
+		ServerAddr:  record.ServerAddr,
+		Record:      record,
+		Output:      output,
+	}
+}
+
+// busSubscriber is a handler registered on the bus
+type busSubscriber struct {
+	name    string
+	types   map[string]bool // nil = every event
+	handler func(BusEvent)
+}
+
+// EventBus delivers build and server events to the client features that react to them,
+// so the code observing an event does not need to know who consumes it
+type EventBus struct {
+	subscribers []busSubscriber
+	mux         sync.RWMutex
+}
+
+// NewEventBus creates a bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a handler for the given event types (none = every event). Handlers run in
+// the publishing goroutine, in subscription order, and must not block; slow consumers queue
+// events themselves.
+func (b *EventBus) Subscribe(name string, handler func(BusEvent), types ...string) {
+	subscriber := busSubscriber{name: name, handler: handler}
+	if len(types) > 0 {
+		subscriber.types = make(map[string]bool)
+		for _, kind := range types {
+			subscriber.types[kind] = true
+		}
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.subscribers = append(b.subscribers, subscriber)
+}
+
+// Publish delivers an event to every subscriber of its type. A panicking subscriber is logged
+// and does not keep the event from the others.
+func (b *EventBus) Publish(event BusEvent) {
+	event.Time = time.Now()
+
+	b.mux.RLock()
+	subscribers := b.subscribers
+	b.mux.RUnlock()
+
+	for _, subscriber := range subscribers {
+		if subscriber.types == nil || subscriber.types[event.Type] {
+			b.deliver(subscriber, event)
+		}
+	}
+}
+
+// deliver runs one handler, recovering from panics
+func (b *EventBus) deliver(subscriber busSubscriber, event BusEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Infof("Warning: Event bus subscriber %s failed on %s: %v", subscriber.name, event.Type, r)
+		}
+	}()
+	subscriber.handler(event)
+}
+
+// subscribeFeatures connects the client features that consume bus events. Usage accounting
+// runs first as it completes the record the history then stores.
+func (c *Coordinator) subscribeFeatures() {
+	c.bus.Subscribe("usage", func(event BusEvent) {
+		c.accountBuild(event.Record)
+	}, BusBuildFinished)
+	c.bus.Subscribe("history", func(event BusEvent) {
+		c.history.Add(event.Record, event.Output)
+	}, BusBuildFinished)
+	c.bus.Subscribe("notifications", c.notify, BusBuildFinished, BusServerJoined, BusServerLeft)
+	if c.events != nil {
+		c.bus.Subscribe("event stream", c.events.handle, BusBuildSubmitted, BusBuildDispatched, BusBuildOutput, BusBuildFinished)
+	}
+	if logging.EventLogEnabled() {
+		c.bus.Subscribe("event log", func(event BusEvent) {
+			if !event.Record.Success {
+				logging.ReportEventf(logging.EventError, "Build %s (%s) on %s failed: %s", event.BuildID, event.Environment, event.ServerID, event.Record.Error)
+			}
+		}, BusBuildFinished)
+	}
+	if c.statsd != nil {
+		c.bus.Subscribe("statsd", c.statsd.handle, BusBuildSubmitted, BusBuildFinished)
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/protocol"
+)
+
+// splitUpload serializes the files of a request into chunks and describes them in request.Upload
+func splitUpload(request *protocol.BuildRequest, chunkSize int) ([][]byte, error) {
+	data, err := json.Marshal(request.Files)
+	if err != nil {
+		return nil, err
+	}
+	var chunks [][]byte
+	for start := 0; start < len(data); start += chunkSize {
+		end := start + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, data[start:end])
+	}
+
+	sum := sha256.Sum256(data)
+	request.Upload = &protocol.ChunkedUpload{Chunks: len(chunks), Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}
+	request.Files = nil
+	return chunks, nil
+}
+
+// useChunkedUpload reports whether a request should be uploaded in chunks to a server
+func (c *Coordinator) useChunkedUpload(request protocol.BuildRequest, server protocol.ServerInfo) bool {
+	threshold := c.config.Client.Transfer.ChunkThreshold
+	return server.ChunkedUpload && threshold > 0 && requestSize(request) > int64(threshold)
+}
+
+// sendRequest sends a build request with its files; large projects follow in parallel chunks
+func (c *Coordinator) sendRequest(ctx context.Context, server *ServerConnection, serverAddr string, request protocol.BuildRequest) error {
+	uploaded := requestFileBytes(request)
+	var chunks [][]byte
+	if c.useChunkedUpload(request, server.info) {
+		var err error
+		if chunks, err = splitUpload(&request, int(c.config.Client.Transfer.ChunkSize)); err != nil {
+			return err
+		}
+	}
+	if err := server.send(protocol.Message{Type: protocol.MessageBuild, Request: &request}); err != nil {
+		return err
+	}
+	c.usage.AddUpload(request.ID, uploaded)
+	if chunks != nil {
+		return c.sendChunked(ctx, server, serverAddr, request, chunks)
+	}
+	return nil
+}
+
+// sendChunked uploads the chunks of a request over parallel connections to the server. Servers
+// that cannot be dialed again (reached through SSH or a relay) get the chunks over the build
+// connection instead. Chunks not yet sent when ctx is done are dropped.
+func (c *Coordinator) sendChunked(ctx context.Context, server *ServerConnection, serverAddr string, request protocol.BuildRequest, chunks [][]byte) error {
+	streams := c.config.Client.Transfer.Streams
+	if streams > len(chunks) {
+		streams = len(chunks)
+	}
+
+	senders := make([]func(protocol.Message) error, 0, streams)
+	for i := 0; i < streams; i++ {
+		stream, err := c.openUploadStream(serverAddr, server.info.ID)
+		if err != nil {
+			logging.Debugf("Upload stream to %s unavailable, using the build connection: %v", serverAddr, err)
+			break
+		}
+		defer stream.conn.Close()
+		senders = append(senders, stream.send)
+	}
+	if len(senders) == 0 {
+		senders = append(senders, server.send)
+	}
+
+	start := time.Now()
+	next := make(chan int, len(chunks))
+	for i := range chunks {
+		next <- i
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(senders))
+	for _, send := range senders {
+		wg.Add(1)
+		go func(send func(protocol.Message) error) {
+			defer wg.Done()
+			for index := range next {
+				if err := ctx.Err(); err != nil {
+					errs <- err
+					return
+				}
+				if err := send(protocol.Message{Type: protocol.MessageChunk, BuildID: request.ID, Chunk: &protocol.UploadChunk{Index: index, Data: chunks[index]}}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(send)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return fmt.Errorf("chunked upload failed: %v", err)
+	}
+
+	logging.Debugf("Uploaded %d chunks (%s) of build %s to %s over %d streams in %v",
+		len(chunks), protocol.ByteSize(request.Upload.Size), request.ID, serverAddr, len(senders), time.Since(start))
+	return nil
+}
+
+// openUploadStream opens an additional connection to a server that only carries chunks
+func (c *Coordinator) openUploadStream(serverAddr, serverID string) (*ServerConnection, error) {
+	conn, err := transport.DialServer(serverAddr, c.config.Client.Discovery.ConnectTimeout, c.config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var serverInfo protocol.ServerInfo
+	if err := json.NewDecoder(conn).Decode(&serverInfo); err != nil || serverInfo.ID != serverID {
+		conn.Close()
+		return nil, fmt.Errorf("no handshake from server %s", serverID)
+	}
+
+	stream := &ServerConnection{info: serverInfo, conn: transport.Throttle(conn, c.bandwidth), faults: c.faults}
+	info := c.info
+	if err := stream.send(protocol.Message{Type: protocol.MessageHello, Client: &info}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return stream, nil
+}
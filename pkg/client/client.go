@@ -0,0 +1,254 @@
+// Package client lets Go programs submit builds to a boltbuild client coordinator and query its
+// servers and build logs through the coordinator's web API, the same API the "boltbuild remote"
+// command uses:
+//
+//	c := client.New("http://buildhost:8081", client.WithAPIKey(os.Getenv("BOLTBUILD_API_KEY")))
+//	response, err := c.BuildDir(ctx, client.BuildSpec{Environment: "go"}, ".")
+//
+// The package also contains the coordinator itself. A Coordinator keeps the connections to the
+// configured servers, schedules builds on them and serves the web interface and API:
+//
+//	coordinator := client.NewCoordinator(cfg)
+//	err := coordinator.Start()
+//
+// Wire types are defined in the protocol package.
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"boltbuild/pkg/protocol"
+)
+
+// metadataDir holds boltbuild's own files in a project and is never uploaded
+const metadataDir = ".boltbuild"
+
+// Client talks to one boltbuild client coordinator
+type Client struct {
+	base   string
+	apiKey string
+	http   *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey authenticates every request with an API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient replaces http.DefaultClient, e.g. to set timeouts or TLS settings
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.http = httpClient }
+}
+
+// New creates a client for the coordinator whose web interface is at baseURL, including a
+// base path if it runs behind a reverse proxy
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{base: strings.TrimRight(baseURL, "/"), http: http.DefaultClient}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// BuildSpec selects what to build; either Environment or Project is required
+type BuildSpec struct {
+	Environment string            `json:"environment,omitempty"`
+	Project     string            `json:"project,omitempty"`        // Named project, provides the default environment
+	Parameters  map[string]string `json:"parameters,omitempty"`     // Values for the environment's parameters
+	Server      string            `json:"selectedServer,omitempty"` // Server address (default: any available server)
+	Subpath     string            `json:"subpath,omitempty"`        // Monorepo sub-project
+	Speculative bool              `json:"speculative,omitempty"`    // Also run on a second idle server
+	Clean       bool              `json:"clean,omitempty"`          // Start the persistent workspace over
+}
+
+// APIError is a request the coordinator refused. Known causes can be tested with errors.Is,
+// e.g. errors.Is(err, protocol.ErrNoServers).
+type APIError struct {
+	StatusCode int
+	Code       string // Name of a protocol error, e.g. "no_servers" ("" if unknown)
+	Message    string
+}
+
+// Error returns the coordinator's message
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Unwrap returns the protocol error named by Code
+func (e *APIError) Unwrap() error {
+	return protocol.CodeError(e.Code)
+}
+
+// do sends a request and decodes the JSON answer into target (nil = ignore the body)
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		request.Header.Set(protocol.APIKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Code: resp.Header.Get(protocol.ErrorCodeHeader), Message: strings.TrimSpace(string(message))}
+	}
+	if target == nil {
+		return nil
+	}
+	if text, ok := target.(*string); ok {
+		data, err := io.ReadAll(resp.Body)
+		*text = string(data)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	return nil
+}
+
+// Build runs a build of a project directory configured on the coordinator and waits for its result.
+// A failed build is not an error; response.Err() returns it as a *protocol.BuildError.
+func (c *Client) Build(ctx context.Context, spec BuildSpec) (*protocol.BuildResponse, error) {
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var response protocol.BuildResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/build", "application/json", bytes.NewReader(body), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// BuildDir uploads a local directory, builds it and waits for the result. Artifacts are returned
+// base64 encoded in OutputFiles.
+func (c *Client) BuildDir(ctx context.Context, spec BuildSpec, dir string) (*protocol.BuildResponse, error) {
+	query := url.Values{}
+	query.Set("environment", spec.Environment)
+	query.Set("project", spec.Project)
+	query.Set("server", spec.Server)
+	if len(spec.Parameters) > 0 {
+		data, _ := json.Marshal(spec.Parameters)
+		query.Set("parameters", string(data))
+	}
+
+	// Stream the archive while it is being created
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(WriteArchive(writer, dir, nil))
+	}()
+	defer reader.Close()
+
+	var response protocol.BuildResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/build/upload?"+query.Encode(), "application/gzip", reader, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Servers returns the servers connected to the coordinator, keyed by address
+func (c *Client) Servers(ctx context.Context) (map[string]protocol.ServerStatusInfo, error) {
+	var servers map[string]protocol.ServerStatusInfo
+	if err := c.do(ctx, http.MethodGet, "/api/v1/servers", "", nil, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// Log returns the stored output of a finished build
+func (c *Client) Log(ctx context.Context, buildID string) (string, error) {
+	var log string
+	err := c.do(ctx, http.MethodGet, "/api/v1/builds/"+url.PathEscape(buildID)+"/log", "", nil, &log)
+	return log, err
+}
+
+// Cancel stops a running build
+func (c *Client) Cancel(ctx context.Context, buildID string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/build/"+url.PathEscape(buildID)+"/cancel", "", nil, nil)
+}
+
+// WriteArchive writes a directory and extra files as a tar.gz archive, the upload format of the
+// coordinator, skipping VCS and boltbuild metadata directories
+func WriteArchive(w io.Writer, dir string, extra map[string][]byte) error {
+	gzipWriter := gzip.NewWriter(w)
+	archive := tar.NewWriter(gzipWriter)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == metadataDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, content := range extra {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write(content); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
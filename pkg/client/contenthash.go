@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// submitRequest sends a build request, leaving out the files when the server keeps previous
+// projects; the server asks for them with a resend message when it does not hold the project
+func (c *Coordinator) submitRequest(ctx context.Context, server *ServerConnection, serverAddr string, request protocol.BuildRequest) error {
+	if !server.info.ContentCache || len(request.Files) == 0 {
+		return c.sendRequest(ctx, server, serverAddr, request)
+	}
+
+	request.ContentHash = buildutil.ProjectHash(request.Files)
+	c.unsentMux.Lock()
+	c.unsent[request.ID] = unsentRequest{ctx: ctx, request: request, addr: serverAddr}
+	c.unsentMux.Unlock()
+
+	sent := request
+	sent.Files = nil
+	if err := server.send(protocol.Message{Type: protocol.MessageBuild, Request: &sent}); err != nil {
+		c.forgetUnsent(request.ID)
+		return err
+	}
+	return nil
+}
+
+// unsentRequest is a build request submitted by content hash, kept until the server accepted it
+type unsentRequest struct {
+	ctx     context.Context // Of the dispatch waiting for the build
+	request protocol.BuildRequest
+	addr    string
+}
+
+// resendRequest sends the files of a build the server did not hold the project of
+func (c *Coordinator) resendRequest(server *ServerConnection, buildID string) {
+	c.unsentMux.Lock()
+	unsent, exists := c.unsent[buildID]
+	delete(c.unsent, buildID)
+	c.unsentMux.Unlock()
+	if !exists {
+		return
+	}
+
+	logging.Debugf("Server %s does not hold the project of build %s, uploading it", server.info.ID, buildID)
+	if err := c.sendRequest(unsent.ctx, server, unsent.addr, unsent.request); err != nil {
+		// Without the files the server cannot build, fail the build instead of waiting for its timeout
+		response := protocol.BuildResponse{ID: buildID, Error: "failed to upload project files: " + err.Error()}
+		c.deliverResponse(&response)
+		releaseServer(server)
+	}
+}
+
+// forgetUnsent drops the request kept for a resend
+func (c *Coordinator) forgetUnsent(buildID string) {
+	c.unsentMux.Lock()
+	delete(c.unsent, buildID)
+	c.unsentMux.Unlock()
+}
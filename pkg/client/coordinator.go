@@ -0,0 +1,1410 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// Coordinator manages build requests and server connections
+type Coordinator struct {
+	config            *config.Config
+	info              protocol.ClientInfo // Identity sent to servers when connecting
+	servers           map[string]*ServerConnection
+	serversMux        sync.RWMutex
+	pendingBuilds     map[string]chan *protocol.BuildResponse
+	pendingMux        sync.RWMutex
+	discoveredServers map[string]protocol.ServerInfo
+	discoveryMux      sync.RWMutex
+	history           *BuildHistory
+	preferences       *PreferenceStore // Quick launch entries of the dashboard users
+	audit             *AuditLog        // Refused builds and other security decisions
+	quotas            *QuotaTracker    // Builds of the last day per user and team
+	usage             *UsageLedger     // Resources used by every build, for chargeback
+	activeBuilds      map[string]*activeBuild
+	activeMux         sync.RWMutex
+	notifications     *NotificationCenter
+	bus               *EventBus       // Build and server events for notifications, history and other subscribers
+	events            *EventStream    // nil when no event stream sink is configured
+	statsd            *StatsD         // nil when no StatsD agent is configured
+	local             localExecutor   // Runs builds while no server is connected (client.local_fallback)
+	relayed           map[string]bool // IDs of servers connected (or connecting) through the relay
+	announced         map[string]bool // Addresses of servers that announced themselves, guarded by discoveryMux
+	relayMux          sync.Mutex
+	bandwidth         *transport.TokenBucket   // Upload limit shared by all server connections (nil = unlimited)
+	unsent            map[string]unsentRequest // Requests submitted by content hash, in case the server asks for the files
+	unsentMux         sync.Mutex
+	inspections       map[string]chan *protocol.WorkspaceBrowse // Workspace browse requests waiting for their server
+	inspectMux        sync.Mutex
+	preflights        map[string]chan *protocol.PreflightCheck // Pre-flight checks waiting for their server
+	preflightMux      sync.Mutex
+	affinity          *projectAffinity         // Server that last built each named project
+	plugins           *PluginManager           // Environments of external provider plugins (nil = none configured)
+	faults            *transport.FaultInjector // Injected failures for resilience tests (nil = none)
+}
+
+// ServerConnection represents a connection to a build server
+type ServerConnection struct {
+	info     protocol.ServerInfo
+	conn     net.Conn
+	busy     bool
+	network  protocol.NetworkStats // Latest probe results
+	pongs    chan string           // IDs of answered network probes
+	mux      sync.Mutex
+	writeMux sync.Mutex
+	faults   *transport.FaultInjector
+}
+
+// snapshot returns the server info including the latest reported load
+func (sc *ServerConnection) snapshot() protocol.ServerInfo {
+	sc.mux.Lock()
+	defer sc.mux.Unlock()
+	return sc.info
+}
+
+// updateLoad records a load report; the free disk space also refreshes the resources
+func (sc *ServerConnection) updateLoad(load protocol.ServerLoad) {
+	sc.mux.Lock()
+	defer sc.mux.Unlock()
+	sc.info.Load = load
+	if load.FreeDisk > 0 {
+		sc.info.Resources.FreeDisk = load.FreeDisk
+	}
+}
+
+// send writes a message to the server; safe for concurrent use
+func (sc *ServerConnection) send(msg protocol.Message) error {
+	sc.writeMux.Lock()
+	defer sc.writeMux.Unlock()
+	if sc.faults.Disconnect(sc.conn, sc.info.ID) {
+		return transport.ErrFaultInjected
+	}
+	return json.NewEncoder(sc.conn).Encode(msg)
+}
+
+// NewCoordinator creates a new client instance
+func NewCoordinator(cfg *config.Config) *Coordinator {
+	hostname, _ := os.Hostname()
+	id := cfg.Client.ID
+	if id == "" {
+		id = generateClientID()
+	}
+	history := NewBuildHistory(cfg.Client.History.Dir, cfg.Client.History.MaxEntries)
+	c := &Coordinator{
+		config:            cfg,
+		info:              protocol.ClientInfo{ID: id, Hostname: hostname, Version: protocol.Version},
+		servers:           make(map[string]*ServerConnection),
+		pendingBuilds:     make(map[string]chan *protocol.BuildResponse),
+		discoveredServers: make(map[string]protocol.ServerInfo),
+		history:           history,
+		quotas:            NewQuotaTracker(cfg.Client.Quotas, history.List()),
+		usage:             NewUsageLedger(cfg.Client.History.Dir),
+		preferences:       NewPreferenceStore(cfg.Client.History.Dir),
+		audit:             NewAuditLog(cfg.Client.History.Dir),
+		activeBuilds:      make(map[string]*activeBuild),
+		notifications:     NewNotificationCenter(),
+		bus:               NewEventBus(),
+		events:            NewEventStream(cfg.Client.Events),
+		statsd:            NewStatsD(cfg.Client.StatsD),
+		relayed:           make(map[string]bool),
+		announced:         make(map[string]bool),
+		bandwidth:         transport.NewTokenBucket(cfg.Client.Transfer.BandwidthConfig),
+		unsent:            make(map[string]unsentRequest),
+		inspections:       make(map[string]chan *protocol.WorkspaceBrowse),
+		preflights:        make(map[string]chan *protocol.PreflightCheck),
+		affinity:          newProjectAffinity(),
+		faults:            transport.NewFaultInjector(cfg.Faults),
+	}
+	if len(cfg.Plugins) > 0 {
+		c.plugins = NewPluginManager(cfg.Plugins)
+	}
+	c.subscribeFeatures()
+	return c
+}
+
+// Start begins server discovery and connection management
+func (c *Coordinator) Start() error {
+	if c.statsd != nil {
+		go c.reportGauges()
+	}
+
+	// In WAN mode servers are only reached through the relay
+	if c.config.WANMode {
+		logging.Infof("Client started in WAN mode, using relay %s", c.config.Client.Relay.Address)
+		c.connectRelay(c.config.Client.Relay)
+		return nil
+	}
+
+	logging.Info("Client started, discovering build servers...")
+
+	// Start server discovery
+	go c.discoverServers()
+
+	// Start connection manager
+	go c.manageConnections()
+
+	// Reach servers in other networks through the relay
+	if c.config.Client.Relay.Address != "" {
+		go c.connectRelay(c.config.Client.Relay)
+	}
+
+	// Reach servers whose port is only open to SSH
+	for _, target := range c.config.Client.SSH {
+		go c.connectSSH(target)
+	}
+
+	// Accept servers that connect to this client themselves
+	if port := c.config.Client.ListenPort; port != 0 {
+		go func() {
+			if err := c.listenForServers(port); err != nil {
+				logging.Infof("Warning: %v", err)
+			}
+		}()
+	}
+
+	// Keep running
+	select {}
+}
+
+// discoverServers discovers available build servers on the network
+func (c *Coordinator) discoverServers() {
+	for {
+		// Try configured ports on local network
+		c.scanForServers()
+		time.Sleep(c.config.Client.Discovery.ScanInterval)
+	}
+}
+
+// scanForServers scans for build servers on configured ports
+func (c *Coordinator) scanForServers() {
+	ports := c.config.Client.Discovery.Ports
+
+	// Determine network range
+	var networkPrefix string
+	var startIP, endIP int
+
+	if c.config.Client.Discovery.NetworkRange.Auto {
+		localIP := c.getLocalIP()
+		networkPrefix = c.getNetworkPrefix(localIP)
+		startIP = 1
+		endIP = 254
+	} else {
+		networkPrefix = c.config.Client.Discovery.NetworkRange.Subnet
+		startIP = c.config.Client.Discovery.NetworkRange.StartIP
+		endIP = c.config.Client.Discovery.NetworkRange.EndIP
+	}
+
+	for i := startIP; i <= endIP; i++ {
+		ip := fmt.Sprintf("%s.%d", networkPrefix, i)
+		for _, port := range ports {
+			go c.tryConnectToServer(ip, port)
+		}
+	}
+
+	// Servers outside the scanned range, possibly behind HTTP proxies
+	for _, addr := range c.config.Client.Discovery.Servers {
+		go c.connectToServer(addr)
+	}
+	for _, addr := range c.announcedServers() {
+		go c.connectToServer(addr)
+	}
+}
+
+// tryConnectToServer attempts to connect to a potential server
+func (c *Coordinator) tryConnectToServer(ip string, port int) {
+	c.connectToServer(net.JoinHostPort(ip, strconv.Itoa(port)))
+}
+
+// connectToServer attempts to connect to a server address or ws:// / wss:// URL
+func (c *Coordinator) connectToServer(addr string) {
+	// Skip if already connected
+	c.serversMux.RLock()
+	_, exists := c.servers[addr]
+	c.serversMux.RUnlock()
+	if exists {
+		return
+	}
+
+	// Try to connect with configured timeout
+	conn, err := transport.DialServer(addr, c.config.Client.Discovery.ConnectTimeout, c.config.TLS)
+	if err != nil {
+		return
+	}
+
+	// Try to read server info
+	decoder := json.NewDecoder(conn)
+	var serverInfo protocol.ServerInfo
+	if err := decoder.Decode(&serverInfo); err != nil {
+		conn.Close()
+		return
+	}
+
+	// Verify this is a build server
+	if !strings.HasPrefix(serverInfo.ID, "server-") {
+		conn.Close()
+		return
+	}
+
+	// Check version compatibility
+	if serverInfo.Version != protocol.Version {
+		logging.Debugf("WARNING: Version mismatch with server %s! Client: %s, Server: %s", serverInfo.ID, protocol.Version, serverInfo.Version)
+	}
+
+	logging.Infof("Discovered build server %s at %s (capacity: %d, version: %s)", serverInfo.ID, addr, serverInfo.Capacity, serverInfo.Version)
+
+	// Add to discovered servers
+	c.discoveryMux.Lock()
+	c.discoveredServers[addr] = serverInfo
+	c.discoveryMux.Unlock()
+
+	// Start managing this connection
+	go c.handleServerConnection(conn, serverInfo, addr)
+}
+
+// handleServerConnection manages a single server connection
+func (c *Coordinator) handleServerConnection(conn net.Conn, serverInfo protocol.ServerInfo, addr string) {
+	defer conn.Close()
+	conn = transport.Throttle(conn, c.bandwidth)
+
+	serverConn := &ServerConnection{
+		info:   serverInfo,
+		conn:   conn,
+		busy:   false,
+		pongs:  make(chan string, 1),
+		faults: c.faults,
+	}
+
+	// Identify this client so the server can account and list its builds
+	info := c.info
+	if err := serverConn.send(protocol.Message{Type: protocol.MessageHello, Client: &info}); err != nil {
+		logging.Debugf("Failed to identify to server %s: %v", serverInfo.ID, err)
+		return
+	}
+
+	c.serversMux.Lock()
+	c.servers[addr] = serverConn
+	c.serversMux.Unlock()
+
+	logging.Infof("Connected to build server %s at %s (capacity: %d)", serverInfo.ID, addr, serverInfo.Capacity)
+	c.bus.Publish(BusEvent{Type: BusServerJoined, ServerID: serverInfo.ID, ServerAddr: addr, Server: &serverInfo})
+
+	// Measure the connection for scheduling and the dashboard until the server disconnects
+	done := make(chan struct{})
+	defer close(done)
+	go serverConn.probeNetwork(done)
+
+	// Keep connection alive and handle streamed output and responses
+	decoder := json.NewDecoder(conn)
+	var err error
+	for {
+		var msg protocol.Message
+		if err = decoder.Decode(&msg); err != nil {
+			logging.Infof("Server %s disconnected: %v", serverInfo.ID, err)
+			c.bus.Publish(BusEvent{Type: BusServerLeft, ServerID: serverInfo.ID, ServerAddr: addr, Server: &serverInfo, Err: err})
+			break
+		}
+
+		if msg.Type == protocol.MessageLog {
+			c.appendBuildLog(msg.BuildID, msg.Data)
+			continue
+		}
+		if msg.Type == protocol.MessagePong {
+			select {
+			case serverConn.pongs <- msg.BuildID:
+			default:
+			}
+			continue
+		}
+		if msg.Type == protocol.MessageError {
+			logging.Infof("Server %s closed the connection: %s", serverInfo.ID, msg.Data)
+			continue
+		}
+		if msg.Type == protocol.MessageListing {
+			if msg.Browse != nil {
+				c.deliverListing(msg.Browse)
+			}
+			continue
+		}
+		if msg.Type == protocol.MessagePreflightResult {
+			if msg.Preflight != nil {
+				c.deliverPreflight(msg.BuildID, msg.Preflight)
+			}
+			continue
+		}
+		if msg.Type == protocol.MessageResend {
+			go c.resendRequest(serverConn, msg.BuildID)
+			continue
+		}
+		if msg.Type == protocol.MessageStatus {
+			if msg.Load != nil {
+				serverConn.updateLoad(*msg.Load)
+			}
+			continue
+		}
+		if msg.Type != protocol.MessageResult || msg.Response == nil {
+			logging.Debugf("Ignoring unexpected %q message from server %s", msg.Type, serverInfo.ID)
+			continue
+		}
+		response := *msg.Response
+
+		logging.Debugf("Build %s completed by server %s: success=%v, output_files=%d", response.ID, serverInfo.ID, response.Success, len(response.OutputFiles))
+
+		// Send response to waiting SubmitBuild call
+		c.deliverResponse(&response)
+
+		serverConn.mux.Lock()
+		serverConn.busy = false
+		serverConn.mux.Unlock()
+	}
+
+	// Remove server on disconnect
+	c.serversMux.Lock()
+	delete(c.servers, addr)
+	c.serversMux.Unlock()
+
+	// Remove from discovered servers
+	c.discoveryMux.Lock()
+	delete(c.discoveredServers, addr)
+	c.discoveryMux.Unlock()
+
+	c.failServerBuilds(serverConn, err)
+}
+
+// manageConnections manages server connections and reconnections
+func (c *Coordinator) manageConnections() {
+	for {
+		time.Sleep(c.config.Client.Timeouts.HealthCheck)
+
+		// Check for disconnected servers and try to reconnect
+		c.discoveryMux.RLock()
+		for addr, serverInfo := range c.discoveredServers {
+			c.serversMux.RLock()
+			_, connected := c.servers[addr]
+			c.serversMux.RUnlock()
+
+			if !connected {
+				go c.reconnectToServer(addr, serverInfo)
+			}
+		}
+		c.discoveryMux.RUnlock()
+	}
+}
+
+// reconnectToServer attempts to reconnect to a disconnected server
+func (c *Coordinator) reconnectToServer(addr string, serverInfo protocol.ServerInfo) {
+	conn, err := transport.DialServer(addr, c.config.Client.Timeouts.Reconnect, c.config.TLS)
+	if err != nil {
+		return
+	}
+
+	// Try to read server info again
+	decoder := json.NewDecoder(conn)
+	var newServerInfo protocol.ServerInfo
+	if err := decoder.Decode(&newServerInfo); err != nil {
+		conn.Close()
+		return
+	}
+
+	// Verify it's the same server
+	if newServerInfo.ID != serverInfo.ID {
+		conn.Close()
+		return
+	}
+
+	logging.Infof("Reconnected to build server %s at %s", serverInfo.ID, addr)
+	go c.handleServerConnection(conn, newServerInfo, addr)
+}
+
+// BuildOptions carries optional per-submission settings
+type BuildOptions struct {
+	Project     string            // Named project the build belongs to, if any
+	Subpath     string            // Build only this subdirectory of the project (monorepo support)
+	Parameters  map[string]string // Values for the environment's declared parameters
+	Files       map[string]string // Uploaded files sent instead of reading the project directory
+	Command     string            // Replaces the environment command (requires allow_command_override)
+	OutputPaths []string          // Replaces the environment output paths
+	Speculative bool              // Also run the build on a second idle server, first success wins
+	Clean       bool              // Start the persistent workspace over instead of building incrementally
+	User        string            // Dashboard, API or CLI user the build is accounted to ("" = not accounted)
+	Teams       []string          // Groups of the user, for team quotas
+	ForwardedBy string            // Federation site that forwarded the build here; it is never forwarded again
+	DryRun      bool              // Resolve and collect the build without counting or sending it
+	ReplayOf    string            // Build of the history this one replays
+
+	skipped func(relPath, reason string) // Receives the files left out of a dry run
+	started func(buildID string)         // Receives the build ID once the build is tracked and can be subscribed to
+}
+
+// preparedBuild is a fully resolved build that is ready to be dispatched
+type preparedBuild struct {
+	request    protocol.BuildRequest
+	env        *config.BuildEnvironment
+	project    string
+	parameters map[string]string
+	projectDir string // Local directory the files were read from ("" = uploaded), for retries
+	subpath    string
+	user       string
+	teams      []string
+	workdir    string              // Local directory for hooks and post-build scripts
+	roots      []config.SourceRoot // Local source roots that artifacts are saved into
+	backup     *ServerConnection   // Second server for speculative execution, if reserved
+	createdAt  time.Time           // When the build was submitted, for queue wait statistics
+	overrides  BuildOptions        // Command and output path overrides, kept in the history for replays
+	replayOf   string
+	started    func(buildID string) // Called once when the build is tracked, see BuildOptions
+}
+
+// announce hands the build ID to the caller waiting for it, once
+func (b *preparedBuild) announce() {
+	if b.started != nil {
+		b.started(b.request.ID)
+		b.started = nil
+	}
+}
+
+// runningInfo describes the build for status queries once it is dispatched to server,
+// including the duration expected from the history
+func (b *preparedBuild) runningInfo(server *ServerConnection, history *BuildHistory) RunningBuild {
+	info := RunningBuild{
+		ID:          b.request.ID,
+		Environment: b.request.Environment,
+		Project:     b.project,
+		ServerID:    server.info.ID,
+		ServerAddr:  net.JoinHostPort(server.info.Address, strconv.Itoa(server.info.Port)),
+		StartedAt:   time.Now(),
+	}
+	if estimate, ok := history.Estimate(info.Environment, info.ServerAddr); ok {
+		info.Estimate = &estimate
+	}
+	return info
+}
+
+// SubmitBuild submits a build request to an available server with file transfer
+// The build is cancelled on its server when ctx is done.
+func (c *Coordinator) SubmitBuild(ctx context.Context, environment, entry, projectDir string, args []string, opts BuildOptions) (*protocol.BuildResponse, error) {
+	build, err := c.prepareBuild(environment, projectDir, projectDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.runSubmitHooks(build); err != nil {
+		return nil, err
+	}
+
+	server, err := c.acquireServer("", build.request)
+	if errors.Is(err, protocol.ErrNoServers) && c.canRunLocally("") {
+		return c.runLocally(ctx, build)
+	}
+	if errors.Is(err, protocol.ErrNoServers) && c.canForward(build, opts) {
+		return c.forwardToIdlePeer(ctx, build, opts)
+	}
+	if err != nil {
+		c.quotas.Release(build.request.ID)
+		return nil, err
+	}
+	c.reserveBackup(build, "", opts)
+
+	// Ask for compressed artifacts if the server supports it
+	build.request.Compression = negotiateCompression(server.info)
+
+	return c.dispatchBuild(ctx, server, build)
+}
+
+// SubmitBuildToServer submits a build request to a specific server
+func (c *Coordinator) SubmitBuildToServer(ctx context.Context, environment, entry, projectDir, workdir string, args []string, serverAddr string, opts BuildOptions) (*protocol.BuildResponse, error) {
+	build, err := c.prepareBuild(environment, projectDir, workdir, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.runSubmitHooks(build); err != nil {
+		return nil, err
+	}
+
+	server, err := c.acquireServer(serverAddr, build.request)
+	if errors.Is(err, protocol.ErrNoServers) && c.canRunLocally(serverAddr) {
+		return c.runLocally(ctx, build)
+	}
+	if errors.Is(err, protocol.ErrNoServers) && c.canForward(build, opts) {
+		return c.forwardToIdlePeer(ctx, build, opts)
+	}
+	if err != nil {
+		c.quotas.Release(build.request.ID)
+		return nil, err
+	}
+	c.reserveBackup(build, serverAddr, opts)
+
+	// Ask for compressed artifacts if the server supports it
+	build.request.Compression = negotiateCompression(server.info)
+
+	return c.dispatchBuild(ctx, server, build)
+}
+
+// StartBuild prepares a build and dispatches it in the background, returning its ID.
+// Progress can be followed with SubscribeBuild and the result is recorded in the history.
+// The build outlives the caller; CancelBuild stops it.
+func (c *Coordinator) StartBuild(environment, projectDir, serverAddr string, opts BuildOptions) (string, error) {
+	build, err := c.prepareBuild(environment, projectDir, projectDir, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := c.runSubmitHooks(build); err != nil {
+		return "", err
+	}
+
+	server, err := c.acquireServer(serverAddr, build.request)
+	if errors.Is(err, protocol.ErrNoServers) && c.canRunLocally(serverAddr) {
+		// Track before returning so callers can subscribe immediately
+		c.trackBuild(localInfo(build), nil)
+		go func() {
+			if _, err := c.runLocally(context.Background(), build); err != nil {
+				logging.Infof("Build %s failed: %v", build.request.ID, err)
+			}
+		}()
+		return build.request.ID, nil
+	}
+	if errors.Is(err, protocol.ErrNoServers) && c.canForward(build, opts) {
+		peer, err := c.idleForwardPeer()
+		if err != nil {
+			c.quotas.Release(build.request.ID)
+			return "", err
+		}
+		c.trackBuild(forwardedInfo(build, peer), nil)
+		go func() {
+			if _, err := c.forwardBuild(context.Background(), build, peer, opts); err != nil {
+				logging.Infof("Build %s failed: %v", build.request.ID, err)
+			}
+		}()
+		return build.request.ID, nil
+	}
+	if err != nil {
+		c.quotas.Release(build.request.ID)
+		return "", err
+	}
+	c.reserveBackup(build, serverAddr, opts)
+	build.request.Compression = negotiateCompression(server.info)
+
+	// Track before returning so callers can subscribe immediately
+	c.trackBuild(build.runningInfo(server, c.history), server)
+	go func() {
+		if _, err := c.dispatchBuild(context.Background(), server, build); err != nil {
+			logging.Infof("Build %s failed: %v", build.request.ID, err)
+		}
+	}()
+
+	return build.request.ID, nil
+}
+
+// runSubmitHooks runs the on_submit hooks of a build before a server is reserved for it, so a
+// slow hook never holds a build server and a vetoed build never takes one
+func (c *Coordinator) runSubmitHooks(build *preparedBuild) error {
+	event := HookEvent{
+		Event:       config.HookOnSubmit,
+		BuildID:     build.request.ID,
+		Environment: build.request.Environment,
+		Project:     build.project,
+		ProjectDir:  build.workdir,
+	}
+	if err := c.runHooks(event, build.env); err != nil {
+		c.quotas.Release(build.request.ID)
+		return err
+	}
+	c.bus.Publish(buildEvent(BusBuildSubmitted, event))
+	return nil
+}
+
+// reserveBackup reserves a second idle server for speculative builds. Builds for a selected
+// server and builds without a second free server run once.
+func (c *Coordinator) reserveBackup(build *preparedBuild, serverAddr string, opts BuildOptions) {
+	if !(opts.Speculative || build.env.Speculative) || serverAddr != "" {
+		return
+	}
+	backup, err := c.acquireServer("", build.request)
+	if err != nil {
+		logging.Debugf("No second server for speculative build %s: %v", build.request.ID, err)
+		return
+	}
+	build.backup = backup
+}
+
+// releaseServer marks a reserved server as free again
+func releaseServer(server *ServerConnection) {
+	server.mux.Lock()
+	server.busy = false
+	server.mux.Unlock()
+}
+
+// deliverResponse hands a build response to the dispatch waiting for it
+func (c *Coordinator) deliverResponse(response *protocol.BuildResponse) {
+	c.forgetUnsent(response.ID)
+	c.pendingMux.Lock()
+	if responseChan, exists := c.pendingBuilds[response.ID]; exists {
+		responseChan <- response
+		delete(c.pendingBuilds, response.ID)
+	}
+	c.pendingMux.Unlock()
+}
+
+// serverAddr returns the address a connected server is known by
+func (c *Coordinator) serverAddr(server *ServerConnection) string {
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+	for addr, connection := range c.servers {
+		if connection == server {
+			return addr
+		}
+	}
+	return ""
+}
+
+// acquireServer picks a specific (or any free) server able to run the request, checks its
+// version and marks it busy
+func (c *Coordinator) acquireServer(serverAddr string, request protocol.BuildRequest) (*ServerConnection, error) {
+	var server *ServerConnection
+	if serverAddr == "" {
+		server = c.findAvailableServer(request)
+		if server == nil {
+			if err := c.checkAnyServerSupports(request); err != nil {
+				return nil, fmt.Errorf("no connected server can run environment %s: %v", request.Environment, err)
+			}
+			return nil, protocol.ErrNoServers
+		}
+	} else {
+		server = c.findServerByAddress(serverAddr)
+		if server == nil {
+			return nil, fmt.Errorf("server %s not found or not connected", serverAddr)
+		}
+		if err := c.checkServerSupport(server.snapshot(), request); err != nil {
+			return nil, fmt.Errorf("server %s cannot run environment %s: %v", serverAddr, request.Environment, err)
+		}
+	}
+
+	// Check version compatibility before submitting build
+	if server.info.Version != protocol.Version {
+		return nil, fmt.Errorf("%w: client version %s, server %s version %s. Please ensure all components are using the same version", protocol.ErrVersionMismatch, protocol.Version, server.info.ID, server.info.Version)
+	}
+
+	// Check if server is available
+	server.mux.Lock()
+	defer server.mux.Unlock()
+	if server.busy {
+		return nil, fmt.Errorf("%w: %s is running another build", protocol.ErrServerBusy, net.JoinHostPort(server.info.Address, strconv.Itoa(server.info.Port)))
+	}
+	server.busy = true
+	return server, nil
+}
+
+// prepareBuild resolves the environment and reads the project files for a new build
+func (c *Coordinator) prepareBuild(environment, projectDir, workdir string, opts BuildOptions) (*preparedBuild, error) {
+	start := time.Now()
+
+	// Generate unique build ID and project name
+	buildID := buildutil.GenerateID()
+	projectName := buildutil.ProjectDirPrefix + buildID
+
+	// Get environment configuration
+	env, exists := c.lookupEnvironment(environment)
+	if !exists {
+		return nil, fmt.Errorf("environment %s not found in client configuration", environment)
+	}
+
+	// Let plugin-provided environments prepare the project
+	env, err := c.prepareEnvironment(env, environment, projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	command, args, steps, variants := env.Command, env.Args, env.Commands, env.PlatformCommands
+	if len(steps) > 0 {
+		command = env.CommandLine()
+	}
+	if opts.Command != "" {
+		if !env.AllowCommandOverride {
+			return nil, fmt.Errorf("environment %s does not allow command overrides", environment)
+		}
+		command, args, steps, variants = opts.Command, nil, nil, nil
+	}
+	outputPaths := env.OutputPaths
+	if opts.OutputPaths != nil {
+		outputPaths = opts.OutputPaths
+	}
+
+	// Fill in build parameters
+	parameters, err := env.Parameters.Resolve(opts.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if env.UsesShell() {
+		if err := checkShellParameters(parameters); err != nil {
+			return nil, err
+		}
+	}
+
+	// Determine which directories are transferred and where the build runs
+	roots, executionDir, err := resolveSourceRoots(env, projectDir, opts.Subpath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read all files from the project directory unless they were uploaded
+	files := opts.Files
+	saveRoots := roots
+	readDir := ""
+	if files == nil {
+		readDir = projectDir
+		if files, err = c.readSourceRoots(roots, env.Transfer, opts.skipped); err != nil {
+			return nil, fmt.Errorf("failed to read project files: %v", err)
+		}
+
+		// Artifacts are saved relative to the working directory
+		if workdir != projectDir {
+			if saveRoots, _, err = resolveSourceRoots(env, workdir, opts.Subpath); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Uploaded builds return their artifacts to the caller only
+		saveRoots = nil
+	}
+
+	// Count the build against the quotas of its user and teams
+	if !opts.DryRun {
+		if err := c.quotas.Admit(buildID, opts.User, opts.Teams); err != nil {
+			return nil, err
+		}
+	}
+
+	return &preparedBuild{
+		request: protocol.BuildRequest{
+			ID:                 buildID,
+			Environment:        environment,
+			Project:            opts.Project,
+			Command:            expandParameters(command, parameters),
+			Args:               expandArgs(args, parameters),
+			Shell:              env.Shell,
+			Steps:              buildSteps(steps, env.Shell, parameters),
+			Platforms:          platformVariants(variants, env.Shell, parameters),
+			ProjectDir:         env.ProjectDir,
+			ExecutionDir:       executionDir,
+			OutputPaths:        outputPaths,
+			AlwaysCollectPaths: env.AlwaysCollectPaths,
+			EnvVars:            env.EnvVars,
+			Files:              files,
+			ProjectName:        projectName,
+			Image:              env.Image,
+			WSL:                env.WSL,
+			Requirements:       env.Requirements,
+			Provenance:         env.Provenance,
+			Workspace:          persistentWorkspace(env, environment, opts.Project, projectDir),
+			PreservePaths:      env.PreservePaths,
+			Clean:              opts.Clean,
+			DebugBundle:        debugBundleRequest(env),
+		},
+		env:        env,
+		project:    opts.Project,
+		parameters: parameters,
+		projectDir: readDir,
+		subpath:    opts.Subpath,
+		user:       opts.User,
+		teams:      opts.Teams,
+		workdir:    workdir,
+		roots:      saveRoots,
+		createdAt:  start,
+		overrides:  BuildOptions{Command: opts.Command, OutputPaths: opts.OutputPaths},
+		replayOf:   opts.ReplayOf,
+		started:    opts.started,
+	}, nil
+}
+
+// dispatchBuild sends a prepared request to an already reserved server and waits for the result
+func (c *Coordinator) dispatchBuild(ctx context.Context, server *ServerConnection, build *preparedBuild) (response *protocol.BuildResponse, err error) {
+	request, env, workdir := build.request, build.env, build.workdir
+	serverAddr := net.JoinHostPort(server.info.Address, strconv.Itoa(server.info.Port))
+
+	// Make the build visible to status queries and log subscribers until it finishes
+	c.trackBuild(build.runningInfo(server, c.history), server)
+	build.announce()
+	defer func() {
+		c.finishBuild(request.ID, response, err)
+		c.quotas.Finish(request.ID)
+	}()
+
+	record := &BuildRecord{
+		ID:          request.ID,
+		Environment: request.Environment,
+		Project:     build.project,
+		Parameters:  build.parameters,
+		ProjectDir:  build.projectDir,
+		Subpath:     build.subpath,
+		User:        build.user,
+		Teams:       build.teams,
+		ServerID:    server.info.ID,
+		ServerAddr:  serverAddr,
+		SubmittedAt: build.createdAt,
+		Command:     build.overrides.Command,
+		OutputPaths: build.overrides.OutputPaths,
+		ReplayOf:    build.replayOf,
+	}
+	event := HookEvent{
+		BuildID:     request.ID,
+		Environment: request.Environment,
+		Project:     build.project,
+		ProjectDir:  workdir,
+		ServerID:    server.info.ID,
+		ServerAddr:  serverAddr,
+	}
+
+	// Ask the server whether it can run the build before sending possibly large files
+	if err := c.preflight(ctx, server, request); err != nil {
+		releaseServer(server)
+		if build.backup != nil {
+			releaseServer(build.backup)
+		}
+		return nil, err
+	}
+
+	// Create response channel for this build
+	responseChan := make(chan *protocol.BuildResponse, 1)
+	c.pendingMux.Lock()
+	c.pendingBuilds[request.ID] = responseChan
+	c.pendingMux.Unlock()
+
+	// Send build request with files, unless the server already holds the project
+	if err := c.submitRequest(ctx, server, serverAddr, request); err != nil {
+		// The server may already have the request while its chunks stopped arriving
+		if ctx.Err() != nil {
+			c.abandonBuild(server, request.ID, true)
+		}
+		releaseServer(server)
+		if build.backup != nil {
+			releaseServer(build.backup)
+		}
+
+		// Clean up pending build
+		c.pendingMux.Lock()
+		delete(c.pendingBuilds, request.ID)
+		c.pendingMux.Unlock()
+
+		return nil, fmt.Errorf("failed to send build request to %s: %v", serverAddr, err)
+	}
+
+	record.QueueWait = time.Since(build.createdAt)
+	c.snapshotBuild(build, record)
+	logging.Debugf("Build %s submitted to server %s (%s) with %d files", request.ID, server.info.ID, serverAddr, len(request.Files))
+
+	// Speculative builds also run on the reserved second server
+	backupChan := c.dispatchBackup(ctx, build)
+
+	c.bus.Publish(buildEvent(BusBuildDispatched, event))
+	event.Event = config.HookOnDispatch
+	if err := c.runHooks(event, env); err != nil {
+		logging.Infof("Warning: %v", err)
+	}
+
+	// Wait for the first successful response (or the first failure once every copy failed),
+	// until the build timeout or until the caller gives up
+	waitCtx, cancelWait := context.WithTimeout(ctx, c.config.Client.Timeouts.Build)
+	defer cancelWait()
+	var failed *protocol.BuildResponse
+	for response == nil {
+		var received *protocol.BuildResponse
+		select {
+		case received = <-responseChan:
+			responseChan = nil
+		case received = <-backupChan:
+			backupChan = nil
+			received.ID = request.ID
+			if received.Success {
+				// The speculative copy won
+				serverAddr = net.JoinHostPort(build.backup.info.Address, strconv.Itoa(build.backup.info.Port))
+				record.ServerID, record.ServerAddr = build.backup.info.ID, serverAddr
+				event.ServerID, event.ServerAddr = build.backup.info.ID, serverAddr
+			}
+		case <-waitCtx.Done():
+			// Stop the build on its servers
+			c.abandonBuild(server, request.ID, responseChan != nil)
+			if build.backup != nil {
+				c.abandonBuild(build.backup, backupID(request.ID), backupChan != nil)
+			}
+
+			err := fmt.Errorf("build timeout after %v", c.config.Client.Timeouts.Build)
+			if ctx.Err() != nil {
+				err = fmt.Errorf("build cancelled: %v", ctx.Err())
+			}
+			record.Error = err.Error()
+			record.Duration = time.Since(record.SubmittedAt)
+			c.bus.Publish(finishedEvent(record, c.partialLog(request.ID)))
+
+			event.Event = config.HookOnFailure
+			event.Error = record.Error
+			event.Duration = record.Duration
+			if err := c.runHooks(event, env); err != nil {
+				logging.Infof("Warning: %v", err)
+			}
+
+			return nil, err
+		}
+
+		if received.Success || (responseChan == nil && backupChan == nil) {
+			response = received
+			if !received.Success && failed != nil {
+				response = failed
+			}
+		} else if failed == nil {
+			failed = received
+		}
+	}
+
+	// The slower copy is no longer needed
+	if build.backup != nil {
+		c.abandonBuild(server, request.ID, responseChan != nil)
+		c.abandonBuild(build.backup, backupID(request.ID), backupChan != nil)
+	}
+
+	// Later builds of the project go where its workspace is warm
+	c.affinity.remember(build.project, record.ServerID)
+
+	return c.completeBuild(ctx, build, record, event, response), nil
+}
+
+// completeBuild verifies and saves the artifacts of a finished build, runs its hooks and
+// records it in the history
+func (c *Coordinator) completeBuild(ctx context.Context, build *preparedBuild, record *BuildRecord, event HookEvent, response *protocol.BuildResponse) *protocol.BuildResponse {
+	env := build.env
+
+	// Never save artifacts that were damaged on the way
+	artifacts, err := verifyArtifacts(response)
+	if err != nil {
+		response.Success = false
+		if response.Error != "" {
+			response.Error += "; "
+		}
+		response.Error += err.Error()
+	}
+
+	event.Success = response.Success
+	event.Error = response.Error
+	event.Duration = response.Duration
+	for name := range response.OutputFiles {
+		event.OutputFiles = append(event.OutputFiles, name)
+	}
+	sort.Strings(event.OutputFiles)
+
+	// Save returned files to output directory (failed builds may still return always-collected files)
+	if len(response.OutputFiles) > 0 && len(build.roots) > 0 {
+		if err := c.saveOutputFiles(build.roots, response.OutputFiles, response.Compression); err != nil {
+			logging.Debugf("Warning: Failed to save output files: %v", err)
+		} else {
+			for i := range artifacts {
+				artifacts[i].LocalPath = localArtifactPath(build.roots, artifacts[i].Path)
+			}
+			event.Event = config.HookOnArtifactsSaved
+			if err := c.runHooks(event, env); err != nil {
+				logging.Infof("Warning: %v", err)
+			}
+		}
+	}
+
+	// The server does not send the build log twice; it is the output of the build
+	if len(build.roots) > 0 {
+		if err := saveBuildLog(build.roots, response.Output); err != nil {
+			logging.Debugf("Warning: Failed to save build log: %v", err)
+		}
+	}
+
+	event.Event = config.HookOnFailure
+	if response.Success {
+		event.Event = config.HookOnSuccess
+	}
+	if err := c.runHooks(event, env); err != nil {
+		logging.Infof("Warning: %v", err)
+	}
+
+	record.Success = response.Success
+	record.Error = response.Error
+	record.Duration = response.Duration
+	record.Warnings = response.Warnings
+	record.OutputFiles = event.OutputFiles
+	record.Artifacts = artifacts
+	record.Incremental = response.Incremental
+	record.Steps = stepSummaries(response.Steps)
+	for i := range artifacts {
+		if artifacts[i].Path == "./"+buildutil.ProvenancePath {
+			record.Provenance = &artifacts[i]
+		}
+	}
+	c.keepDebugBundle(record, response, artifacts)
+	c.bus.Publish(finishedEvent(record, response.Output))
+
+	return response
+}
+
+// backupID is the request ID of the speculative copy of a build
+func backupID(id string) string {
+	return id + "-speculative"
+}
+
+// dispatchBackup sends the speculative copy of a build to its reserved server after a
+// pre-flight check and returns the channel receiving its response (nil without a copy)
+func (c *Coordinator) dispatchBackup(ctx context.Context, build *preparedBuild) chan *protocol.BuildResponse {
+	if build.backup == nil {
+		return nil
+	}
+
+	request := build.request
+	request.ID = backupID(request.ID)
+	request.Compression = negotiateCompression(build.backup.info)
+
+	// The copy is only worth sending if its server accepts it
+	if err := c.preflight(ctx, build.backup, request); err != nil {
+		logging.Infof("Warning: Speculative copy of build %s not sent to %s: %v", build.request.ID, build.backup.info.ID, err)
+		releaseServer(build.backup)
+		build.backup = nil
+		return nil
+	}
+
+	responseChan := make(chan *protocol.BuildResponse, 1)
+	c.pendingMux.Lock()
+	c.pendingBuilds[request.ID] = responseChan
+	c.pendingMux.Unlock()
+
+	if err := c.submitRequest(ctx, build.backup, c.serverAddr(build.backup), request); err != nil {
+		logging.Infof("Warning: Failed to send speculative copy of build %s to %s: %v", build.request.ID, build.backup.info.ID, err)
+		c.pendingMux.Lock()
+		delete(c.pendingBuilds, request.ID)
+		c.pendingMux.Unlock()
+		releaseServer(build.backup)
+		build.backup = nil
+		return nil
+	}
+
+	logging.Debugf("Speculative copy of build %s submitted to server %s", build.request.ID, build.backup.info.ID)
+	c.trackBackup(build.request.ID, build.backup)
+	return responseChan
+}
+
+// abandonBuild stops waiting for a build and cancels it if it is still running; the server
+// becomes available once it reports the cancelled result
+func (c *Coordinator) abandonBuild(server *ServerConnection, id string, running bool) {
+	c.pendingMux.Lock()
+	delete(c.pendingBuilds, id)
+	c.pendingMux.Unlock()
+	c.forgetUnsent(id)
+
+	if !running {
+		return
+	}
+	if err := server.send(protocol.Message{Type: protocol.MessageCancel, BuildID: id}); err != nil {
+		logging.Debugf("Warning: Failed to cancel build %s: %v", id, err)
+	}
+}
+
+// findServerByAddress finds a server by its address
+func (c *Coordinator) findServerByAddress(serverAddr string) *ServerConnection {
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+
+	for _, server := range c.servers {
+		currentAddr := fmt.Sprintf("%s:%d", server.info.Address, server.info.Port)
+		if currentAddr == serverAddr {
+			return server
+		}
+	}
+	return nil
+}
+
+// findAvailableServer returns an available server able to run the request or nil.
+// Builds of a named project go to the server that last built it while that one is free;
+// otherwise, with the least_loaded strategy the server with the lowest reported CPU load is picked.
+func (c *Coordinator) findAvailableServer(request protocol.BuildRequest) *ServerConnection {
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+
+	size := requestSize(request)
+	preferred := c.affinity.server(request.Project)
+	var best *ServerConnection
+	var bestLoad protocol.ServerLoad
+	var bestTransfer time.Duration
+	for _, server := range c.servers {
+		server.mux.Lock()
+		busy, info, network := server.busy, server.info, server.network
+		server.mux.Unlock()
+
+		// Servers low on disk space finish their builds but get no new ones
+		if busy || info.Load.LowDisk || c.checkServerSupport(info, request) != nil {
+			continue
+		}
+		if info.ID == preferred {
+			return server
+		}
+		transfer := network.TransferTime(size)
+
+		switch c.config.Client.Scheduling {
+		case config.SchedulingLeastLoaded:
+			if best == nil || lessLoaded(info.Load, bestLoad, transfer, bestTransfer) {
+				best, bestLoad, bestTransfer = server, info.Load, transfer
+			}
+		case config.SchedulingFastestTransfer:
+			if best == nil || transfer < bestTransfer {
+				best, bestTransfer = server, transfer
+			}
+		default:
+			if best == nil {
+				best = server
+			}
+		}
+	}
+	return best
+}
+
+// lessLoaded orders servers by CPU load, then by free memory and the expected transfer time
+func lessLoaded(a, b protocol.ServerLoad, aTransfer, bTransfer time.Duration) bool {
+	if a.CPULoad != b.CPULoad {
+		return a.CPULoad < b.CPULoad
+	}
+	if a.FreeMemory != b.FreeMemory {
+		return a.FreeMemory > b.FreeMemory
+	}
+	return aTransfer < bTransfer
+}
+
+// checkAnyServerSupports returns why none of the connected servers, busy or not, can run
+// the request, or nil if one can (or none is connected)
+func (c *Coordinator) checkAnyServerSupports(request protocol.BuildRequest) error {
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+
+	var reasons []string
+	for _, server := range c.servers {
+		err := c.checkServerSupport(server.snapshot(), request)
+		if err == nil {
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", server.info.ID, err))
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	sort.Strings(reasons)
+	return fmt.Errorf("%s", strings.Join(reasons, "; "))
+}
+
+// checkServerSupport reports why a server cannot run a request, or nil if it can
+func (c *Coordinator) checkServerSupport(info protocol.ServerInfo, request protocol.BuildRequest) error {
+	if request.Image != "" && info.Containers == "" {
+		return fmt.Errorf("image %s requires a server with container support", request.Image)
+	}
+	if request.WSL != "" && !buildutil.ContainsString(info.WSL, request.WSL) {
+		return fmt.Errorf("WSL distribution %s requires a Windows server where it is installed", request.WSL)
+	}
+	if err := request.Requirements.Check(info.Resources); err != nil {
+		return err
+	}
+	if err := checkPlatformSupport(info, request); err != nil {
+		return err
+	}
+	return c.checkToolchainSupport(info, request)
+}
+
+// GetServerStatus returns the status of all connected servers
+func (c *Coordinator) GetServerStatus() map[string]protocol.ServerStatusInfo {
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+
+	status := make(map[string]protocol.ServerStatusInfo)
+	for id, server := range c.servers {
+		server.mux.Lock()
+		environments := make([]string, 0, len(server.info.Environments))
+		for name := range server.info.Environments {
+			environments = append(environments, name)
+		}
+		sort.Strings(environments)
+		status[id] = protocol.ServerStatusInfo{
+			ID:         server.info.ID,
+			Address:    server.info.Address,
+			Port:       server.info.Port,
+			Capacity:   server.info.Capacity,
+			Available:  !server.busy,
+			Version:    server.info.Version,
+			OS:         server.info.OS,
+			Arch:       server.info.Arch,
+			OSVersion:  server.info.OSVersion,
+			Containers: server.info.Containers,
+			WSL:        server.info.WSL,
+			Resources:  server.info.Resources,
+			Load:       server.info.Load,
+			Network:    server.network,
+
+			Environments: environments,
+			Tools:        server.info.Tools,
+		}
+		server.mux.Unlock()
+	}
+	return status
+}
+
+// readProjectFiles reads all files selected by the transfer configuration from the project directory
+func (c *Coordinator) readProjectFiles(workdir string, transfer config.TransferConfig, skipped func(relPath, reason string)) (map[string]string, error) {
+	files := make(map[string]string)
+	maxFileSize := transfer.FileSizeLimit()
+
+	err := filepath.WalkDir(workdir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Get relative path from workdir
+		relPath, err := filepath.Rel(workdir, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %v", path, err)
+		}
+
+		// Normalize path to use forward slashes for cross-platform compatibility
+		normalizedRelPath := filepath.ToSlash(relPath)
+
+		// Skip directories (and boltbuild's own metadata such as returned build logs)
+		if d.IsDir() {
+			if path != workdir && (d.Name() == buildutil.BuildMetadataDir || transfer.SkipDir(normalizedRelPath)) {
+				if skipped != nil {
+					reason := "excluded directory"
+					if d.Name() == buildutil.BuildMetadataDir {
+						reason = "boltbuild metadata"
+					}
+					skipped(normalizedRelPath+"/", reason)
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Apply include/exclude patterns
+		if !transfer.IncludeFile(normalizedRelPath) {
+			if skipped != nil {
+				skipped(normalizedRelPath, "excluded by transfer patterns")
+			}
+			return nil
+		}
+
+		// Get file info for size check
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		// Skip large files
+		if protocol.ByteSize(info.Size()) > maxFileSize {
+			logging.Debugf("Skipping %s: size %s exceeds transfer limit of %s", normalizedRelPath, protocol.ByteSize(info.Size()), maxFileSize)
+			if skipped != nil {
+				skipped(normalizedRelPath, fmt.Sprintf("size %s exceeds transfer limit of %s", protocol.ByteSize(info.Size()), maxFileSize))
+			}
+			return nil
+		}
+
+		// Read file content
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", path, err)
+		}
+
+		// Store file content with normalized relative path as key
+		files[normalizedRelPath] = string(content)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Debugf("Read %d files from project directory: %s", len(files), workdir)
+	return files, nil
+}
+
+// saveOutputFiles saves compiled output files into the source roots they belong to
+func (c *Coordinator) saveOutputFiles(roots []config.SourceRoot, outputFiles map[string]string, compression string) error {
+	for relPath, encodedContent := range outputFiles {
+		// Decode base64 content and decompress if needed
+		content, err := decodeArtifact(encodedContent, compression)
+		if err != nil {
+			logging.Debugf("Warning: Failed to decode file %s: %v", relPath, err)
+			continue
+		}
+
+		// Map the workspace path (always forward slashes) back to a local path
+		outputPath := localArtifactPath(roots, relPath)
+
+		// Create directory if needed
+		dir := filepath.Dir(outputPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logging.Debugf("Warning: Failed to create directory %s: %v", dir, err)
+			continue
+		}
+
+		// Write file
+		if err := os.WriteFile(outputPath, content, 0755); err != nil {
+			logging.Debugf("Warning: Failed to write file %s: %v", outputPath, err)
+			continue
+		}
+
+		logging.Debugf("Saved output file: %s", outputPath)
+	}
+
+	logging.Debugf("Saved %d output files to project directory %s", len(outputFiles), roots[0].Path)
+	return nil
+}
+
+// saveBuildLog writes the output of a build to the build log location of the project
+func saveBuildLog(roots []config.SourceRoot, output string) error {
+	logPath := localArtifactPath(roots, "./"+buildutil.BuildLogPath)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(logPath, []byte(output), 0644)
+}
+
+// generateClientID creates a client ID from the hostname and a random suffix, so several
+// clients on one machine stay distinguishable
+func generateClientID() string {
+	bytes := make([]byte, 4)
+	rand.Read(bytes)
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "client-" + hex.EncodeToString(bytes)
+	}
+	return fmt.Sprintf("client-%s-%s", hostname, hex.EncodeToString(bytes))
+}
+
+// getLocalIP returns the local IP address of the client
+func (c *Coordinator) getLocalIP() string {
+	conn, err := net.Dial("udp", "8.8.8.8:80")
+	if err != nil {
+		return "192.168.1"
+	}
+	defer conn.Close()
+
+	localAddr := conn.LocalAddr().(*net.UDPAddr)
+	return localAddr.IP.String()
+}
+
+// getNetworkPrefix returns the network prefix (e.g., "192.168.1" from "192.168.1.100")
+func (c *Coordinator) getNetworkPrefix(ip string) string {
+	parts := strings.Split(ip, ".")
+	if len(parts) >= 3 {
+		return strings.Join(parts[:3], ".")
+	}
+	return "192.168.1"
+}
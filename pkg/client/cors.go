@@ -0,0 +1,52 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"boltbuild/pkg/config"
+)
+
+// Default CORS settings
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT"}
+	defaultCORSHeaders = []string{"Content-Type", webUserHeader}
+	corsExposedHeaders = []string{"X-Boltbuild-API-Version", "Deprecation", "Sunset", "Link"}
+)
+
+// corsMaxAge is how long browsers may cache a preflight answer, in seconds
+const corsMaxAge = 600
+
+// corsHandler adds CORS headers to responses for allowed origins and answers preflight
+// requests itself, before routing, as API routes only accept their own methods
+func corsHandler(cfg config.CORSConfig, next http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := append(append([]string{}, defaultCORSHeaders...), cfg.AllowedHeaders...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !cfg.AllowsOrigin(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		next.ServeHTTP(w, r)
+	})
+}
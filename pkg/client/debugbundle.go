@@ -0,0 +1,55 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+	"github.com/gorilla/mux"
+)
+
+// debugBundleRequest is the bundle configuration sent with builds of an environment, nil unless enabled
+func debugBundleRequest(env *config.BuildEnvironment) *protocol.DebugBundleConfig {
+	if !env.DebugBundle.Enabled {
+		return nil
+	}
+	bundle := env.DebugBundle
+	return &bundle
+}
+
+// keepDebugBundle stores the debug bundle a failed build returned with its history entry
+func (c *Coordinator) keepDebugBundle(record *BuildRecord, response *protocol.BuildResponse, artifacts []ArtifactInfo) {
+	encoded, exists := response.OutputFiles["./"+buildutil.DebugBundlePath]
+	if !exists {
+		return
+	}
+	bundle, err := decodeArtifact(encoded, response.Compression)
+	if err != nil {
+		logging.Debugf("Warning: Failed to decode debug bundle of build %s: %v", record.ID, err)
+		return
+	}
+	for i := range artifacts {
+		if artifacts[i].Path == "./"+buildutil.DebugBundlePath {
+			record.DebugBundle = &artifacts[i]
+		}
+	}
+	c.history.SaveBundle(record.ID, bundle)
+}
+
+// handleBuildBundleAPI downloads the debug bundle of a failed build
+func (ws *WebServer) handleBuildBundleAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	bundle, exists := ws.client.history.Bundle(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("No debug bundle for build: %s", id), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "boltbuild-"+id+"-debug.tar.gz"))
+	w.Write(bundle)
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// DetectedKind is a project type found in a directory
+type DetectedKind struct {
+	Kind   string `json:"kind"`
+	Marker string `json:"marker"` // file that triggered the detection
+}
+
+// DetectionResult is returned by environment detection
+type DetectionResult struct {
+	ProjectDir string         `json:"project_dir"`
+	Detected   []DetectedKind `json:"detected"`
+	Suggested  []string       `json:"suggested"` // matching environment names, best match first
+}
+
+// DetectProjectKinds inspects the root of a project directory for known marker files
+func DetectProjectKinds(projectDir string) ([]DetectedKind, error) {
+	if _, err := os.Stat(projectDir); err != nil {
+		return nil, err
+	}
+
+	var detected []DetectedKind
+	for _, kind := range buildutil.KnownProjectKinds {
+		for _, marker := range kind.Markers {
+			matches, err := filepath.Glob(filepath.Join(projectDir, marker))
+			if err == nil && len(matches) > 0 {
+				detected = append(detected, DetectedKind{Kind: kind.Kind, Marker: filepath.Base(matches[0])})
+				break
+			}
+		}
+	}
+	return detected, nil
+}
+
+// DetectEnvironments detects the project type and suggests matching environments of envs
+func DetectEnvironments(projectDir string, envs map[string]config.BuildEnvironment) (*DetectionResult, error) {
+	detected, err := DetectProjectKinds(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DetectionResult{
+		ProjectDir: projectDir,
+		Detected:   detected,
+		Suggested:  []string{},
+	}
+
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Rank by detection order so the most specific project kind wins
+	seen := make(map[string]bool)
+	for _, found := range detected {
+		for _, name := range names {
+			if !seen[name] && environmentMatchesKind(envs[name], name, found.Kind) {
+				result.Suggested = append(result.Suggested, name)
+				seen[name] = true
+			}
+		}
+	}
+
+	logging.Debugf("Detected %d project kinds in %s, suggesting %v", len(detected), projectDir, result.Suggested)
+	return result, nil
+}
+
+// environmentMatchesKind reports whether an environment builds the given project kind
+func environmentMatchesKind(env config.BuildEnvironment, name, kind string) bool {
+	if strings.EqualFold(name, kind) || strings.EqualFold(env.Name, kind) {
+		return true
+	}
+
+	// Compare the executable of the environment command with the kind's tools
+	fields := strings.Fields(env.CommandLine())
+	if len(fields) == 0 {
+		return false
+	}
+	executable := strings.ToLower(strings.TrimSuffix(filepath.Base(fields[0]), filepath.Ext(fields[0])))
+
+	for _, known := range buildutil.KnownProjectKinds {
+		if known.Kind != kind {
+			continue
+		}
+		for _, tool := range known.Tools {
+			if executable == tool {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxSubprojectDepth limits how deep findSubprojects descends into a repository
+const maxSubprojectDepth = 3
+
+// Subproject is a buildable directory inside a larger repository
+type Subproject struct {
+	Path  string   `json:"path"` // Relative to the project directory, slash separated
+	Kinds []string `json:"kinds"`
+}
+
+// findSubprojects lists directories below projectDir that contain a recognizable project
+func findSubprojects(projectDir string) ([]Subproject, error) {
+	subprojects := []Subproject{}
+
+	err := filepath.WalkDir(projectDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == projectDir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		// Skip hidden, dependency and build output directories
+		name := d.Name()
+		if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "target" || name == "build" || name == "bin" || name == "obj" {
+			return filepath.SkipDir
+		}
+		if strings.Count(relPath, "/") >= maxSubprojectDepth {
+			return filepath.SkipDir
+		}
+
+		detected, err := DetectProjectKinds(path)
+		if err == nil && len(detected) > 0 {
+			subproject := Subproject{Path: relPath}
+			for _, found := range detected {
+				subproject.Kinds = append(subproject.Kinds, found.Kind)
+			}
+			subprojects = append(subprojects, subproject)
+		}
+		return nil
+	})
+
+	return subprojects, err
+}
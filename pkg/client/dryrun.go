@@ -0,0 +1,109 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"boltbuild/pkg/protocol"
+)
+
+// dryRunLargestFiles is how many of the largest collected files a dry run lists
+const dryRunLargestFiles = 10
+
+// DryRunFile is a file a dry run collected or left out
+type DryRunFile struct {
+	Path   string            `json:"path"`
+	Size   protocol.ByteSize `json:"size,omitempty"`
+	Reason string            `json:"reason,omitempty"` // Why it is left out
+}
+
+// DryRunReport shows what a build would do without transferring or executing anything
+type DryRunReport struct {
+	DryRun       bool              `json:"dry_run"` // Always true, tells reports apart from build responses
+	Environment  string            `json:"environment"`
+	Project      string            `json:"project,omitempty"`
+	ProjectDir   string            `json:"project_dir,omitempty"` // Empty for uploaded files
+	Command      string            `json:"command"`               // With the parameters filled in
+	Parameters   map[string]string `json:"parameters,omitempty"`
+	ExecutionDir string            `json:"execution_dir"`
+	OutputPaths  []string          `json:"output_paths"`
+	Image        string            `json:"image,omitempty"`
+	WSL          string            `json:"wsl,omitempty"`
+	Workspace    string            `json:"workspace,omitempty"` // Persistent workspace key, if incremental
+
+	Files        int               `json:"files"`
+	TotalSize    protocol.ByteSize `json:"total_size"`
+	LargestFiles []DryRunFile      `json:"largest_files"`
+	Skipped      []DryRunFile      `json:"skipped"` // Files and directories the transfer settings leave out
+
+	Server      string `json:"server,omitempty"` // ID of the server the build would go to
+	ServerAddr  string `json:"server_addr,omitempty"`
+	ServerError string `json:"server_error,omitempty"` // Why no server would take it right now
+}
+
+// DryRunBuild resolves the environment, collects the project files and picks a server like a
+// real submission, without counting, transferring or executing the build
+func (c *Coordinator) DryRunBuild(environment, projectDir, serverAddr string, opts BuildOptions) (*DryRunReport, error) {
+	report := &DryRunReport{DryRun: true, Skipped: []DryRunFile{}, LargestFiles: []DryRunFile{}}
+	opts.DryRun = true
+	opts.skipped = func(relPath, reason string) {
+		report.Skipped = append(report.Skipped, DryRunFile{Path: relPath, Reason: reason})
+	}
+	build, err := c.prepareBuild(environment, projectDir, projectDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	request := build.request
+	report.Environment = request.Environment
+	report.Project = build.project
+	report.ProjectDir = build.projectDir
+	report.Command = request.Command
+	report.Parameters = build.parameters
+	report.ExecutionDir = request.ExecutionDir
+	report.OutputPaths = request.OutputPaths
+	report.Image = request.Image
+	report.WSL = request.WSL
+	report.Workspace = request.Workspace
+
+	for path, content := range request.Files {
+		report.Files++
+		report.TotalSize += protocol.ByteSize(len(content))
+		report.LargestFiles = append(report.LargestFiles, DryRunFile{Path: path, Size: protocol.ByteSize(len(content))})
+	}
+	sort.Slice(report.LargestFiles, func(i, j int) bool {
+		if report.LargestFiles[i].Size != report.LargestFiles[j].Size {
+			return report.LargestFiles[i].Size > report.LargestFiles[j].Size
+		}
+		return report.LargestFiles[i].Path < report.LargestFiles[j].Path
+	})
+	if len(report.LargestFiles) > dryRunLargestFiles {
+		report.LargestFiles = report.LargestFiles[:dryRunLargestFiles]
+	}
+	sort.Slice(report.Skipped, func(i, j int) bool { return report.Skipped[i].Path < report.Skipped[j].Path })
+
+	// Pick the server without reserving it
+	var server *ServerConnection
+	if serverAddr == "" {
+		if server = c.findAvailableServer(request); server == nil {
+			if err := c.checkAnyServerSupports(request); err != nil {
+				report.ServerError = fmt.Sprintf("no connected server can run environment %s: %v", request.Environment, err)
+			} else {
+				report.ServerError = protocol.ErrNoServers.Error()
+			}
+		}
+	} else if server = c.findServerByAddress(serverAddr); server == nil {
+		report.ServerError = fmt.Sprintf("server %s not found or not connected", serverAddr)
+	} else if err := c.checkServerSupport(server.snapshot(), request); err != nil {
+		report.ServerError = fmt.Sprintf("server %s cannot run environment %s: %v", serverAddr, request.Environment, err)
+		server = nil
+	}
+	if server != nil {
+		info := server.snapshot()
+		report.Server = info.ID
+		report.ServerAddr = net.JoinHostPort(info.Address, strconv.Itoa(info.Port))
+	}
+	return report, nil
+}
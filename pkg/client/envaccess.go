@@ -0,0 +1,64 @@
+package client
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// APIKeyHeader carries an API key; "Authorization: Bearer <key>" is accepted as well
+const APIKeyHeader = protocol.APIKeyHeader
+
+// accessAllows reports whether an authenticated caller may build an environment
+func accessAllows(a config.EnvironmentAccess, identity Identity) bool {
+	if sharesGroup(identity.Roles, a.Roles) {
+		return true
+	}
+	if identity.Method == "api_key" {
+		return sharesGroup([]string{identity.User}, a.APIKeys)
+	}
+	return sharesGroup([]string{identity.User}, a.Users)
+}
+
+// apiKeyIdentity returns the identity of a request's API key; present is false without a key
+func (a *authenticator) apiKeyIdentity(r *http.Request) (identity Identity, present, valid bool) {
+	key := r.Header.Get(APIKeyHeader)
+	if bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); key == "" && found {
+		key = strings.TrimSpace(bearer)
+	}
+	if key == "" {
+		return Identity{}, false, false
+	}
+	for _, configured := range a.config.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(configured.Key)) == 1 {
+			return Identity{User: configured.Name, Roles: configured.Roles, Method: "api_key"}, true, true
+		}
+	}
+	return Identity{}, true, false
+}
+
+// authorizeBuild checks the environment's access list for the caller of a build request,
+// auditing refused attempts
+func (ws *WebServer) authorizeBuild(r *http.Request, environment string) error {
+	env, exists := ws.client.lookupEnvironment(environment)
+	if !exists || !env.Access.Restricted() {
+		return nil
+	}
+	identity, authenticated := requestIdentity(r)
+	if authenticated && accessAllows(env.Access, identity) {
+		return nil
+	}
+
+	event := AuditEvent{User: identity.User, Method: identity.Method, Remote: r.RemoteAddr, Action: "build", Environment: environment}
+	if authenticated {
+		event.Reason = "not in the environment's access list"
+	} else {
+		event.Reason = "not authenticated"
+	}
+	ws.client.audit.Record(event)
+	return fmt.Errorf("access to environment %s denied: %s", environment, event.Reason)
+}
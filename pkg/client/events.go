@@ -0,0 +1,181 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// Lifecycle event types written to the build event stream
+const (
+	StreamSubmitted  = "submitted"  // Accepted by the client, submit hooks passed
+	StreamDispatched = "dispatched" // Sent to a server or federation peer
+	StreamStarted    = "started"    // First output arrived from the server
+	StreamProgress   = "progress"   // More output arrived, at most every progress_interval
+	StreamFinished   = "finished"   // Succeeded, failed, timed out or cancelled
+)
+
+// eventStreamBuffer is the number of events queued for a slow sink before events are dropped
+const eventStreamBuffer = 4096
+
+// eventStreamRetry is how long events are dropped after a sink could not be opened
+const eventStreamRetry = 5 * time.Second
+
+// StreamEvent is one line of the build event stream
+type StreamEvent struct {
+	Type        string        `json:"type"`
+	BuildID     string        `json:"build_id"`
+	Environment string        `json:"environment"`
+	Project     string        `json:"project,omitempty"`
+	ServerID    string        `json:"server_id,omitempty"`
+	ServerAddr  string        `json:"server_addr,omitempty"`
+	Success     *bool         `json:"success,omitempty"`      // Finished events only
+	Error       string        `json:"error,omitempty"`        // Finished events only
+	Duration    time.Duration `json:"duration,omitempty"`     // Finished events only
+	OutputBytes int64         `json:"output_bytes,omitempty"` // Output received so far, for started and progress events
+	Time        time.Time     `json:"time"`
+}
+
+// EventStream writes events to a sink in the background, so a slow or unreachable consumer
+// never delays builds. Lost TCP connections are redialed when the next event arrives.
+type EventStream struct {
+	sink     string
+	interval time.Duration
+	queue    chan []byte
+	writer   io.WriteCloser
+	retryAt  time.Time
+	reported map[string]time.Time // Last started or progress event of each running build
+	mux      sync.Mutex
+}
+
+// NewEventStream starts writing events to the configured sink (nil when disabled)
+func NewEventStream(cfg config.EventStreamConfig) *EventStream {
+	if cfg.Sink == "" {
+		return nil
+	}
+	e := &EventStream{
+		sink:     cfg.Sink,
+		interval: cfg.ProgressInterval,
+		queue:    make(chan []byte, eventStreamBuffer),
+		reported: make(map[string]time.Time),
+	}
+	if e.interval <= 0 {
+		e.interval = 5 * time.Second
+	}
+	go e.run()
+	logging.Infof("Writing build events to %s", e.sink)
+	return e
+}
+
+// streamTypes maps bus events to event stream types
+var streamTypes = map[string]string{
+	BusBuildSubmitted:  StreamSubmitted,
+	BusBuildDispatched: StreamDispatched,
+	BusBuildOutput:     StreamProgress,
+	BusBuildFinished:   StreamFinished,
+}
+
+// handle turns a bus event into a stream event. Output becomes a started event for the first
+// chunk of a build and a progress event at most once per interval after that.
+func (e *EventStream) handle(event BusEvent) {
+	stream := StreamEvent{
+		Type:        streamTypes[event.Type],
+		BuildID:     event.BuildID,
+		Environment: event.Environment,
+		Project:     event.Project,
+		ServerID:    event.ServerID,
+		ServerAddr:  event.ServerAddr,
+	}
+
+	switch event.Type {
+	case BusBuildOutput:
+		e.mux.Lock()
+		last, started := e.reported[event.BuildID]
+		if started && time.Since(last) < e.interval {
+			e.mux.Unlock()
+			return
+		}
+		e.reported[event.BuildID] = time.Now()
+		e.mux.Unlock()
+		if !started {
+			stream.Type = StreamStarted
+		}
+		stream.OutputBytes = event.OutputBytes
+	case BusBuildFinished:
+		e.mux.Lock()
+		delete(e.reported, event.BuildID)
+		e.mux.Unlock()
+		success := event.Record.Success
+		stream.Success = &success
+		stream.Error = event.Record.Error
+		stream.Duration = event.Record.Duration
+	}
+	e.Emit(stream)
+}
+
+// Emit queues an event; nil streams ignore events
+func (e *EventStream) Emit(event StreamEvent) {
+	if e == nil {
+		return
+	}
+	event.Time = time.Now()
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	select {
+	case e.queue <- append(line, '\n'):
+	default:
+		logging.Debugf("Warning: Dropping %s event of build %s, the event stream is not keeping up", event.Type, event.BuildID)
+	}
+}
+
+// run writes queued events, reopening the sink after write errors
+func (e *EventStream) run() {
+	for line := range e.queue {
+		if e.writer == nil {
+			if time.Now().Before(e.retryAt) {
+				continue
+			}
+			writer, err := e.open()
+			if err != nil {
+				logging.Debugf("Warning: Failed to open event stream %s: %v", e.sink, err)
+				e.retryAt = time.Now().Add(eventStreamRetry)
+				continue
+			}
+			e.writer = writer
+		}
+		if _, err := e.writer.Write(line); err != nil {
+			logging.Debugf("Warning: Failed to write to event stream %s: %v", e.sink, err)
+			e.writer.Close()
+			e.writer = nil
+		}
+	}
+}
+
+// open connects to the sink
+func (e *EventStream) open() (io.WriteCloser, error) {
+	kind, target, _ := strings.Cut(e.sink, ":")
+	switch kind {
+	case config.SinkFile:
+		return os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	case config.SinkTCP:
+		return net.DialTimeout("tcp", target, eventStreamRetry)
+	}
+	return nopWriteCloser{os.Stdout}, nil
+}
+
+// nopWriteCloser keeps stdout open when the stream closes its writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing
+func (nopWriteCloser) Close() error { return nil }
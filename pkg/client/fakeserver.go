@@ -0,0 +1,289 @@
+//go:build fakeserver
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// This file is a test harness, only compiled with the fakeserver build tag:
+//
+//	go test -tags fakeserver ./...
+//
+// A FakeServer speaks the client/server protocol on a loopback port inside the test process and
+// answers builds from a script instead of running commands, so the client's scheduling, retry and
+// transfer logic can be tested without compilers or a second machine.
+
+// FakeOutcome is the scripted answer to one build request
+type FakeOutcome struct {
+	Response   protocol.BuildResponse // Sent as the result; ID is filled in
+	Log        []string               // Output chunks streamed as log messages before the result
+	Delay      time.Duration          // Before the result is sent; a cancel message ends the build early
+	Disconnect bool                   // Drop the connection instead of answering, e.g. to test retries
+	Silent     bool                   // Only answer a cancel, e.g. to test timeouts
+}
+
+// FakeScript decides the outcome of each build request
+type FakeScript func(request protocol.BuildRequest) FakeOutcome
+
+// FakeSucceed answers every build with a successful result
+func FakeSucceed(request protocol.BuildRequest) FakeOutcome {
+	return FakeOutcome{Response: protocol.BuildResponse{Success: true, Output: "ok\n"}}
+}
+
+// FakeSequence answers builds with the given outcomes in order, repeating the last one
+func FakeSequence(outcomes ...FakeOutcome) FakeScript {
+	var mux sync.Mutex
+	next := 0
+	return func(request protocol.BuildRequest) FakeOutcome {
+		mux.Lock()
+		defer mux.Unlock()
+		outcome := outcomes[next]
+		if next < len(outcomes)-1 {
+			next++
+		}
+		return outcome
+	}
+}
+
+// FakeServer is an in-process build server with scripted responses
+type FakeServer struct {
+	Info      protocol.ServerInfo                                // Sent to connecting clients; Address and Port are set by Start
+	Script    FakeScript                                         // Outcome of each build (default: FakeSucceed)
+	Preflight func(check protocol.PreflightCheck) (bool, string) // Accept or reject preflight checks (default: accept)
+
+	listener net.Listener
+	requests []protocol.BuildRequest // Received builds, chunked uploads reassembled
+	chunks   int                     // Received upload chunks
+	uploads  *transport.UploadStore
+	conns    map[net.Conn]bool
+	mux      sync.Mutex
+}
+
+// NewFakeServer creates a fake server that advertises the given ID and capacity
+func NewFakeServer(id string, capacity int) *FakeServer {
+	return &FakeServer{
+		Info: protocol.ServerInfo{
+			ID:            "server-" + id,
+			Capacity:      capacity,
+			Version:       protocol.Version,
+			OS:            runtime.GOOS,
+			Arch:          runtime.GOARCH,
+			Compression:   buildutil.SupportedCompressions,
+			ChunkedUpload: true,
+		},
+		uploads: transport.NewUploadStore(0),
+		conns:   make(map[net.Conn]bool),
+	}
+}
+
+// Start listens on a free loopback port and returns the address clients connect to
+func (f *FakeServer) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	f.listener = listener
+	f.Info.Address = "127.0.0.1"
+	f.Info.Port = listener.Addr().(*net.TCPAddr).Port
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f.Addr(), nil
+}
+
+// Addr returns the address of the fake server
+func (f *FakeServer) Addr() string {
+	return net.JoinHostPort(f.Info.Address, strconv.Itoa(f.Info.Port))
+}
+
+// Connect makes a client discover the fake server and waits until the connection is registered
+func (f *FakeServer) Connect(c *Coordinator) error {
+	addr := f.Addr()
+	go c.connectToServer(addr)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if c.findServerByAddress(addr) != nil {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("client did not connect to fake server %s", f.Info.ID)
+}
+
+// Disconnect drops every open connection, as if the server restarted; clients may reconnect
+func (f *FakeServer) Disconnect() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for conn := range f.conns {
+		conn.Close()
+	}
+}
+
+// Close stops listening and drops every connection
+func (f *FakeServer) Close() {
+	if f.listener != nil {
+		f.listener.Close()
+	}
+	f.Disconnect()
+}
+
+// Requests returns the build requests received so far
+func (f *FakeServer) Requests() []protocol.BuildRequest {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return append([]protocol.BuildRequest{}, f.requests...)
+}
+
+// Chunks returns the number of upload chunks received so far
+func (f *FakeServer) Chunks() int {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.chunks
+}
+
+// serve handles one client connection like Server.handleClientConnection
+func (f *FakeServer) serve(conn net.Conn) {
+	f.mux.Lock()
+	f.conns[conn] = true
+	f.mux.Unlock()
+	defer func() {
+		f.mux.Lock()
+		delete(f.conns, conn)
+		f.mux.Unlock()
+		conn.Close()
+	}()
+
+	var sendMux sync.Mutex
+	encoder := json.NewEncoder(conn)
+	send := func(msg protocol.Message) error {
+		sendMux.Lock()
+		defer sendMux.Unlock()
+		return encoder.Encode(msg)
+	}
+	if err := encoder.Encode(f.Info); err != nil {
+		return
+	}
+
+	cancels := make(map[string]chan struct{})
+	var cancelsMux sync.Mutex
+	decoder := json.NewDecoder(conn)
+	for {
+		var msg protocol.Message
+		if err := decoder.Decode(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case protocol.MessageBuild:
+			if msg.Request == nil {
+				continue
+			}
+			cancel := make(chan struct{})
+			cancelsMux.Lock()
+			cancels[msg.Request.ID] = cancel
+			cancelsMux.Unlock()
+			go func(request protocol.BuildRequest) {
+				defer func() {
+					cancelsMux.Lock()
+					delete(cancels, request.ID)
+					cancelsMux.Unlock()
+				}()
+				f.runBuild(conn, send, request, cancel)
+			}(*msg.Request)
+		case protocol.MessageChunk:
+			if msg.Chunk != nil && msg.Chunk.Index >= 0 {
+				f.mux.Lock()
+				f.chunks++
+				f.mux.Unlock()
+				f.uploads.Add(msg.BuildID, *msg.Chunk)
+			}
+		case protocol.MessageCancel:
+			cancelsMux.Lock()
+			if cancel, exists := cancels[msg.BuildID]; exists {
+				close(cancel)
+				delete(cancels, msg.BuildID)
+			}
+			cancelsMux.Unlock()
+		case protocol.MessagePreflight:
+			if msg.Preflight == nil {
+				continue
+			}
+			check := *msg.Preflight
+			check.Accepted = true
+			if f.Preflight != nil {
+				check.Accepted, check.Reason = f.Preflight(check)
+			}
+			send(protocol.Message{Type: protocol.MessagePreflightResult, BuildID: msg.BuildID, Preflight: &check})
+		case protocol.MessagePing:
+			send(protocol.Message{Type: protocol.MessagePong, BuildID: msg.BuildID})
+		}
+	}
+}
+
+// runBuild records a request and answers it as scripted
+func (f *FakeServer) runBuild(conn net.Conn, send func(protocol.Message) error, request protocol.BuildRequest, cancel <-chan struct{}) {
+	if request.Upload != nil {
+		if err := f.uploads.Assemble(context.Background(), &request); err != nil {
+			send(protocol.Message{Type: protocol.MessageResult, BuildID: request.ID, Response: &protocol.BuildResponse{ID: request.ID, Error: err.Error()}})
+			return
+		}
+	}
+	f.mux.Lock()
+	f.requests = append(f.requests, request)
+	f.mux.Unlock()
+
+	script := f.Script
+	if script == nil {
+		script = FakeSucceed
+	}
+	outcome := script(request)
+	if outcome.Disconnect {
+		conn.Close()
+		return
+	}
+
+	for _, chunk := range outcome.Log {
+		send(protocol.Message{Type: protocol.MessageLog, BuildID: request.ID, Data: chunk})
+	}
+	response := outcome.Response
+	response.ID = request.ID
+	finished := time.After(outcome.Delay)
+	if outcome.Silent {
+		finished = nil
+	}
+	select {
+	case <-finished:
+	case <-cancel:
+		response = protocol.BuildResponse{ID: request.ID, Error: "build cancelled"}
+	}
+	send(protocol.Message{Type: protocol.MessageResult, BuildID: request.ID, Response: &response})
+}
+
+// NewFakeCoordinator returns a coordinator for cfg (nil = config.Default()) that only connects to
+// the servers it is given, e.g. with FakeServer.Connect
+func NewFakeCoordinator(cfg *config.Config) *Coordinator {
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	return NewCoordinator(cfg)
+}
@@ -0,0 +1,248 @@
+//go:build fakeserver
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// newFakeFarm starts fake servers, connects a fresh client with a "test" environment to them
+// and stops everything when the test ends
+func newFakeFarm(t *testing.T, servers ...*FakeServer) *Coordinator {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Build.Environments = map[string]config.BuildEnvironment{
+		"test": {Name: "test", Command: "make", ProjectDir: ".", ExecutionDir: "."},
+	}
+	cfg.Projects = map[string]config.ProjectConfig{
+		"app": {Dir: ".", Environment: "test"},
+	}
+	c := NewFakeCoordinator(cfg)
+
+	for _, server := range servers {
+		if _, err := server.Start(); err != nil {
+			t.Fatalf("failed to start %s: %v", server.Info.ID, err)
+		}
+		t.Cleanup(server.Close)
+		if err := server.Connect(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// fakeBuild submits an uploaded build of the test environment
+func fakeBuild(t *testing.T, c *Coordinator, opts BuildOptions) (*protocol.BuildResponse, error) {
+	t.Helper()
+	if opts.Files == nil {
+		opts.Files = map[string]string{"main.c": "int main() { return 0; }"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return c.SubmitBuild(ctx, "test", "", "", []string{}, opts)
+}
+
+func TestFakeServerBuild(t *testing.T) {
+	server := NewFakeServer("a", 1)
+	server.Script = FakeSequence(FakeOutcome{
+		Response: protocol.BuildResponse{Success: true, Output: "compiled\n"},
+		Log:      []string{"compiling\n"},
+	})
+	c := newFakeFarm(t, server)
+
+	response, err := fakeBuild(t, c, BuildOptions{})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if !response.Success || response.Output != "compiled\n" {
+		t.Errorf("unexpected response: success=%v output=%q", response.Success, response.Output)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("server received %d builds, want 1", len(requests))
+	}
+	if requests[0].Command != "make" || requests[0].Files["main.c"] == "" {
+		t.Errorf("unexpected request: command=%q files=%v", requests[0].Command, requests[0].Files)
+	}
+
+	// The server is free again once the result arrived
+	if server := c.findAvailableServer(requests[0]); server == nil {
+		t.Error("server still busy after the build finished")
+	}
+}
+
+func TestFakeServerPreflightRejection(t *testing.T) {
+	server := NewFakeServer("a", 1)
+	server.Preflight = func(check protocol.PreflightCheck) (bool, string) {
+		return false, "disk full"
+	}
+	c := newFakeFarm(t, server)
+
+	_, err := fakeBuild(t, c, BuildOptions{})
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the pre-flight rejection, got %v", err)
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("rejected build was sent anyway: %d requests", len(requests))
+	}
+}
+
+func TestFakeServerDisconnect(t *testing.T) {
+	server := NewFakeServer("a", 1)
+	server.Script = FakeSequence(FakeOutcome{Disconnect: true}, FakeOutcome{Response: protocol.BuildResponse{Success: true}})
+	c := newFakeFarm(t, server)
+
+	// A dropped connection fails the build right away instead of at the build timeout
+	start := time.Now()
+	response, err := fakeBuild(t, c, BuildOptions{})
+	if err != nil {
+		t.Fatalf("expected a failed build, got error %v", err)
+	}
+	if response.Success || !strings.Contains(response.Error, "disconnected") {
+		t.Fatalf("unexpected response: success=%v error=%q", response.Success, response.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("dropped build took %v to fail", elapsed)
+	}
+	if c.findServerByAddress(server.Addr()) != nil {
+		t.Error("disconnected server is still listed")
+	}
+
+	// Once the server is back, the retried build runs there
+	if err := server.Connect(c); err != nil {
+		t.Fatal(err)
+	}
+	response, err = fakeBuild(t, c, BuildOptions{})
+	if err != nil || !response.Success {
+		t.Fatalf("retried build failed: %v", err)
+	}
+	if n := len(server.Requests()); n != 2 {
+		t.Errorf("server received %d builds, want 2", n)
+	}
+}
+
+func TestFakeServerTimeout(t *testing.T) {
+	server := NewFakeServer("a", 1)
+	server.Script = FakeSequence(FakeOutcome{Silent: true}, FakeOutcome{Response: protocol.BuildResponse{Success: true}})
+	c := newFakeFarm(t, server)
+	c.config.Client.Timeouts.Build = 200 * time.Millisecond
+
+	_, err := fakeBuild(t, c, BuildOptions{})
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected a build timeout, got %v", err)
+	}
+
+	// The timed-out build is cancelled, which frees the server for the next build
+	deadline := time.Now().Add(5 * time.Second)
+	for c.findAvailableServer(protocol.BuildRequest{Environment: "test"}) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("server still busy after the timed-out build was cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	response, err := fakeBuild(t, c, BuildOptions{})
+	if err != nil || !response.Success {
+		t.Fatalf("build after the timeout failed: %v", err)
+	}
+}
+
+func TestFakeServerChunkedUpload(t *testing.T) {
+	server := NewFakeServer("a", 1)
+	c := newFakeFarm(t, server)
+	c.config.Client.Transfer.ChunkThreshold = 1024
+	c.config.Client.Transfer.ChunkSize = 256
+	c.config.Client.Transfer.Streams = 2
+
+	files := make(map[string]string)
+	for i := 0; i < 8; i++ {
+		files[fmt.Sprintf("src/file%d.c", i)] = strings.Repeat(fmt.Sprintf("int value%d = %d;\n", i, i), 20)
+	}
+	response, err := fakeBuild(t, c, BuildOptions{Files: files})
+	if err != nil || !response.Success {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	if n := server.Chunks(); n < 2 {
+		t.Errorf("project was sent in %d chunks, want several", n)
+	}
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("server received %d builds, want 1", len(requests))
+	}
+	if !reflect.DeepEqual(requests[0].Files, files) {
+		t.Errorf("reassembled %d files, want %d unchanged", len(requests[0].Files), len(files))
+	}
+}
+
+func TestFakeServerSpeculativePreflight(t *testing.T) {
+	primary := NewFakeServer("a", 1)
+	backup := NewFakeServer("b", 1)
+	c := newFakeFarm(t, primary, backup)
+
+	// The primary is checked first; whichever server becomes the backup refuses the copy
+	var checks int32
+	for _, server := range []*FakeServer{primary, backup} {
+		server.Preflight = func(check protocol.PreflightCheck) (bool, string) {
+			if atomic.AddInt32(&checks, 1) > 1 {
+				return false, "busy"
+			}
+			return true, ""
+		}
+	}
+
+	response, err := fakeBuild(t, c, BuildOptions{Speculative: true})
+	if err != nil || !response.Success {
+		t.Fatalf("build failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&checks); n != 2 {
+		t.Fatalf("%d pre-flight checks, want one per copy", n)
+	}
+	for _, server := range []*FakeServer{primary, backup} {
+		for _, request := range server.Requests() {
+			if strings.HasSuffix(request.ID, "-speculative") {
+				t.Errorf("refused speculative copy was sent to %s", server.Info.ID)
+			}
+		}
+	}
+	if c.findAvailableServer(protocol.BuildRequest{Environment: "test"}) == nil {
+		t.Error("refused backup server was not released")
+	}
+}
+
+func TestFakeServerProjectAffinity(t *testing.T) {
+	servers := []*FakeServer{NewFakeServer("a", 1), NewFakeServer("b", 1), NewFakeServer("c", 1)}
+	c := newFakeFarm(t, servers...)
+
+	var first string
+	for i := 0; i < 5; i++ {
+		if _, err := fakeBuild(t, c, BuildOptions{Project: "app"}); err != nil {
+			t.Fatalf("build %d failed: %v", i+1, err)
+		}
+		for _, server := range servers {
+			if len(server.Requests()) > 0 && first == "" {
+				first = server.Info.ID
+			}
+		}
+	}
+
+	for _, server := range servers {
+		count := len(server.Requests())
+		if server.Info.ID == first && count != 5 {
+			t.Errorf("%s built %d of 5 builds of the project, want all", first, count)
+		}
+		if server.Info.ID != first && count != 0 {
+			t.Errorf("%s built %d builds of a project that has another server", server.Info.ID, count)
+		}
+	}
+}
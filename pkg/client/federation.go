@@ -0,0 +1,242 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// Federation defaults
+const (
+	defaultFederationTimeout = 5 * time.Second
+	federationHistoryLimit   = 25 // Recent builds shown per site
+)
+
+// FederatedSite is the state of one client coordinator
+type FederatedSite struct {
+	Name    string                               `json:"name"`
+	URL     string                               `json:"url,omitempty"` // Empty for this client
+	Local   bool                                 `json:"local"`
+	Error   string                               `json:"error,omitempty"` // Why the peer could not be queried
+	Servers map[string]protocol.ServerStatusInfo `json:"servers"`
+	Running []RunningBuild                       `json:"running"`
+	History []BuildRecord                        `json:"history"` // Newest first
+}
+
+// peerClient returns the HTTP client used to query peers
+func peerClient(c config.FederationConfig) *http.Client {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultFederationTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// peerGet decodes the JSON response of a peer API path
+func peerGet(client *http.Client, p config.FederationPeer, path string, target interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, strings.TrimRight(p.URL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if p.APIKey != "" {
+		request.Header.Set(APIKeyHeader, p.APIKey)
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// fetchSite queries the servers, running builds and history of a peer
+func fetchSite(client *http.Client, p config.FederationPeer) FederatedSite {
+	site := FederatedSite{Name: p.Name, URL: p.URL}
+	err := peerGet(client, p, "/api/v1/servers", &site.Servers)
+	if err == nil {
+		err = peerGet(client, p, "/api/v1/builds/running", &site.Running)
+	}
+	if err == nil {
+		err = peerGet(client, p, "/api/v1/builds", &site.History)
+	}
+	if err != nil {
+		logging.Debugf("Federation peer %s unavailable: %v", p.Name, err)
+		return FederatedSite{Name: p.Name, URL: p.URL, Error: err.Error()}
+	}
+	if len(site.History) > federationHistoryLimit {
+		site.History = site.History[:federationHistoryLimit]
+	}
+	return site
+}
+
+// localSite returns the state of this client
+func (ws *WebServer) localSite() FederatedSite {
+	history := ws.client.history.List()
+	if len(history) > federationHistoryLimit {
+		history = history[:federationHistoryLimit]
+	}
+	return FederatedSite{
+		Name:    ws.config.Federation.SiteName(ws.config.Client.ID),
+		Local:   true,
+		Servers: ws.client.GetServerStatus(),
+		Running: ws.client.RunningBuilds(),
+		History: history,
+	}
+}
+
+// collectFederation queries every peer in parallel; unreachable peers are reported with their error
+func (ws *WebServer) collectFederation() []FederatedSite {
+	cfg := ws.config.Federation
+	client := peerClient(cfg)
+	sites := make([]FederatedSite, len(cfg.Peers)+1)
+	sites[0] = ws.localSite()
+
+	var wg sync.WaitGroup
+	for i, peer := range cfg.Peers {
+		wg.Add(1)
+		go func(i int, peer config.FederationPeer) {
+			defer wg.Done()
+			sites[i+1] = fetchSite(client, peer)
+		}(i, peer)
+	}
+	wg.Wait()
+	return sites
+}
+
+// handleFederationAPI returns the servers, running builds and history of this client and its peers
+func (ws *WebServer) handleFederationAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"sites": ws.collectFederation()})
+}
+
+// handleFederationPage serves the combined view of all sites
+func (ws *WebServer) handleFederationPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>BoltBuild - Federation</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: 'Inter', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            background: #031C26;
+            color: #A4FFF0;
+            min-height: 100vh;
+            padding: 20px;
+        }
+        .container { max-width: 1400px; margin: 0 auto; }
+        .header { display: flex; justify-content: space-between; align-items: center; padding: 20px 0 30px; }
+        .header h1 { font-size: 2rem; letter-spacing: -1px; }
+        .header a { color: #A4FFF0; }
+        .card {
+            background: rgba(164, 255, 240, 0.05);
+            border: 1px solid rgba(164, 255, 240, 0.2);
+            border-radius: 12px;
+            padding: 20px;
+            margin-bottom: 24px;
+        }
+        .card h2 { font-size: 1.1rem; margin-bottom: 15px; }
+        table { width: 100%; border-collapse: collapse; font-size: 0.9rem; }
+        th, td { text-align: left; padding: 8px; border-bottom: 1px solid rgba(164, 255, 240, 0.1); }
+        th { opacity: 0.7; font-weight: 600; }
+        .ok { color: #4ade80; }
+        .error { color: #ff6b6b; }
+        .empty { opacity: 0.7; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🌐 Federation</h1>
+            <div><a href="./">← Dashboard</a></div>
+        </div>
+
+        <div class="card"><h2>Sites</h2><table id="sites"></table></div>
+        <div class="card"><h2>Servers</h2><table id="servers"></table></div>
+        <div class="card"><h2>Running builds</h2><table id="running"></table></div>
+        <div class="card"><h2>Recent builds</h2><table id="history"></table></div>
+    </div>
+
+    <script>
+        function escapeHtml(text) {
+            const div = document.createElement('div');
+            div.textContent = text == null ? '' : String(text);
+            return div.innerHTML;
+        }
+
+        function formatDuration(nanoseconds) {
+            const ms = nanoseconds / 1000000;
+            if (ms < 1000) return Math.round(ms) + 'ms';
+            const seconds = ms / 1000;
+            if (seconds < 60) return seconds.toFixed(1) + 's';
+            return Math.floor(seconds / 60) + 'm ' + Math.round(seconds % 60) + 's';
+        }
+
+        function fillTable(id, header, rows, empty) {
+            const table = document.getElementById(id);
+            if (rows.length === 0) {
+                table.innerHTML = '<tr><td class="empty">' + empty + '</td></tr>';
+                return;
+            }
+            table.innerHTML = '<tr>' + header.map(title => '<th>' + title + '</th>').join('') + '</tr>' +
+                rows.map(cells => '<tr>' + cells.map(cell => '<td>' + cell + '</td>').join('') + '</tr>').join('');
+        }
+
+        function loadFederation() {
+            fetch('api/v1/federation')
+                .then(response => response.json())
+                .then(data => {
+                    const servers = [], running = [], history = [];
+                    const sites = data.sites.map(site => {
+                        const siteServers = Object.values(site.servers || {});
+                        const siteName = escapeHtml(site.name) + (site.local ? ' (this client)' : '');
+                        siteServers.forEach(server => servers.push([
+                            siteName, escapeHtml(server.id), escapeHtml(server.address + ':' + server.port),
+                            server.capacity, server.available ? '<span class="ok">available</span>' : 'busy',
+                            Math.round((server.load.cpu_load || 0) * 100) + '%', server.load.active_builds || 0, server.load.queued_builds || 0
+                        ]));
+                        (site.running || []).forEach(build => running.push([
+                            siteName, escapeHtml(build.id), escapeHtml(build.environment), escapeHtml(build.server_id),
+                            formatDuration((Date.now() - new Date(build.started_at).getTime()) * 1000000)
+                        ]));
+                        (site.history || []).forEach(build => history.push({ time: new Date(build.submitted_at), cells: [
+                            siteName, escapeHtml(build.id), escapeHtml(build.environment), escapeHtml(build.server_id),
+                            build.success ? '<span class="ok">✅ succeeded</span>' : '<span class="error">❌ failed</span>',
+                            formatDuration(build.duration), new Date(build.submitted_at).toLocaleString()
+                        ]}));
+                        const status = site.error ? '<span class="error">' + escapeHtml(site.error) + '</span>' : '<span class="ok">online</span>';
+                        const link = site.url ? '<a href="' + escapeHtml(site.url) + '" style="color: #A4FFF0;">' + escapeHtml(site.url) + '</a>' : '-';
+                        const queued = siteServers.reduce((sum, server) => sum + (server.load.queued_builds || 0), 0);
+                        return [siteName, link, status, siteServers.length, siteServers.filter(server => server.available).length, (site.running || []).length, queued];
+                    });
+                    history.sort((a, b) => b.time - a.time);
+
+                    fillTable('sites', ['Site', 'URL', 'Status', 'Servers', 'Available', 'Running', 'Queued'], sites, 'No sites');
+                    fillTable('servers', ['Site', 'Server', 'Address', 'Capacity', 'Status', 'CPU load', 'Active', 'Queued'], servers, 'No servers connected');
+                    fillTable('running', ['Site', 'Build', 'Environment', 'Server', 'Running for'], running, 'No builds running');
+                    fillTable('history', ['Site', 'Build', 'Environment', 'Server', 'Result', 'Duration', 'Submitted'], history.map(entry => entry.cells), 'No builds yet');
+                })
+                .catch(error => {
+                    console.error('Error loading federation:', error);
+                });
+        }
+
+        loadFederation();
+        setInterval(loadFederation, 5000);
+    </script>
+</body>
+</html>`))
+}
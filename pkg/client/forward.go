@@ -0,0 +1,225 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// forwardedByHeader names the federation site that forwarded a build, so it is not forwarded again
+const forwardedByHeader = "X-Boltbuild-Forwarded-By"
+
+// canForward reports whether a build may go to a peer when every local server is busy.
+// Builds forwarded here and builds of a monorepo subpath (resolved from local configuration)
+// always stay local.
+func (c *Coordinator) canForward(build *preparedBuild, opts BuildOptions) bool {
+	if opts.ForwardedBy != "" || build.subpath != "" {
+		return false
+	}
+	for _, peer := range c.config.Federation.Peers {
+		if peer.Forward {
+			return true
+		}
+	}
+	return false
+}
+
+// idleForwardPeer returns the first trusted peer reporting an available server
+func (c *Coordinator) idleForwardPeer() (config.FederationPeer, error) {
+	cfg := c.config.Federation
+	client := peerClient(cfg)
+	for _, peer := range cfg.Peers {
+		if !peer.Forward {
+			continue
+		}
+		var servers map[string]protocol.ServerStatusInfo
+		if err := peerGet(client, peer, "/api/v1/servers", &servers); err != nil {
+			logging.Debugf("Federation peer %s unavailable for forwarding: %v", peer.Name, err)
+			continue
+		}
+		for _, server := range servers {
+			if server.Available {
+				return peer, nil
+			}
+		}
+	}
+	return config.FederationPeer{}, fmt.Errorf("%w and no federation peer has an idle server", protocol.ErrNoServers)
+}
+
+// forwardedInfo describes a forwarded build for status queries
+func forwardedInfo(build *preparedBuild, peer config.FederationPeer) RunningBuild {
+	return RunningBuild{
+		ID:          build.request.ID,
+		Environment: build.request.Environment,
+		Project:     build.project,
+		ServerID:    "peer:" + peer.Name,
+		ServerAddr:  peer.URL,
+		StartedAt:   time.Now(),
+	}
+}
+
+// forwardToIdlePeer forwards a build that found every local server busy to an idle peer
+func (c *Coordinator) forwardToIdlePeer(ctx context.Context, build *preparedBuild, opts BuildOptions) (*protocol.BuildResponse, error) {
+	peer, err := c.idleForwardPeer()
+	if err != nil {
+		c.quotas.Release(build.request.ID)
+		return nil, err
+	}
+	return c.forwardBuild(ctx, build, peer, opts)
+}
+
+// forwardBuild runs a build on a trusted peer client with an idle server and completes it
+// locally as if one of this client's servers had run it: artifacts are saved, hooks run and
+// the build is recorded in the history with the peer as its server
+func (c *Coordinator) forwardBuild(ctx context.Context, build *preparedBuild, peer config.FederationPeer, opts BuildOptions) (response *protocol.BuildResponse, err error) {
+	request := build.request
+	info := forwardedInfo(build, peer)
+	c.trackBuild(info, nil)
+	build.announce()
+	defer func() {
+		c.finishBuild(request.ID, response, err)
+		c.quotas.Finish(request.ID)
+	}()
+
+	record := &BuildRecord{
+		ID:          request.ID,
+		Environment: request.Environment,
+		Project:     build.project,
+		Parameters:  build.parameters,
+		ProjectDir:  build.projectDir,
+		Subpath:     build.subpath,
+		User:        build.user,
+		Teams:       build.teams,
+		ServerID:    info.ServerID,
+		ServerAddr:  info.ServerAddr,
+		SubmittedAt: build.createdAt,
+		Command:     build.overrides.Command,
+		OutputPaths: build.overrides.OutputPaths,
+		ReplayOf:    build.replayOf,
+	}
+	event := HookEvent{
+		BuildID:     request.ID,
+		Environment: request.Environment,
+		Project:     build.project,
+		ProjectDir:  build.workdir,
+		ServerID:    info.ServerID,
+		ServerAddr:  info.ServerAddr,
+	}
+	c.snapshotBuild(build, record)
+
+	logging.Infof("All servers busy, forwarding build %s to federation peer %s", request.ID, peer.Name)
+	record.QueueWait = time.Since(build.createdAt)
+	c.bus.Publish(buildEvent(BusBuildDispatched, event))
+	event.Event = config.HookOnDispatch
+	if err := c.runHooks(event, build.env); err != nil {
+		logging.Infof("Warning: %v", err)
+	}
+
+	response, err = c.submitToPeer(ctx, peer, request, build.parameters, opts)
+	if err != nil {
+		err = fmt.Errorf("build forwarded to %s failed: %v", peer.Name, err)
+		record.Error = err.Error()
+		record.Duration = time.Since(record.SubmittedAt)
+		c.bus.Publish(finishedEvent(record, ""))
+
+		event.Event = config.HookOnFailure
+		event.Error = record.Error
+		event.Duration = record.Duration
+		if err := c.runHooks(event, build.env); err != nil {
+			logging.Infof("Warning: %v", err)
+		}
+		return nil, err
+	}
+
+	// The caller knows the build by this client's ID
+	response.ID = request.ID
+	c.usage.AddUpload(request.ID, requestFileBytes(request))
+	return c.completeBuild(ctx, build, record, event, response), nil
+}
+
+// submitToPeer uploads the files of a build to a peer's upload API and waits for the result
+func (c *Coordinator) submitToPeer(ctx context.Context, p config.FederationPeer, request protocol.BuildRequest, parameters map[string]string, opts BuildOptions) (*protocol.BuildResponse, error) {
+	query := url.Values{}
+	query.Set("environment", request.Environment)
+	if len(parameters) > 0 {
+		data, _ := json.Marshal(parameters)
+		query.Set("parameters", string(data))
+	}
+	if opts.Command != "" {
+		query.Set("command", opts.Command)
+	}
+	if opts.OutputPaths != nil {
+		query.Set("outputs", strings.Join(opts.OutputPaths, ","))
+	}
+
+	// Stream the archive while it is being created
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeFilesTarGz(writer, request.Files))
+	}()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.URL, "/")+"/api/v1/build/upload?"+query.Encode(), reader)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/gzip")
+	httpRequest.Header.Set(forwardedByHeader, c.config.Federation.SiteName(c.config.Client.ID))
+	if p.APIKey != "" {
+		httpRequest.Header.Set(APIKeyHeader, p.APIKey)
+	}
+
+	client := &http.Client{Timeout: c.config.Client.Timeouts.Build}
+	resp, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rejected: %s", strings.TrimSpace(string(message)))
+	}
+	var response protocol.BuildResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	return &response, nil
+}
+
+// writeFilesTarGz archives build files held in memory
+func writeFilesTarGz(w io.Writer, files map[string]string) error {
+	gzipWriter := gzip.NewWriter(w)
+	archive := tar.NewWriter(gzipWriter)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(archive, files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,352 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// BuildRecord describes a finished build in the client history
+type BuildRecord struct {
+	ID            string                `json:"id"`
+	Environment   string                `json:"environment"`
+	Project       string                `json:"project,omitempty"`
+	Parameters    map[string]string     `json:"parameters,omitempty"`
+	ProjectDir    string                `json:"project_dir,omitempty"` // Local directory the files were read from ("" = uploaded)
+	Subpath       string                `json:"subpath,omitempty"`
+	User          string                `json:"user,omitempty"`           // Who submitted the build, for quotas
+	Teams         []string              `json:"teams,omitempty"`          // Groups of the user
+	UploadedBytes int64                 `json:"uploaded_bytes,omitempty"` // Project files sent to the server (0 = reused from its cache)
+	ServerID      string                `json:"server_id"`
+	ServerAddr    string                `json:"server_addr"`
+	Success       bool                  `json:"success"`
+	Error         string                `json:"error,omitempty"`
+	SubmittedAt   time.Time             `json:"submitted_at"`
+	Duration      time.Duration         `json:"duration"`
+	QueueWait     time.Duration         `json:"queue_wait,omitempty"` // From submission until the server received the build
+	OutputFiles   []string              `json:"output_files,omitempty"`
+	Artifacts     []ArtifactInfo        `json:"artifacts,omitempty"`    // Size and hash of every output file
+	Provenance    *ArtifactInfo         `json:"provenance,omitempty"`   // Provenance/SBOM document attached by the server
+	DebugBundle   *ArtifactInfo         `json:"debug_bundle,omitempty"` // Triage archive of a failed build
+	Incremental   bool                  `json:"incremental,omitempty"`  // Built in a persistent workspace holding a previous build's state
+	Warnings      []string              `json:"warnings,omitempty"`
+	Command       string                `json:"command,omitempty"`      // Command override the build was submitted with
+	OutputPaths   []string              `json:"output_paths,omitempty"` // Output path override the build was submitted with
+	Snapshot      bool                  `json:"snapshot,omitempty"`     // The project files are archived for replays
+	ReplayOf      string                `json:"replay_of,omitempty"`    // Build this one replayed, also from an imported bundle
+	Steps         []protocol.StepResult `json:"steps,omitempty"`        // Outcome and duration of every step of multi-step builds, without their output
+}
+
+// BuildHistory keeps recent build records and their logs, optionally persisted to disk
+type BuildHistory struct {
+	records    []*BuildRecord // oldest first
+	logs       map[string]string
+	bundles    map[string][]byte // Debug bundles when not persisted
+	snapshots  map[string][]byte // Project file archives when not persisted
+	dir        string
+	maxEntries int
+	mux        sync.RWMutex
+}
+
+// NewBuildHistory creates a history store and loads previously persisted records
+func NewBuildHistory(dir string, maxEntries int) *BuildHistory {
+	h := &BuildHistory{
+		logs:       make(map[string]string),
+		bundles:    make(map[string][]byte),
+		snapshots:  make(map[string][]byte),
+		dir:        dir,
+		maxEntries: maxEntries,
+	}
+
+	if dir != "" {
+		if err := h.load(); err != nil {
+			logging.Infof("Failed to load build history from %s: %v", dir, err)
+		}
+	}
+
+	return h
+}
+
+// Add stores a build record together with its full log
+func (h *BuildHistory) Add(record *BuildRecord, log string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.records = append(h.records, record)
+
+	if h.dir != "" {
+		if err := os.WriteFile(h.logPath(record.ID), []byte(log), 0644); err != nil {
+			logging.Debugf("Warning: Failed to persist log for build %s: %v", record.ID, err)
+		}
+	} else {
+		h.logs[record.ID] = log
+	}
+
+	// Drop the oldest records beyond the configured limit
+	for h.maxEntries > 0 && len(h.records) > h.maxEntries {
+		oldest := h.records[0]
+		h.records = h.records[1:]
+		delete(h.logs, oldest.ID)
+		delete(h.bundles, oldest.ID)
+		delete(h.snapshots, oldest.ID)
+		if h.dir != "" {
+			os.Remove(h.logPath(oldest.ID))
+			os.Remove(h.bundlePath(oldest.ID))
+			os.Remove(h.snapshotPath(oldest.ID))
+		}
+	}
+
+	if err := h.save(); err != nil {
+		logging.Debugf("Warning: Failed to persist build history: %v", err)
+	}
+}
+
+// List returns all records, newest first
+func (h *BuildHistory) List() []BuildRecord {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	records := make([]BuildRecord, 0, len(h.records))
+	for i := len(h.records) - 1; i >= 0; i-- {
+		records = append(records, *h.records[i])
+	}
+	return records
+}
+
+// ListProject returns the records of a named project, newest first
+func (h *BuildHistory) ListProject(project string) []BuildRecord {
+	records := []BuildRecord{}
+	for _, record := range h.List() {
+		if record.Project == project {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// BuildEstimate is the expected duration of a build derived from earlier builds
+type BuildEstimate struct {
+	Duration time.Duration `json:"duration"`
+	Samples  int           `json:"samples"` // Number of builds the estimate is based on
+	Scope    string        `json:"scope"`   // "server" for the same environment and server, else "environment"
+}
+
+// estimateSamples is how many recent successful builds an estimate considers
+const estimateSamples = 10
+
+// Estimate returns the median duration of recent successful builds of an environment,
+// preferring builds on the same server
+func (h *BuildHistory) Estimate(environment, serverAddr string) (BuildEstimate, bool) {
+	var sameServer, sameEnvironment []time.Duration
+	for _, record := range h.List() {
+		if !record.Success || record.Environment != environment {
+			continue
+		}
+		if record.ServerAddr == serverAddr && len(sameServer) < estimateSamples {
+			sameServer = append(sameServer, record.Duration)
+		}
+		if len(sameEnvironment) < estimateSamples {
+			sameEnvironment = append(sameEnvironment, record.Duration)
+		}
+	}
+
+	switch {
+	case len(sameServer) > 0:
+		return BuildEstimate{Duration: medianDuration(sameServer), Samples: len(sameServer), Scope: "server"}, true
+	case len(sameEnvironment) > 0:
+		return BuildEstimate{Duration: medianDuration(sameEnvironment), Samples: len(sameEnvironment), Scope: "environment"}, true
+	}
+	return BuildEstimate{}, false
+}
+
+// medianDuration returns the median of a non-empty list of durations
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// Get returns a single record by build ID
+func (h *BuildHistory) Get(id string) (BuildRecord, bool) {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	for _, record := range h.records {
+		if record.ID == id {
+			return *record, true
+		}
+	}
+	return BuildRecord{}, false
+}
+
+// Log returns the stored log of a build
+func (h *BuildHistory) Log(id string) (string, bool) {
+	if _, exists := h.Get(id); !exists {
+		return "", false
+	}
+
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	if h.dir == "" {
+		log, exists := h.logs[id]
+		return log, exists
+	}
+
+	data, err := os.ReadFile(h.logPath(id))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// SaveBundle stores the debug bundle of a build, before or after its record is added
+func (h *BuildHistory) SaveBundle(id string, bundle []byte) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.dir == "" {
+		h.bundles[id] = bundle
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.bundlePath(id)), 0755); err != nil {
+		logging.Debugf("Warning: Failed to persist debug bundle for build %s: %v", id, err)
+		return
+	}
+	if err := os.WriteFile(h.bundlePath(id), bundle, 0644); err != nil {
+		logging.Debugf("Warning: Failed to persist debug bundle for build %s: %v", id, err)
+	}
+}
+
+// Bundle returns the stored debug bundle of a build
+func (h *BuildHistory) Bundle(id string) ([]byte, bool) {
+	if _, exists := h.Get(id); !exists {
+		return nil, false
+	}
+
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	if h.dir == "" {
+		bundle, exists := h.bundles[id]
+		return bundle, exists
+	}
+
+	data, err := os.ReadFile(h.bundlePath(id))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// SaveSnapshot archives the project files of a build, before its record is added
+func (h *BuildHistory) SaveSnapshot(id string, files map[string]string) error {
+	var archive bytes.Buffer
+	if err := writeFilesTarGz(&archive, files); err != nil {
+		return err
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.dir == "" {
+		h.snapshots[id] = archive.Bytes()
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.snapshotPath(id)), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.snapshotPath(id), archive.Bytes(), 0644)
+}
+
+// Snapshot returns the archived project files of a build
+func (h *BuildHistory) Snapshot(id string) (map[string]string, error) {
+	h.mux.RLock()
+	archive, exists := h.snapshots[id]
+	h.mux.RUnlock()
+
+	if h.dir != "" {
+		data, err := os.ReadFile(h.snapshotPath(id))
+		if err != nil {
+			return nil, err
+		}
+		archive, exists = data, true
+	}
+	if !exists {
+		return nil, fmt.Errorf("no project files archived for build %s", id)
+	}
+	return readTarFiles(bytes.NewReader(archive))
+}
+
+// snapshotPath returns the file used to persist the project files of a build
+func (h *BuildHistory) snapshotPath(id string) string {
+	return filepath.Join(h.dir, "snapshots", id+".tar.gz")
+}
+
+// bundlePath returns the file used to persist a debug bundle
+func (h *BuildHistory) bundlePath(id string) string {
+	return filepath.Join(h.dir, "bundles", id+".tar.gz")
+}
+
+// logPath returns the file used to persist a build log
+func (h *BuildHistory) logPath(id string) string {
+	return filepath.Join(h.dir, "logs", id+".log")
+}
+
+// indexPath returns the file holding the persisted records
+func (h *BuildHistory) indexPath() string {
+	return filepath.Join(h.dir, "history.json")
+}
+
+// load reads persisted records from the history directory
+func (h *BuildHistory) load() error {
+	if err := os.MkdirAll(filepath.Join(h.dir, "logs"), 0755); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(h.indexPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &h.records); err != nil {
+		return fmt.Errorf("corrupt history index: %v", err)
+	}
+
+	logging.Debugf("Loaded %d build records from %s", len(h.records), h.dir)
+	return nil
+}
+
+// save writes the records to the history directory (caller holds the lock)
+func (h *BuildHistory) save() error {
+	if h.dir == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(h.records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so a crash never leaves a truncated index behind
+	tmpPath := h.indexPath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, h.indexPath())
+}
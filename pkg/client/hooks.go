@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// defaultHookTimeout bounds hooks that do not configure their own timeout
+const defaultHookTimeout = 30 * time.Second
+
+// HookEvent is the JSON document handed to every hook
+type HookEvent struct {
+	Event       string        `json:"event"`
+	BuildID     string        `json:"build_id"`
+	Environment string        `json:"environment"`
+	Project     string        `json:"project,omitempty"`
+	ProjectDir  string        `json:"project_dir"`
+	ServerID    string        `json:"server_id,omitempty"`
+	ServerAddr  string        `json:"server_addr,omitempty"`
+	Success     bool          `json:"success"`
+	Error       string        `json:"error,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	OutputFiles []string      `json:"output_files,omitempty"`
+	Timestamp   time.Time     `json:"timestamp"`
+}
+
+// runHooks runs the global and environment hooks for an event, stopping at the first failure
+func (c *Coordinator) runHooks(event HookEvent, env *config.BuildEnvironment) error {
+	event.Timestamp = time.Now()
+
+	hooks := append([]config.HookConfig{}, c.config.Hooks.ForEvent(event.Event)...)
+	if env != nil {
+		hooks = append(hooks, env.Hooks.ForEvent(event.Event)...)
+		if event.Event == config.HookOnSuccess && env.PostBuildScript != "" && event.ProjectDir != "" {
+			hooks = append(hooks, postBuildHook(env, event.ProjectDir, c.config.Client.Timeouts.Build))
+		}
+	}
+
+	for _, hook := range hooks {
+		if err := runHook(hook, event); err != nil {
+			return fmt.Errorf("%s hook failed: %v", event.Event, err)
+		}
+	}
+	return nil
+}
+
+// postBuildHook maps an environment's post_build_script onto an on_success command hook. The
+// script is resolved against the project directory and started through the interpreter its
+// extension calls for; it sees the environment's env_vars and BOLTBUILD_OUTPUT_DIR.
+func postBuildHook(env *config.BuildEnvironment, projectDir string, timeout time.Duration) config.HookConfig {
+	script := env.PostBuildScript
+	if !filepath.IsAbs(script) {
+		script = filepath.Join(projectDir, script)
+	}
+
+	command := shellArg(script)
+	switch strings.ToLower(filepath.Ext(script)) {
+	case ".bat", ".cmd":
+		command = "cmd /C " + command
+	case ".sh":
+		command = "bash " + command
+	case ".ps1":
+		command = "powershell -ExecutionPolicy Bypass -File " + command
+	case ".py":
+		command = "python " + command
+	}
+
+	vars := map[string]string{"BOLTBUILD_OUTPUT_DIR": filepath.Join(projectDir, "output")}
+	for key, value := range env.EnvVars {
+		vars[key] = value
+	}
+	return config.HookConfig{Command: command, Timeout: timeout, Vars: vars}
+}
+
+// runHook executes a single command or webhook hook
+func runHook(hook config.HookConfig, event HookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if hook.URL != "" {
+		logging.Debugf("Calling %s webhook %s for build %s", event.Event, hook.URL, event.BuildID)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s returned %s", hook.URL, resp.Status)
+		}
+		return nil
+	}
+
+	logging.Debugf("Running %s hook for build %s: %s", event.Event, event.BuildID, hook.Command)
+
+	cmd := buildutil.ShellCommand(ctx, hook.Command)
+	cmd.Dir = event.ProjectDir
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"BOLTBUILD_EVENT="+event.Event,
+		"BOLTBUILD_BUILD_ID="+event.BuildID,
+		"BOLTBUILD_ENVIRONMENT="+event.Environment,
+		"BOLTBUILD_PROJECT="+event.Project,
+		"BOLTBUILD_PROJECT_DIR="+event.ProjectDir,
+		"BOLTBUILD_SERVER_ID="+event.ServerID,
+		"BOLTBUILD_SERVER_ADDR="+event.ServerAddr,
+		"BOLTBUILD_SUCCESS="+strconv.FormatBool(event.Success),
+	)
+	for key, value := range hook.Vars {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\nOutput: %s", err, string(output))
+	}
+
+	logging.Debugf("%s hook completed. Output: %s", event.Event, string(output))
+	return nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/protocol"
+	"github.com/gorilla/mux"
+)
+
+// inspectTimeout bounds the wait for a server's listing or file
+const inspectTimeout = 10 * time.Second
+
+// inspectWorkspace asks the server that ran a failed build for a path in its workspace
+func (c *Coordinator) inspectWorkspace(buildID, workspacePath string) (*protocol.WorkspaceBrowse, error) {
+	record, exists := c.history.Get(buildID)
+	if !exists {
+		return nil, fmt.Errorf("build %s not found", buildID)
+	}
+	var server *ServerConnection
+	c.serversMux.RLock()
+	for _, connection := range c.servers {
+		if connection.info.ID == record.ServerID {
+			server = connection
+		}
+	}
+	c.serversMux.RUnlock()
+	if server == nil {
+		return nil, fmt.Errorf("server %s is not connected", record.ServerID)
+	}
+
+	requestID := buildutil.GenerateID()
+	results := make(chan *protocol.WorkspaceBrowse, 1)
+	c.inspectMux.Lock()
+	c.inspections[requestID] = results
+	c.inspectMux.Unlock()
+	defer func() {
+		c.inspectMux.Lock()
+		delete(c.inspections, requestID)
+		c.inspectMux.Unlock()
+	}()
+
+	browse := protocol.WorkspaceBrowse{RequestID: requestID, Path: workspacePath}
+	if err := server.send(protocol.Message{Type: protocol.MessageBrowse, BuildID: buildID, Browse: &browse}); err != nil {
+		return nil, err
+	}
+	select {
+	case result := <-results:
+		return result, nil
+	case <-time.After(inspectTimeout):
+		return nil, fmt.Errorf("server %s did not answer", record.ServerID)
+	}
+}
+
+// deliverListing hands a workspace listing to the inspection waiting for it
+func (c *Coordinator) deliverListing(result *protocol.WorkspaceBrowse) {
+	c.inspectMux.Lock()
+	defer c.inspectMux.Unlock()
+	if results, exists := c.inspections[result.RequestID]; exists {
+		select {
+		case results <- result:
+		default:
+		}
+	}
+}
+
+// handleBuildWorkspaceAPI browses the workspace a failed build left on its server
+func (ws *WebServer) handleBuildWorkspaceAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, exists := ws.client.history.Get(id); !exists {
+		http.Error(w, fmt.Sprintf("Build not found: %s", id), http.StatusNotFound)
+		return
+	}
+
+	result, err := ws.client.inspectWorkspace(id, r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if result.Error != "" {
+		http.Error(w, result.Error, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
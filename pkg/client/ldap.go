@@ -0,0 +1,120 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"boltbuild/pkg/config"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Default LDAP settings
+const (
+	defaultLDAPUserFilter     = "(uid=%s)"
+	defaultLDAPGroupAttribute = "memberOf"
+	ldapTimeout               = 10 * time.Second
+)
+
+// ldapProvider checks user names and passwords against the directory
+type ldapProvider struct {
+	config config.LDAPConfig
+}
+
+// connect opens a connection to the directory, upgraded to TLS with start_tls
+func (p *ldapProvider) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: p.config.InsecureSkipVerify, MinVersion: tls.VersionTLS12}
+	conn, err := ldap.DialURL(p.config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", p.config.URL, err)
+	}
+	conn.SetTimeout(ldapTimeout)
+	if p.config.StartTLS {
+		if host, _, err := net.SplitHostPort(strings.TrimPrefix(p.config.URL, "ldap://")); err == nil {
+			tlsConfig.ServerName = host
+		} else {
+			tlsConfig.ServerName = strings.TrimPrefix(p.config.URL, "ldap://")
+		}
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS with %s failed: %v", p.config.URL, err)
+		}
+	}
+	return conn, nil
+}
+
+// authenticate binds as the user and returns their identity with the groups of group_attribute
+func (p *ldapProvider) authenticate(username, password string) (Identity, error) {
+	// An empty password would be an anonymous bind, which directories accept
+	if username == "" || password == "" {
+		return Identity{}, fmt.Errorf("user name and password required")
+	}
+	conn, err := p.connect()
+	if err != nil {
+		return Identity{}, err
+	}
+	defer conn.Close()
+
+	groupAttribute := p.config.GroupAttribute
+	if groupAttribute == "" {
+		groupAttribute = defaultLDAPGroupAttribute
+	}
+	attributes := []string{"mail", groupAttribute}
+
+	var userDN string
+	var entry *ldap.Entry
+	if p.config.UserDNTemplate != "" {
+		userDN = strings.ReplaceAll(p.config.UserDNTemplate, "%s", ldap.EscapeDN(username))
+		if err := conn.Bind(userDN, password); err != nil {
+			return Identity{}, fmt.Errorf("invalid credentials for %s", username)
+		}
+		// UPN templates such as user@example.com name no entry to read, so groups may stay empty
+		if result, err := conn.Search(ldap.NewSearchRequest(userDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+			1, int(ldapTimeout.Seconds()), false, "(objectClass=*)", attributes, nil)); err == nil && len(result.Entries) == 1 {
+			entry = result.Entries[0]
+		}
+	} else {
+		if p.config.BindDN != "" {
+			if err := conn.Bind(p.config.BindDN, p.config.BindPassword); err != nil {
+				return Identity{}, fmt.Errorf("service account bind failed: %v", err)
+			}
+		}
+		filter := p.config.UserFilter
+		if filter == "" {
+			filter = defaultLDAPUserFilter
+		}
+		result, err := conn.Search(ldap.NewSearchRequest(p.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+			2, int(ldapTimeout.Seconds()), false, strings.ReplaceAll(filter, "%s", ldap.EscapeFilter(username)), attributes, nil))
+		if err != nil {
+			return Identity{}, fmt.Errorf("user search failed: %v", err)
+		}
+		if len(result.Entries) != 1 {
+			return Identity{}, fmt.Errorf("invalid credentials for %s", username)
+		}
+		entry = result.Entries[0]
+		userDN = entry.DN
+		if err := conn.Bind(userDN, password); err != nil {
+			return Identity{}, fmt.Errorf("invalid credentials for %s", username)
+		}
+	}
+
+	identity := Identity{User: username, Method: "ldap"}
+	if entry != nil {
+		identity.Email = entry.GetAttributeValue("mail")
+		for _, groupDN := range entry.GetAttributeValues(groupAttribute) {
+			identity.Groups = append(identity.Groups, ldapGroupName(groupDN))
+		}
+	}
+	return identity, nil
+}
+
+// ldapGroupName returns the common name of a group DN ("cn=release,ou=groups,..." -> "release")
+func ldapGroupName(groupDN string) string {
+	dn, err := ldap.ParseDN(groupDN)
+	if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+		return groupDN
+	}
+	return dn.RDNs[0].Attributes[0].Value
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+	"boltbuild/pkg/server"
+)
+
+// localServerID names this machine as the server of builds run by the local fallback
+const localServerID = "local"
+
+// localExecutor runs builds of the local fallback with the server's build pipeline; it is
+// created on the first fallback build, as detecting container runtimes and tools takes a while
+type localExecutor struct {
+	server *server.Server
+	once   sync.Once
+}
+
+// get returns the in-process server running local builds one at a time
+func (l *localExecutor) get(cfg *config.Config) *server.Server {
+	l.once.Do(func() {
+		l.server = server.New(cfg, server.WithID(localServerID), server.WithPort(0), server.WithCapacity(1))
+	})
+	return l.server
+}
+
+// canRunLocally reports whether a build that found no server may run on this machine: the
+// fallback is enabled, no particular server was asked for and no server is connected at all
+func (c *Coordinator) canRunLocally(serverAddr string) bool {
+	if !c.config.Client.LocalFallback || serverAddr != "" {
+		return false
+	}
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+	return len(c.servers) == 0
+}
+
+// localInfo describes a local build for status queries
+func localInfo(build *preparedBuild) RunningBuild {
+	return RunningBuild{
+		ID:          build.request.ID,
+		Environment: build.request.Environment,
+		Project:     build.project,
+		ServerID:    localServerID,
+		ServerAddr:  localServerID,
+		StartedAt:   time.Now(),
+	}
+}
+
+// runLocally executes a build in a temp workspace on this machine when no build server is
+// connected. The files, hooks, artifact verification and history go through the same steps as
+// for a remote server, so workflows keep working offline.
+func (c *Coordinator) runLocally(ctx context.Context, build *preparedBuild) (response *protocol.BuildResponse, err error) {
+	request := build.request
+	info := localInfo(build)
+	c.trackBuild(info, nil)
+	build.announce()
+	defer func() {
+		c.finishBuild(request.ID, response, err)
+		c.quotas.Finish(request.ID)
+	}()
+
+	record := &BuildRecord{
+		ID:          request.ID,
+		Environment: request.Environment,
+		Project:     build.project,
+		Parameters:  build.parameters,
+		ProjectDir:  build.projectDir,
+		Subpath:     build.subpath,
+		User:        build.user,
+		Teams:       build.teams,
+		ServerID:    localServerID,
+		ServerAddr:  localServerID,
+		SubmittedAt: build.createdAt,
+		Command:     build.overrides.Command,
+		OutputPaths: build.overrides.OutputPaths,
+		ReplayOf:    build.replayOf,
+	}
+	event := HookEvent{
+		BuildID:     request.ID,
+		Environment: request.Environment,
+		Project:     build.project,
+		ProjectDir:  build.workdir,
+		ServerID:    localServerID,
+		ServerAddr:  localServerID,
+	}
+	// Local builds can be cancelled like builds on a server
+	buildCtx, cancel := context.WithTimeout(ctx, c.config.Client.Timeouts.Build)
+	defer cancel()
+	c.trackCancel(request.ID, cancel)
+
+	started := func() {
+		logging.Infof("No build server connected, running build %s of environment %s locally", request.ID, request.Environment)
+		record.QueueWait = time.Since(build.createdAt)
+		c.snapshotBuild(build, record)
+		c.bus.Publish(buildEvent(BusBuildDispatched, event))
+		event.Event = config.HookOnDispatch
+		if err := c.runHooks(event, build.env); err != nil {
+			logging.Infof("Warning: %v", err)
+		}
+	}
+	request.Compression = buildutil.CompressionNone
+	result, err := c.local.get(c.config).RunBuild(buildCtx, request, started, func(chunk string) {
+		c.appendBuildLog(request.ID, chunk)
+	})
+	if err != nil {
+		err = fmt.Errorf("build cancelled: %v", err)
+		record.Error = err.Error()
+		record.Duration = time.Since(record.SubmittedAt)
+		c.bus.Publish(finishedEvent(record, ""))
+		return nil, err
+	}
+	if buildCtx.Err() == context.DeadlineExceeded {
+		result.Error = fmt.Sprintf("build timeout after %v", c.config.Client.Timeouts.Build)
+	}
+
+	return c.completeBuild(ctx, build, record, event, &result), nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// logLevelChange is the body of PUT /api/admin/loglevel
+type logLevelChange struct {
+	Level  string `json:"level"`            // "info" or "debug"
+	Server string `json:"server,omitempty"` // Address of a connected server, "all" for every server (default: this client)
+}
+
+// errServerNotConnected is a server address the client has no connection to
+var errServerNotConnected = errors.New("server is not connected")
+
+// SetServerLogLevel switches the log level of a connected server ("all" = every server) and
+// returns the IDs of the servers told
+func (c *Coordinator) SetServerLogLevel(serverAddr, level string) ([]string, error) {
+	if _, err := logging.ParseLevel(level); err != nil {
+		return nil, err
+	}
+
+	var servers []*ServerConnection
+	if serverAddr == "all" {
+		c.serversMux.RLock()
+		for _, server := range c.servers {
+			servers = append(servers, server)
+		}
+		c.serversMux.RUnlock()
+	} else if server := c.findServerByAddress(serverAddr); server != nil {
+		servers = append(servers, server)
+	} else {
+		return nil, fmt.Errorf("%s: %w", serverAddr, errServerNotConnected)
+	}
+
+	told := []string{}
+	for _, server := range servers {
+		if err := server.send(protocol.Message{Type: protocol.MessageLogLevel, Data: level}); err != nil {
+			addr := net.JoinHostPort(server.info.Address, strconv.Itoa(server.info.Port))
+			return told, fmt.Errorf("failed to reach server %s: %v", addr, err)
+		}
+		told = append(told, server.info.ID)
+	}
+	sort.Strings(told)
+	return told, nil
+}
+
+// handleLogLevelAPI returns the log level of the client
+func (ws *WebServer) handleLogLevelAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"level": logging.CurrentLevel()})
+}
+
+// handleSetLogLevelAPI switches the log level of the client or of its servers at runtime
+func (ws *WebServer) handleSetLogLevelAPI(w http.ResponseWriter, r *http.Request) {
+	var change logLevelChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := logging.ParseLevel(change.Level); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := map[string]interface{}{"level": change.Level}
+	if change.Server == "" {
+		logging.SetLevel(change.Level)
+	} else {
+		servers, err := ws.client.SetServerLogLevel(change.Server, change.Level)
+		if err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, errServerNotConnected) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		result["servers"] = servers
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Log page sizes for the dashboard log viewer
+const (
+	defaultLogPageLines = 1000
+	maxLogPageLines     = 10000
+)
+
+// LogLine is one line of a build log with its 1-based line number
+type LogLine struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+}
+
+// LogPage is a range of a build log, optionally only the lines matching a filter
+type LogPage struct {
+	BuildID string    `json:"build_id"`
+	Filter  string    `json:"filter,omitempty"`
+	Total   int       `json:"total"` // Lines of the log, or matching lines with a filter
+	Start   int       `json:"start"` // Index of the first returned line among Total
+	Lines   []LogLine `json:"lines"`
+}
+
+// ansiEscape matches terminal escape sequences such as the color codes compilers emit
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// pageLog returns limit lines of a log starting at start, or the last page when start is negative.
+// A filter keeps only lines containing it, ignoring case and color codes.
+func pageLog(buildID, log, filter string, start, limit int) LogPage {
+	if limit <= 0 {
+		limit = defaultLogPageLines
+	}
+	if limit > maxLogPageLines {
+		limit = maxLogPageLines
+	}
+
+	text := strings.TrimSuffix(log, "\n")
+	var lines []LogLine
+	if text != "" {
+		needle := strings.ToLower(filter)
+		for i, line := range strings.Split(text, "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if needle != "" && !strings.Contains(strings.ToLower(ansiEscape.ReplaceAllString(line, "")), needle) {
+				continue
+			}
+			lines = append(lines, LogLine{Number: i + 1, Text: line})
+		}
+	}
+
+	page := LogPage{BuildID: buildID, Filter: filter, Total: len(lines)}
+	if start < 0 {
+		start = len(lines) - limit
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > len(lines) {
+		start = len(lines)
+	}
+	end := start + limit
+	if end > len(lines) {
+		end = len(lines)
+	}
+	page.Start = start
+	page.Lines = append([]LogLine{}, lines[start:end]...)
+	return page
+}
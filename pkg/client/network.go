@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// Network probe settings
+const (
+	probePayloadSize = 1 << 20 // Bytes sent to measure the bandwidth towards a server
+	probeTimeout     = 10 * time.Second
+	probeInterval    = 5 * time.Minute // Idle servers are measured again after this long
+)
+
+// probe sends a message carrying payload bytes and waits for the server's echo
+func (sc *ServerConnection) probe(payload int) (time.Duration, error) {
+	id := buildutil.GenerateID()
+	start := time.Now()
+	if err := sc.send(protocol.Message{Type: protocol.MessagePing, BuildID: id, Data: strings.Repeat("0", payload)}); err != nil {
+		return 0, err
+	}
+
+	timeout := time.After(probeTimeout)
+	for {
+		select {
+		case pong := <-sc.pongs:
+			if pong == id {
+				return time.Since(start), nil
+			}
+		case <-timeout:
+			return 0, fmt.Errorf("no probe response within %v", probeTimeout)
+		}
+	}
+}
+
+// measureNetwork probes latency and upload bandwidth of an idle server
+func (sc *ServerConnection) measureNetwork() error {
+	latency, err := sc.probe(0)
+	if err != nil {
+		return err
+	}
+	elapsed, err := sc.probe(probePayloadSize)
+	if err != nil {
+		return err
+	}
+
+	stats := protocol.NetworkStats{Latency: latency, MeasuredAt: time.Now()}
+	if transfer := elapsed - latency; transfer > 0 {
+		stats.Bandwidth = float64(probePayloadSize) / transfer.Seconds()
+	}
+
+	sc.mux.Lock()
+	sc.network = stats
+	sc.mux.Unlock()
+
+	logging.Debugf("Network to server %s: latency %v, bandwidth %.1f MB/s", sc.info.ID, latency, stats.Bandwidth/float64(protocol.MB))
+	return nil
+}
+
+// probeNetwork measures a server after connecting and again periodically while it is idle
+func (sc *ServerConnection) probeNetwork(done <-chan struct{}) {
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+
+	for {
+		sc.mux.Lock()
+		busy := sc.busy
+		sc.mux.Unlock()
+
+		// Probes would queue behind build traffic and measure nothing useful
+		if !busy {
+			if err := sc.measureNetwork(); err != nil {
+				logging.Debugf("Network probe of server %s failed: %v", sc.info.ID, err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// requestSize is the number of bytes a build request transfers
+func requestSize(request protocol.BuildRequest) int64 {
+	var size int64
+	for name, content := range request.Files {
+		size += int64(len(name) + len(content))
+	}
+	return size
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// Notification types shown in the dashboard notification center
+const (
+	NotificationBuildFinished   = "build_finished"
+	NotificationServerJoined    = "server_joined"
+	NotificationServerLeft      = "server_left"
+	NotificationVersionMismatch = "version_mismatch"
+)
+
+// Notification severity levels
+const (
+	LevelInfo    = "info"
+	LevelSuccess = "success"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// maxNotifications is the number of past notifications kept for dashboards that connect later
+const maxNotifications = 100
+
+// notificationKeepAlive is how often an idle notification stream sends a comment to stay open
+const notificationKeepAlive = 30 * time.Second
+
+// Notification is something that happened on the client that users should see
+type Notification struct {
+	ID       int64     `json:"id"` // Increasing, so dashboards can tell which ones they have seen
+	Type     string    `json:"type"`
+	Level    string    `json:"level"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message,omitempty"`
+	BuildID  string    `json:"build_id,omitempty"`
+	ServerID string    `json:"server_id,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// NotificationCenter keeps recent notifications and delivers new ones to subscribers
+type NotificationCenter struct {
+	recent      []Notification // oldest first
+	nextID      int64
+	subscribers map[chan Notification]bool
+	mux         sync.Mutex
+}
+
+// NewNotificationCenter creates an empty notification center
+func NewNotificationCenter() *NotificationCenter {
+	return &NotificationCenter{
+		nextID:      1,
+		subscribers: make(map[chan Notification]bool),
+	}
+}
+
+// Publish records a notification and sends it to all subscribers
+func (n *NotificationCenter) Publish(notification Notification) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	notification.ID = n.nextID
+	notification.Time = time.Now()
+	n.nextID++
+
+	n.recent = append(n.recent, notification)
+	if len(n.recent) > maxNotifications {
+		n.recent = n.recent[len(n.recent)-maxNotifications:]
+	}
+
+	for subscriber := range n.subscribers {
+		select {
+		case subscriber <- notification:
+		default:
+			logging.Debugf("Warning: Dropping notification %d for a slow subscriber", notification.ID)
+		}
+	}
+}
+
+// Since returns the retained notifications newer than the given ID
+func (n *NotificationCenter) Since(id int64) []Notification {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	result := []Notification{}
+	for _, notification := range n.recent {
+		if notification.ID > id {
+			result = append(result, notification)
+		}
+	}
+	return result
+}
+
+// Subscribe returns the notifications newer than id and a channel receiving further ones
+func (n *NotificationCenter) Subscribe(id int64) ([]Notification, chan Notification) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	var missed []Notification
+	for _, notification := range n.recent {
+		if notification.ID > id {
+			missed = append(missed, notification)
+		}
+	}
+	subscriber := make(chan Notification, subscriberBuffer)
+	n.subscribers[subscriber] = true
+	return missed, subscriber
+}
+
+// Unsubscribe stops delivery to a subscriber
+func (n *NotificationCenter) Unsubscribe(subscriber chan Notification) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	delete(n.subscribers, subscriber)
+}
+
+// notify turns build and server events of the bus into dashboard notifications
+func (c *Coordinator) notify(event BusEvent) {
+	switch event.Type {
+	case BusBuildFinished:
+		record := event.Record
+		notification := Notification{
+			Type:     NotificationBuildFinished,
+			Level:    LevelSuccess,
+			Title:    fmt.Sprintf("Build %s succeeded", record.Environment),
+			Message:  fmt.Sprintf("Finished on %s in %v", record.ServerID, record.Duration.Round(time.Millisecond)),
+			BuildID:  record.ID,
+			ServerID: record.ServerID,
+		}
+		if !record.Success {
+			notification.Level = LevelError
+			notification.Title = fmt.Sprintf("Build %s failed", record.Environment)
+			notification.Message = record.Error
+		}
+		c.notifications.Publish(notification)
+	case BusServerJoined:
+		c.notifications.Publish(Notification{
+			Type:     NotificationServerJoined,
+			Level:    LevelInfo,
+			Title:    fmt.Sprintf("Server %s joined", event.ServerID),
+			Message:  fmt.Sprintf("%s, capacity %d", event.ServerAddr, event.Server.Capacity),
+			ServerID: event.ServerID,
+		})
+		if event.Server.Version != protocol.Version {
+			c.notifications.Publish(Notification{
+				Type:     NotificationVersionMismatch,
+				Level:    LevelWarning,
+				Title:    fmt.Sprintf("Version mismatch with server %s", event.ServerID),
+				Message:  fmt.Sprintf("Client %s, server %s; builds will be rejected until both are updated", protocol.Version, event.Server.Version),
+				ServerID: event.ServerID,
+			})
+		}
+	case BusServerLeft:
+		c.notifications.Publish(Notification{
+			Type:     NotificationServerLeft,
+			Level:    LevelWarning,
+			Title:    fmt.Sprintf("Server %s left", event.ServerID),
+			Message:  event.Err.Error(),
+			ServerID: event.ServerID,
+		})
+	}
+}
+
+// handleNotificationsAPI returns the retained notifications newer than ?since=ID
+func (ws *WebServer) handleNotificationsAPI(w http.ResponseWriter, r *http.Request) {
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.client.notifications.Since(since))
+}
+
+// handleNotificationStream delivers notifications as server-sent events. Browsers reconnecting
+// with Last-Event-ID (or ?since=ID) first receive the ones they missed.
+func (ws *WebServer) handleNotificationStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	since, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		since, _ = strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	}
+
+	missed, notifications := ws.client.notifications.Subscribe(since)
+	defer ws.client.notifications.Unsubscribe(notifications)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	send := func(notification Notification) {
+		data, _ := json.Marshal(notification)
+		fmt.Fprintf(w, "id: %d\ndata: %s\n\n", notification.ID, data)
+	}
+	for _, notification := range missed {
+		send(notification)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(notificationKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case notification := <-notifications:
+			send(notification)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
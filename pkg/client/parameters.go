@@ -0,0 +1,13 @@
+package client
+
+import (
+	"boltbuild/pkg/config"
+)
+
+// expandParameters substitutes {{name}} placeholders with parameter values
+func expandParameters(template string, values map[string]string) string {
+	return config.ParameterPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := config.ParameterPlaceholder.FindStringSubmatch(placeholder)[1]
+		return values[name]
+	})
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// platformVariants turns the platform variants of an environment into those of a build request
+func platformVariants(variants map[string]config.PlatformCommand, shell bool, parameters map[string]string) map[string]protocol.CommandVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+	requestVariants := make(map[string]protocol.CommandVariant, len(variants))
+	for platform, variant := range variants {
+		requestVariants[platform] = protocol.CommandVariant{
+			Command: expandParameters(variant.Command, parameters),
+			Args:    expandArgs(variant.Args, parameters),
+			Shell:   variant.Shell || shell,
+			Steps:   buildSteps(variant.Commands, variant.Shell || shell, parameters),
+		}
+	}
+	return requestVariants
+}
+
+// checkPlatformSupport reports why a server's platform cannot run a build: the environment
+// requires other platforms, or it only defines command variants for other platforms. Servers
+// that do not report their platform are only used for builds that run anywhere.
+func checkPlatformSupport(info protocol.ServerInfo, request protocol.BuildRequest) error {
+	variantsOnly := request.Command == "" && len(request.Args) == 0 && len(request.Steps) == 0 && len(request.Platforms) > 0
+	if len(request.Requirements.Platforms) == 0 && !variantsOnly {
+		return nil
+	}
+	if info.OS == "" {
+		return fmt.Errorf("requires a server reporting its platform")
+	}
+	platform := info.OS + "/" + info.Arch
+	if request.Image != "" || request.WSL != "" {
+		platform = "linux/" + info.Arch
+	}
+	if !buildutil.MatchesPlatform(request.Requirements.Platforms, platform) {
+		return fmt.Errorf("requires platform %s, server is %s", strings.Join(request.Requirements.Platforms, " or "), platform)
+	}
+	if variantsOnly {
+		goos, _, _ := strings.Cut(platform, "/")
+		_, forPlatform := request.Platforms[platform]
+		_, forOS := request.Platforms[goos]
+		if !forPlatform && !forOS {
+			return fmt.Errorf("no command for platform %s", platform)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// defaultPluginTimeout bounds a single plugin invocation
+const defaultPluginTimeout = 30 * time.Second
+
+// pluginEnvironment is the JSON form of an environment returned by describe
+type pluginEnvironment struct {
+	Name               string            `json:"name"`
+	Command            string            `json:"command"`
+	ProjectDir         string            `json:"project_dir"`
+	ExecutionDir       string            `json:"execution_dir"`
+	OutputPaths        []string          `json:"output_paths"`
+	AlwaysCollectPaths []string          `json:"always_collect_paths"`
+	EnvVars            map[string]string `json:"env_vars"`
+}
+
+// pluginPrepareResult carries optional overrides returned by prepare
+type pluginPrepareResult struct {
+	Command string            `json:"command"`
+	EnvVars map[string]string `json:"env_vars"`
+}
+
+// PluginManager caches environments provided by plugins
+type PluginManager struct {
+	plugins []config.PluginConfig
+	caches  map[string]*pluginCache
+	mux     sync.Mutex
+}
+
+// pluginCache is the last snapshot of one plugin's environments
+type pluginCache struct {
+	environments map[string]config.BuildEnvironment
+	refreshed    time.Time
+	refreshing   chan struct{} // Closed when the running refresh finishes, nil when idle
+}
+
+// NewPluginManager creates a manager for the configured plugins
+func NewPluginManager(plugins []config.PluginConfig) *PluginManager {
+	caches := make(map[string]*pluginCache, len(plugins))
+	for _, plugin := range plugins {
+		caches[plugin.Name] = &pluginCache{}
+	}
+	return &PluginManager{
+		plugins: plugins,
+		caches:  caches,
+	}
+}
+
+// Environments returns all plugin-provided environments. Stale plugins are refreshed in the
+// background while the last snapshot is served; only a plugin that was never listed is waited for.
+func (pm *PluginManager) Environments() map[string]config.BuildEnvironment {
+	var pending []chan struct{}
+
+	pm.mux.Lock()
+	for _, plugin := range pm.plugins {
+		cache := pm.caches[plugin.Name]
+		if cache.refreshing == nil && time.Since(cache.refreshed) > plugin.RefreshAfter() {
+			cache.refreshing = make(chan struct{})
+			go pm.refresh(plugin, cache)
+		}
+		if cache.refreshed.IsZero() && cache.refreshing != nil {
+			pending = append(pending, cache.refreshing)
+		}
+	}
+	pm.mux.Unlock()
+
+	for _, done := range pending {
+		<-done
+	}
+
+	pm.mux.Lock()
+	defer pm.mux.Unlock()
+
+	envs := make(map[string]config.BuildEnvironment)
+	for _, plugin := range pm.plugins {
+		for name, env := range pm.caches[plugin.Name].environments {
+			envs[name] = env
+		}
+	}
+	return envs
+}
+
+// Prepare lets the providing plugin prepare a build and apply overrides to the environment
+func (pm *PluginManager) Prepare(env *config.BuildEnvironment, environment, projectDir string) (*config.BuildEnvironment, error) {
+	plugin := pm.plugin(env.Plugin)
+	if plugin == nil {
+		return env, nil
+	}
+
+	output, err := pm.invoke(*plugin, "prepare", environment, projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s failed to prepare %s: %v", plugin.Name, environment, err)
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return env, nil
+	}
+
+	var result pluginPrepareResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("plugin %s returned invalid prepare result: %v", plugin.Name, err)
+	}
+
+	// Apply overrides on a copy so the cached definition stays untouched
+	prepared := *env
+	if result.Command != "" {
+		prepared.Command = result.Command
+	}
+	if len(result.EnvVars) > 0 {
+		prepared.EnvVars = make(map[string]string, len(env.EnvVars)+len(result.EnvVars))
+		for key, value := range env.EnvVars {
+			prepared.EnvVars[key] = value
+		}
+		for key, value := range result.EnvVars {
+			prepared.EnvVars[key] = value
+		}
+	}
+	return &prepared, nil
+}
+
+// refresh re-lists and re-describes the environments of a plugin without holding the lock and
+// swaps in the new snapshot. A plugin that fails to list keeps its previous snapshot.
+func (pm *PluginManager) refresh(plugin config.PluginConfig, cache *pluginCache) {
+	environments, err := pm.load(plugin)
+	if err != nil {
+		logging.Infof("Plugin %s %v", plugin.Name, err)
+	} else {
+		logging.Debugf("Loaded %d environments from plugin %s", len(environments), plugin.Name)
+	}
+
+	pm.mux.Lock()
+	if err == nil {
+		cache.environments = environments
+	}
+	cache.refreshed = time.Now()
+	done := cache.refreshing
+	cache.refreshing = nil
+	pm.mux.Unlock()
+
+	close(done)
+}
+
+// load lists and describes the environments of a plugin
+func (pm *PluginManager) load(plugin config.PluginConfig) (map[string]config.BuildEnvironment, error) {
+	output, err := pm.invoke(plugin, "list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %v", err)
+	}
+
+	var names []string
+	if err := json.Unmarshal(output, &names); err != nil {
+		return nil, fmt.Errorf("returned invalid environment list: %v", err)
+	}
+
+	environments := make(map[string]config.BuildEnvironment)
+	for _, name := range names {
+		output, err := pm.invoke(plugin, "describe", name)
+		if err != nil {
+			logging.Infof("Plugin %s failed to describe %s: %v", plugin.Name, name, err)
+			continue
+		}
+
+		var described pluginEnvironment
+		if err := json.Unmarshal(output, &described); err != nil {
+			logging.Infof("Plugin %s returned invalid definition for %s: %v", plugin.Name, name, err)
+			continue
+		}
+		if described.Command == "" {
+			logging.Infof("Plugin %s returned no command for %s", plugin.Name, name)
+			continue
+		}
+
+		env := config.BuildEnvironment{
+			Name:               described.Name,
+			Command:            described.Command,
+			ProjectDir:         described.ProjectDir,
+			ExecutionDir:       described.ExecutionDir,
+			OutputPaths:        described.OutputPaths,
+			AlwaysCollectPaths: described.AlwaysCollectPaths,
+			EnvVars:            described.EnvVars,
+			Plugin:             plugin.Name,
+		}
+		if env.Name == "" {
+			env.Name = name
+		}
+		if env.ProjectDir == "" {
+			env.ProjectDir = "."
+		}
+		if env.ExecutionDir == "" {
+			env.ExecutionDir = "."
+		}
+		environments[name] = env
+	}
+	return environments, nil
+}
+
+// invoke runs a plugin subcommand and returns its stdout
+func (pm *PluginManager) invoke(plugin config.PluginConfig, args ...string) ([]byte, error) {
+	timeout := plugin.Timeout
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, plugin.Command, append(append([]string{}, plugin.Args...), args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, stderr.String())
+	}
+	return output, nil
+}
+
+// plugin returns the configuration of a named plugin
+func (pm *PluginManager) plugin(name string) *config.PluginConfig {
+	for i := range pm.plugins {
+		if pm.plugins[i].Name == name {
+			return &pm.plugins[i]
+		}
+	}
+	return nil
+}
+
+// lookupEnvironment resolves an environment from the configuration or, failing that, from plugins
+func (c *Coordinator) lookupEnvironment(name string) (*config.BuildEnvironment, bool) {
+	if env, exists := c.config.GetBuildEnvironment(name); exists {
+		return env, true
+	}
+	if c.plugins != nil {
+		if env, exists := c.plugins.Environments()[name]; exists {
+			return &env, true
+		}
+	}
+	return nil, false
+}
+
+// allEnvironments returns configured and plugin-provided environments (configuration wins on conflicts)
+func (c *Coordinator) allEnvironments() map[string]config.BuildEnvironment {
+	envs := make(map[string]config.BuildEnvironment)
+	if c.plugins != nil {
+		for name, env := range c.plugins.Environments() {
+			envs[name] = env
+		}
+	}
+	for name, env := range c.config.Build.Environments {
+		envs[name] = env
+	}
+	return envs
+}
+
+// prepareEnvironment runs plugin preparation for plugin-provided environments
+func (c *Coordinator) prepareEnvironment(env *config.BuildEnvironment, environment, projectDir string) (*config.BuildEnvironment, error) {
+	if env.Plugin == "" || c.plugins == nil {
+		return env, nil
+	}
+	return c.plugins.Prepare(env, environment, projectDir)
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+)
+
+// Preference limits
+const (
+	maxRecentLaunches = 8
+	maxFavorites      = 20
+	defaultWebUser    = "default"
+)
+
+// webUserHeader names the dashboard user, as set by an authenticating reverse proxy or an API integration
+const webUserHeader = "X-Boltbuild-User"
+
+// QuickLaunch is an environment (optionally of a project, on a server) the dashboard can build in one click
+type QuickLaunch struct {
+	Environment string    `json:"environment"`
+	Project     string    `json:"project,omitempty"`
+	ServerID    string    `json:"server_id,omitempty"`
+	ServerAddr  string    `json:"server_addr,omitempty"` // Empty = any available server
+	UsedAt      time.Time `json:"used_at"`
+}
+
+// same reports whether two launches build the same thing in the same place
+func (q QuickLaunch) same(other QuickLaunch) bool {
+	return q.Environment == other.Environment && q.Project == other.Project && q.ServerAddr == other.ServerAddr
+}
+
+// Preferences are the quick launch entries of one dashboard user
+type Preferences struct {
+	Recent    []QuickLaunch `json:"recent"`    // Most recently used first
+	Favorites []QuickLaunch `json:"favorites"` // In the order the user pinned them
+}
+
+// PreferenceStore keeps the preferences of every user, persisted next to the build history
+type PreferenceStore struct {
+	users map[string]*Preferences
+	path  string // Empty = memory only
+	mux   sync.Mutex
+}
+
+// NewPreferenceStore creates a preference store in dir and loads the saved preferences
+func NewPreferenceStore(dir string) *PreferenceStore {
+	p := &PreferenceStore{users: make(map[string]*Preferences)}
+	if dir == "" {
+		return p
+	}
+
+	p.path = filepath.Join(dir, "preferences.json")
+	data, err := os.ReadFile(p.path)
+	if err == nil {
+		err = json.Unmarshal(data, &p.users)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		logging.Infof("Failed to load preferences from %s: %v", p.path, err)
+	}
+	return p
+}
+
+// Get returns a copy of a user's preferences
+func (p *PreferenceStore) Get(user string) Preferences {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	prefs := Preferences{Recent: []QuickLaunch{}, Favorites: []QuickLaunch{}}
+	if stored, exists := p.users[user]; exists {
+		prefs.Recent = append(prefs.Recent, stored.Recent...)
+		prefs.Favorites = append(prefs.Favorites, stored.Favorites...)
+	}
+	return prefs
+}
+
+// Set replaces a user's preferences, keeping the limits; without a recent list the
+// recently used builds are kept
+func (p *PreferenceStore) Set(user string, prefs Preferences) error {
+	for _, launches := range [][]QuickLaunch{prefs.Recent, prefs.Favorites} {
+		for _, launch := range launches {
+			if launch.Environment == "" {
+				return fmt.Errorf("quick launch entries need an environment")
+			}
+		}
+	}
+	if len(prefs.Recent) > maxRecentLaunches {
+		prefs.Recent = prefs.Recent[:maxRecentLaunches]
+	}
+	if len(prefs.Favorites) > maxFavorites {
+		return fmt.Errorf("too many favorites: %d (max %d)", len(prefs.Favorites), maxFavorites)
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if stored, exists := p.users[user]; exists && prefs.Recent == nil {
+		prefs.Recent = stored.Recent
+	}
+	p.users[user] = &prefs
+	return p.save()
+}
+
+// RecordLaunch moves a build to the front of a user's recently used list
+func (p *PreferenceStore) RecordLaunch(user string, launch QuickLaunch) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	prefs, exists := p.users[user]
+	if !exists {
+		prefs = &Preferences{}
+		p.users[user] = prefs
+	}
+	launch.UsedAt = time.Now().UTC()
+	recent := []QuickLaunch{launch}
+	for _, existing := range prefs.Recent {
+		if !existing.same(launch) && len(recent) < maxRecentLaunches {
+			recent = append(recent, existing)
+		}
+	}
+	prefs.Recent = recent
+
+	if err := p.save(); err != nil {
+		logging.Infof("Failed to save preferences: %v", err)
+	}
+}
+
+// save writes all preferences to disk (caller holds the lock)
+func (p *PreferenceStore) save() error {
+	if p.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(p.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := p.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p.path)
+}
+
+// webUser identifies the user of a dashboard or API request: the signed in user, or without
+// a login the X-Boltbuild-User header
+func (ws *WebServer) webUser(r *http.Request) string {
+	if identity, ok := requestIdentity(r); ok {
+		return identity.User
+	}
+	if ws.config.Web.Auth.Enabled() {
+		return defaultWebUser
+	}
+	if user := strings.TrimSpace(r.Header.Get(webUserHeader)); user != "" {
+		return user
+	}
+	return defaultWebUser
+}
+
+// recordLaunch remembers a build submitted from the dashboard as recently used
+func (ws *WebServer) recordLaunch(r *http.Request, environment, project, serverAddr string) {
+	launch := QuickLaunch{Environment: environment, Project: project, ServerAddr: serverAddr}
+	if server := ws.client.findServerByAddress(serverAddr); serverAddr != "" && server != nil {
+		launch.ServerID = server.info.ID
+	}
+	ws.client.preferences.RecordLaunch(ws.webUser(r), launch)
+}
+
+// handlePreferencesAPI returns the quick launch preferences of the requesting user
+func (ws *WebServer) handlePreferencesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.client.preferences.Get(ws.webUser(r)))
+}
+
+// handleSavePreferencesAPI replaces the quick launch preferences of the requesting user
+func (ws *WebServer) handleSavePreferencesAPI(w http.ResponseWriter, r *http.Request) {
+	var prefs Preferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := ws.client.preferences.Set(ws.webUser(r), prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ws.handlePreferencesAPI(w, r)
+}
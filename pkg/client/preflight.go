@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// preflightTimeout is how long a client waits for a server to answer a pre-flight check.
+// Unanswered checks let the build go ahead, the server then reports problems as usual.
+const preflightTimeout = 5 * time.Second
+
+// newPreflightCheck describes a build request for its pre-flight check
+func newPreflightCheck(request protocol.BuildRequest) protocol.PreflightCheck {
+	return protocol.PreflightCheck{
+		Environment:  request.Environment,
+		Tool:         requestTool(request),
+		Image:        request.Image,
+		WSL:          request.WSL,
+		Requirements: request.Requirements,
+		Size:         requestSize(request),
+	}
+}
+
+// preflight asks a reserved server whether it can run a request before the files are sent
+func (c *Coordinator) preflight(ctx context.Context, server *ServerConnection, request protocol.BuildRequest) error {
+	results := make(chan *protocol.PreflightCheck, 1)
+	c.preflightMux.Lock()
+	c.preflights[request.ID] = results
+	c.preflightMux.Unlock()
+	defer func() {
+		c.preflightMux.Lock()
+		delete(c.preflights, request.ID)
+		c.preflightMux.Unlock()
+	}()
+
+	check := newPreflightCheck(request)
+	if err := server.send(protocol.Message{Type: protocol.MessagePreflight, BuildID: request.ID, Preflight: &check}); err != nil {
+		return err
+	}
+	select {
+	case result := <-results:
+		if !result.Accepted {
+			return fmt.Errorf("server %s rejected the build: %s", server.info.ID, result.Reason)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("build cancelled: %v", ctx.Err())
+	case <-time.After(preflightTimeout):
+		logging.Debugf("Server %s did not answer the pre-flight check of build %s, sending it anyway", server.info.ID, request.ID)
+		return nil
+	}
+}
+
+// deliverPreflight hands a pre-flight answer to the dispatch waiting for it
+func (c *Coordinator) deliverPreflight(buildID string, result *protocol.PreflightCheck) {
+	c.preflightMux.Lock()
+	defer c.preflightMux.Unlock()
+	if results, exists := c.preflights[buildID]; exists {
+		select {
+		case results <- result:
+		default:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"boltbuild/pkg/config"
+)
+
+// ProjectInfo describes a configured project for the web interface and CLI
+type ProjectInfo struct {
+	Name        string            `json:"name"`
+	Dir         string            `json:"dir"`
+	Environment string            `json:"environment"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// lookupProject finds a configured project by name
+func (c *Coordinator) lookupProject(name string) (config.ProjectConfig, bool) {
+	project, exists := c.config.Projects[name]
+	return project, exists
+}
+
+// listProjects returns all configured projects sorted by name
+func (c *Coordinator) listProjects() []ProjectInfo {
+	projects := make([]ProjectInfo, 0, len(c.config.Projects))
+	for name, project := range c.config.Projects {
+		projects = append(projects, ProjectInfo{
+			Name:        name,
+			Dir:         project.Dir,
+			Environment: project.Environment,
+			Labels:      project.Labels,
+		})
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	return projects
+}
+
+// resolveProjectBuild determines the environment and project directory for a build.
+// If a project is named, its directory is used and its default environment applies
+// unless an environment is given explicitly.
+func (c *Coordinator) resolveProjectBuild(projectName, environment string) (string, string, error) {
+	if projectName == "" {
+		env, exists := c.lookupEnvironment(environment)
+		if !exists {
+			return "", "", fmt.Errorf("unknown environment: %s", environment)
+		}
+		return environment, env.PrimaryDir(), nil
+	}
+
+	project, exists := c.lookupProject(projectName)
+	if !exists {
+		return "", "", fmt.Errorf("unknown project: %s", projectName)
+	}
+	if environment == "" {
+		environment = project.Environment
+	}
+	if _, exists := c.lookupEnvironment(environment); !exists {
+		return "", "", fmt.Errorf("unknown environment: %s", environment)
+	}
+
+	return environment, project.Dir, nil
+}
+
+// projectAffinity remembers which server last built each named project
+type projectAffinity struct {
+	servers map[string]string // Project name -> server ID
+	mux     sync.Mutex
+}
+
+// newProjectAffinity creates an empty affinity table
+func newProjectAffinity() *projectAffinity {
+	return &projectAffinity{servers: make(map[string]string)}
+}
+
+// remember records the server that built a project
+func (pa *projectAffinity) remember(project, serverID string) {
+	if project == "" || serverID == "" {
+		return
+	}
+	pa.mux.Lock()
+	defer pa.mux.Unlock()
+	pa.servers[project] = serverID
+}
+
+// server returns the server that last built a project, "" if none
+func (pa *projectAffinity) server(project string) string {
+	if project == "" {
+		return ""
+	}
+	pa.mux.Lock()
+	defer pa.mux.Unlock()
+	return pa.servers[project]
+}
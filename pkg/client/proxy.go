@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// forwardedValue returns the first value of a X-Forwarded-* header set by a reverse proxy
+func forwardedValue(r *http.Request, header string) string {
+	value, _, _ := strings.Cut(r.Header.Get(header), ",")
+	return strings.TrimSpace(value)
+}
+
+// externalURL returns the scheme and host the browser used to reach the web interface,
+// as reported by a reverse proxy in X-Forwarded-Proto and X-Forwarded-Host
+func externalURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := forwardedValue(r, "X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwardedHost := forwardedValue(r, "X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+	return scheme + "://" + host
+}
+
+// basePathKey is the request context key of the base path a request was served under
+type basePathKey struct{}
+
+// externalPath returns the browser-visible path of a web interface path, below the base path
+// the request was served under
+func externalPath(r *http.Request, path string) string {
+	basePath, _ := r.Context().Value(basePathKey{}).(string)
+	return basePath + path
+}
+
+// mountBasePath serves the router below the configured base path, redirecting the bare prefix to
+// its directory form so the dashboard's relative links resolve below it
+func mountBasePath(basePath string, router http.Handler) http.Handler {
+	if basePath == "" {
+		return router
+	}
+	mux := http.NewServeMux()
+	mounted := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), basePathKey{}, basePath)))
+	})
+	mux.Handle(basePath+"/", http.StripPrefix(basePath, mounted))
+	mux.HandleFunc(basePath, func(w http.ResponseWriter, r *http.Request) {
+		target := externalURL(r) + basePath + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	return mux
+}
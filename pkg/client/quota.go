@@ -0,0 +1,242 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// quotaEntry is a build counted against quotas
+type quotaEntry struct {
+	id       string
+	user     string
+	teams    []string
+	started  time.Time
+	duration time.Duration // Until finished, the time since started is used
+	running  bool
+}
+
+// used returns how long the build has taken so far
+func (e *quotaEntry) used(now time.Time) time.Duration {
+	if e.running {
+		return now.Sub(e.started)
+	}
+	return e.duration
+}
+
+// quotaScope is a user or team quota a build counts against
+type quotaScope struct {
+	kind   string // "user" or "team"
+	name   string
+	limits config.QuotaLimits
+}
+
+// QuotaUsage is the consumption of one user or team quota
+type QuotaUsage struct {
+	Scope          string             `json:"scope"` // "user" or "team"
+	Name           string             `json:"name"`
+	Limits         config.QuotaLimits `json:"limits"`
+	BuildsLastHour int                `json:"builds_last_hour"`
+	BuildsLastDay  int                `json:"builds_last_day"`
+	MinutesLastDay float64            `json:"minutes_last_day"`
+}
+
+// QuotaTracker counts the builds of the last 24 hours per user and team
+type QuotaTracker struct {
+	config  config.QuotaConfig
+	entries []*quotaEntry // Oldest first
+	mux     sync.Mutex
+}
+
+// NewQuotaTracker creates a tracker, counting the builds of the last day found in the history
+func NewQuotaTracker(cfg config.QuotaConfig, records []BuildRecord) *QuotaTracker {
+	q := &QuotaTracker{config: cfg}
+	cutoff := time.Now().Add(-24 * time.Hour)
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.User != "" && record.SubmittedAt.After(cutoff) {
+			q.entries = append(q.entries, &quotaEntry{id: record.ID, user: record.User, teams: record.Teams, started: record.SubmittedAt, duration: record.Duration})
+		}
+	}
+	return q
+}
+
+// scopes returns the quotas a build of user and teams counts against
+func (q *QuotaTracker) scopes(user string, teams []string) []quotaScope {
+	var scopes []quotaScope
+	if user != "" {
+		limits, exists := q.config.Users[user]
+		if !exists {
+			limits = q.config.Default
+		}
+		if limits.Limited() {
+			scopes = append(scopes, quotaScope{kind: "user", name: user, limits: limits})
+		}
+	}
+	for team, limits := range q.config.Teams {
+		if limits.Limited() && containsFold(teams, team) {
+			scopes = append(scopes, quotaScope{kind: "team", name: team, limits: limits})
+		}
+	}
+	return scopes
+}
+
+// counts reports whether an entry counts against a scope
+func (s quotaScope) counts(entry *quotaEntry) bool {
+	if s.kind == "user" {
+		return entry.user == s.name
+	}
+	return containsFold(entry.teams, s.name)
+}
+
+// usage sums the builds of a scope in the last hour and day; oldest is the first counted build
+func (q *QuotaTracker) usage(scope quotaScope, now time.Time) (usage QuotaUsage, oldestHour, oldestDay time.Time) {
+	usage = QuotaUsage{Scope: scope.kind, Name: scope.name, Limits: scope.limits}
+	var minutes time.Duration
+	for _, entry := range q.entries {
+		if !scope.counts(entry) || now.Sub(entry.started) > 24*time.Hour {
+			continue
+		}
+		if usage.BuildsLastDay == 0 {
+			oldestDay = entry.started
+		}
+		usage.BuildsLastDay++
+		minutes += entry.used(now)
+		if now.Sub(entry.started) <= time.Hour {
+			if usage.BuildsLastHour == 0 {
+				oldestHour = entry.started
+			}
+			usage.BuildsLastHour++
+		}
+	}
+	usage.MinutesLastDay = minutes.Minutes()
+	return usage, oldestHour, oldestDay
+}
+
+// Admit counts a new build against the quotas of its user and teams, or explains which quota it exceeds
+func (q *QuotaTracker) Admit(id, user string, teams []string) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	now := time.Now()
+	q.prune(now)
+	for _, scope := range q.scopes(user, teams) {
+		usage, oldestHour, oldestDay := q.usage(scope, now)
+		limits := scope.limits
+		switch {
+		case limits.BuildsPerHour > 0 && usage.BuildsLastHour >= limits.BuildsPerHour:
+			return fmt.Errorf("%w for %s %s: %d of %d builds in the last hour, next build possible at %s",
+				protocol.ErrQuotaExceeded, scope.kind, scope.name, usage.BuildsLastHour, limits.BuildsPerHour, oldestHour.Add(time.Hour).Format("15:04"))
+		case limits.BuildsPerDay > 0 && usage.BuildsLastDay >= limits.BuildsPerDay:
+			return fmt.Errorf("%w for %s %s: %d of %d builds in the last 24 hours, next build possible at %s",
+				protocol.ErrQuotaExceeded, scope.kind, scope.name, usage.BuildsLastDay, limits.BuildsPerDay, oldestDay.Add(24*time.Hour).Format("Jan 2 15:04"))
+		case limits.BuildMinutesPerDay > 0 && usage.MinutesLastDay >= float64(limits.BuildMinutesPerDay):
+			return fmt.Errorf("%w for %s %s: %.0f of %d build minutes used in the last 24 hours, minutes are freed from %s",
+				protocol.ErrQuotaExceeded, scope.kind, scope.name, usage.MinutesLastDay, limits.BuildMinutesPerDay, oldestDay.Add(24*time.Hour).Format("Jan 2 15:04"))
+		}
+	}
+
+	q.entries = append(q.entries, &quotaEntry{id: id, user: user, teams: teams, started: now, running: true})
+	return nil
+}
+
+// Release stops counting a build that never started, e.g. because no server was available
+func (q *QuotaTracker) Release(id string) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	for i, entry := range q.entries {
+		if entry.id == id {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Finish fixes the duration of a finished build
+func (q *QuotaTracker) Finish(id string) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	for _, entry := range q.entries {
+		if entry.id == id && entry.running {
+			entry.running = false
+			entry.duration = time.Since(entry.started)
+			return
+		}
+	}
+}
+
+// Usage returns the consumption of the quotas that apply to a user and their teams
+func (q *QuotaTracker) Usage(user string, teams []string) []QuotaUsage {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	now := time.Now()
+	usages := []QuotaUsage{}
+	for _, scope := range q.scopes(user, teams) {
+		usage, _, _ := q.usage(scope, now)
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+// prune forgets finished builds older than a day
+func (q *QuotaTracker) prune(now time.Time) {
+	kept := q.entries[:0]
+	for _, entry := range q.entries {
+		if entry.running || now.Sub(entry.started) <= 24*time.Hour {
+			kept = append(kept, entry)
+		}
+	}
+	q.entries = kept
+}
+
+// containsFold reports whether a list holds name, ignoring case
+func containsFold(list []string, name string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildUser returns the user and teams a build request is accounted to
+func (ws *WebServer) buildUser(r *http.Request) (string, []string) {
+	identity, _ := requestIdentity(r)
+	return ws.webUser(r), identity.Groups
+}
+
+// buildErrorStatus returns the HTTP status of a failed build submission
+func buildErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, protocol.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, protocol.ErrNoServers), errors.Is(err, protocol.ErrServerBusy):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, protocol.ErrVersionMismatch):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// writeBuildError answers a failed build submission, naming known errors in the error code header
+func writeBuildError(w http.ResponseWriter, err error) {
+	if code := protocol.ErrorCode(err); code != "" {
+		w.Header().Set(protocol.ErrorCodeHeader, code)
+	}
+	http.Error(w, err.Error(), buildErrorStatus(err))
+}
+
+// handleQuotaAPI returns the quota usage of the calling user and their teams
+func (ws *WebServer) handleQuotaAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user, teams := ws.buildUser(r)
+	usages := ws.client.quotas.Usage(user, teams)
+	json.NewEncoder(w).Encode(map[string]interface{}{"enabled": len(usages) > 0, "user": user, "quotas": usages})
+}
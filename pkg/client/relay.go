@@ -0,0 +1,84 @@
+package client
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/relay"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// connectRelay follows the server list of the client's tenant at a relay and connects to every
+// listed server through a tunnel, reconnecting whenever the relay connection is lost
+func (c *Coordinator) connectRelay(cfg config.RelayConnection) {
+	for {
+		conn, decoder, err := relay.DialRelay(cfg, relay.RelayMessage{Role: relay.RelayRoleClient}, c.config.Client.Discovery.ConnectTimeout, c.config.TLS)
+		if err == nil {
+			if err = relay.ExpectWelcome(decoder); err == nil {
+				logging.Infof("Connected to relay %s as tenant %s", cfg.Address, cfg.Tenant)
+				for {
+					var msg relay.RelayMessage
+					if err = decoder.Decode(&msg); err != nil {
+						break
+					}
+					if msg.Type != relay.RelayMessageServers {
+						continue
+					}
+					for _, id := range msg.Servers {
+						c.relayMux.Lock()
+						connected := c.relayed[id]
+						c.relayed[id] = true
+						c.relayMux.Unlock()
+						if !connected {
+							go c.openRelayedServer(cfg, id)
+						}
+					}
+				}
+			}
+			conn.Close()
+		}
+		logging.Infof("Relay %s unavailable: %v", cfg.Address, err)
+		time.Sleep(transport.ReverseRetryInterval)
+	}
+}
+
+// openRelayedServer connects to one server through the relay and manages the connection like a
+// discovered one until it ends
+func (c *Coordinator) openRelayedServer(cfg config.RelayConnection, serverID string) {
+	defer func() {
+		c.relayMux.Lock()
+		delete(c.relayed, serverID)
+		c.relayMux.Unlock()
+	}()
+
+	conn, decoder, err := relay.DialRelay(cfg, relay.RelayMessage{Role: relay.RelayRoleClientData, ServerID: serverID}, c.config.Client.Discovery.ConnectTimeout, c.config.TLS)
+	if err != nil {
+		logging.Debugf("Failed to open relay tunnel to %s: %v", serverID, err)
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(relay.RelayOpenTimeout))
+	var serverInfo protocol.ServerInfo
+	if err := decoder.Decode(&serverInfo); err != nil || serverInfo.ID != serverID {
+		logging.Debugf("No handshake from server %s through the relay: %v", serverID, err)
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	addr := net.JoinHostPort(serverInfo.Address, strconv.Itoa(serverInfo.Port))
+	c.serversMux.RLock()
+	_, exists := c.servers[addr]
+	c.serversMux.RUnlock()
+	if exists {
+		conn.Close()
+		return
+	}
+
+	logging.Infof("Build server %s connected through relay %s (capacity: %d, version: %s)", serverInfo.ID, cfg.Address, serverInfo.Capacity, serverInfo.Version)
+	c.handleServerConnection(logging.TraceConn(conn, "relay:"+serverID), serverInfo, addr)
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+	"github.com/gorilla/mux"
+)
+
+// Where a replay takes the project files from
+const (
+	ReplayArchive = "archive" // The files archived when the build ran (history.snapshots)
+	ReplayDisk    = "disk"    // The current state of the build's project directory
+)
+
+// errFilesUnavailable is a build whose project files are not available from the requested source
+var errFilesUnavailable = errors.New("project files not available")
+
+// ReplayRequest is the body of POST /api/build/{id}/replay
+type ReplayRequest struct {
+	Source string `json:"source,omitempty"` // archive or disk (default: archive when the files were archived)
+	Server string `json:"server,omitempty"` // Optional build server address
+}
+
+// snapshotBuild archives the project files of a build when history snapshots are enabled
+func (c *Coordinator) snapshotBuild(build *preparedBuild, record *BuildRecord) {
+	if !c.config.Client.History.Snapshots {
+		return
+	}
+	if err := c.history.SaveSnapshot(build.request.ID, build.request.Files); err != nil {
+		logging.Infof("Warning: Failed to archive the project files of build %s: %v", build.request.ID, err)
+		return
+	}
+	record.Snapshot = true
+}
+
+// ReplayBuild submits a build of the history again with the environment, sub-project, parameters
+// and overrides it ran with. The files come from the archive taken when it ran or from its project
+// directory as it is now; builds replayed from the archive return their artifacts without saving them.
+func (c *Coordinator) ReplayBuild(ctx context.Context, record BuildRecord, source, serverAddr string, opts BuildOptions) (*protocol.BuildResponse, error) {
+	source = replaySource(record, source)
+	opts.Project = record.Project
+	opts.Subpath = record.Subpath
+	opts.Parameters = record.Parameters
+	opts.Command = record.Command
+	opts.OutputPaths = record.OutputPaths
+	opts.ReplayOf = record.ID
+
+	switch source {
+	case ReplayArchive:
+		files, err := c.archivedFiles(record)
+		if err != nil {
+			return nil, err
+		}
+		opts.Files = files
+	case ReplayDisk:
+		if record.ProjectDir == "" {
+			return nil, fmt.Errorf("%w on disk: build %s was uploaded", errFilesUnavailable, record.ID)
+		}
+	default:
+		return nil, fmt.Errorf("invalid replay source: %s (use %s or %s)", source, ReplayArchive, ReplayDisk)
+	}
+
+	logging.Infof("Replaying build %s of environment %s from %s", record.ID, record.Environment, source)
+	return c.SubmitBuildToServer(ctx, record.Environment, "", record.ProjectDir, record.ProjectDir, []string{}, serverAddr, opts)
+}
+
+// replaySource returns the source of a build's files, by default the archive when there is one
+func replaySource(record BuildRecord, source string) string {
+	if source != "" {
+		return source
+	}
+	if record.Snapshot {
+		return ReplayArchive
+	}
+	return ReplayDisk
+}
+
+// archivedFiles returns the project files archived when a build ran
+func (c *Coordinator) archivedFiles(record BuildRecord) (map[string]string, error) {
+	if !record.Snapshot {
+		return nil, fmt.Errorf("%w in the archive: build %s ran without client.history.snapshots", errFilesUnavailable, record.ID)
+	}
+	files, err := c.history.Snapshot(record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w in the archive: %v", errFilesUnavailable, err)
+	}
+	return files, nil
+}
+
+// handleReplayBuildAPI re-submits a past build from its archived files or the current disk state
+func (ws *WebServer) handleReplayBuildAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	record, exists := ws.client.history.Get(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Unknown build: %s", id), http.StatusNotFound)
+		return
+	}
+
+	var req ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Source != "" && req.Source != ReplayArchive && req.Source != ReplayDisk {
+		http.Error(w, fmt.Sprintf("Invalid replay source: %s", req.Source), http.StatusBadRequest)
+		return
+	}
+
+	if err := ws.authorizeBuild(r, record.Environment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ws.recordLaunch(r, record.Environment, record.Project, req.Server)
+	var opts BuildOptions
+	opts.User, opts.Teams = ws.buildUser(r)
+	response, err := ws.client.ReplayBuild(r.Context(), record, req.Source, req.Server, opts)
+	if errors.Is(err, errFilesUnavailable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeBuildError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// listenForServers accepts connections from servers configured to dial this client
+func (c *Coordinator) listenForServers(port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for servers: %v", err)
+	}
+	defer listener.Close()
+
+	logging.Infof("Accepting build server connections on port %d", port)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logging.Debugf("Failed to accept server connection: %v", err)
+			continue
+		}
+		go c.acceptServer(conn)
+	}
+}
+
+// acceptServer performs the usual handshake on a connection a server opened to this client
+func (c *Coordinator) acceptServer(conn net.Conn) {
+	remote := conn.RemoteAddr().String()
+	timeout := c.config.Client.Discovery.ConnectTimeout
+	if c.config.TLS.Enabled {
+		tlsConfig, err := transport.ClientTLSConfig(c.config.TLS, config.ClientKey(remote))
+		if err != nil {
+			logging.Infof("Refused server connection from %s: %v", remote, err)
+			conn.Close()
+			return
+		}
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	conn = logging.TraceConn(conn, remote)
+
+	// The server speaks first, as on connections this client opens
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	var serverInfo protocol.ServerInfo
+	if err := json.NewDecoder(conn).Decode(&serverInfo); err != nil || !strings.HasPrefix(serverInfo.ID, "server-") {
+		logging.Debugf("Ignoring connection from %s: no build server handshake", remote)
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	// Servers are known by the address they report, which also avoids a second connection
+	// to a server that discovery reaches directly
+	addr := net.JoinHostPort(serverInfo.Address, strconv.Itoa(serverInfo.Port))
+	c.serversMux.RLock()
+	_, exists := c.servers[addr]
+	c.serversMux.RUnlock()
+	if exists {
+		logging.Debugf("Server %s is already connected, closing its connection from %s", serverInfo.ID, remote)
+		conn.Close()
+		return
+	}
+
+	logging.Infof("Build server %s connected from %s (capacity: %d, version: %s)", serverInfo.ID, remote, serverInfo.Capacity, serverInfo.Version)
+	c.handleServerConnection(conn, serverInfo, addr)
+}
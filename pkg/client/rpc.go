@@ -0,0 +1,282 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"boltbuild/pkg/protocol"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcBuildError     = -32000
+)
+
+// RPCRequest is a JSON-RPC 2.0 request
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response or, without ID, a notification
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"` // Notifications only
+	Params  interface{}     `json:"params,omitempty"` // Notifications only
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error object
+type RPCError struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    map[string]string `json:"data,omitempty"` // "error": web API name of a known submission error
+}
+
+// Diagnostic is a compiler error or warning found in streamed build output
+type Diagnostic struct {
+	BuildID  string `json:"build_id"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // "error", "warning" or "note"
+	Message  string `json:"message"`
+}
+
+// diagnosticPatterns recognize GCC/Clang/Go/Rust style ("file:line:col: error: msg")
+// and MSVC style ("file(line,col): error C1234: msg") diagnostics
+var diagnosticPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?P<file>[^\s:][^:]*):(?P<line>\d+):(?:(?P<col>\d+):)?\s*(?:fatal )?(?P<severity>error|warning|note)(?:\[\w+\])?:\s*(?P<message>.*)$`),
+	regexp.MustCompile(`^(?P<file>[^(]+)\((?P<line>\d+)(?:,(?P<col>\d+))?\):\s*(?:fatal )?(?P<severity>error|warning)\s*\w*:\s*(?P<message>.*)$`),
+}
+
+// parseDiagnostic extracts a diagnostic from one line of build output
+func parseDiagnostic(line string) (Diagnostic, bool) {
+	line = strings.TrimRight(line, "\r")
+	for _, pattern := range diagnosticPatterns {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		var diagnostic Diagnostic
+		for i, name := range pattern.SubexpNames() {
+			switch name {
+			case "file":
+				diagnostic.File = strings.TrimSpace(match[i])
+			case "line":
+				diagnostic.Line, _ = strconv.Atoi(match[i])
+			case "col":
+				diagnostic.Column, _ = strconv.Atoi(match[i])
+			case "severity":
+				diagnostic.Severity = match[i]
+			case "message":
+				diagnostic.Message = match[i]
+			}
+		}
+		return diagnostic, true
+	}
+	return Diagnostic{}, false
+}
+
+// handleRPC serves the JSON-RPC 2.0 control interface for editor integrations.
+// Methods: build.submit, build.cancel, build.status, build.subscribe, builds.running,
+// environments.list and projects.list. build.subscribe keeps the response open and
+// streams newline-delimited notifications (build.log, build.diagnostic, build.finished).
+func (ws *WebServer) handleRPC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPC(w, rpcResponse{Error: &RPCError{Code: rpcParseError, Message: err.Error()}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPC(w, rpcResponse{ID: req.ID, Error: &RPCError{Code: rpcInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}})
+		return
+	}
+
+	var params struct {
+		BuildID     string            `json:"build_id"`
+		Environment string            `json:"environment"`
+		Project     string            `json:"project"`
+		ProjectDir  string            `json:"project_dir"`
+		Subpath     string            `json:"subpath"`
+		Parameters  map[string]string `json:"parameters"`
+		Server      string            `json:"server"`
+		Speculative bool              `json:"speculative"`
+		Clean       bool              `json:"clean"`
+		DryRun      bool              `json:"dry_run"`
+	}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			writeRPC(w, rpcResponse{ID: req.ID, Error: &RPCError{Code: rpcInvalidParams, Message: err.Error()}})
+			return
+		}
+	}
+
+	var result interface{}
+	var err error
+	switch req.Method {
+	case "build.submit":
+		var environment, projectDir string
+		environment, projectDir, err = ws.client.resolveProjectBuild(params.Project, params.Environment)
+		if err == nil && params.ProjectDir != "" {
+			projectDir, _, err = ws.client.resolveBrowsePath(params.ProjectDir)
+		}
+		if err == nil {
+			err = ws.authorizeBuild(r, environment)
+		}
+		if err == nil {
+			opts := BuildOptions{Project: params.Project, Subpath: params.Subpath, Parameters: params.Parameters, Speculative: params.Speculative, Clean: params.Clean}
+			opts.User, opts.Teams = ws.buildUser(r)
+			var id string
+			if params.DryRun {
+				result, err = ws.client.DryRunBuild(environment, projectDir, params.Server, opts)
+			} else if id, err = ws.client.StartBuild(environment, projectDir, params.Server, opts); err == nil {
+				result = map[string]string{"build_id": id}
+			}
+		}
+	case "build.cancel":
+		if err = ws.client.CancelBuild(params.BuildID); err == nil {
+			result = map[string]bool{"cancelled": true}
+		}
+	case "build.status":
+		result, err = ws.buildStatus(params.BuildID)
+	case "build.subscribe":
+		ws.streamBuild(w, r, req.ID, params.BuildID)
+		return
+	case "builds.running":
+		result = ws.client.RunningBuilds()
+	case "environments.list":
+		result = ws.client.allEnvironments()
+	case "projects.list":
+		result = ws.client.listProjects()
+	default:
+		writeRPC(w, rpcResponse{ID: req.ID, Error: &RPCError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %s", req.Method)}})
+		return
+	}
+
+	if err != nil {
+		rpcErr := &RPCError{Code: rpcBuildError, Message: err.Error()}
+		if code := protocol.ErrorCode(err); code != "" {
+			rpcErr.Data = map[string]string{"error": code}
+		}
+		writeRPC(w, rpcResponse{ID: req.ID, Error: rpcErr})
+		return
+	}
+	writeRPC(w, rpcResponse{ID: req.ID, Result: result})
+}
+
+// buildStatus reports a running build or the history record of a finished one
+func (ws *WebServer) buildStatus(id string) (interface{}, error) {
+	if running, exists := ws.client.RunningBuild(id); exists {
+		return map[string]interface{}{"state": "running", "build": running}, nil
+	}
+
+	record, exists := ws.client.history.Get(id)
+	if !exists {
+		return nil, fmt.Errorf("unknown build %s", id)
+	}
+	state := "failed"
+	if record.Success {
+		state = "succeeded"
+	}
+	return map[string]interface{}{"state": state, "build": record}, nil
+}
+
+// streamBuild sends the output of a build as notifications until it finishes
+func (ws *WebServer) streamBuild(w http.ResponseWriter, r *http.Request, id json.RawMessage, buildID string) {
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	notify := func(method string, params interface{}) {
+		encoder.Encode(rpcResponse{JSONRPC: "2.0", Method: method, Params: params})
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// Split output into lines for diagnostics, keeping partial lines until completed
+	var pending string
+	emitLog := func(data string) {
+		notify("build.log", map[string]string{"build_id": buildID, "data": data})
+		pending += data
+		for {
+			newline := strings.IndexByte(pending, '\n')
+			if newline < 0 {
+				break
+			}
+			if diagnostic, ok := parseDiagnostic(pending[:newline]); ok {
+				diagnostic.BuildID = buildID
+				notify("build.diagnostic", diagnostic)
+			}
+			pending = pending[newline+1:]
+		}
+	}
+
+	sofar, events, running := ws.client.SubscribeBuild(buildID)
+	if !running {
+		// Already finished: replay the stored log
+		record, exists := ws.client.history.Get(buildID)
+		if !exists {
+			writeRPC(w, rpcResponse{ID: id, Error: &RPCError{Code: rpcBuildError, Message: fmt.Sprintf("unknown build %s", buildID)}})
+			return
+		}
+		log, _ := ws.client.history.Log(buildID)
+		emitLog(log + "\n")
+		notify("build.finished", map[string]interface{}{"build_id": buildID, "success": record.Success, "error": record.Error})
+		encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: id, Result: map[string]bool{"success": record.Success}})
+		return
+	}
+	defer ws.client.UnsubscribeBuild(buildID, events)
+
+	if sofar != "" {
+		emitLog(sofar)
+	}
+
+	success := false
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: id, Result: map[string]bool{"success": success}})
+				return
+			}
+			switch event.Type {
+			case BuildEventLog:
+				emitLog(event.Data)
+			case BuildEventFinished:
+				if pending != "" {
+					emitLog("\n")
+				}
+				finished := map[string]interface{}{"build_id": buildID, "success": false, "error": event.Error}
+				if event.Response != nil {
+					success = event.Response.Success
+					finished["success"] = event.Response.Success
+					finished["error"] = event.Response.Error
+				}
+				notify("build.finished", finished)
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeRPC writes a single JSON-RPC response
+func writeRPC(w http.ResponseWriter, response rpcResponse) {
+	response.JSONRPC = "2.0"
+	json.NewEncoder(w).Encode(response)
+}
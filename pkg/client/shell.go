@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/protocol"
+)
+
+// requestTool returns the executable a build request starts (the first step's for multi-step
+// builds); shell builds start their first word
+func requestTool(request protocol.BuildRequest) string {
+	if len(request.Steps) > 0 {
+		return requestTool(buildutil.StepRequest(request, request.Steps[0]))
+	}
+	if len(request.Args) > 0 {
+		return commandTool(request.Args[0])
+	}
+	return commandTool(request.Command)
+}
+
+// shellMetacharacters have a meaning to sh or cmd; parameter values of shell builds must not
+// contain them, or a value could run commands of its own
+const shellMetacharacters = "\n\"'\\$`&|;<>()^%!"
+
+// checkShellParameters rejects parameter values a shell would interpret
+func checkShellParameters(parameters map[string]string) error {
+	for name, value := range parameters {
+		if strings.ContainsAny(value, shellMetacharacters) {
+			return fmt.Errorf("parameter %s contains characters the shell would interpret", name)
+		}
+	}
+	return nil
+}
+
+// expandArgs substitutes {{name}} placeholders in every argument
+func expandArgs(args []string, values map[string]string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = expandParameters(arg, values)
+	}
+	return expanded
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package client
+
+import (
+	"boltbuild/internal/buildutil"
+)
+
+// shellArg quotes a single word for sh
+func shellArg(arg string) string {
+	return buildutil.ShellQuote(arg)
+}
@@ -0,0 +1,6 @@
+package client
+
+// shellArg quotes a single word for cmd, which only understands double quotes
+func shellArg(arg string) string {
+	return `"` + arg + `"`
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// sshAddr is the address of a connection tunneled through ssh
+type sshAddr string
+
+// Network implements net.Addr
+func (a sshAddr) Network() string { return "ssh" }
+
+// String implements net.Addr
+func (a sshAddr) String() string { return string(a) }
+
+// sshConn carries the build protocol over the stdio of "ssh -W", which forwards it to the server
+// port on the remote machine. Using the ssh binary keeps keys, known_hosts, agents and
+// ~/.ssh/config working exactly as for interactive logins.
+type sshConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr *strings.Builder // What ssh reported, to explain failed connections
+	remote sshAddr
+}
+
+// Read implements net.Conn
+func (c *sshConn) Read(p []byte) (int, error) { return c.stdout.Read(p) }
+
+// Write implements net.Conn
+func (c *sshConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+// Close ends the ssh process
+func (c *sshConn) Close() error {
+	c.stdin.Close()
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+	return nil
+}
+
+// LocalAddr implements net.Conn
+func (c *sshConn) LocalAddr() net.Addr { return sshAddr("local") }
+
+// RemoteAddr implements net.Conn
+func (c *sshConn) RemoteAddr() net.Addr { return c.remote }
+
+// SetDeadline implements net.Conn; ssh's ConnectTimeout and ServerAlive options bound the waits instead
+func (c *sshConn) SetDeadline(time.Time) error { return nil }
+
+// SetReadDeadline implements net.Conn
+func (c *sshConn) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline implements net.Conn
+func (c *sshConn) SetWriteDeadline(time.Time) error { return nil }
+
+// sshArgs builds the ssh command line forwarding stdio to the remote build server port
+func sshArgs(target config.SSHServer, timeout time.Duration, defaultPort int) []string {
+	port := target.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	args := []string{
+		"-o", "BatchMode=yes", // Never prompt, a missing key is an error
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", fmt.Sprintf("ConnectTimeout=%d", int(timeout.Seconds()+0.5)),
+		"-o", "ServerAliveInterval=15",
+	}
+	if target.SSHPort != 0 {
+		args = append(args, "-p", strconv.Itoa(target.SSHPort))
+	}
+	if target.IdentityFile != "" {
+		args = append(args, "-i", target.IdentityFile, "-o", "IdentitiesOnly=yes")
+	}
+	for _, option := range target.Options {
+		args = append(args, "-o", option)
+	}
+	return append(args, "-W", net.JoinHostPort("localhost", strconv.Itoa(port)), target.Host)
+}
+
+// dialSSH starts ssh and returns its forwarded stdio as a connection
+func dialSSH(target config.SSHServer, timeout time.Duration, defaultPort int) (net.Conn, error) {
+	cmd := exec.Command("ssh", sshArgs(target, timeout, defaultPort)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %v", err)
+	}
+	return &sshConn{cmd: cmd, stdin: stdin, stdout: stdout, stderr: stderr, remote: sshAddr("ssh://" + target.Host)}, nil
+}
+
+// connectSSH keeps a connection to a server reached through SSH, reconnecting when it ends
+func (c *Coordinator) connectSSH(target config.SSHServer) {
+	for {
+		if err := c.openSSHServer(target); err != nil {
+			logging.Infof("SSH connection to %s failed: %v", target.Host, err)
+		}
+		time.Sleep(transport.ReverseRetryInterval)
+	}
+}
+
+// openSSHServer performs the handshake through ssh and manages the connection until it ends
+func (c *Coordinator) openSSHServer(target config.SSHServer) error {
+	tunnel, err := dialSSH(target, c.config.Client.Discovery.ConnectTimeout, c.config.Server.Port)
+	if err != nil {
+		return err
+	}
+
+	// TLS still applies end to end when the server requires it; ssh forwards to localhost,
+	// which server certificates include
+	conn := net.Conn(tunnel)
+	if c.config.TLS.Enabled {
+		tlsConfig, err := transport.ClientTLSConfig(c.config.TLS, "localhost")
+		if err != nil {
+			tunnel.Close()
+			return err
+		}
+		conn = tls.Client(tunnel, tlsConfig)
+	}
+	conn = logging.TraceConn(conn, "ssh:"+target.Host)
+
+	var serverInfo protocol.ServerInfo
+	if err := json.NewDecoder(conn).Decode(&serverInfo); err != nil || !strings.HasPrefix(serverInfo.ID, "server-") {
+		conn.Close()
+		if reason := strings.Join(strings.Fields(tunnel.(*sshConn).stderr.String()), " "); reason != "" {
+			return fmt.Errorf("no build server handshake: %s", reason)
+		}
+		return fmt.Errorf("no build server handshake")
+	}
+
+	addr := net.JoinHostPort(serverInfo.Address, strconv.Itoa(serverInfo.Port))
+	c.serversMux.RLock()
+	_, exists := c.servers[addr]
+	c.serversMux.RUnlock()
+	if exists {
+		conn.Close()
+		return nil
+	}
+
+	logging.Infof("Build server %s connected through SSH to %s (capacity: %d, version: %s)", serverInfo.ID, target.Host, serverInfo.Capacity, serverInfo.Version)
+	c.handleServerConnection(conn, serverInfo, addr)
+	return nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// statsdTag is a dimension of a metric
+type statsdTag struct {
+	name, value string
+}
+
+// StatsD sends metrics to a StatsD agent; sending never blocks or fails a build
+type StatsD struct {
+	config config.StatsDConfig
+	conn   net.Conn
+	tags   string // Global tags in DogStatsD syntax, sorted
+}
+
+// NewStatsD connects to the configured agent (nil when disabled)
+func NewStatsD(cfg config.StatsDConfig) *StatsD {
+	if cfg.Host == "" {
+		return nil
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		logging.Infof("Warning: StatsD metrics disabled, failed to reach %s: %v", addr, err)
+		return nil
+	}
+
+	tags := make([]string, 0, len(cfg.Tags))
+	for name, value := range cfg.Tags {
+		tags = append(tags, name+":"+value)
+	}
+	sort.Strings(tags)
+	logging.Infof("Sending StatsD metrics to %s", addr)
+	return &StatsD{config: cfg, conn: conn, tags: strings.Join(tags, ",")}
+}
+
+// send writes one metric of a type (c, ms or g)
+func (s *StatsD) send(name string, value int64, kind string, tags ...statsdTag) {
+	line := s.config.Prefix + name
+	var tagList []string
+	if s.tags != "" {
+		tagList = append(tagList, s.tags)
+	}
+	for _, tag := range tags {
+		if s.config.DogStatsD {
+			tagList = append(tagList, tag.name+":"+tag.value)
+		} else {
+			line += "." + statsdName(tag.value)
+		}
+	}
+	line += ":" + strconv.FormatInt(value, 10) + "|" + kind
+	if len(tagList) > 0 {
+		line += "|#" + strings.Join(tagList, ",")
+	}
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		logging.Debugf("Warning: Failed to send StatsD metric %s: %v", name, err)
+	}
+}
+
+// statsdName makes a tag value usable as a metric name segment
+func statsdName(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == ':' || r == '|' || r == '@' || r == '#' || r == ' ' || r == '/' {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// handle counts builds and records their durations and transfers
+func (s *StatsD) handle(event BusEvent) {
+	env := statsdTag{"environment", event.Environment}
+	switch event.Type {
+	case BusBuildSubmitted:
+		s.send("builds.submitted", 1, "c", env)
+	case BusBuildFinished:
+		record := event.Record
+		result := statsdTag{"result", "success"}
+		if !record.Success {
+			result.value = "failure"
+		}
+		s.send("builds.finished", 1, "c", env, result)
+		s.send("build.duration", record.Duration.Milliseconds(), "ms", env)
+		if record.QueueWait > 0 {
+			s.send("build.queue_wait", record.QueueWait.Milliseconds(), "ms", env)
+		}
+
+		var artifactBytes int64
+		for _, artifact := range record.Artifacts {
+			artifactBytes += artifact.Size
+		}
+		s.send("transfer.uploaded_bytes", record.UploadedBytes, "c", env)
+		s.send("transfer.artifact_bytes", artifactBytes, "c", env)
+	}
+}
+
+// reportGauges sends the running builds and the connected and busy servers every interval
+func (c *Coordinator) reportGauges() {
+	ticker := time.NewTicker(c.statsd.config.Interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.activeMux.RLock()
+		running := len(c.activeBuilds)
+		c.activeMux.RUnlock()
+
+		servers := c.GetServerStatus()
+		busy := 0
+		for _, server := range servers {
+			if !server.Available {
+				busy++
+			}
+		}
+
+		c.statsd.send("builds.running", int64(running), "g")
+		c.statsd.send("servers.connected", int64(len(servers)), "g")
+		c.statsd.send("servers.busy", int64(busy), "g")
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// buildSteps turns the commands of an environment into the steps of a build request
+func buildSteps(steps []config.CommandStep, shell bool, parameters map[string]string) []protocol.BuildStep {
+	if len(steps) == 0 {
+		return nil
+	}
+	requestSteps := make([]protocol.BuildStep, len(steps))
+	for i, step := range steps {
+		requestSteps[i] = protocol.BuildStep{
+			Name:            step.Name,
+			Command:         expandParameters(step.Command, parameters),
+			Args:            expandArgs(step.Args, parameters),
+			Shell:           step.Shell || shell,
+			Dir:             step.Dir,
+			EnvVars:         step.EnvVars,
+			Timeout:         step.Timeout,
+			ContinueOnError: step.ContinueOnError,
+			Platforms:       step.Platforms,
+		}
+	}
+	return requestSteps
+}
+
+// stepSummaries returns step results without their output, which the build log holds already
+func stepSummaries(results []protocol.StepResult) []protocol.StepResult {
+	if len(results) == 0 {
+		return nil
+	}
+	summaries := make([]protocol.StepResult, len(results))
+	for i, result := range results {
+		result.Output = ""
+		summaries[i] = result
+	}
+	return summaries
+}
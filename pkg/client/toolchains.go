@@ -0,0 +1,95 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// EnvironmentAvailability tells whether one connected server can run an environment
+type EnvironmentAvailability struct {
+	ServerID  string `json:"server_id,omitempty"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`       // Why not
+	Tool      string `json:"tool,omitempty"`         // Executable the command starts
+	Version   string `json:"tool_version,omitempty"` // As reported by the server
+}
+
+// commandTool returns the executable a build command starts, e.g. "go" for "go build ./..."
+func commandTool(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(fields[0]), ".exe")
+}
+
+// checkToolchainSupport reports why a server cannot run an environment: it declares its own
+// environments without this configured one, or the command's tool is one servers probe but is
+// missing there. Servers that advertise nothing are assumed to support every environment, and
+// plugin-provided environments are never declared by servers.
+func (c *Coordinator) checkToolchainSupport(info protocol.ServerInfo, request protocol.BuildRequest) error {
+	if _, configured := c.config.Build.Environments[request.Environment]; configured && len(info.Environments) > 0 {
+		if _, exists := info.Environments[request.Environment]; !exists {
+			return fmt.Errorf("environment %s is not configured on the server", request.Environment)
+		}
+	}
+	// Container builds bring their own toolchain
+	tool := requestTool(request)
+	if info.Tools == nil || request.Image != "" || !buildutil.ContainsString(buildutil.ProbedTools(), tool) {
+		return nil
+	}
+	if _, installed := info.Tools[tool]; !installed {
+		return fmt.Errorf("%s is not installed on the server", tool)
+	}
+	return nil
+}
+
+// environmentAvailability returns per connected server whether it can run an environment
+func (c *Coordinator) environmentAvailability(name string, env config.BuildEnvironment) map[string]EnvironmentAvailability {
+	request := protocol.BuildRequest{Environment: name, Command: env.Command, Args: env.Args, Image: env.Image, WSL: env.WSL, Requirements: env.Requirements,
+		Steps: buildSteps(env.Commands, env.Shell, nil), Platforms: platformVariants(env.PlatformCommands, env.Shell, nil)}
+	tool := requestTool(request)
+
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+	availability := make(map[string]EnvironmentAvailability)
+	for _, server := range c.servers {
+		info := server.snapshot()
+		addr := net.JoinHostPort(info.Address, strconv.Itoa(info.Port))
+		entry := EnvironmentAvailability{ServerID: info.ID, Available: true, Tool: tool, Version: info.Tools[tool]}
+		if err := c.checkServerSupport(info, request); err != nil {
+			entry.Available, entry.Reason = false, err.Error()
+		}
+		availability[addr] = entry
+	}
+	return availability
+}
+
+// serverOnlyEnvironments returns the environments servers advertise that this client does not
+// define, with the servers declaring them. They are listed but cannot be built from here.
+func (c *Coordinator) serverOnlyEnvironments(known map[string]config.BuildEnvironment) map[string]map[string]protocol.AdvertisedEnvironment {
+	c.serversMux.RLock()
+	defer c.serversMux.RUnlock()
+	envs := make(map[string]map[string]protocol.AdvertisedEnvironment)
+	for _, server := range c.servers {
+		info := server.snapshot()
+		addr := net.JoinHostPort(info.Address, strconv.Itoa(info.Port))
+		for name, env := range info.Environments {
+			if _, exists := known[name]; exists {
+				continue
+			}
+			if envs[name] == nil {
+				envs[name] = make(map[string]protocol.AdvertisedEnvironment)
+			}
+			envs[name][addr] = env
+		}
+	}
+	return envs
+}
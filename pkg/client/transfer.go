@@ -0,0 +1,114 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// readSourceRoots reads the files of all source roots into one map keyed by remote workspace path,
+// reporting left out files and directories to skipped (may be nil)
+func (c *Coordinator) readSourceRoots(roots []config.SourceRoot, transfer config.TransferConfig, skipped func(relPath, reason string)) (map[string]string, error) {
+	if len(roots) == 1 && roots[0].Target == "" {
+		return c.readProjectFiles(roots[0].Path, transfer, skipped)
+	}
+
+	files := make(map[string]string)
+	for _, root := range roots {
+		prefix := rootTarget(root)
+		var rootSkipped func(relPath, reason string)
+		if skipped != nil {
+			rootSkipped = func(relPath, reason string) {
+				remotePath := path.Join(prefix, relPath)
+				if strings.HasSuffix(relPath, "/") {
+					remotePath += "/"
+				}
+				skipped(remotePath, reason)
+			}
+		}
+		rootFiles, err := c.readProjectFiles(root.Path, transfer, rootSkipped)
+		if err != nil {
+			return nil, fmt.Errorf("source root %s: %v", root.Path, err)
+		}
+
+		for relPath, content := range rootFiles {
+			remotePath := path.Join(prefix, relPath)
+			if _, exists := files[remotePath]; exists {
+				logging.Debugf("Warning: %s from %s overrides a file of another source root", remotePath, root.Path)
+			}
+			files[remotePath] = content
+		}
+	}
+	return files, nil
+}
+
+// rootTarget returns the normalized workspace location of a source root
+func rootTarget(root config.SourceRoot) string {
+	target := path.Clean(filepath.ToSlash(root.Target))
+	if target == "." || target == "/" {
+		return ""
+	}
+	return strings.TrimPrefix(target, "./")
+}
+
+// localArtifactPath maps a workspace relative artifact path back to the local source root it belongs to
+func localArtifactPath(roots []config.SourceRoot, relPath string) string {
+	relPath = strings.TrimPrefix(filepath.ToSlash(relPath), "./")
+
+	// The root with the longest matching target owns the artifact
+	best := -1
+	bestLen := -1
+	for i, root := range roots {
+		target := rootTarget(root)
+		if target == "" || relPath == target || strings.HasPrefix(relPath, target+"/") {
+			if len(target) > bestLen {
+				best = i
+				bestLen = len(target)
+			}
+		}
+	}
+
+	if best < 0 {
+		// No root maps to this location, keep it below the first root
+		return filepath.Join(roots[0].Path, filepath.FromSlash(relPath))
+	}
+
+	target := rootTarget(roots[best])
+	local := strings.TrimPrefix(strings.TrimPrefix(relPath, target), "/")
+	return filepath.Join(roots[best].Path, filepath.FromSlash(local))
+}
+
+// resolveSourceRoots returns the source roots to transfer and the execution directory,
+// narrowed to a monorepo sub-project (plus the environment's shared paths) when subpath is set
+func resolveSourceRoots(env *config.BuildEnvironment, projectDir, subpath string) ([]config.SourceRoot, string, error) {
+	if subpath == "" {
+		return env.SourceRoots(projectDir), env.ExecutionDir, nil
+	}
+
+	subpath, ok := config.ProjectRelativePath(subpath)
+	if !ok {
+		return nil, "", fmt.Errorf("subpath %s must be inside the project directory", subpath)
+	}
+	if subpath == "." {
+		return env.SourceRoots(projectDir), env.ExecutionDir, nil
+	}
+	if len(env.ProjectDirs) > 0 {
+		return nil, "", fmt.Errorf("subpath builds are not supported for environments with project_dirs")
+	}
+
+	// Keep repository-relative paths so the sub-project still finds its shared code
+	roots := []config.SourceRoot{{Path: filepath.Join(projectDir, filepath.FromSlash(subpath)), Target: subpath}}
+	for _, shared := range env.SharedPaths {
+		shared, ok := config.ProjectRelativePath(shared)
+		if !ok {
+			return nil, "", fmt.Errorf("shared path %s must be inside the project directory", shared)
+		}
+		roots = append(roots, config.SourceRoot{Path: filepath.Join(projectDir, filepath.FromSlash(shared)), Target: shared})
+	}
+
+	return roots, path.Join(subpath, filepath.ToSlash(env.ExecutionDir)), nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/protocol"
+)
+
+// uploadLimit caps the size of an uploaded build payload
+func (ws *WebServer) uploadLimit() int64 {
+	if ws.config.Build.MaxWorkspaceSize > 0 {
+		return int64(ws.config.Build.MaxWorkspaceSize)
+	}
+	return int64(1 * protocol.GB)
+}
+
+// readUploadedFiles extracts the build files from a request body.
+// The body is either multipart form data (file parts named by their relative path,
+// or a single "archive" part) or a plain tar / tar.gz stream.
+func readUploadedFiles(r *http.Request) (map[string]string, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type: %v", err)
+	}
+
+	if mediaType == "multipart/form-data" {
+		return readMultipartFiles(multipart.NewReader(r.Body, params["boundary"]), r)
+	}
+	return readTarFiles(r.Body)
+}
+
+// readMultipartFiles collects file parts and form values from a multipart upload
+func readMultipartFiles(reader *multipart.Reader, r *http.Request) (map[string]string, error) {
+	files := make(map[string]string)
+	if r.Form == nil {
+		r.Form = make(map[string][]string)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// part.FileName() drops directories, so read the raw disposition
+		_, disposition, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		fileName := disposition["filename"]
+		content, err := io.ReadAll(part)
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case part.FormName() == "archive":
+			archived, err := readTarFiles(bytes.NewReader(content))
+			if err != nil {
+				return nil, err
+			}
+			for name, data := range archived {
+				files[name] = data
+			}
+		case fileName != "":
+			relPath, err := buildutil.CleanUploadPath(fileName)
+			if err != nil {
+				return nil, err
+			}
+			files[relPath] = string(content)
+		default:
+			r.Form.Add(part.FormName(), string(content))
+		}
+	}
+}
+
+// readTarFiles reads regular files from a tar stream, transparently handling gzip
+func readTarFiles(body io.Reader) (map[string]string, error) {
+	buffered := bufio.NewReader(body)
+	var reader io.Reader = buffered
+
+	// gzip streams start with the magic bytes 0x1f 0x8b
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip archive: %v", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	files := make(map[string]string)
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tar archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		relPath, err := buildutil.CleanUploadPath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		files[relPath] = string(content)
+	}
+}
+
+// decompressArtifacts re-encodes output files without compression for external callers
+func decompressArtifacts(response *protocol.BuildResponse) error {
+	if response.Compression == buildutil.CompressionNone {
+		return nil
+	}
+
+	for name, encoded := range response.OutputFiles {
+		content, err := decodeArtifact(encoded, response.Compression)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %v", name, err)
+		}
+		if response.OutputFiles[name], err = buildutil.EncodeArtifact(content, buildutil.CompressionNone); err != nil {
+			return err
+		}
+	}
+	response.Compression = buildutil.CompressionNone
+	return nil
+}
@@ -0,0 +1,327 @@
+package client
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// usageMonthFormat names the monthly ledger files and the month parameter of the usage API
+const usageMonthFormat = "2006-01"
+
+// UsageEntry is the resource consumption of one finished build
+type UsageEntry struct {
+	BuildID       string            `json:"build_id"`
+	Time          time.Time         `json:"time"`
+	User          string            `json:"user,omitempty"`
+	Teams         []string          `json:"teams,omitempty"`
+	Project       string            `json:"project,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"` // Labels of the project
+	Environment   string            `json:"environment"`
+	ServerID      string            `json:"server_id"`
+	Success       bool              `json:"success"`
+	BuildSeconds  float64           `json:"build_seconds"`
+	UploadedBytes int64             `json:"uploaded_bytes"` // Project files sent to the server
+	ArtifactBytes int64             `json:"artifact_bytes"` // Artifacts, provenance and debug bundle returned and kept
+}
+
+// UsageRollup sums the usage of one user, team, label value, project, environment or server
+type UsageRollup struct {
+	Key              string  `json:"key"`
+	Builds           int     `json:"builds"`
+	FailedBuilds     int     `json:"failed_builds"`
+	BuildMinutes     float64 `json:"build_minutes"`
+	UploadedBytes    int64   `json:"uploaded_bytes"`
+	ArtifactBytes    int64   `json:"artifact_bytes"`
+	TransferredBytes int64   `json:"transferred_bytes"` // Uploaded plus artifact bytes
+}
+
+// add counts an entry
+func (r *UsageRollup) add(entry UsageEntry) {
+	r.Builds++
+	if !entry.Success {
+		r.FailedBuilds++
+	}
+	r.BuildMinutes += entry.BuildSeconds / 60
+	r.UploadedBytes += entry.UploadedBytes
+	r.ArtifactBytes += entry.ArtifactBytes
+	r.TransferredBytes += entry.UploadedBytes + entry.ArtifactBytes
+}
+
+// UsageLedger records the usage of every build in one file per month next to the build history
+type UsageLedger struct {
+	dir      string                  // Empty = memory only
+	entries  map[string][]UsageEntry // Per month, only without a directory
+	uploaded map[string]int64        // Bytes uploaded per running build
+	mux      sync.Mutex
+}
+
+// NewUsageLedger creates a ledger in <dir>/usage
+func NewUsageLedger(dir string) *UsageLedger {
+	l := &UsageLedger{entries: make(map[string][]UsageEntry), uploaded: make(map[string]int64)}
+	if dir != "" {
+		l.dir = filepath.Join(dir, "usage")
+		if err := os.MkdirAll(l.dir, 0755); err != nil {
+			logging.Infof("Failed to create usage directory %s, keeping usage in memory: %v", l.dir, err)
+			l.dir = ""
+		}
+	}
+	return l
+}
+
+// AddUpload counts project bytes sent to a server for a build
+func (l *UsageLedger) AddUpload(buildID string, bytes int64) {
+	l.mux.Lock()
+	l.uploaded[buildID] += bytes
+	l.mux.Unlock()
+}
+
+// takeUpload returns and forgets the bytes uploaded for a build and its speculative copy
+func (l *UsageLedger) takeUpload(buildID string) int64 {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	bytes := l.uploaded[buildID] + l.uploaded[backupID(buildID)]
+	delete(l.uploaded, buildID)
+	delete(l.uploaded, backupID(buildID))
+	return bytes
+}
+
+// Record stores the usage of a finished build
+func (l *UsageLedger) Record(entry UsageEntry) {
+	month := entry.Time.Format(usageMonthFormat)
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.dir == "" {
+		l.entries[month] = append(l.entries[month], entry)
+		return
+	}
+	data, _ := json.Marshal(entry)
+	path := filepath.Join(l.dir, month+".jsonl")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Infof("Failed to write usage ledger %s: %v", path, err)
+		return
+	}
+	defer file.Close()
+	file.Write(append(data, '\n'))
+}
+
+// Entries returns the usage recorded in a month ("2006-01")
+func (l *UsageLedger) Entries(month string) ([]UsageEntry, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.dir == "" {
+		return append([]UsageEntry{}, l.entries[month]...), nil
+	}
+	file, err := os.Open(filepath.Join(l.dir, month+".jsonl"))
+	if os.IsNotExist(err) {
+		return []UsageEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []UsageEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), int(1*protocol.MB))
+	for scanner.Scan() {
+		var entry UsageEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Months lists the months with recorded usage, newest first
+func (l *UsageLedger) Months() []string {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	months := []string{}
+	if l.dir == "" {
+		for month := range l.entries {
+			months = append(months, month)
+		}
+	} else {
+		files, _ := filepath.Glob(filepath.Join(l.dir, "*.jsonl"))
+		for _, file := range files {
+			months = append(months, strings.TrimSuffix(filepath.Base(file), ".jsonl"))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(months)))
+	return months
+}
+
+// rollupUsage groups entries by user, team, project, environment, server or "label:<name>".
+// A build of several teams counts for each of them; entries without a value are grouped as "(none)".
+func rollupUsage(entries []UsageEntry, by string) ([]UsageRollup, error) {
+	keys := func(entry UsageEntry) []string {
+		switch {
+		case by == "user":
+			return []string{entry.User}
+		case by == "team":
+			return entry.Teams
+		case by == "project":
+			return []string{entry.Project}
+		case by == "environment":
+			return []string{entry.Environment}
+		case by == "server":
+			return []string{entry.ServerID}
+		case strings.HasPrefix(by, "label:"):
+			return []string{entry.Labels[strings.TrimPrefix(by, "label:")]}
+		}
+		return nil
+	}
+	if by != "user" && by != "team" && by != "project" && by != "environment" && by != "server" && !strings.HasPrefix(by, "label:") {
+		return nil, fmt.Errorf("invalid grouping %q: use user, team, project, environment, server or label:<name>", by)
+	}
+
+	rollups := make(map[string]*UsageRollup)
+	for _, entry := range entries {
+		values := keys(entry)
+		if len(values) == 0 {
+			values = []string{""}
+		}
+		for _, key := range values {
+			if key == "" {
+				key = "(none)"
+			}
+			if rollups[key] == nil {
+				rollups[key] = &UsageRollup{Key: key}
+			}
+			rollups[key].add(entry)
+		}
+	}
+
+	result := make([]UsageRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		result = append(result, *rollup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BuildMinutes != result[j].BuildMinutes {
+			return result[i].BuildMinutes > result[j].BuildMinutes
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// accountBuild records the usage of a finished build, filling in the bytes uploaded for it
+func (c *Coordinator) accountBuild(record *BuildRecord) {
+	record.UploadedBytes = c.usage.takeUpload(record.ID)
+	entry := UsageEntry{
+		BuildID:       record.ID,
+		Time:          record.SubmittedAt,
+		User:          record.User,
+		Teams:         record.Teams,
+		Project:       record.Project,
+		Environment:   record.Environment,
+		ServerID:      record.ServerID,
+		Success:       record.Success,
+		BuildSeconds:  record.Duration.Seconds(),
+		UploadedBytes: record.UploadedBytes,
+	}
+	if project, exists := c.config.Projects[record.Project]; exists {
+		entry.Labels = project.Labels
+	}
+	for _, artifact := range record.Artifacts {
+		entry.ArtifactBytes += artifact.Size
+	}
+	if record.DebugBundle != nil {
+		entry.ArtifactBytes += record.DebugBundle.Size
+	}
+	c.usage.Record(entry)
+}
+
+// requestFileBytes returns the size of the project files of a request
+func requestFileBytes(request protocol.BuildRequest) int64 {
+	var total int64
+	for _, content := range request.Files {
+		total += int64(len(content))
+	}
+	return total
+}
+
+// handleUsageAPI returns the usage of a month (?month=2006-01, default: current) grouped by
+// ?by= (default: user), as JSON or, with ?format=csv, as a chargeback spreadsheet
+func (ws *WebServer) handleUsageAPI(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().Format(usageMonthFormat)
+	}
+	if _, err := time.Parse(usageMonthFormat, month); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid month %q, expected YYYY-MM", month), http.StatusBadRequest)
+		return
+	}
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = "user"
+	}
+
+	entries, err := ws.client.usage.Entries(month)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	rollups, err := rollupUsage(entries, by)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	total := UsageRollup{Key: "total"}
+	for _, entry := range entries {
+		total.add(entry)
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=boltbuild-usage-%s-%s.csv", month, strings.ReplaceAll(by, ":", "-")))
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"month", by, "builds", "failed_builds", "build_minutes", "uploaded_bytes", "artifact_bytes", "transferred_bytes"})
+		for _, rollup := range append(rollups, total) {
+			writer.Write([]string{month, rollup.Key, strconv.Itoa(rollup.Builds), strconv.Itoa(rollup.FailedBuilds),
+				strconv.FormatFloat(rollup.BuildMinutes, 'f', 2, 64), strconv.FormatInt(rollup.UploadedBytes, 10),
+				strconv.FormatInt(rollup.ArtifactBytes, 10), strconv.FormatInt(rollup.TransferredBytes, 10)})
+		}
+		writer.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"month": month, "by": by, "rollups": rollups, "total": total})
+}
+
+// handleUsageMonthsAPI returns the totals of every recorded month (keyed by month), newest first
+func (ws *WebServer) handleUsageMonthsAPI(w http.ResponseWriter, r *http.Request) {
+	months := []UsageRollup{}
+	for _, month := range ws.client.usage.Months() {
+		entries, err := ws.client.usage.Entries(month)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read usage: %v", err), http.StatusInternalServerError)
+			return
+		}
+		total := UsageRollup{Key: month}
+		for _, entry := range entries {
+			total.add(entry)
+		}
+		months = append(months, total)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(months)
+}
@@ -0,0 +1,3077 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+	"github.com/gorilla/mux"
+)
+
+// WebServer provides HTTP interface for the client
+type WebServer struct {
+	client *Coordinator
+	config *config.Config // Configuration of the client
+	port   int
+	auth   *authenticator // nil = anonymous access
+}
+
+// NewWebServer creates a new web server instance
+func NewWebServer(client *Coordinator, port int) *WebServer {
+	return &WebServer{
+		client: client,
+		config: client.config,
+		port:   port,
+		auth:   newAuthenticator(client.config.Web.Auth),
+	}
+}
+
+// Start begins the web server
+func (ws *WebServer) Start() error {
+	r := mux.NewRouter()
+
+	// Static routes
+	r.HandleFunc("/", ws.handleHome).Methods("GET")
+	r.HandleFunc("/stats", ws.handleStatsPage).Methods("GET")
+	r.HandleFunc("/federation", ws.handleFederationPage).Methods("GET")
+	r.HandleFunc("/rpc", ws.handleRPC).Methods("POST")
+	r.HandleFunc("/api", ws.handleAPIVersionsAPI).Methods("GET")
+	if ws.auth != nil && ws.auth.config.Enabled() {
+		r.HandleFunc("/auth/login", ws.auth.handleLogin).Methods("GET")
+		if ws.auth.ldap != nil {
+			r.HandleFunc("/auth/login", ws.auth.handleLDAPLogin).Methods("POST")
+		}
+		if ws.auth.oidc != nil {
+			r.HandleFunc("/auth/oidc", ws.auth.handleOIDCLogin).Methods("GET")
+			r.HandleFunc("/auth/callback", ws.auth.handleCallback).Methods("GET")
+		}
+		r.HandleFunc("/auth/logout", ws.auth.handleLogout).Methods("GET", "POST")
+	}
+	if ws.auth != nil {
+		r.Use(ws.auth.middleware)
+	}
+
+	// Versioned API, with the unversioned /api routes as aliases for the current version
+	for _, version := range apiVersions {
+		api := r.PathPrefix("/api/" + version).Subrouter()
+		api.Use(apiVersionMiddleware(version))
+		ws.registerAPIRoutes(api)
+	}
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(apiVersionMiddleware(currentAPIVersion))
+	ws.registerAPIRoutes(api)
+
+	basePath := ws.config.Web.Prefix()
+	listener, err := ws.listen()
+	if err != nil {
+		return err
+	}
+	scheme := "http"
+	if cfg := ws.config.Web.TLS; cfg.Enabled {
+		tlsConfig, challenges, err := webTLSConfig(cfg, ws.config.TLS)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "https"
+		if cfg.RedirectPort != 0 {
+			go serveHTTPSRedirect(ws.config.Web.BindAddress, cfg.RedirectPort, ws.port, challenges)
+		}
+	}
+	if socket := ws.config.Web.UnixSocket; socket != "" {
+		logging.Infof("Web interface available on Unix socket %s (path %s/)", socket, basePath)
+	} else {
+		host := ws.config.Web.BindAddress
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+		logging.Infof("Web interface available at %s://%s%s/", scheme, net.JoinHostPort(host, strconv.Itoa(ws.port)), basePath)
+	}
+	return http.Serve(listener, corsHandler(ws.config.Web.CORS, mountBasePath(basePath, r)))
+}
+
+// listen opens the web server's listener: the Unix socket if configured, else the TCP port on
+// the bind address (all interfaces by default)
+func (ws *WebServer) listen() (net.Listener, error) {
+	socket := ws.config.Web.UnixSocket
+	if socket == "" {
+		return net.Listen("tcp", net.JoinHostPort(ws.config.Web.BindAddress, strconv.Itoa(ws.port)))
+	}
+
+	// A socket file left behind by an earlier run would make the listen fail
+	if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0660); err != nil {
+		logging.Infof("Warning: Failed to set permissions of %s: %v", socket, err)
+	}
+	return listener, nil
+}
+
+// registerAPIRoutes adds the web API routes below a version prefix
+func (ws *WebServer) registerAPIRoutes(api *mux.Router) {
+	api.HandleFunc("/stats", ws.handleStatsAPI).Methods("GET")
+	api.HandleFunc("/stats/environments", ws.handleEnvironmentStatsAPI).Methods("GET")
+	api.HandleFunc("/servers", ws.handleServersAPI).Methods("GET")
+	api.HandleFunc("/servers/announce", ws.handleServerAnnounceAPI).Methods("POST")
+	api.HandleFunc("/notifications", ws.handleNotificationsAPI).Methods("GET")
+	api.HandleFunc("/notifications/stream", ws.handleNotificationStream).Methods("GET")
+	api.HandleFunc("/estimate", ws.handleEstimateAPI).Methods("GET")
+	api.HandleFunc("/environments", ws.handleEnvironmentsAPI).Methods("GET")
+	api.HandleFunc("/projects", ws.handleProjectsAPI).Methods("GET")
+	api.HandleFunc("/build", ws.handleBuildAPI).Methods("POST")
+	api.HandleFunc("/build/upload", ws.handleBuildUploadAPI).Methods("POST")
+	api.HandleFunc("/build/bundle", ws.handleBundleImportAPI).Methods("POST")
+	api.HandleFunc("/preferences", ws.handlePreferencesAPI).Methods("GET")
+	api.HandleFunc("/preferences", ws.handleSavePreferencesAPI).Methods("PUT")
+	api.HandleFunc("/build/{id}/cancel", ws.handleCancelBuildAPI).Methods("POST")
+	api.HandleFunc("/build/{id}/retry", ws.handleRetryBuildAPI).Methods("POST")
+	api.HandleFunc("/build/{id}/replay", ws.handleReplayBuildAPI).Methods("POST")
+	api.HandleFunc("/version", ws.handleVersionAPI).Methods("GET")
+	api.HandleFunc("/session", ws.handleSessionAPI).Methods("GET")
+	api.HandleFunc("/audit", ws.handleAuditAPI).Methods("GET")
+	api.HandleFunc("/admin/loglevel", ws.handleLogLevelAPI).Methods("GET")
+	api.HandleFunc("/admin/loglevel", ws.handleSetLogLevelAPI).Methods("PUT")
+	api.HandleFunc("/quota", ws.handleQuotaAPI).Methods("GET")
+	api.HandleFunc("/usage", ws.handleUsageAPI).Methods("GET")
+	api.HandleFunc("/usage/months", ws.handleUsageMonthsAPI).Methods("GET")
+	api.HandleFunc("/federation", ws.handleFederationAPI).Methods("GET")
+	api.HandleFunc("/detect", ws.handleDetectAPI).Methods("POST")
+	api.HandleFunc("/subprojects", ws.handleSubprojectsAPI).Methods("GET")
+	api.HandleFunc("/fs", ws.handleFSAPI).Methods("GET")
+	api.HandleFunc("/builds", ws.handleBuildsAPI).Methods("GET")
+	api.HandleFunc("/builds/export", ws.handleBuildsExportAPI).Methods("GET") // Before {id}
+	api.HandleFunc("/builds/running", ws.handleRunningBuildsAPI).Methods("GET")
+	api.HandleFunc("/builds/diff", ws.handleBuildDiffAPI).Methods("GET")
+	api.HandleFunc("/builds/{id}", ws.handleBuildRecordAPI).Methods("GET")
+	api.HandleFunc("/builds/{id}/log", ws.handleBuildLogAPI).Methods("GET")
+	api.HandleFunc("/builds/{id}/export", ws.handleBundleExportAPI).Methods("GET")
+	api.HandleFunc("/builds/{id}/workspace", ws.handleBuildWorkspaceAPI).Methods("GET")
+	api.HandleFunc("/builds/{id}/bundle", ws.handleBuildBundleAPI).Methods("GET")
+	api.HandleFunc("/builds/{id}/artifact", ws.handleBuildArtifactAPI).Methods("GET")
+}
+
+// handleHome serves the main dashboard
+func (ws *WebServer) handleHome(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <link rel="preconnect" href="https://fonts.googleapis.com">
+    <link rel="preconnect" href="https://fonts.gstatic.com" crossorigin>
+    <link href="https://fonts.googleapis.com/css2?family=Montserrat:ital,wght@0,100..900;1,100..900&display=swap" rel="stylesheet">
+    <title>BoltBuild - Client Dashboard</title>
+    <style>
+        * {
+            margin: 0;
+            padding: 0;
+            box-sizing: border-box;
+        }
+        
+        body {
+            font-family: 'Inter', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            background: #031C26;
+            color: #A4FFF0;
+            min-height: 100vh;
+            padding: 20px;
+        }
+        
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+        }
+        
+        .header {
+            text-align: center;
+            padding: 30px 0;
+            margin-bottom: 40px;
+        }
+        
+        .header .logo {
+            width: 200px;
+            height: auto;
+            margin-bottom: 20px;
+            filter: drop-shadow(0 4px 8px rgba(164, 255, 240, 0.3));
+        }
+        
+        .header h1 {
+            font-family: "Montserrat", Inter;
+            letter-spacing: -3px;
+            color: #A4FFF0;
+            font-size: 3rem;
+            font-weight: 700;
+            text-shadow: 0 0 6px #a4fff09e;
+        }
+        
+        .header h1 span {
+            color: #fff;
+            font-size: 3rem;
+            font-weight: 300;
+            margin-bottom: 10px;
+            text-shadow: 0 2px 4px rgba(164, 255, 240, 0.3);
+        }
+
+        
+        .header p {
+            color: rgba(164, 255, 240, 0.8);
+            font-weight: 300;
+        }
+        
+        .dashboard-grid {
+            display: grid;
+            grid-template-columns: 1fr 1fr;
+            gap: 30px;
+            margin-bottom: 30px;
+        }
+        
+        @media (max-width: 768px) {
+            .dashboard-grid {
+                grid-template-columns: 1fr;
+            }
+        }
+        
+        .card {
+            background: rgba(164, 255, 240, 0.05);
+            backdrop-filter: blur(10px);
+            padding: 30px;
+            border-radius: 20px;
+            box-shadow: 0 20px 40px rgba(0,0,0,0.3);
+            border: 1px solid rgba(164, 255, 240, 0.2);
+        }
+        
+        .card h2 {
+            color: #A4FFF0;
+            font-size: 1.5rem;
+            font-weight: 600;
+            margin-bottom: 20px;
+            display: flex;
+            align-items: center;
+            gap: 10px;
+        }
+        
+        .servers-grid {
+            display: grid;
+            grid-template-columns: repeat(auto-fill, minmax(280px, 1fr));
+            gap: 20px;
+        }
+        
+        .server-card {
+            background: rgba(164, 255, 240, 0.08);
+            padding: 20px;
+            border-radius: 15px;
+            box-shadow: 0 8px 25px rgba(0,0,0,0.2);
+            border: 2px solid rgba(164, 255, 240, 0.2);
+            transition: all 0.3s ease;
+            position: relative;
+            overflow: hidden;
+            cursor: pointer;
+        }
+        
+        .server-card.selected {
+            border-color: #A4FFF0;
+            box-shadow: 0 8px 20px rgba(164, 255, 240, 0.2);
+            transform: translateY(-3px);
+        }
+        
+        .server-card.selected::before {
+            background: linear-gradient(90deg, #A4FFF0 0%, #7BFFF0 100%);
+        }
+        
+        .server-card::before {
+            content: '';
+            position: absolute;
+            top: 0;
+            left: 0;
+            right: 0;
+            height: 4px;
+            background: linear-gradient(90deg, #A4FFF0 0%, #7BFFF0 100%);
+        }
+        
+        .server-available {
+            border-color: rgba(164, 255, 240, 0.3);
+            background: rgba(164, 255, 240, 0.05);
+        }
+        
+        .server-available::before {
+            background: linear-gradient(90deg, rgba(164, 255, 240, 0.6) 0%, rgba(123, 255, 240, 0.6) 100%);
+        }
+        
+        .server-busy {
+            border-color: #f56565;
+        }
+        
+        .server-busy::before {
+            background: linear-gradient(90deg, #f56565 0%, #e53e3e 100%);
+        }
+        
+        .server-card:hover {
+            transform: translateY(-5px);
+            box-shadow: 0 15px 35px rgba(164, 255, 240, 0.2);
+        }
+        
+        .server-header {
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+            margin-bottom: 15px;
+        }
+        
+        .server-id {
+            font-weight: 600;
+            color: #A4FFF0;
+            font-size: 1.1rem;
+        }
+        
+        .server-status {
+            padding: 6px 12px;
+            border-radius: 20px;
+            font-size: 0.85rem;
+            font-weight: 600;
+            text-transform: uppercase;
+            letter-spacing: 0.5px;
+        }
+        
+        .status-available {
+            background: rgba(164, 255, 240, 0.1);
+            color: rgba(164, 255, 240, 0.9);
+        }
+        
+        .status-busy {
+            background: #fed7d7;
+            color: #742a2a;
+        }
+        
+        .version-mismatch {
+            border: 2px solid #ff6b6b !important;
+            background: rgba(255, 107, 107, 0.05) !important;
+        }
+
+        .version-mismatch::before {
+            background: #ff6b6b !important;
+        }
+        
+        .version-mismatch:hover {
+            border-color: #ff6b6b !important;
+            background: rgba(255, 107, 107, 0.1) !important;
+        }
+        
+        .server-info {
+            color: rgba(164, 255, 240, 0.7);
+            font-size: 0.9rem;
+            line-height: 1.5;
+        }
+        
+        .form-group {
+            margin-bottom: 20px;
+        }
+        
+        .form-group label {
+            display: block;
+            margin-bottom: 8px;
+            font-weight: 600;
+            color: #A4FFF0;
+        }
+        
+        .form-control {
+            width: 100%;
+            padding: 12px 16px;
+            border: 2px solid rgba(164, 255, 240, 0.3);
+            border-radius: 10px;
+            font-size: 1rem;
+            transition: all 0.3s ease;
+            background: rgba(164, 255, 240, 0.05);
+            color: #A4FFF0;
+        }
+        
+        .form-control:focus {
+            outline: none;
+            border-color: #A4FFF0;
+            box-shadow: 0 0 0 3px rgba(164, 255, 240, 0.2);
+        }
+        
+        .form-control option {
+            background: #031C26;
+            color: #A4FFF0;
+        }
+        
+        .btn {
+            background: linear-gradient(135deg, #A4FFF0 0%, #7BFFF0 100%);
+            color: #031C26;
+            padding: 14px 28px;
+            border: none;
+            border-radius: 10px;
+            font-size: 1rem;
+            font-weight: 600;
+            cursor: pointer;
+            transition: all 0.3s ease;
+            text-transform: uppercase;
+            letter-spacing: 0.5px;
+        }
+        
+        .btn:hover {
+            transform: translateY(-2px);
+            box-shadow: 0 10px 25px rgba(164, 255, 240, 0.4);
+        }
+        
+        .btn:active {
+            transform: translateY(0);
+        }
+        
+        .result {
+            margin-top: 25px;
+            padding: 20px;
+            border-radius: 10px;
+            font-size: 0.95rem;
+            line-height: 1.6;
+        }
+        
+        .result-success {
+            background: rgba(164, 255, 240, 0.1);
+            border: 2px solid #A4FFF0;
+            color: #A4FFF0;
+        }
+        
+        .result-error {
+            background: rgba(245, 101, 101, 0.1);
+            border: 2px solid #f56565;
+            color: #A4FFF0;
+        }
+        
+        .loading {
+            display: inline-block;
+            width: 20px;
+            height: 20px;
+            border: 3px solid rgba(164, 255, 240, 0.3);
+            border-top: 3px solid #A4FFF0;
+            border-radius: 50%;
+            animation: spin 1s linear infinite;
+        }
+        
+        @keyframes spin {
+            0% { transform: rotate(0deg); }
+            100% { transform: rotate(360deg); }
+        }
+        
+        .stats {
+            display: grid;
+            grid-template-columns: repeat(auto-fit, minmax(120px, 1fr));
+            gap: 15px;
+            margin-bottom: 20px;
+        }
+        
+        .stat-item {
+            text-align: center;
+            padding: 15px;
+            background: rgba(164, 255, 240, 0.08);
+            border-radius: 10px;
+            border: 1px solid rgba(164, 255, 240, 0.2);
+        }
+        
+        .stat-number {
+            font-size: 2rem;
+            font-weight: 700;
+            color: #A4FFF0;
+        }
+        
+        .stat-label {
+            font-size: 0.85rem;
+            color: rgba(164, 255, 240, 0.7);
+            text-transform: uppercase;
+            letter-spacing: 0.5px;
+        }
+        
+        /* Modal styles */
+        .modal {
+            display: none;
+            position: fixed;
+            z-index: 1000;
+            left: 0;
+            top: 0;
+            width: 100%;
+            height: 100%;
+            background-color: rgba(0, 0, 0, 0.8);
+            backdrop-filter: blur(5px);
+        }
+        
+        .modal-content {
+            background: #031C26;
+            margin: 5% auto;
+            padding: 0;
+            border-radius: 20px;
+            width: 90%;
+            max-width: 1000px;
+            max-height: 80vh;
+            box-shadow: 0 25px 50px rgba(0, 0, 0, 0.5);
+            border: 2px solid rgba(164, 255, 240, 0.3);
+            overflow: hidden;
+        }
+        
+        .modal-header {
+            background: linear-gradient(135deg, rgba(164, 255, 240, 0.1) 0%, rgba(123, 255, 240, 0.1) 100%);
+            padding: 20px 30px;
+            border-bottom: 1px solid rgba(164, 255, 240, 0.2);
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+        }
+        
+        .modal-title {
+            color: #A4FFF0;
+            font-size: 1.5rem;
+            font-weight: 600;
+            margin: 0;
+        }
+        
+        .close {
+            background: none;
+            border: none;
+            color: #A4FFF0;
+            font-size: 2rem;
+            font-weight: bold;
+            cursor: pointer;
+            padding: 0;
+            width: 30px;
+            height: 30px;
+            border-radius: 50%;
+            display: flex;
+            align-items: center;
+            justify-content: center;
+            transition: all 0.3s ease;
+        }
+        
+        .close:hover {
+            background: rgba(164, 255, 240, 0.1);
+            transform: scale(1.1);
+        }
+        
+        .modal-body {
+            padding: 30px;
+            max-height: 60vh;
+            overflow-y: auto;
+        }
+        
+        .output-content {
+            background: rgba(164, 255, 240, 0.05);
+            padding: 20px;
+            border-radius: 10px;
+            border: 1px solid rgba(164, 255, 240, 0.2);
+            font-family: 'Courier New', monospace;
+            font-size: 0.9rem;
+            line-height: 1.4;
+            color: #A4FFF0;
+            white-space: pre-wrap;
+            word-break: break-word;
+            max-height: 50vh;
+            overflow-y: auto;
+        }
+        
+        .output-content::-webkit-scrollbar {
+            width: 8px;
+        }
+        
+        .output-content::-webkit-scrollbar-track {
+            background: rgba(164, 255, 240, 0.1);
+            border-radius: 4px;
+        }
+        
+        .output-content::-webkit-scrollbar-thumb {
+            background: rgba(164, 255, 240, 0.3);
+            border-radius: 4px;
+        }
+        
+        .output-content::-webkit-scrollbar-thumb:hover {
+            background: rgba(164, 255, 240, 0.5);
+        }
+        
+        .log-toolbar {
+            display: none;
+            gap: 10px;
+            align-items: center;
+            margin-bottom: 10px;
+            color: rgba(164, 255, 240, 0.7);
+        }
+        
+        .log-line {
+            display: flex;
+        }
+        
+        .log-number {
+            flex: 0 0 auto;
+            min-width: 3.5em;
+            padding-right: 1em;
+            text-align: right;
+            color: rgba(164, 255, 240, 0.35);
+            user-select: none;
+        }
+        
+        .log-section {
+            cursor: pointer;
+            color: #ffd166;
+        }
+        
+        .output-content mark {
+            background: #ffd166;
+            color: #1a1a2e;
+        }
+        
+        .btn-view-output {
+            background: linear-gradient(135deg, rgba(164, 255, 240, 0.2) 0%, rgba(123, 255, 240, 0.2) 100%);
+            color: #A4FFF0;
+            padding: 8px 16px;
+            border: 1px solid rgba(164, 255, 240, 0.3);
+            border-radius: 8px;
+            font-size: 0.9rem;
+            font-weight: 500;
+            cursor: pointer;
+            transition: all 0.3s ease;
+            margin-top: 10px;
+            display: inline-block;
+            text-decoration: none;
+        }
+        
+        .history-list {
+            display: flex;
+            flex-direction: column;
+            gap: 10px;
+        }
+        
+        .history-item {
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+            gap: 15px;
+            padding: 12px 16px;
+            border-radius: 10px;
+            background: rgba(164, 255, 240, 0.05);
+            border-left: 4px solid rgba(164, 255, 240, 0.6);
+            font-size: 0.9rem;
+        }
+        
+        .history-item.history-failed {
+            border-left-color: #f56565;
+        }
+        
+        .history-item .btn-view-output {
+            margin-top: 0;
+        }
+        
+        .quick-launch {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 6px;
+            margin-bottom: 15px;
+        }
+        
+        .quick-launch .btn-view-output {
+            margin-top: 0;
+            padding: 6px 10px;
+        }
+        
+        .browse-row {
+            display: flex;
+            gap: 10px;
+        }
+        
+        .browse-row .btn-view-output {
+            margin-top: 0;
+            white-space: nowrap;
+        }
+        
+        .browse-entry {
+            padding: 8px 12px;
+            border-radius: 6px;
+            cursor: pointer;
+            color: #A4FFF0;
+        }
+        
+        .browse-entry:hover {
+            background: rgba(164, 255, 240, 0.1);
+        }
+        
+        .btn-view-output:hover {
+            background: linear-gradient(135deg, rgba(164, 255, 240, 0.3) 0%, rgba(123, 255, 240, 0.3) 100%);
+            border-color: #A4FFF0;
+            transform: translateY(-1px);
+        }
+        
+        .notification-bell {
+            position: fixed;
+            top: 20px;
+            right: 24px;
+            z-index: 900;
+            background: rgba(164, 255, 240, 0.1);
+            border: 1px solid rgba(164, 255, 240, 0.3);
+            border-radius: 50%;
+            width: 44px;
+            height: 44px;
+            font-size: 1.2rem;
+            cursor: pointer;
+        }
+        
+        .notification-badge {
+            position: absolute;
+            top: -4px;
+            right: -4px;
+            background: #fc8181;
+            color: #1a202c;
+            border-radius: 10px;
+            padding: 1px 6px;
+            font-size: 0.7rem;
+            font-weight: 700;
+        }
+        
+        .notification-panel {
+            display: none;
+            position: fixed;
+            top: 72px;
+            right: 24px;
+            z-index: 900;
+            width: 360px;
+            max-height: 60vh;
+            overflow-y: auto;
+            background: #1a202c;
+            border: 1px solid rgba(164, 255, 240, 0.3);
+            border-radius: 10px;
+            padding: 10px;
+        }
+        
+        .notification-item, .toast {
+            border-left: 3px solid #A4FFF0;
+            padding: 8px 10px;
+            margin-bottom: 6px;
+            color: #e2e8f0;
+            font-size: 0.85rem;
+        }
+        
+        .notification-item.success, .toast.success { border-left-color: #68d391; }
+        .notification-item.warning, .toast.warning { border-left-color: #f6e05e; }
+        .notification-item.error, .toast.error { border-left-color: #fc8181; }
+        
+        .notification-item.unread {
+            background: rgba(164, 255, 240, 0.08);
+        }
+        
+        .notification-time {
+            color: rgba(164, 255, 240, 0.5);
+            font-size: 0.75rem;
+        }
+        
+        .toast-container {
+            position: fixed;
+            bottom: 20px;
+            right: 24px;
+            z-index: 1100;
+            width: 320px;
+        }
+        
+        .toast {
+            background: #1a202c;
+            border: 1px solid rgba(164, 255, 240, 0.3);
+            border-left-width: 3px;
+            border-radius: 8px;
+            box-shadow: 0 4px 12px rgba(0, 0, 0, 0.4);
+            cursor: pointer;
+        }
+
+    </style>
+</head>
+<body>
+    <button class="notification-bell" id="notification-bell" title="Notifications">🔔<span class="notification-badge" id="notification-badge" style="display: none;"></span></button>
+    <div class="notification-panel" id="notification-panel"></div>
+    <div class="toast-container" id="toast-container"></div>
+    <div class="container">
+        <div class="header">
+            <h1>bolt<span>build</span></h1>
+            <p>Remote Build System</p>
+            <p style="margin-top: 5px; font-size: 0.9rem; color: rgba(164, 255, 240, 0.6);">Client Version: <span id="client-version">Loading...</span> · <a href="stats" style="color: #A4FFF0;">📈 Analytics</a><span id="federation-link"></span><span id="session-info"></span></p>
+        </div>
+        
+        <div class="dashboard-grid">
+            <div class="card">
+                <h2>📊 Build Servers Status</h2>
+                <div class="stats" id="server-stats">
+                    <div class="stat-item">
+                        <div class="stat-number" id="total-servers">0</div>
+                        <div class="stat-label">Total Servers</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-number" id="available-servers">0</div>
+                        <div class="stat-label">Available</div>
+                    </div>
+                    <div class="stat-item">
+                        <div class="stat-number" id="busy-servers">0</div>
+                        <div class="stat-label">Busy</div>
+                    </div>
+                </div>
+                <div id="servers-container" class="servers-grid">
+                    <div style="text-align: center; padding: 40px; color: #718096;">
+                        <div class="loading"></div>
+                        <p style="margin-top: 15px;">Loading servers...</p>
+                    </div>
+                </div>
+            </div>
+            
+            <div class="card">
+                <h2>🔨 Submit Build Request</h2>
+                <div id="quick-launch" class="quick-launch"></div>
+                <form id="build-form">
+                    <div class="form-group">
+                        <label for="selected-server">Selected Server:</label>
+                        <div id="selected-server" class="form-control" style="color: rgba(164, 255, 240, 0.7); font-style: italic;">No server selected - Click on a server to select</div>
+                    </div>
+                    <div class="form-group">
+                        <label for="project">Project:</label>
+                        <select id="project" name="project" class="form-control">
+                            <option value="">No project (environment directory)</option>
+                        </select>
+                    </div>
+                    <div class="form-group">
+                        <label for="environment">Build Environment:</label>
+                        <select id="environment" name="environment" class="form-control" required>
+                            <option value="">Loading environments...</option>
+                        </select>
+                    </div>
+                    <div id="parameter-fields"></div>
+                    <div class="form-group">
+                        <label for="project-dir">Directory (optional):</label>
+                        <div class="browse-row">
+                            <input id="project-dir" name="project_dir" class="form-control" placeholder="Project or environment directory">
+                            <button type="button" class="btn-view-output" onclick="browseDirectory(document.getElementById('project-dir').value)">📁 Browse</button>
+                        </div>
+                    </div>
+                    <div class="form-group">
+                        <label for="subpath">Sub-project (optional):</label>
+                        <input id="subpath" name="subpath" class="form-control" list="subproject-list" placeholder="Whole project">
+                        <datalist id="subproject-list"></datalist>
+                    </div>
+                    <button type="submit" class="btn">🚀 Start Build</button>
+                </form>
+                <div id="build-result"></div>
+            </div>
+        </div>
+        
+        <div class="card" style="margin-bottom: 30px;">
+            <h2>📈 Environment Statistics</h2>
+            <div id="environment-stats">
+                <p style="color: rgba(164, 255, 240, 0.7);">No builds yet</p>
+            </div>
+        </div>
+        
+        <div class="card" id="quota-card" style="margin-bottom: 30px; display: none;">
+            <h2>🎟️ Build Quota</h2>
+            <div id="quota-container"></div>
+        </div>
+        
+        <div class="card" style="margin-bottom: 30px;">
+            <h2>⏳ Running Builds</h2>
+            <div id="running-container" class="history-list">
+                <p style="color: rgba(164, 255, 240, 0.7);">No builds running</p>
+            </div>
+        </div>
+        
+        <div class="card">
+            <h2>📜 Recent Builds</h2>
+            <div id="history-container" class="history-list">
+                <p style="color: rgba(164, 255, 240, 0.7);">No builds yet</p>
+            </div>
+        </div>
+    </div>
+    
+    <!-- Modal for viewing build output -->
+    <div id="outputModal" class="modal">
+        <div class="modal-content">
+            <div class="modal-header">
+                <h2 class="modal-title" id="modalTitle">Build Output</h2>
+                <button class="close" onclick="closeOutputModal()">&times;</button>
+            </div>
+            <div class="modal-body">
+                <div id="logToolbar" class="log-toolbar">
+                    <input type="text" id="logFilter" placeholder="Filter lines…" style="flex: 1;">
+                    <span id="logInfo"></span>
+                    <button type="button" id="logEarlier" class="btn-view-output">⬆ Earlier lines</button>
+                </div>
+                <div id="modalOutput" class="output-content"></div>
+            </div>
+        </div>
+    </div>
+    
+    <!-- Modal for choosing a project directory -->
+    <div id="browseModal" class="modal">
+        <div class="modal-content">
+            <div class="modal-header">
+                <h2 class="modal-title" id="browseTitle">Choose Directory</h2>
+                <button class="close" onclick="closeBrowseModal()">&times;</button>
+            </div>
+            <div class="modal-body">
+                <div id="browseKinds" style="color: rgba(164, 255, 240, 0.7); margin-bottom: 10px;"></div>
+                <div id="browseEntries"></div>
+                <button type="button" id="browseUse" class="btn" style="margin-top: 15px;">Use this directory</button>
+            </div>
+        </div>
+    </div>
+    
+    <script>
+        let selectedServer = null;
+        
+        // Modal functions
+        function showOutputModal(title, output) {
+            logView = null;
+            document.getElementById('logToolbar').style.display = 'none';
+            document.getElementById('modalTitle').textContent = title;
+            document.getElementById('modalOutput').textContent = output;
+            document.getElementById('outputModal').style.display = 'block';
+            document.body.style.overflow = 'hidden'; // Prevent background scrolling
+        }
+        
+        // Log viewer state: the loaded lines of a stored build log, or of output only held in the page
+        let logView = null;
+        let logFilterTimer = null;
+        const logPageLines = 1000;
+        const ansiColors = ['#6c757d', '#ff6b6b', '#8ce99a', '#ffd166', '#74c0fc', '#e599f7', '#66d9e8', '#e9ecef'];
+        
+        // Open the log viewer for a build; logs are paged from the history, text is used for
+        // builds without a stored log
+        function showLogViewer(title, buildId, text) {
+            logView = { buildId: buildId || null, text: text, lines: [], start: 0, total: 0, filter: '' };
+            document.getElementById('modalTitle').textContent = title;
+            document.getElementById('logToolbar').style.display = 'flex';
+            document.getElementById('logFilter').value = '';
+            document.getElementById('modalOutput').textContent = 'Loading…';
+            document.getElementById('outputModal').style.display = 'block';
+            document.body.style.overflow = 'hidden';
+            loadLogPage(-1, 0, false);
+        }
+        
+        // Load a page of the log: the last one (start < 0) or earlier lines put before the loaded ones
+        function loadLogPage(start, limit, prepend) {
+            const view = logView;
+            if (!view.buildId) {
+                const needle = view.filter.toLowerCase();
+                const text = (view.text || '').replace(/\n$/, '');
+                const lines = text ? text.split('\n').map((line, i) => ({ number: i + 1, text: line.replace(/\r$/, '') })) : [];
+                view.lines = needle ? lines.filter(line => stripAnsi(line.text).toLowerCase().includes(needle)) : lines;
+                view.start = 0;
+                view.total = view.lines.length;
+                renderLog(false);
+                return;
+            }
+            
+            let url = 'api/v1/builds/' + encodeURIComponent(view.buildId) + '/log?format=json&limit=' + (limit || logPageLines) +
+                '&q=' + encodeURIComponent(view.filter);
+            if (start >= 0) {
+                url += '&start=' + start;
+            }
+            fetch(url)
+                .then(response => {
+                    if (!response.ok) {
+                        return response.text().then(text => { throw new Error(text); });
+                    }
+                    return response.json();
+                })
+                .then(page => {
+                    if (view !== logView) {
+                        return; // Closed or reopened meanwhile
+                    }
+                    view.lines = prepend ? page.lines.concat(view.lines) : page.lines;
+                    view.start = page.start;
+                    view.total = page.total;
+                    renderLog(prepend);
+                })
+                .catch(error => {
+                    if (view !== logView) {
+                        return;
+                    }
+                    if (view.text) {
+                        // Not in the history (yet), fall back to the output of the result
+                        view.buildId = null;
+                        loadLogPage(-1, 0, false);
+                        return;
+                    }
+                    document.getElementById('modalOutput').textContent = 'Log not available: ' + error.message;
+                });
+        }
+        
+        function stripAnsi(text) {
+            return text.replace(/\x1b\[[0-9;?]*[A-Za-z]/g, '');
+        }
+        
+        function escapeHtml(text) {
+            return text.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+        }
+        
+        // Render the SGR color and bold codes compilers emit; other escape sequences are dropped
+        function ansiToHtml(text) {
+            let html = '';
+            let color = null;
+            let bold = false;
+            // Splitting on a captured group alternates text and SGR parameters
+            text.split(/\x1b\[([0-9;]*)m/).forEach((part, i) => {
+                if (i % 2 === 1) {
+                    (part || '0').split(';').forEach(code => {
+                        const n = parseInt(code || '0', 10);
+                        if (n === 0) {
+                            color = null;
+                            bold = false;
+                        } else if (n === 1) {
+                            bold = true;
+                        } else if (n === 22) {
+                            bold = false;
+                        } else if (n >= 30 && n <= 37) {
+                            color = ansiColors[n - 30];
+                        } else if (n >= 90 && n <= 97) {
+                            color = ansiColors[n - 90];
+                        } else if (n === 39) {
+                            color = null;
+                        }
+                    });
+                    return;
+                }
+                part = stripAnsi(part);
+                if (!part) {
+                    return;
+                }
+                const style = (color ? 'color: ' + color + ';' : '') + (bold ? 'font-weight: bold;' : '');
+                html += style ? '<span style="' + style + '">' + escapeHtml(part) + '</span>' : escapeHtml(part);
+            });
+            return html;
+        }
+        
+        // Mark the occurrences of the filter in a line (without colors)
+        function highlightHtml(text, filter) {
+            const plain = stripAnsi(text);
+            const lower = plain.toLowerCase();
+            const needle = filter.toLowerCase();
+            let html = '';
+            let from = 0;
+            let at = lower.indexOf(needle);
+            while (at >= 0) {
+                html += escapeHtml(plain.slice(from, at)) + '<mark>' + escapeHtml(plain.slice(at, at + needle.length)) + '</mark>';
+                from = at + needle.length;
+                at = lower.indexOf(needle, from);
+            }
+            return html + escapeHtml(plain.slice(from));
+        }
+        
+        function logLineHtml(line, filter) {
+            return '<div class="log-line"><span class="log-number">' + line.number + '</span><span>' +
+                (filter ? highlightHtml(line.text, filter) : ansiToHtml(line.text)) + '</span></div>';
+        }
+        
+        // Collapsed section of log lines
+        function logSectionHtml(title, lines, filter) {
+            return '<details><summary class="log-section">' + escapeHtml(title) + ' (' + lines.length + ' lines)</summary>' +
+                lines.map(line => logLineHtml(line, filter)).join('') + '</details>';
+        }
+        
+        // Show the loaded lines. ::group::/::endgroup:: markers become collapsed sections, and without
+        // a filter long stretches are folded in blocks so the end of the log, where errors are, stays open.
+        function renderLog(keepScroll) {
+            const view = logView;
+            const container = document.getElementById('modalOutput');
+            const previousHeight = container.scrollHeight - container.scrollTop;
+            
+            let info = view.filter ? view.total + ' matching lines' : view.total + ' lines';
+            if (view.start > 0) {
+                info += ', ' + view.lines.length + ' loaded';
+            }
+            document.getElementById('logInfo').textContent = info;
+            document.getElementById('logEarlier').style.display = view.start > 0 ? 'inline-block' : 'none';
+            if (view.lines.length === 0) {
+                container.textContent = view.filter ? 'No matching lines' : 'No output available';
+                return;
+            }
+            
+            const segments = [];
+            let group = null;
+            let plain = [];
+            const flush = () => {
+                if (plain.length > 0) {
+                    segments.push({ lines: plain });
+                    plain = [];
+                }
+            };
+            view.lines.forEach(line => {
+                const text = stripAnsi(line.text);
+                const start = text.match(/^(?:::group::|##\[group\])(.*)$/);
+                if (start) {
+                    flush();
+                    group = { title: start[1].trim() || 'Group', lines: [] };
+                    segments.push(group);
+                } else if (/^(?:::endgroup::|##\[endgroup\])/.test(text)) {
+                    group = null;
+                } else if (group) {
+                    group.lines.push(line);
+                } else {
+                    plain.push(line);
+                }
+            });
+            flush();
+            
+            const foldSize = 500;
+            const keepOpen = 200;
+            let html = '';
+            segments.forEach((segment, index) => {
+                if (segment.title) {
+                    html += logSectionHtml(segment.title, segment.lines, view.filter);
+                    return;
+                }
+                let lines = segment.lines;
+                const last = index === segments.length - 1;
+                while (!view.filter && lines.length > foldSize + (last ? keepOpen : 0)) {
+                    const block = lines.slice(0, foldSize);
+                    html += logSectionHtml('Lines ' + block[0].number + '–' + block[block.length - 1].number, block, '');
+                    lines = lines.slice(foldSize);
+                }
+                html += lines.map(line => logLineHtml(line, view.filter)).join('');
+            });
+            container.innerHTML = html;
+            container.scrollTop = keepScroll ? container.scrollHeight - previousHeight : container.scrollHeight;
+        }
+        
+        document.getElementById('logFilter').addEventListener('input', event => {
+            clearTimeout(logFilterTimer);
+            logFilterTimer = setTimeout(() => {
+                if (logView) {
+                    logView.filter = event.target.value;
+                    loadLogPage(-1, 0, false);
+                }
+            }, 300);
+        });
+        
+        document.getElementById('logEarlier').addEventListener('click', () => {
+            const start = Math.max(0, logView.start - logPageLines);
+            loadLogPage(start, logView.start - start, true);
+        });
+        
+        function closeOutputModal() {
+            document.getElementById('outputModal').style.display = 'none';
+            document.body.style.overflow = 'auto'; // Restore scrolling
+        }
+        
+        function browseDirectory(path) {
+            fetch('api/v1/fs' + (path ? '?path=' + encodeURIComponent(path) : ''))
+                .then(response => {
+                    if (!response.ok) {
+                        return response.text().then(text => { throw new Error(text); });
+                    }
+                    return response.json();
+                })
+                .then(listing => {
+                    document.getElementById('browseTitle').textContent = listing.path || 'Choose Directory';
+                    document.getElementById('browseKinds').textContent = listing.kinds ? 'Detected: ' + listing.kinds.join(', ') : '';
+                    
+                    const container = document.getElementById('browseEntries');
+                    container.innerHTML = '';
+                    const entries = listing.parent ? [{ name: '..', path: listing.parent }].concat(listing.entries) : listing.entries;
+                    if (listing.path && !listing.parent) {
+                        entries.unshift({ name: '.. (allowed directories)', path: '' });
+                    }
+                    entries.forEach(entry => {
+                        const item = document.createElement('div');
+                        item.className = 'browse-entry';
+                        item.textContent = '📁 ' + entry.name;
+                        item.addEventListener('click', () => browseDirectory(entry.path));
+                        container.appendChild(item);
+                    });
+                    
+                    const useButton = document.getElementById('browseUse');
+                    useButton.style.display = listing.path ? 'inline-block' : 'none';
+                    useButton.onclick = () => {
+                        document.getElementById('project-dir').value = listing.path;
+                        closeBrowseModal();
+                        loadSubprojects();
+                        suggestEnvironment();
+                    };
+                    
+                    document.getElementById('browseModal').style.display = 'block';
+                    document.body.style.overflow = 'hidden';
+                })
+                .catch(error => {
+                    // Fall back to the allowed roots if the typed path cannot be browsed
+                    if (path) {
+                        browseDirectory('');
+                    } else {
+                        console.error('Error browsing directories:', error);
+                    }
+                });
+        }
+        
+        // Render the artifacts of a build as a tree of directories with sizes and download links
+        function artifactTree(buildId, artifacts) {
+            const root = { dirs: {}, files: [], size: 0 };
+            artifacts.forEach(artifact => {
+                const parts = artifact.path.replace(/^\.\//, '').split('/');
+                let node = root;
+                node.size += artifact.size;
+                parts.slice(0, -1).forEach(part => {
+                    node.dirs[part] = node.dirs[part] || { dirs: {}, files: [], size: 0 };
+                    node = node.dirs[part];
+                    node.size += artifact.size;
+                });
+                node.files.push({ name: parts[parts.length - 1], artifact: artifact });
+            });
+            
+            const render = node => {
+                const list = document.createElement('div');
+                list.style.marginLeft = '14px';
+                Object.keys(node.dirs).sort().forEach(name => {
+                    const dir = document.createElement('details');
+                    dir.open = true;
+                    const summary = document.createElement('summary');
+                    summary.className = 'browse-entry';
+                    summary.textContent = '📁 ' + name + ' (' + formatBytes(node.dirs[name].size) + ')';
+                    dir.appendChild(summary);
+                    dir.appendChild(render(node.dirs[name]));
+                    list.appendChild(dir);
+                });
+                node.files.sort((a, b) => a.name.localeCompare(b.name)).forEach(file => {
+                    const item = document.createElement('div');
+                    item.className = 'browse-entry';
+                    const label = '📄 ' + file.name + ' (' + formatBytes(file.artifact.size) + ')';
+                    if (file.artifact.local_path) {
+                        const link = document.createElement('a');
+                        link.href = 'api/v1/builds/' + encodeURIComponent(buildId) + '/artifact?path=' + encodeURIComponent(file.artifact.path);
+                        link.textContent = label;
+                        link.title = 'Download ' + file.artifact.local_path;
+                        link.style.color = 'inherit';
+                        item.appendChild(link);
+                    } else {
+                        item.textContent = label;
+                    }
+                    list.appendChild(item);
+                });
+                return list;
+            };
+            
+            const tree = render(root);
+            tree.style.marginLeft = '0';
+            return tree;
+        }
+        
+        // Show the artifacts of a build from the history
+        function showArtifacts(buildId) {
+            fetch('api/v1/builds/' + encodeURIComponent(buildId))
+                .then(response => response.json())
+                .then(record => {
+                    const artifacts = record.artifacts || [];
+                    const total = artifacts.reduce((sum, artifact) => sum + artifact.size, 0);
+                    document.getElementById('browseTitle').textContent = '🗂 Artifacts of ' + buildId;
+                    document.getElementById('browseKinds').textContent = artifacts.length + ' files, ' + formatBytes(total);
+                    document.getElementById('browseUse').style.display = 'none';
+                    const container = document.getElementById('browseEntries');
+                    container.innerHTML = '';
+                    container.appendChild(artifactTree(buildId, artifacts));
+                    document.getElementById('browseModal').style.display = 'block';
+                    document.body.style.overflow = 'hidden';
+                })
+                .catch(error => console.error('Error loading artifacts:', error));
+        }
+        
+        // Fill a build result with the tree of its artifacts once the history record is stored
+        function loadResultArtifacts(buildId, title) {
+            fetch('api/v1/builds/' + encodeURIComponent(buildId))
+                .then(response => response.ok ? response.json() : null)
+                .then(record => {
+                    const container = document.getElementById('build-artifacts');
+                    if (!record || !container || !record.artifacts || record.artifacts.length === 0) {
+                        return;
+                    }
+                    const total = record.artifacts.reduce((sum, artifact) => sum + artifact.size, 0);
+                    container.innerHTML = '<br><strong>' + title + '</strong> (' + record.artifacts.length + ' files, ' + formatBytes(total) + ')';
+                    container.appendChild(artifactTree(buildId, record.artifacts));
+                })
+                .catch(error => console.error('Error loading artifacts:', error));
+        }
+        
+        // Browse the workspace a failed build left on its server (read-only)
+        function browseWorkspace(buildId, path) {
+            fetch('api/v1/builds/' + buildId + '/workspace?path=' + encodeURIComponent(path))
+                .then(response => {
+                    if (!response.ok) {
+                        return response.text().then(text => { throw new Error(text); });
+                    }
+                    return response.json();
+                })
+                .then(listing => {
+                    if (listing.file) {
+                        let content = listing.binary ? '(binary file, ' + listing.size + ' bytes)' : listing.content;
+                        if (listing.truncated) {
+                            content += '\n\n… truncated, ' + listing.size + ' bytes in total';
+                        }
+                        showOutputModal('📄 ' + buildId + ' / ' + listing.path, content);
+                        return;
+                    }
+                    
+                    document.getElementById('browseTitle').textContent = '📂 ' + buildId + ' / ' + (listing.path || '');
+                    document.getElementById('browseKinds').textContent = 'Workspace on the build server (read-only)';
+                    document.getElementById('browseUse').style.display = 'none';
+                    
+                    const container = document.getElementById('browseEntries');
+                    container.innerHTML = '';
+                    const entries = (listing.entries || []).map(entry => ({
+                        label: (entry.dir ? '📁 ' : '📄 ') + entry.name + (entry.dir ? '' : ' (' + entry.size + ' bytes)'),
+                        path: (listing.path ? listing.path + '/' : '') + entry.name
+                    }));
+                    if (listing.path) {
+                        entries.unshift({ label: '📁 ..', path: listing.path.split('/').slice(0, -1).join('/') });
+                    }
+                    entries.forEach(entry => {
+                        const item = document.createElement('div');
+                        item.className = 'browse-entry';
+                        item.textContent = entry.label;
+                        item.addEventListener('click', () => browseWorkspace(buildId, entry.path));
+                        container.appendChild(item);
+                    });
+                    
+                    document.getElementById('browseModal').style.display = 'block';
+                    document.body.style.overflow = 'hidden';
+                })
+                .catch(error => {
+                    showOutputModal('📂 ' + buildId, 'Workspace not available: ' + error.message);
+                });
+        }
+        
+        function closeBrowseModal() {
+            document.getElementById('browseModal').style.display = 'none';
+            document.body.style.overflow = 'auto';
+        }
+        
+        // Close modal when clicking outside of it
+        window.onclick = function(event) {
+            if (event.target === document.getElementById('outputModal')) {
+                closeOutputModal();
+            }
+            if (event.target === document.getElementById('browseModal')) {
+                closeBrowseModal();
+            }
+        }
+        
+        // Close modal with Escape key
+        document.addEventListener('keydown', function(event) {
+            if (event.key === 'Escape') {
+                closeOutputModal();
+                closeBrowseModal();
+            }
+        });
+        
+        function selectServer(serverAddr, serverInfo) {
+            selectedServer = { addr: serverAddr, info: serverInfo };
+            
+            // Update UI
+            document.querySelectorAll('.server-card').forEach(card => {
+                card.classList.remove('selected');
+            });
+            
+            const selectedCard = document.querySelector('[data-server-addr="' + serverAddr + '"]');
+            if (selectedCard) {
+                selectedCard.classList.add('selected');
+            }
+            
+            // Update selected server display
+            const selectedServerDiv = document.getElementById('selected-server');
+            selectedServerDiv.innerHTML = '<strong>' + serverInfo.id + '</strong> - ' + serverInfo.address + ':' + serverInfo.port + ' (Capacity: ' + serverInfo.capacity + ')';
+            selectedServerDiv.style.background = 'rgba(164, 255, 240, 0.1)';
+            selectedServerDiv.style.color = '#A4FFF0';
+            selectedServerDiv.style.fontStyle = 'normal';
+            renderEnvironmentOptions();
+        }
+        
+        let environments = {};
+        let serversByAddr = {};
+        let knownServerAddrs = '';
+        let preferences = { recent: [], favorites: [] };
+        
+        function renderParameters() {
+            const container = document.getElementById('parameter-fields');
+            container.innerHTML = '';
+            const env = environments[document.getElementById('environment').value];
+            if (!env || !env.parameters) {
+                return;
+            }
+            
+            env.parameters.forEach(param => {
+                const group = document.createElement('div');
+                group.className = 'form-group';
+                const label = document.createElement('label');
+                label.textContent = param.name + ':';
+                group.appendChild(label);
+                
+                let field;
+                if (param.type === 'choice') {
+                    field = document.createElement('select');
+                    param.choices.forEach(choice => {
+                        const option = document.createElement('option');
+                        option.value = choice;
+                        option.textContent = choice;
+                        field.appendChild(option);
+                    });
+                } else {
+                    field = document.createElement('input');
+                    field.placeholder = param.default || '';
+                }
+                field.className = 'form-control build-parameter';
+                field.dataset.name = param.name;
+                field.value = param.default || '';
+                group.appendChild(field);
+                container.appendChild(group);
+            });
+        }
+        
+        // renderEnvironmentOptions offers only the environments the selected server (or, without
+        // a selection, any connected server) can run
+        function renderEnvironmentOptions() {
+            const environmentSelect = document.getElementById('environment');
+            const current = environmentSelect.value;
+            environmentSelect.innerHTML = '<option value="">Select build environment...</option>';
+            
+            Object.values(environments).sort((a, b) => a.name.localeCompare(b.name)).forEach(env => {
+                const option = document.createElement('option');
+                option.value = env.name;
+                option.textContent = env.name;
+                if (env.description) {
+                    option.textContent += ' - ' + env.description;
+                }
+                const servers = env.servers || {};
+                const entry = selectedServer ? servers[selectedServer.addr] : null;
+                if (env.source === 'server') {
+                    option.disabled = true;
+                    option.textContent += ' (defined on servers only)';
+                } else if (entry && !entry.available) {
+                    option.disabled = true;
+                    option.textContent += ' (' + entry.reason + ')';
+                } else if (entry && entry.tool_version) {
+                    option.textContent += ' · ' + entry.tool_version;
+                } else if (!selectedServer && Object.keys(servers).length > 0 && !Object.values(servers).some(server => server.available)) {
+                    option.disabled = true;
+                    option.textContent += ' (no connected server can run it)';
+                }
+                environmentSelect.appendChild(option);
+            });
+            
+            const kept = environmentSelect.querySelector('option[value="' + current + '"]');
+            if (kept && !kept.disabled) {
+                environmentSelect.value = current;
+            } else if (current) {
+                renderParameters();
+            }
+        }
+        
+        function loadEnvironments() {
+            fetch('api/v1/environments')
+                .then(response => response.json())
+                .then(data => {
+                    environments = data;
+                    renderEnvironmentOptions();
+                    suggestEnvironment();
+                })
+                .catch(error => {
+                    console.error('Error loading environments:', error);
+                    const environmentSelect = document.getElementById('environment');
+                    environmentSelect.innerHTML = '<option value="">Error loading environments</option>';
+                });
+        }
+        
+        function loadProjects() {
+            fetch('api/v1/projects')
+                .then(response => response.json())
+                .then(projects => {
+                    const projectSelect = document.getElementById('project');
+                    projects.forEach(project => {
+                        const option = document.createElement('option');
+                        option.value = project.name;
+                        option.dataset.environment = project.environment;
+                        option.textContent = project.name + ' (' + project.dir + ')';
+                        projectSelect.appendChild(option);
+                    });
+                })
+                .catch(error => {
+                    console.error('Error loading projects:', error);
+                });
+        }
+        
+        function selectProject() {
+            const projectSelect = document.getElementById('project');
+            const option = projectSelect.options[projectSelect.selectedIndex];
+            if (option && option.dataset.environment) {
+                document.getElementById('environment').value = option.dataset.environment;
+                renderParameters();
+            }
+            loadSubprojects();
+            loadHistory();
+            suggestEnvironment();
+        }
+        
+        document.getElementById('project').addEventListener('change', selectProject);
+        document.getElementById('project-dir').addEventListener('change', suggestEnvironment);
+        
+        function loadSubprojects() {
+            const project = document.getElementById('project').value;
+            const environment = document.getElementById('environment').value;
+            const projectDir = document.getElementById('project-dir').value;
+            const list = document.getElementById('subproject-list');
+            list.innerHTML = '';
+            if (!environment && !project && !projectDir) {
+                return;
+            }
+            
+            let query = project ? 'project=' + encodeURIComponent(project) : 'environment=' + encodeURIComponent(environment);
+            if (projectDir) {
+                query = 'project_dir=' + encodeURIComponent(projectDir);
+            }
+            fetch('api/v1/subprojects?' + query)
+                .then(response => response.json())
+                .then(subprojects => {
+                    subprojects.forEach(subproject => {
+                        const option = document.createElement('option');
+                        option.value = subproject.path;
+                        option.textContent = subproject.kinds.join(', ');
+                        list.appendChild(option);
+                    });
+                })
+                .catch(error => {
+                    console.error('Error loading sub-projects:', error);
+                });
+        }
+        
+        document.getElementById('environment').addEventListener('change', loadSubprojects);
+        document.getElementById('environment').addEventListener('change', renderParameters);
+        
+        function suggestEnvironment() {
+            fetch('api/v1/detect', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({
+                    project: document.getElementById('project').value,
+                    project_dir: document.getElementById('project-dir').value
+                })
+            })
+                .then(response => response.ok ? response.json() : {})
+                .then(data => {
+                    const environmentSelect = document.getElementById('environment');
+                    if (!data.suggested || data.suggested.length === 0 || environmentSelect.value) {
+                        return;
+                    }
+                    environmentSelect.value = data.suggested[0];
+                    loadSubprojects();
+                    renderParameters();
+                    const option = environmentSelect.querySelector('option[value="' + data.suggested[0] + '"]');
+                    if (option && !option.textContent.endsWith(' (detected)')) {
+                        option.textContent += ' (detected)';
+                    }
+                })
+                .catch(error => {
+                    console.error('Error detecting environment:', error);
+                });
+        }
+        
+        function loadServers() {
+            // Fetch both servers and client version for comparison
+            Promise.all([
+                fetch('api/v1/servers').then(response => response.json()),
+                fetch('api/v1/version').then(response => response.json())
+            ])
+                .then(([serverData, versionData]) => {
+                    const container = document.getElementById('servers-container');
+                    const servers = Object.values(serverData);
+                    const clientVersion = versionData.version;
+                    
+                    // Update stats
+                    const totalServers = servers.length;
+                    const availableServers = servers.filter(s => s.available).length;
+                    const busyServers = totalServers - availableServers;
+                    
+                    document.getElementById('total-servers').textContent = totalServers;
+                    document.getElementById('available-servers').textContent = availableServers;
+                    document.getElementById('busy-servers').textContent = busyServers;
+                    
+                    if (totalServers === 0) {
+                        container.innerHTML = '<div style="text-align: center; padding: 40px; color: rgba(164, 255, 240, 0.7); grid-column: 1 / -1;"><h3>No Build Servers Connected</h3><p>Start some build servers to begin compilation</p></div>';
+                        return;
+                    }
+                    
+                    // Connected servers determine which environments can be built
+                    const serverAddrs = servers.map(server => server.address + ':' + server.port).sort().join(',');
+                    if (serverAddrs !== knownServerAddrs) {
+                        knownServerAddrs = serverAddrs;
+                        loadEnvironments();
+                    }
+                    
+                    container.innerHTML = '';
+                    serversByAddr = {};
+                    servers.forEach((server, index) => {
+                        const serverAddr = server.address + ':' + server.port;
+                        serversByAddr[serverAddr] = server;
+                        const versionMismatch = server.version !== clientVersion;
+                        const serverCard = document.createElement('div');
+                        
+                        // Add version-mismatch class if versions don't match
+                        let cardClasses = 'server-card ' + (server.available ? 'server-available' : 'server-busy');
+                        if (versionMismatch) {
+                            cardClasses += ' version-mismatch';
+                        }
+                        serverCard.className = cardClasses;
+                        serverCard.setAttribute('data-server-addr', serverAddr);
+                        
+                        // Check if this server is currently selected
+                        if (selectedServer && selectedServer.addr === serverAddr) {
+                            serverCard.classList.add('selected');
+                        }
+                        
+                        // Create version display with warning if mismatch
+                        let versionDisplay = '<div><strong>Version:</strong> ' + server.version;
+                        let clickHint = '<div style="margin-top: 10px; font-size: 0.8rem; color: #A4FFF0;">💡 Click to select this server</div>';
+                        
+                        if (versionMismatch) {
+                            versionDisplay += ' <span style="color: #ff6b6b; font-weight: bold;">⚠️ MISMATCH</span>';
+                            clickHint = '<div style="margin-top: 10px; font-size: 0.8rem; color: #ff6b6b;">⚠️ Version mismatch - builds will fail!</div>';
+                        }
+                        versionDisplay += '</div>';
+                        let platformDisplay = '';
+                        if (server.os) {
+                            platformDisplay = '<div><strong>Platform:</strong> ' + escapeHtml(server.os + '/' + server.arch) +
+                                (server.os_version ? ' <span style="opacity: 0.8;">(' + escapeHtml(server.os_version) + ')</span>' : '') + '</div>';
+                        }
+                        
+                        serverCard.innerHTML = '<div class="server-header">' +
+                            '<div class="server-id">' + server.id + '</div>' +
+                            '<div class="server-status ' + (server.available ? 'status-available' : 'status-busy') + '">' +
+                                (server.available ? '✅ Available' : '⚡ Busy') +
+                            '</div>' +
+                        '</div>' +
+                        '<div class="server-info">' +
+                            '<div><strong>Address:</strong> ' + server.address + ':' + server.port + '</div>' +
+                            '<div><strong>Capacity:</strong> ' + server.capacity + ' concurrent builds</div>' +
+                            platformDisplay +
+                            loadDisplay(server.load) +
+                            networkDisplay(server.network) +
+                            versionDisplay +
+                            clickHint +
+                        '</div>';
+                        
+                        // Add click event to select server
+                        serverCard.addEventListener('click', () => {
+                            selectServer(serverAddr, server);
+                        });
+                        
+                        container.appendChild(serverCard);
+                    });
+                })
+                .catch(error => {
+                    console.error('Error loading servers:', error);
+                    document.getElementById('servers-container').innerHTML = '<div style="text-align: center; padding: 40px; color: #f56565; grid-column: 1 / -1;"><h3>❌ Error Loading Servers</h3><p>Please check your connection</p></div>';
+                });
+        }
+        
+        document.getElementById('build-form').addEventListener('submit', function(e) {
+            e.preventDefault();
+            
+            // Check if a server is selected
+            if (!selectedServer) {
+                alert('Please select a server first by clicking on one of the server cards above.');
+                return;
+            }
+            
+            const formData = new FormData(e.target);
+            const buildRequest = {
+                environment: formData.get('environment'),
+                project: formData.get('project') || '',
+                project_dir: formData.get('project_dir') || '',
+                selectedServer: selectedServer.addr,
+                subpath: formData.get('subpath') || '',
+                parameters: {}
+            };
+            document.querySelectorAll('.build-parameter').forEach(field => {
+                buildRequest.parameters[field.dataset.name] = field.value;
+            });
+            
+            const resultDiv = document.getElementById('build-result');
+            resultDiv.innerHTML = '<div style="text-align: center; padding: 20px;"><div class="loading"></div><p style="margin-top: 15px; color: #A4FFF0; font-weight: 600;">Building project...</p><div id="build-eta"></div></div>';
+            const stopProgress = showBuildProgress(buildRequest.environment, selectedServer.addr);
+            
+            fetch('api/v1/build', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify(buildRequest)
+            })
+            .then(response => {
+                if (!response.ok) {
+                    return response.text().then(text => {
+                        const error = new Error(text.trim());
+                        error.rejected = true;
+                        throw error;
+                    });
+                }
+                return response.json();
+            })
+            .then(data => {
+                stopProgress();
+                showBuildResult(data);
+                loadPreferences();
+                loadQuota();
+            })
+            .catch(error => {
+                stopProgress();
+                console.error('Error submitting build:', error);
+                if (error.rejected) {
+                    resultDiv.innerHTML = '<div class="result result-error">' +
+                        '<h3>❌ Build Rejected!</h3>' +
+                        '<p>' + escapeHtml(error.message) + '</p>' +
+                    '</div>';
+                    loadQuota();
+                    return;
+                }
+                resultDiv.innerHTML = '<div class="result result-error">' +
+                    '<h3>❌ Network Error!</h3>' +
+                    '<p>Failed to submit build request. Please check your connection.</p>' +
+                '</div>';
+            });
+        });
+        
+        // List the steps of a multi-step build with their duration and output
+        function stepsInfo(data) {
+            if (!data.steps || data.steps.length === 0) {
+                return '';
+            }
+            window.lastBuildSteps = data.steps;
+            let html = '<br><strong>Steps:</strong><br>';
+            data.steps.forEach((step, i) => {
+                html += (step.success ? '✅ ' : '❌ ') + escapeHtml(step.name) + ' (' + formatDuration(step.duration) + ')';
+                if (step.error) {
+                    html += ': ' + escapeHtml(step.error);
+                }
+                if (step.output) {
+                    html += ' <button class="btn-view-output" onclick="showLogViewer(\'Step ' + (i + 1) + ' - ' + data.id + '\', null, window.lastBuildSteps[' + i + '].output)">📋 Output</button>';
+                }
+                html += '<br>';
+            });
+            return html;
+        }
+
+        // Show the result of a build in the submission panel
+        function showBuildResult(data) {
+            const resultDiv = document.getElementById('build-result');
+            if (data.success) {
+                let outputFilesInfo = '';
+                if (data.output_files && Object.keys(data.output_files).length > 0) {
+                    outputFilesInfo = '<div id="build-artifacts"></div>';
+                }
+                
+                let warningsInfo = '';
+                if (data.warnings && data.warnings.length > 0) {
+                    warningsInfo = '<br><br><strong>⚠️ Warnings:</strong><br>';
+                    data.warnings.forEach(warning => {
+                        warningsInfo += '• ' + warning + '<br>';
+                    });
+                }
+                
+                // Store output for modal
+                window.lastBuildOutput = data.output;
+                window.lastBuildId = data.id;
+                
+                resultDiv.innerHTML = '<div class="result result-success">' +
+                    '<h3>✅ Build Successful!</h3>' +
+                    '<p><strong>Build ID:</strong> ' + data.id + '</p>' +
+                    '<p><strong>Duration:</strong> ' + formatDuration(data.duration) + '</p>' +
+                    '<button class="btn-view-output" onclick="showLogViewer(\'✅ Build Output - ' + data.id + '\', window.lastBuildId, window.lastBuildOutput)">📋 View Build Output</button>' +
+                    stepsInfo(data) +
+                    outputFilesInfo +
+                    warningsInfo +
+                '</div>';
+                if (outputFilesInfo) {
+                    loadResultArtifacts(data.id, '📁 Output Files');
+                }
+            } else {
+                // Store output for modal (including error output)
+                window.lastBuildOutput = data.output || 'No output available';
+                window.lastBuildId = data.id || 'Unknown';
+                
+                let viewOutputButton = '';
+                if (data.output) {
+                    viewOutputButton = '<button class="btn-view-output" onclick="showLogViewer(\'❌ Build Error Output - ' + window.lastBuildId + '\', window.lastBuildId, window.lastBuildOutput)">📋 View Error Output</button>';
+                }
+                
+                let collectedFilesInfo = '';
+                if (data.output_files && Object.keys(data.output_files).length > 0) {
+                    collectedFilesInfo = '<div id="build-artifacts"></div>';
+                }
+                
+                resultDiv.innerHTML = '<div class="result result-error">' +
+                    '<h3>❌ Build Failed!</h3>' +
+                    '<p><strong>Error:</strong> ' + (data.error || 'Unknown error') + '</p>' +
+                    viewOutputButton +
+                    stepsInfo(data) +
+                    collectedFilesInfo +
+                '</div>';
+                if (collectedFilesInfo && data.id) {
+                    loadResultArtifacts(data.id, '📁 Collected Files');
+                }
+            }
+            loadServers();
+            loadHistory();
+            loadEnvironmentStats();
+        }
+        
+        // Shows a progress bar with the remaining time expected from earlier builds; returns a stop function
+        function showBuildProgress(environment, serverAddr) {
+            const started = Date.now();
+            let timer = null;
+            let stopped = false;
+            fetch('api/v1/estimate?environment=' + encodeURIComponent(environment) + '&server=' + encodeURIComponent(serverAddr))
+                .then(response => response.json())
+                .then(estimate => {
+                    if (stopped || !estimate.samples) {
+                        return;
+                    }
+                    const expected = estimate.duration / 1000000;
+                    const update = () => {
+                        const container = document.getElementById('build-eta');
+                        if (!container) {
+                            return;
+                        }
+                        const elapsed = Date.now() - started;
+                        const percent = Math.min(99, Math.round(elapsed / expected * 100));
+                        const remaining = expected - elapsed;
+                        const label = remaining > 0 ? 'about ' + formatDuration(remaining * 1000000) + ' left' :
+                            'taking longer than usual (' + formatDuration(expected * 1000000) + ' expected)';
+                        container.innerHTML = '<div style="margin: 15px auto 0; max-width: 400px; background: rgba(164, 255, 240, 0.1); border-radius: 6px; overflow: hidden;">' +
+                            '<div style="width: ' + percent + '%; height: 8px; background: #A4FFF0; transition: width 0.5s;"></div></div>' +
+                            '<p style="margin-top: 8px; font-size: 0.85rem; color: rgba(164, 255, 240, 0.8);">' + percent + '% · ' + label +
+                            ' (based on ' + estimate.samples + ' ' + (estimate.scope === 'server' ? 'builds on this server' : 'builds of this environment') + ')</p>';
+                    };
+                    update();
+                    timer = setInterval(update, 500);
+                })
+                .catch(() => {});
+            return () => {
+                stopped = true;
+                if (timer) {
+                    clearInterval(timer);
+                }
+            };
+        }
+
+        // Live load reported by the server (omitted until the first report arrives)
+        function loadDisplay(load) {
+            if (!load || !load.updated_at || load.updated_at.startsWith('0001')) {
+                return '';
+            }
+            const cpu = Math.round(load.cpu_load * 100);
+            const color = cpu >= 90 ? '#ff6b6b' : (cpu >= 60 ? '#ffd166' : '#A4FFF0');
+            let html = '<div><strong>Load:</strong> <span style="color: ' + color + ';">' + cpu + '% CPU</span>, ' +
+                load.active_builds + ' running</div>';
+            if (load.free_memory || load.free_disk) {
+                html += '<div><strong>Free:</strong> ' + formatBytes(load.free_memory) + ' memory, ' +
+                    formatBytes(load.free_disk) + ' disk</div>';
+                html += '<div><strong>Disk usage:</strong> ' + formatBytes(load.temp_usage) + ' builds, ' +
+                    formatBytes(load.cache_size) + ' cache</div>';
+            }
+            if (load.low_disk) {
+                html += '<div style="color: #ff6b6b;">⚠ Low disk space, no new builds scheduled</div>';
+            }
+            return html;
+        }
+
+        // Measured latency and upload bandwidth from this client to the server
+        function networkDisplay(network) {
+            if (!network || !network.measured_at || network.measured_at.startsWith('0001')) {
+                return '';
+            }
+            const latency = network.latency < 1000000 ? '<1ms' : formatDuration(network.latency);
+            const bandwidth = network.bandwidth ? formatBytes(network.bandwidth) + '/s' : '?';
+            return '<div><strong>Network:</strong> ' + latency + ' latency, ' + bandwidth + '</div>';
+        }
+
+        // Function to format a byte count with binary units
+        function formatBytes(bytes) {
+            if (!bytes) {
+                return '?';
+            }
+            const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+            let unit = 0;
+            while (bytes >= 1024 && unit < units.length - 1) {
+                bytes /= 1024;
+                unit++;
+            }
+            return bytes.toFixed(unit === 0 ? 0 : 1) + units[unit];
+        }
+
+        // Function to format duration from nanoseconds to human readable format
+          function formatDuration(nanoseconds) {
+              const totalMilliseconds = Math.floor(nanoseconds / 1000000);
+              const totalSeconds = Math.floor(nanoseconds / 1000000000);
+              const hours = Math.floor(totalSeconds / 3600);
+              const minutes = Math.floor((totalSeconds % 3600) / 60);
+              const seconds = totalSeconds % 60;
+              
+              if (totalSeconds < 1) {
+                  return totalMilliseconds + 'ms';
+              } else if (hours > 0) {
+                  return hours + 'h ' + minutes + 'm ' + seconds + 's';
+              } else if (minutes > 0) {
+                  return minutes + 'm ' + seconds + 's';
+              } else {
+                  return seconds + 's';
+              }
+          }
+        
+        function loadHistory() {
+            const project = document.getElementById('project').value;
+            fetch('api/v1/builds' + (project ? '?project=' + encodeURIComponent(project) : ''))
+                .then(response => response.json())
+                .then(builds => {
+                    const container = document.getElementById('history-container');
+                    if (!builds || builds.length === 0) {
+                        container.innerHTML = '<p style="color: rgba(164, 255, 240, 0.7);">No builds yet</p>';
+                        return;
+                    }
+                    
+                    container.innerHTML = '';
+                    builds.slice(0, 20).forEach(build => {
+                        const item = document.createElement('div');
+                        item.className = 'history-item' + (build.success ? '' : ' history-failed');
+                        item.innerHTML = '<div>' +
+                                '<strong>' + (build.success ? '✅ ' : '❌ ') + (build.project ? build.project + ' / ' : '') + build.environment + '</strong> on ' + build.server_id +
+                                '<div style="color: rgba(164, 255, 240, 0.6); font-size: 0.8rem;">' + build.id + ' • ' + new Date(build.submitted_at).toLocaleString() + ' • ' + formatDuration(build.duration) + (build.incremental ? ' • incremental' : '') + '</div>' +
+                            '</div>';
+                        
+                        const button = document.createElement('button');
+                        button.className = 'btn-view-output';
+                        button.textContent = '📋 Log';
+                        button.addEventListener('click', () => showBuildLog(build.id));
+                        item.appendChild(button);
+                        
+                        if (build.artifacts && build.artifacts.length > 0) {
+                            const artifacts = document.createElement('button');
+                            artifacts.className = 'btn-view-output';
+                            artifacts.textContent = '🗂 Artifacts';
+                            artifacts.addEventListener('click', () => showArtifacts(build.id));
+                            item.appendChild(artifacts);
+                        }
+                        
+                        // Failed builds may have left their workspace on the server
+                        if (!build.success) {
+                            const files = document.createElement('button');
+                            files.className = 'btn-view-output';
+                            files.textContent = '📂 Files';
+                            files.addEventListener('click', () => browseWorkspace(build.id, ''));
+                            item.appendChild(files);
+                        }
+                        
+                        // Builds read from a local directory can be submitted again
+                        if (build.project_dir) {
+                            const retry = document.createElement('button');
+                            retry.className = 'btn-view-output';
+                            retry.textContent = '🔁 Retry';
+                            retry.title = 'Build ' + build.project_dir + ' again with the same parameters';
+                            retry.addEventListener('click', () => retryBuild(build));
+                            item.appendChild(retry);
+                        }
+                        
+                        if (build.debug_bundle) {
+                            const bundle = document.createElement('a');
+                            bundle.className = 'btn-view-output';
+                            bundle.style.textDecoration = 'none';
+                            bundle.textContent = '🧰 Bundle';
+                            bundle.title = 'Download the debug bundle (' + formatBytes(build.debug_bundle.size) + ')';
+                            bundle.href = 'api/v1/builds/' + encodeURIComponent(build.id) + '/bundle';
+                            item.appendChild(bundle);
+                        }
+                        
+                        const compare = document.createElement('button');
+                        compare.className = 'btn-view-output';
+                        compare.style.marginLeft = '6px';
+                        compare.textContent = diffBase === build.id ? '⇄ Base ✓' : '⇄ Compare';
+                        compare.title = 'Select two builds of the same environment to compare their artifacts';
+                        compare.addEventListener('click', () => compareBuild(build.id));
+                        item.appendChild(compare);
+                        
+                        container.appendChild(item);
+                    });
+                })
+                .catch(error => {
+                    console.error('Error loading build history:', error);
+                });
+        }
+        
+        function launchLabel(launch) {
+            return (launch.project ? launch.project + ' / ' : '') + launch.environment +
+                (launch.server_id ? ' @ ' + launch.server_id : '');
+        }
+        
+        function loadPreferences() {
+            fetch('api/v1/preferences')
+                .then(response => response.json())
+                .then(data => {
+                    preferences = data;
+                    renderQuickLaunch();
+                })
+                .catch(error => {
+                    console.error('Error loading preferences:', error);
+                });
+        }
+        
+        // Favorites first, then recently used builds that are not favorites
+        function renderQuickLaunch() {
+            const container = document.getElementById('quick-launch');
+            container.innerHTML = '';
+            const isFavorite = launch => preferences.favorites.some(f => f.environment === launch.environment &&
+                (f.project || '') === (launch.project || '') && (f.server_addr || '') === (launch.server_addr || ''));
+            const entries = preferences.favorites.map(launch => ({ launch: launch, favorite: true }))
+                .concat(preferences.recent.filter(launch => !isFavorite(launch)).map(launch => ({ launch: launch, favorite: false })));
+            
+            entries.forEach(entry => {
+                const button = document.createElement('button');
+                button.type = 'button';
+                button.className = 'btn-view-output';
+                button.textContent = (entry.favorite ? '★ ' : '🕘 ') + launchLabel(entry.launch);
+                button.title = 'Build ' + launchLabel(entry.launch) + ' now';
+                button.addEventListener('click', () => quickLaunch(entry.launch));
+                container.appendChild(button);
+                
+                const pin = document.createElement('button');
+                pin.type = 'button';
+                pin.className = 'btn-view-output';
+                pin.textContent = entry.favorite ? '✕' : '☆';
+                pin.title = entry.favorite ? 'Remove from favorites' : 'Add to favorites';
+                pin.addEventListener('click', () => toggleFavorite(entry.launch, !entry.favorite));
+                container.appendChild(pin);
+            });
+        }
+        
+        function toggleFavorite(launch, favorite) {
+            const favorites = preferences.favorites.filter(f => f !== launch);
+            if (favorite) {
+                favorites.push({ environment: launch.environment, project: launch.project, server_id: launch.server_id, server_addr: launch.server_addr });
+            }
+            fetch('api/v1/preferences', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ recent: preferences.recent, favorites: favorites })
+            })
+                .then(response => {
+                    if (!response.ok) {
+                        return response.text().then(text => { throw new Error(text.trim()); });
+                    }
+                    return response.json();
+                })
+                .then(data => {
+                    preferences = data;
+                    renderQuickLaunch();
+                })
+                .catch(error => {
+                    alert('Failed to save favorites: ' + error.message);
+                });
+        }
+        
+        // Fill in the form from a quick launch entry and submit it; a server that is gone is
+        // replaced by any available one
+        function quickLaunch(launch) {
+            if (!environments[launch.environment]) {
+                alert('Environment ' + launch.environment + ' is no longer configured.');
+                return;
+            }
+            document.getElementById('project').value = launch.project || '';
+            document.getElementById('environment').value = launch.environment;
+            document.getElementById('project-dir').value = '';
+            document.getElementById('subpath').value = '';
+            renderParameters();
+            
+            let server = serversByAddr[launch.server_addr];
+            let serverAddr = launch.server_addr;
+            if (!server || !server.available) {
+                serverAddr = Object.keys(serversByAddr).find(addr => serversByAddr[addr].available);
+                server = serversByAddr[serverAddr];
+            }
+            if (!server) {
+                alert('No build server is available.');
+                return;
+            }
+            selectServer(serverAddr, server);
+            document.getElementById('build-form').requestSubmit();
+        }
+        
+        function loadRunningBuilds() {
+            fetch('api/v1/builds/running')
+                .then(response => response.json())
+                .then(builds => {
+                    const container = document.getElementById('running-container');
+                    if (!builds || builds.length === 0) {
+                        container.innerHTML = '<p style="color: rgba(164, 255, 240, 0.7);">No builds running</p>';
+                        return;
+                    }
+                    
+                    container.innerHTML = '';
+                    builds.forEach(build => {
+                        const item = document.createElement('div');
+                        item.className = 'history-item';
+                        const elapsed = Date.now() - new Date(build.started_at).getTime();
+                        item.innerHTML = '<div>' +
+                                '<strong>🔨 ' + (build.project ? build.project + ' / ' : '') + build.environment + '</strong> on ' + build.server_id +
+                                '<div style="color: rgba(164, 255, 240, 0.6); font-size: 0.8rem;">' + build.id + ' • running for ' + formatDuration(elapsed * 1000000) + '</div>' +
+                            '</div>';
+                        
+                        const cancel = document.createElement('button');
+                        cancel.className = 'btn-view-output';
+                        cancel.textContent = '⏹ Cancel';
+                        cancel.addEventListener('click', () => cancelBuild(build.id, cancel));
+                        item.appendChild(cancel);
+                        
+                        container.appendChild(item);
+                    });
+                })
+                .catch(error => {
+                    console.error('Error loading running builds:', error);
+                });
+        }
+        
+        function cancelBuild(buildId, button) {
+            if (!confirm('Cancel build ' + buildId + '?')) {
+                return;
+            }
+            button.disabled = true;
+            fetch('api/v1/build/' + encodeURIComponent(buildId) + '/cancel', { method: 'POST' })
+                .then(response => {
+                    if (!response.ok) {
+                        return response.text().then(text => { throw new Error(text.trim()); });
+                    }
+                    loadRunningBuilds();
+                })
+                .catch(error => {
+                    button.disabled = false;
+                    alert('Failed to cancel build: ' + error.message);
+                });
+        }
+        
+        function retryBuild(build) {
+            const resultDiv = document.getElementById('build-result');
+            resultDiv.scrollIntoView({ behavior: 'smooth' });
+            resultDiv.innerHTML = '<div style="text-align: center; padding: 20px;"><div class="loading"></div><p style="margin-top: 15px; color: #A4FFF0; font-weight: 600;">Retrying ' + escapeHtml(build.environment) + ' build ' + escapeHtml(build.id) + '...</p></div>';
+            loadRunningBuilds();
+            
+            fetch('api/v1/build/' + encodeURIComponent(build.id) + '/retry', { method: 'POST' })
+                .then(response => {
+                    if (!response.ok) {
+                        return response.text().then(text => { throw new Error(text.trim()); });
+                    }
+                    return response.json();
+                })
+                .then(data => {
+                    showBuildResult(data);
+                    loadRunningBuilds();
+                    loadPreferences();
+                })
+                .catch(error => {
+                    resultDiv.innerHTML = '<div class="result result-error">' +
+                        '<h3>❌ Retry Failed!</h3>' +
+                        '<p>' + escapeHtml(error.message) + '</p>' +
+                    '</div>';
+                });
+        }
+        
+        function loadQuota() {
+            fetch('api/v1/quota')
+                .then(response => response.json())
+                .then(data => {
+                    const card = document.getElementById('quota-card');
+                    if (!data.enabled) {
+                        card.style.display = 'none';
+                        return;
+                    }
+                    card.style.display = 'block';
+                    const meter = (label, used, limit, unit) => {
+                        if (!limit) {
+                            return '';
+                        }
+                        const percent = Math.min(100, Math.round(used / limit * 100));
+                        const color = percent >= 100 ? '#ff6b6b' : (percent >= 80 ? '#ffd166' : '#A4FFF0');
+                        return '<div style="margin-top: 8px;">' +
+                                '<div style="display: flex; justify-content: space-between; font-size: 0.85rem;"><span>' + label + '</span><span>' + Math.round(used) + ' / ' + limit + unit + '</span></div>' +
+                                '<div style="height: 6px; background: rgba(164, 255, 240, 0.1); border-radius: 3px;"><div style="height: 6px; width: ' + percent + '%; background: ' + color + '; border-radius: 3px;"></div></div>' +
+                            '</div>';
+                    };
+                    document.getElementById('quota-container').innerHTML = data.quotas.map(quota =>
+                        '<div class="history-item" style="display: block;">' +
+                            '<strong>' + (quota.scope === 'team' ? '👥 Team ' : '👤 ') + escapeHtml(quota.name) + '</strong>' +
+                            meter('Builds in the last hour', quota.builds_last_hour, quota.limits.builds_per_hour, '') +
+                            meter('Builds in the last 24 hours', quota.builds_last_day, quota.limits.builds_per_day, '') +
+                            meter('Build minutes in the last 24 hours', quota.minutes_last_day, quota.limits.build_minutes_per_day, ' min') +
+                        '</div>'
+                    ).join('');
+                })
+                .catch(error => {
+                    console.error('Error loading quota:', error);
+                });
+        }
+        
+        function loadEnvironmentStats() {
+            fetch('api/v1/stats/environments')
+                .then(response => response.json())
+                .then(stats => {
+                    const container = document.getElementById('environment-stats');
+                    const names = Object.keys(stats).sort();
+                    if (names.length === 0) {
+                        container.innerHTML = '<p style="color: rgba(164, 255, 240, 0.7);">No builds yet</p>';
+                        return;
+                    }
+                    
+                    const cell = 'style="text-align: left; padding: 8px; border-bottom: 1px solid rgba(164, 255, 240, 0.1);"';
+                    let html = '<table style="width: 100%; border-collapse: collapse; font-size: 0.9rem;"><tr>' +
+                        ['Environment', 'Builds today', 'Avg duration', 'Last failure', 'Most used server'].map(h => '<th ' + cell + '>' + h + '</th>').join('') + '</tr>';
+                    names.forEach(name => {
+                        const s = stats[name];
+                        
+                        // Flag environments that got noticeably slower than before today
+                        let trend = '';
+                        if (s.previous_avg && s.avg_duration > s.previous_avg * 1.2) {
+                            trend = ' <span style="color: #ff6b6b;" title="Before today: ' + formatDuration(s.previous_avg) + '">▲ ' +
+                                Math.round((s.avg_duration / s.previous_avg - 1) * 100) + '%</span>';
+                        }
+                        const failure = s.last_failure ?
+                            '<a href="#" style="color: #ff6b6b;" onclick="showBuildLog(\'' + s.last_failure.id + '\'); return false;">' +
+                                new Date(s.last_failure.submitted_at).toLocaleString() + '</a>' : '-';
+                        const server = s.most_used_server ? s.most_used_server_id + ' (' + s.most_used_server + ', ' + s.most_used_builds + ' builds)' : '-';
+                        
+                        html += '<tr><td ' + cell + '><strong>' + name + '</strong></td>' +
+                            '<td ' + cell + '>' + s.builds_today + (s.failed_today ? ' <span style="color: #ff6b6b;">(' + s.failed_today + ' failed)</span>' : '') + '</td>' +
+                            '<td ' + cell + '>' + (s.avg_duration ? formatDuration(s.avg_duration) : '-') + trend + '</td>' +
+                            '<td ' + cell + '>' + failure + '</td>' +
+                            '<td ' + cell + '>' + server + '</td></tr>';
+                    });
+                    container.innerHTML = html + '</table>';
+                })
+                .catch(error => {
+                    console.error('Error loading environment statistics:', error);
+                });
+        }
+        
+        // First click selects the base build, the second one shows the artifact differences
+        let diffBase = null;
+        function compareBuild(buildId) {
+            if (!diffBase || diffBase === buildId) {
+                diffBase = diffBase === buildId ? null : buildId;
+                loadHistory();
+                return;
+            }
+            
+            const base = diffBase;
+            diffBase = null;
+            loadHistory();
+            fetch('api/v1/builds/diff?base=' + base + '&head=' + buildId)
+                .then(response => response.ok ? response.json() : response.text().then(text => { throw new Error(text); }))
+                .then(diff => {
+                    const marks = { added: '+', removed: '-', changed: '~', unchanged: ' ' };
+                    let text = diff.identical ? 'All artifacts are identical.\n\n' :
+                        (diff.counts.changed || 0) + ' changed, ' + (diff.counts.added || 0) + ' added, ' + (diff.counts.removed || 0) + ' removed, ' +
+                        (diff.counts.unchanged || 0) + ' unchanged\n\n';
+                    diff.changes.forEach(change => {
+                        let sizes = '';
+                        if (change.status === 'changed') {
+                            sizes = formatBytes(change.old_size) + ' -> ' + formatBytes(change.new_size);
+                        } else {
+                            sizes = formatBytes(change.status === 'removed' ? change.old_size : change.new_size);
+                        }
+                        text += marks[change.status] + ' ' + change.path + '  (' + sizes + ')\n';
+                    });
+                    showOutputModal('⇄ Artifacts ' + base + ' → ' + buildId, text);
+                })
+                .catch(error => {
+                    showOutputModal('⇄ Comparison failed', error.message);
+                });
+        }
+        
+        function showBuildLog(buildId) {
+            showLogViewer('📋 Build Log - ' + buildId, buildId, null);
+        }
+        
+        function loadClientVersion() {
+            fetch('api/v1/version')
+                .then(response => response.json())
+                .then(data => {
+                    document.getElementById('client-version').textContent = data.version;
+                    if (data.federation) {
+                        document.getElementById('federation-link').innerHTML = ' · <a href="federation" style="color: #A4FFF0;">🌐 Federation</a>';
+                    }
+                })
+                .catch(error => {
+                    console.error('Error loading client version:', error);
+                    document.getElementById('client-version').textContent = 'Unknown';
+                });
+        }
+        
+        function loadSession() {
+            fetch('api/v1/session')
+                .then(response => response.json())
+                .then(data => {
+                    if (data.enabled) {
+                        document.getElementById('session-info').innerHTML = ' · 👤 ' + escapeHtml(data.identity.user) +
+                            ' (<a href="auth/logout" style="color: #A4FFF0;">sign out</a>)';
+                    }
+                })
+                .catch(error => {
+                    console.error('Error loading session:', error);
+                });
+        }
+        
+        // Notification center: the bell lists recent events, new ones also pop up as toasts.
+        // The last seen ID is kept per browser so events from while the tab was closed show as unread.
+        const notificationSeenKey = 'boltbuild-notifications-seen';
+        let notifications = [];
+        
+        function notificationSeen() {
+            return parseInt(localStorage.getItem(notificationSeenKey) || '0', 10);
+        }
+        
+        function notificationElement(notification, className) {
+            const element = document.createElement('div');
+            element.className = className + ' ' + notification.level;
+            const title = document.createElement('strong');
+            title.textContent = notification.title;
+            element.appendChild(title);
+            if (notification.message) {
+                const message = document.createElement('div');
+                message.textContent = notification.message;
+                element.appendChild(message);
+            }
+            const time = document.createElement('div');
+            time.className = 'notification-time';
+            time.textContent = new Date(notification.time).toLocaleString();
+            element.appendChild(time);
+            if (notification.build_id) {
+                element.style.cursor = 'pointer';
+                element.addEventListener('click', () => showBuildLog(notification.build_id));
+            }
+            return element;
+        }
+        
+        function renderNotifications() {
+            const seen = notificationSeen();
+            const unread = notifications.filter(notification => notification.id > seen).length;
+            const badge = document.getElementById('notification-badge');
+            badge.textContent = unread;
+            badge.style.display = unread > 0 ? 'inline' : 'none';
+            
+            const panel = document.getElementById('notification-panel');
+            panel.innerHTML = '';
+            if (notifications.length === 0) {
+                panel.innerHTML = '<div style="color: #718096; padding: 10px;">No notifications yet</div>';
+                return;
+            }
+            notifications.slice().reverse().forEach(notification => {
+                panel.appendChild(notificationElement(notification,
+                    'notification-item' + (notification.id > seen ? ' unread' : '')));
+            });
+        }
+        
+        function showToast(notification) {
+            const toast = notificationElement(notification, 'toast');
+            toast.addEventListener('click', () => toast.remove());
+            document.getElementById('toast-container').appendChild(toast);
+            setTimeout(() => toast.remove(), 8000);
+        }
+        
+        function addNotification(notification) {
+            if (notifications.some(existing => existing.id === notification.id)) {
+                return;
+            }
+            notifications.push(notification);
+            notifications = notifications.slice(-100);
+            renderNotifications();
+            showToast(notification);
+            
+            if (notification.type === 'build_finished') {
+                loadHistory();
+                loadEnvironmentStats();
+            } else {
+                loadServers();
+            }
+        }
+        
+        document.getElementById('notification-bell').addEventListener('click', () => {
+            const panel = document.getElementById('notification-panel');
+            const opening = panel.style.display !== 'block';
+            panel.style.display = opening ? 'block' : 'none';
+            if (!opening && notifications.length > 0) {
+                // Closing the panel marks everything it showed as read
+                localStorage.setItem(notificationSeenKey, notifications[notifications.length - 1].id);
+            }
+            renderNotifications();
+        });
+        
+        function connectNotifications() {
+            fetch('api/v1/notifications')
+                .then(response => response.json())
+                .then(recent => {
+                    // A restarted client starts counting again, forget IDs from before
+                    const last = recent.length > 0 ? recent[recent.length - 1].id : 0;
+                    if (notificationSeen() > last) {
+                        localStorage.setItem(notificationSeenKey, '0');
+                    }
+                    notifications = recent;
+                    renderNotifications();
+                    
+                    // EventSource reconnects by itself and resumes with Last-Event-ID
+                    const source = new EventSource('api/v1/notifications/stream?since=' + last);
+                    source.onmessage = event => addNotification(JSON.parse(event.data));
+                })
+                .catch(error => {
+                    console.error('Error loading notifications:', error);
+                    setTimeout(connectNotifications, 5000);
+                });
+        }
+        
+        // Load environments and servers on page load
+        connectNotifications();
+        loadClientVersion();
+        loadSession();
+        loadEnvironments();
+        loadProjects();
+        loadServers();
+        loadHistory();
+        loadPreferences();
+        loadRunningBuilds();
+        loadEnvironmentStats();
+        loadQuota();
+        setInterval(loadServers, 3000);
+        setInterval(loadRunningBuilds, 2000);
+        setInterval(loadEnvironmentStats, 10000);
+        setInterval(loadQuota, 30000);
+    </script>
+</body>
+</html>`))
+}
+
+// handleServersAPI returns server status as JSON
+func (ws *WebServer) handleServersAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	status := ws.client.GetServerStatus()
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		http.Error(w, "Failed to encode server status", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleEstimateAPI returns the expected duration of a build (?environment=X&server=host:port)
+func (ws *WebServer) handleEstimateAPI(w http.ResponseWriter, r *http.Request) {
+	environment := r.URL.Query().Get("environment")
+	if environment == "" {
+		http.Error(w, "environment is required", http.StatusBadRequest)
+		return
+	}
+
+	// Samples is 0 when there is no earlier successful build to estimate from
+	estimate, _ := ws.client.history.Estimate(environment, r.URL.Query().Get("server"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(estimate)
+}
+
+// handleVersionAPI returns client version as JSON
+func (ws *WebServer) handleVersionAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	version := map[string]interface{}{
+		"version":    protocol.Version,
+		"federation": ws.config.Federation.Enabled(),
+	}
+
+	data, err := json.Marshal(version)
+	if err != nil {
+		http.Error(w, "Failed to encode version", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleEnvironmentsAPI returns available build environments from config
+func (ws *WebServer) handleEnvironmentsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get all build environments from config, with the servers able to run them
+	envs := make(map[string]interface{})
+	configured := ws.client.allEnvironments()
+	for name, env := range configured {
+		envs[name] = map[string]interface{}{
+			"name":       name,
+			"language":   env.Name,
+			"command":    env.CommandLine(),
+			"parameters": env.Parameters,
+			"source":     "client",
+			"servers":    ws.client.environmentAvailability(name, env),
+		}
+	}
+
+	// Environments only servers define are listed, but need a client definition to be built
+	for name, declared := range ws.client.serverOnlyEnvironments(configured) {
+		servers := make(map[string]EnvironmentAvailability)
+		var language, command string
+		for addr, env := range declared {
+			servers[addr] = EnvironmentAvailability{Available: false, Reason: "not configured on this client", Tool: commandTool(env.Command)}
+			language, command = env.Language, env.Command
+		}
+		envs[name] = map[string]interface{}{
+			"name":     name,
+			"language": language,
+			"command":  command,
+			"source":   "server",
+			"servers":  servers,
+		}
+	}
+
+	data, err := json.Marshal(envs)
+	if err != nil {
+		http.Error(w, "Failed to encode environments", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleBuildAPI handles build submission requests
+func (ws *WebServer) handleBuildAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		Environment    string            `json:"environment"`
+		Project        string            `json:"project"`     // Optional named project
+		ProjectDir     string            `json:"project_dir"` // Optional directory chosen in the directory browser
+		SelectedServer string            `json:"selectedServer"`
+		Subpath        string            `json:"subpath"`     // Optional monorepo sub-project
+		Parameters     map[string]string `json:"parameters"`  // Values for the environment's parameters
+		Speculative    bool              `json:"speculative"` // Also run on a second idle server
+		Clean          bool              `json:"clean"`       // Start the persistent workspace over
+		DryRun         bool              `json:"dry_run"`     // Report what the build would do instead of running it
+		Follow         bool              `json:"follow"`      // Send the build ID header once dispatched, see buildFollower
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Determine the environment and the project directory for file reading
+	environment, projectDir, err := ws.client.resolveProjectBuild(req.Project, req.Environment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ProjectDir != "" {
+		if projectDir, _, err = ws.client.resolveBrowsePath(req.ProjectDir); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := ws.authorizeBuild(r, environment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Submit build request - client will handle environment configuration
+	opts := BuildOptions{Project: req.Project, Subpath: req.Subpath, Parameters: req.Parameters, Speculative: req.Speculative, Clean: req.Clean}
+	opts.User, opts.Teams = ws.buildUser(r)
+	if req.DryRun {
+		report, err := ws.client.DryRunBuild(environment, projectDir, req.SelectedServer, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+	follower := &buildFollower{w: w}
+	if req.Follow {
+		follower.follow(&opts)
+	}
+	ws.recordLaunch(r, req.Environment, req.Project, req.SelectedServer)
+	response, err := ws.client.SubmitBuildToServer(r.Context(), environment, "", projectDir, projectDir, []string{}, req.SelectedServer, opts)
+	if err != nil {
+		follower.fail(err)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		follower.fail(fmt.Errorf("failed to encode build response: %v", err))
+		return
+	}
+	w.Write(data)
+}
+
+// handleCancelBuildAPI stops a running build
+func (ws *WebServer) handleCancelBuildAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := ws.client.CancelBuild(id); err != nil {
+		status := http.StatusNotFound
+		if _, finished := ws.client.history.Get(id); finished {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"cancelled": true})
+}
+
+// handleRetryBuildAPI submits a past build again with the same environment, project directory,
+// subpath and parameters, on any available server unless the body names one
+func (ws *WebServer) handleRetryBuildAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	record, exists := ws.client.history.Get(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Unknown build: %s", id), http.StatusNotFound)
+		return
+	}
+	if record.ProjectDir == "" {
+		http.Error(w, fmt.Sprintf("Build %s was uploaded and cannot be retried", id), http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		Server string `json:"server"` // Optional build server address
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := ws.authorizeBuild(r, record.Environment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	logging.Infof("Retrying build %s of environment %s", id, record.Environment)
+	ws.recordLaunch(r, record.Environment, record.Project, req.Server)
+	opts := BuildOptions{
+		Project:     record.Project,
+		Subpath:     record.Subpath,
+		Parameters:  record.Parameters,
+		Command:     record.Command,
+		OutputPaths: record.OutputPaths,
+	}
+	opts.User, opts.Teams = ws.buildUser(r)
+	response, err := ws.client.SubmitBuildToServer(r.Context(), record.Environment, "", record.ProjectDir, record.ProjectDir, []string{}, req.Server, opts)
+	if err != nil {
+		writeBuildError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleRunningBuildsAPI lists the builds in progress, oldest first
+func (ws *WebServer) handleRunningBuildsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.client.RunningBuilds())
+}
+
+// handleBuildsAPI returns the build history as JSON, newest first
+func (ws *WebServer) handleBuildsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	records := ws.client.history.List()
+	if project := r.URL.Query().Get("project"); project != "" {
+		records = ws.client.history.ListProject(project)
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		http.Error(w, "Failed to encode build history", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleBuildRecordAPI returns a single build record as JSON
+func (ws *WebServer) handleBuildRecordAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id := mux.Vars(r)["id"]
+
+	record, exists := ws.client.history.Get(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Unknown build: %s", id), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		http.Error(w, "Failed to encode build record", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleBuildDiffAPI compares the output files of two builds of the same environment (?base=ID&head=ID)
+func (ws *WebServer) handleBuildDiffAPI(w http.ResponseWriter, r *http.Request) {
+	var records [2]BuildRecord
+	for i, param := range []string{"base", "head"} {
+		id := r.URL.Query().Get(param)
+		record, exists := ws.client.history.Get(id)
+		if !exists {
+			http.Error(w, fmt.Sprintf("Unknown %s build: %s", param, id), http.StatusNotFound)
+			return
+		}
+		if len(record.Artifacts) == 0 && len(record.OutputFiles) > 0 {
+			http.Error(w, fmt.Sprintf("Build %s has no artifact hashes to compare", id), http.StatusBadRequest)
+			return
+		}
+		records[i] = record
+	}
+	base, head := records[0], records[1]
+	if base.Environment != head.Environment {
+		http.Error(w, fmt.Sprintf("Builds belong to different environments (%s, %s)", base.Environment, head.Environment), http.StatusBadRequest)
+		return
+	}
+
+	changes := diffArtifacts(base.Artifacts, head.Artifacts)
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Status]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"base":        base.ID,
+		"head":        head.ID,
+		"environment": base.Environment,
+		"identical":   counts[ArtifactAdded]+counts[ArtifactRemoved]+counts[ArtifactChanged] == 0,
+		"counts":      counts,
+		"changes":     changes,
+	})
+}
+
+// handleBuildLogAPI returns the full stored log of a build as plain text, or a page of it as JSON
+func (ws *WebServer) handleBuildLogAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	log, exists := ws.client.history.Log(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("No log for build: %s", id), http.StatusNotFound)
+		return
+	}
+
+	// The log viewer pages through long logs: ?format=json&start=N&limit=N&q=filter,
+	// without start the last page is returned
+	query := r.URL.Query()
+	if query.Get("format") == "json" {
+		start, limit := -1, 0
+		if value := query.Get("start"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid start: "+value, http.StatusBadRequest)
+				return
+			}
+			start = parsed
+		}
+		if value := query.Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid limit: "+value, http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pageLog(id, log, query.Get("q"), start, limit))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".log"))
+	}
+	w.Write([]byte(log))
+}
+
+// handleBuildArtifactAPI downloads an output file of a build from where the client saved it.
+// Files a later build replaced are reported as gone rather than served with the wrong content.
+func (ws *WebServer) handleBuildArtifactAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	record, exists := ws.client.history.Get(id)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Build not found: %s", id), http.StatusNotFound)
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	var artifact *ArtifactInfo
+	for i := range record.Artifacts {
+		if record.Artifacts[i].Path == path {
+			artifact = &record.Artifacts[i]
+		}
+	}
+	if artifact == nil {
+		http.Error(w, fmt.Sprintf("Build %s has no artifact %s", id, path), http.StatusNotFound)
+		return
+	}
+	if artifact.LocalPath == "" {
+		http.Error(w, fmt.Sprintf("Artifact %s was not saved on this machine", path), http.StatusNotFound)
+		return
+	}
+
+	content, err := os.ReadFile(artifact.LocalPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Artifact %s is no longer available: %v", path, err), http.StatusGone)
+		return
+	}
+	if buildutil.ArtifactChecksum(content) != artifact.SHA256 {
+		http.Error(w, fmt.Sprintf("Artifact %s was replaced since build %s", path, id), http.StatusGone)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(artifact.LocalPath)))
+	w.Write(content)
+}
+
+// handleDetectAPI detects the project type of a directory and suggests matching environments
+func (ws *WebServer) handleDetectAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		ProjectDir  string `json:"project_dir"`
+		Project     string `json:"project"`     // Alternatively detect in this project's directory
+		Environment string `json:"environment"` // or in this environment's project_dir
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	projectDir := "."
+	var err error
+	if req.ProjectDir != "" {
+		if projectDir, _, err = ws.client.resolveBrowsePath(req.ProjectDir); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	} else if req.Project != "" || req.Environment != "" {
+		if _, projectDir, err = ws.client.resolveProjectBuild(req.Project, req.Environment); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := DetectEnvironments(projectDir, ws.client.allEnvironments())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to inspect %s: %v", projectDir, err), http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, "Failed to encode detection result", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleSubprojectsAPI lists buildable sub-projects inside a project or environment directory
+func (ws *WebServer) handleSubprojectsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var projectDir string
+	var err error
+	if dir := r.URL.Query().Get("project_dir"); dir != "" {
+		projectDir, _, err = ws.client.resolveBrowsePath(dir)
+	} else {
+		_, projectDir, err = ws.client.resolveProjectBuild(r.URL.Query().Get("project"), r.URL.Query().Get("environment"))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subprojects, err := findSubprojects(projectDir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to scan project directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(subprojects)
+	if err != nil {
+		http.Error(w, "Failed to encode sub-projects", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleProjectsAPI returns the configured projects
+func (ws *WebServer) handleProjectsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := json.Marshal(ws.client.listProjects())
+	if err != nil {
+		http.Error(w, "Failed to encode projects", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// handleFSAPI lists directories below the allowed browse roots
+func (ws *WebServer) handleFSAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	listing, err := ws.client.listDirectory(r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	data, err := json.Marshal(listing)
+	if err != nil {
+		http.Error(w, "Failed to encode directory listing", http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+// BuildIDHeader names the build of a followed build API call
+const BuildIDHeader = "X-Boltbuild-Build-ID"
+
+// buildFollower lets callers of the synchronous build APIs watch the output live: with follow
+// set, the response headers go out with the build ID as soon as the build is dispatched, so the
+// caller can subscribe through build.subscribe while the result is still being waited for
+type buildFollower struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+// follow makes the build announce its ID in the response headers
+func (f *buildFollower) follow(opts *BuildOptions) {
+	opts.started = func(buildID string) {
+		f.w.Header().Set(BuildIDHeader, buildID)
+		f.w.WriteHeader(http.StatusOK)
+		if flusher, ok := f.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		f.started = true
+	}
+}
+
+// fail answers a build that could not be completed; once the status went out the error is
+// reported as a failed build response instead
+func (f *buildFollower) fail(err error) {
+	if !f.started {
+		writeBuildError(f.w, err)
+		return
+	}
+	json.NewEncoder(f.w).Encode(protocol.BuildResponse{ID: f.w.Header().Get(BuildIDHeader), Error: err.Error(), ExitCode: -1})
+}
+
+// handleBuildUploadAPI builds files uploaded with the request instead of a local project directory.
+// Settings are passed as form fields or query parameters: environment, server (optional),
+// project (optional), parameters (JSON object), command and outputs (comma separated) overrides,
+// and follow=true to receive the build ID header early (see buildFollower).
+func (ws *WebServer) handleBuildUploadAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, ws.uploadLimit())
+	files, err := readUploadedFiles(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read upload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(files) == 0 {
+		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		return
+	}
+
+	value := func(name string) string {
+		if v := r.Form.Get(name); v != "" {
+			return v
+		}
+		return r.URL.Query().Get(name)
+	}
+
+	opts := BuildOptions{Project: value("project"), Files: files, Command: value("command"), ForwardedBy: r.Header.Get(forwardedByHeader)}
+	opts.User, opts.Teams = ws.buildUser(r)
+	if outputs := value("outputs"); outputs != "" {
+		opts.OutputPaths = strings.Split(outputs, ",")
+	}
+	if params := value("parameters"); params != "" {
+		if err := json.Unmarshal([]byte(params), &opts.Parameters); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid parameters: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	environment, _, err := ws.client.resolveProjectBuild(opts.Project, value("environment"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ws.authorizeBuild(r, environment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if value("dry_run") == "true" {
+		report, err := ws.client.DryRunBuild(environment, "", value("server"), opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	logging.Infof("Received upload build for environment %s with %d files", environment, len(files))
+	if opts.ForwardedBy != "" {
+		logging.Infof("Build forwarded by federation site %s", opts.ForwardedBy)
+	}
+
+	follower := &buildFollower{w: w}
+	if value("follow") == "true" {
+		follower.follow(&opts)
+	}
+
+	var response *protocol.BuildResponse
+	if server := value("server"); server != "" {
+		response, err = ws.client.SubmitBuildToServer(r.Context(), environment, "", "", "", []string{}, server, opts)
+	} else {
+		response, err = ws.client.SubmitBuild(r.Context(), environment, "", "", []string{}, opts)
+	}
+	if err != nil {
+		follower.fail(err)
+		return
+	}
+
+	// External callers get plain base64 artifacts
+	if err := decompressArtifacts(response); err != nil {
+		follower.fail(err)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		follower.fail(fmt.Errorf("failed to encode build response: %v", err))
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+)
+
+// webTLSConfig loads the web interface certificate and keeps it current. With ACME it also
+// returns the handler answering HTTP challenges, to wrap the redirect listener's handler.
+// Certificates are renewed with the timing of the build connections' TLS settings.
+func webTLSConfig(cfg config.WebTLSConfig, timing config.TLSConfig) (*tls.Config, func(http.Handler) http.Handler, error) {
+	if cfg.ACME.Enabled() {
+		manager := acmeManager(cfg.ACME)
+		logging.Infof("Web interface certificates for %s are obtained by ACME and cached in %s",
+			strings.Join(cfg.ACME.Hosts, ", "), manager.Cache)
+		return manager.TLSConfig(), manager.HTTPHandler, nil
+	}
+
+	settings := cfg.CertificateConfig(timing)
+	certificates, err := transport.NewCertificateStore(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("web TLS: %v", err)
+	}
+	go certificates.Watch(make(chan struct{}))
+	if cfg.SelfSigned {
+		logging.Infof("Web interface certificate issued by the local CA %s; import it into browsers to trust the dashboard", settings.CAFile)
+	}
+	return certificates.ServerTLSConfig(), nil, nil
+}
+
+// serveHTTPSRedirect answers plain HTTP requests on the redirect port with a redirect to the
+// same path on the HTTPS port; challenges, if set, answers ACME HTTP challenges first
+func serveHTTPSRedirect(bindAddress string, redirectPort, httpsPort int, challenges func(http.Handler) http.Handler) {
+	addr := net.JoinHostPort(bindAddress, strconv.Itoa(redirectPort))
+	logging.Infof("Redirecting HTTP requests on %s to HTTPS", addr)
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+	if challenges != nil {
+		handler = challenges(handler)
+	}
+	err := http.ListenAndServe(addr, handler)
+	logging.Infof("Warning: HTTP redirect listener failed: %v", err)
+}
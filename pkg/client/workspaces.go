@@ -0,0 +1,38 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/config"
+)
+
+// workspaceName names the persistent workspace of an environment and project directory; the
+// directory is hashed so projects with the same base name on one client do not share a workspace
+func workspaceName(environment, projectDir string) string {
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		projectDir = abs
+	}
+	sum := sha256.Sum256([]byte(projectDir))
+	return buildutil.SafeName(environment) + "-" + buildutil.SafeName(filepath.Base(projectDir)) + "-" + hex.EncodeToString(sum[:6])
+}
+
+// projectWorkspaceName names the persistent workspace of a named project, shared by every
+// checkout of the project
+func projectWorkspaceName(environment, project string) string {
+	return buildutil.SafeName(environment) + "-project-" + buildutil.SafeName(project)
+}
+
+// persistentWorkspace is the workspace name sent with builds of an environment, "" unless it opts in.
+// Builds of a named project are keyed by the project, others by their directory.
+func persistentWorkspace(env *config.BuildEnvironment, environment, project, projectDir string) string {
+	if !env.PersistentWorkspace {
+		return ""
+	}
+	if project != "" {
+		return projectWorkspaceName(environment, project)
+	}
+	return workspaceName(environment, projectDir)
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/protocol"
+)
+
+// AccessConfig restricts which machines may use a server
+type AccessConfig struct {
+	AllowCIDRs       []string `yaml:"allow_cidrs,omitempty"`        // Only these networks may connect (empty = any)
+	DenyCIDRs        []string `yaml:"deny_cidrs,omitempty"`         // These networks are always refused
+	AllowedClientIDs []string `yaml:"allowed_client_ids,omitempty"` // Only clients identifying with these IDs may build (empty = any)
+}
+
+// AccessPolicy is the parsed form of AccessConfig
+type AccessPolicy struct {
+	allow     []*net.IPNet
+	deny      []*net.IPNet
+	clientIDs []string
+}
+
+// parseCIDRs parses networks; single addresses are accepted as /32 or /128 networks
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address: %s", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			value = fmt.Sprintf("%s/%d", value, bits)
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network: %s", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// NewAccessPolicy parses the access configuration
+func NewAccessPolicy(config AccessConfig) (*AccessPolicy, error) {
+	allow, err := parseCIDRs(config.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseCIDRs(config.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &AccessPolicy{allow: allow, deny: deny, clientIDs: config.AllowedClientIDs}, nil
+}
+
+// containsIP reports whether any of the networks contains ip
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAddress decides at accept time whether a remote address may connect
+func (p *AccessPolicy) CheckAddress(addr string) error {
+	if len(p.allow) == 0 && len(p.deny) == 0 {
+		return nil
+	}
+	ip := net.ParseIP(ClientKey(addr))
+	if ip == nil {
+		return fmt.Errorf("unknown address %s", addr)
+	}
+	if containsIP(p.deny, ip) {
+		return fmt.Errorf("address %s is denied", ip)
+	}
+	if len(p.allow) > 0 && !containsIP(p.allow, ip) {
+		return fmt.Errorf("address %s is not in the allowed networks", ip)
+	}
+	return nil
+}
+
+// RequiresClientID reports whether clients must identify before building
+func (p *AccessPolicy) RequiresClientID() bool {
+	return len(p.clientIDs) > 0
+}
+
+// CheckClient decides at handshake time whether an identified client may build
+func (p *AccessPolicy) CheckClient(info protocol.ClientInfo) error {
+	if !p.RequiresClientID() || buildutil.ContainsString(p.clientIDs, info.ID) {
+		return nil
+	}
+	return fmt.Errorf("client ID %q is not allowed", info.ID)
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACMEConfig obtains and renews the web interface certificate from an ACME CA such as Let's Encrypt.
+// The CA validates the hostnames over TLS on port 443 (web.port) or HTTP on port 80 (web.tls.redirect_port).
+type ACMEConfig struct {
+	Hosts        []string `yaml:"hosts,omitempty"`         // Hostnames to request certificates for; setting any enables ACME
+	Email        string   `yaml:"email,omitempty"`         // Contact for expiry and problem notices from the CA
+	CacheDir     string   `yaml:"cache_dir,omitempty"`     // Account key and certificates (default: ./boltbuild-acme)
+	DirectoryURL string   `yaml:"directory_url,omitempty"` // ACME directory (default: Let's Encrypt production)
+}
+
+// Enabled reports whether certificates are obtained by ACME
+func (c ACMEConfig) Enabled() bool {
+	return len(c.Hosts) > 0
+}
+
+// validate checks the hostnames and the directory URL
+func (c ACMEConfig) validate() error {
+	for _, host := range c.Hosts {
+		if host == "" || strings.ContainsAny(host, ":/ *") {
+			return fmt.Errorf("invalid web.tls.acme host %q: expected a DNS name without port or wildcard", host)
+		}
+	}
+	if url := c.DirectoryURL; url != "" && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("invalid web.tls.acme.directory_url %q: expected an https URL", url)
+	}
+	return nil
+}
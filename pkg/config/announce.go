@@ -0,0 +1,20 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// validateAnnounceTo checks the client addresses of server.announce_to
+func validateAnnounceTo(clients []string) error {
+	for _, client := range clients {
+		if strings.HasPrefix(client, "http://") || strings.HasPrefix(client, "https://") {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(client); err != nil {
+			return fmt.Errorf("invalid server announce_to address %s: %v", client, err)
+		}
+	}
+	return nil
+}
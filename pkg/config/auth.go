@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuthConfig gates the dashboard and API behind a login (no login configured = anonymous access)
+type AuthConfig struct {
+	SessionTTL time.Duration       `yaml:"session_ttl,omitempty"` // How long a login lasts
+	OIDC       *OIDCConfig         `yaml:"oidc,omitempty"`        // OpenID Connect single sign-on
+	LDAP       *LDAPConfig         `yaml:"ldap,omitempty"`        // LDAP or Active Directory user name and password login
+	Roles      map[string][]string `yaml:"roles,omitempty"`       // Group -> roles granted to its members
+	APIKeys    []APIKeyConfig      `yaml:"api_keys,omitempty"`    // Keys of scripts and CI jobs (usable without a login method)
+}
+
+// Enabled reports whether requests must be authenticated
+func (c AuthConfig) Enabled() bool {
+	return c.OIDC != nil || c.LDAP != nil
+}
+
+// validate checks the configured login methods
+func (c AuthConfig) validate() error {
+	if err := validateAPIKeys(c.APIKeys); err != nil {
+		return err
+	}
+	if !c.Enabled() {
+		return nil
+	}
+	if c.SessionTTL <= 0 {
+		return fmt.Errorf("invalid web.auth.session_ttl: %v", c.SessionTTL)
+	}
+	if c.OIDC != nil {
+		if err := c.OIDC.validate(); err != nil {
+			return err
+		}
+	}
+	if c.LDAP != nil {
+		if err := c.LDAP.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
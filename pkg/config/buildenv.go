@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// ScrubbedValue replaces secret values in logs and debug bundles
+const ScrubbedValue = "***"
+
+// BuildEnvConfig controls which variables of the server's environment build commands inherit
+// and which variables are secrets that logs and debug bundles must not show. Names are matched
+// case-insensitively against glob patterns such as "AWS_*".
+type BuildEnvConfig struct {
+	Allow   []string `yaml:"allow,omitempty"`   // Host variables passed to builds (empty = all); PATH, HOME, TEMP and the like are always passed
+	Deny    []string `yaml:"deny,omitempty"`    // Host variables never passed to builds, even when allowed
+	Secrets []string `yaml:"secrets,omitempty"` // Variables whose values are scrubbed from logged command environments
+}
+
+// essentialEnvVars are passed to builds despite an allowlist (but not a denylist), as
+// commands cannot be found or create temp files without them
+var essentialEnvVars = []string{"PATH", "HOME", "USER", "LANG", "TMPDIR", "TEMP", "TMP", "SYSTEMROOT", "SYSTEMDRIVE", "COMSPEC", "PATHEXT", "WINDIR", "USERPROFILE"}
+
+// validate checks the glob patterns
+func (c BuildEnvConfig) validate() error {
+	for _, patterns := range [][]string{c.Allow, c.Deny, c.Secrets} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return fmt.Errorf("invalid server build env pattern: %q", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// matchEnvName reports whether a variable name matches one of the patterns
+func matchEnvName(patterns []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToUpper(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// passes reports whether a host variable is passed to build commands
+func (c BuildEnvConfig) passes(name string) bool {
+	if matchEnvName(c.Deny, name) {
+		return false
+	}
+	return len(c.Allow) == 0 || matchEnvName(c.Allow, name) || matchEnvName(essentialEnvVars, name)
+}
+
+// Environ returns the environment of a build command: the allowed host variables and the
+// variables of the build environment, which may set denied names explicitly
+func (c BuildEnvConfig) Environ(vars map[string]string) []string {
+	var environ []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		// Windows keeps per-drive directories in variables like "=C:"
+		if name == "" && runtime.GOOS == "windows" || c.passes(name) {
+			environ = append(environ, entry)
+		}
+	}
+	for key, value := range vars {
+		environ = append(environ, fmt.Sprintf("%s=%s", key, value))
+	}
+	return environ
+}
+
+// Secret reports whether a variable's value must not be logged
+func (c BuildEnvConfig) Secret(name string) bool {
+	return matchEnvName(c.Secrets, name)
+}
+
+// Scrub returns a copy of build environment variables with secret values replaced
+func (c BuildEnvConfig) Scrub(vars map[string]string) map[string]string {
+	if len(vars) == 0 {
+		return vars
+	}
+	scrubbed := make(map[string]string, len(vars))
+	for key, value := range vars {
+		if c.Secret(key) {
+			value = ScrubbedValue
+		}
+		scrubbed[key] = value
+	}
+	return scrubbed
+}
+
+// ScrubArgs replaces secret values in NAME=value command arguments, as passed to
+// container runtimes and env(1)
+func (c BuildEnvConfig) ScrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	for i, arg := range args {
+		if name, _, found := strings.Cut(arg, "="); found && name != "" && c.Secret(name) {
+			arg = name + "=" + ScrubbedValue
+		}
+		scrubbed[i] = arg
+	}
+	return scrubbed
+}
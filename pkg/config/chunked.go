@@ -0,0 +1,14 @@
+package config
+
+import (
+	"boltbuild/pkg/protocol"
+)
+
+// ClientTransferConfig controls how the client sends project files to servers
+type ClientTransferConfig struct {
+	ChunkThreshold protocol.ByteSize `yaml:"chunk_threshold,omitempty"` // Projects larger than this are uploaded in chunks (0 = never)
+	ChunkSize      protocol.ByteSize `yaml:"chunk_size,omitempty"`      // Size of each chunk
+	Streams        int               `yaml:"streams,omitempty"`         // Parallel connections carrying the chunks
+
+	BandwidthConfig `yaml:",inline"` // Upload limit: max_bandwidth and limit_hours
+}
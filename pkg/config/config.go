@@ -0,0 +1,711 @@
+// Package config loads, defaults and validates the boltbuild YAML configuration shared by the
+// server, client and relay roles:
+//
+//	cfg, err := config.Load("boltbuild.yaml")
+//
+// Load applies Default for every omitted setting and rejects invalid files; programs building a
+// Config by hand should start from Default and call Validate.
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"boltbuild/pkg/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// Config represents the complete configuration for BoltBuild
+type Config struct {
+	Server     ServerConfig             `yaml:"server"`
+	Client     ClientConfig             `yaml:"client"`
+	Web        WebConfig                `yaml:"web"`
+	Build      BuildConfig              `yaml:"build"`
+	TLS        TLSConfig                `yaml:"tls,omitempty"`        // Encryption of client/server connections
+	WANMode    bool                     `yaml:"wan_mode,omitempty"`   // Internet deployment: TLS and relay-only connections enforced
+	Relay      RelayConfig              `yaml:"relay,omitempty"`      // "boltbuild relay" settings
+	Hooks      HooksConfig              `yaml:"hooks,omitempty"`      // Lifecycle hooks applied to all environments
+	Plugins    []PluginConfig           `yaml:"plugins,omitempty"`    // External environment providers
+	Projects   map[string]ProjectConfig `yaml:"projects,omitempty"`   // Named projects with a default environment
+	Federation FederationConfig         `yaml:"federation,omitempty"` // Peer clients shown in the federated view
+	Logging    LoggingConfig            `yaml:"logging"`
+	Faults     FaultConfig              `yaml:"faults,omitempty"` // Injected failures for resilience tests, never in production
+}
+
+// ServerConfig contains server-specific configuration
+type ServerConfig struct {
+	Port             int                `yaml:"port"`
+	Capacity         int                `yaml:"capacity"` // Concurrent builds (0 = from the CPUs and available memory, see auto_capacity)
+	AutoCapacity     AutoCapacityConfig `yaml:"auto_capacity,omitempty"`
+	BuildNice        int                `yaml:"build_nice,omitempty"`        // Nice level of build commands, 0 to 19 (Windows: below normal from 1, idle from 10)
+	BuildIOPriority  string             `yaml:"build_io_priority,omitempty"` // Linux IO priority of builds: normal (default, follows the nice level), low or idle
+	BuildEnv         BuildEnvConfig     `yaml:"build_env,omitempty"`         // Host variables passed to builds and secrets scrubbed from logs
+	CPUPinning       string             `yaml:"cpu_pinning,omitempty"`       // Bind build slots to CPU sets on Linux: none (default), slots or numa
+	Instances        int                `yaml:"instances,omitempty"`         // Logical servers sharing the capacity, on server.port and free discovery ports (default 1)
+	ContainerRuntime string             `yaml:"container_runtime,omitempty"` // auto (default), docker, podman or none
+	FairShare        FairShareConfig    `yaml:"fair_share,omitempty"`        // How builds of several clients share the capacity
+	AdminPort        int                `yaml:"admin_port,omitempty"`        // HTTP status endpoint listing connected clients (0 = disabled)
+	Access           AccessConfig       `yaml:"access,omitempty"`            // Which machines and clients may use this server
+	ConnectTo        []string           `yaml:"connect_to,omitempty"`        // Clients this server dials (host:listen_port) instead of waiting to be discovered
+	AnnounceTo       []string           `yaml:"announce_to,omitempty"`       // Clients (host:web_port or URL) told on startup to connect right away
+	Relay            RelayConnection    `yaml:"relay,omitempty"`             // Relay this server registers at
+	WebSocket        WebSocketConfig    `yaml:"websocket,omitempty"`         // Build protocol over WebSocket for HTTP-only proxies and firewalls
+	Transfer         BandwidthConfig    `yaml:"transfer,omitempty"`          // Limit for results sent to clients
+	ContentCache     ContentCacheConfig `yaml:"content_cache"`               // Keep uploaded projects to skip identical uploads
+	Workspaces       WorkspacesConfig   `yaml:"workspaces,omitempty"`        // Persistent per-project workspaces for incremental builds
+	MinFreeDisk      protocol.ByteSize  `yaml:"min_free_disk,omitempty"`     // Below this free space in the temp directory clients stop scheduling builds here (0 = never)
+}
+
+// AutoCapacityConfig sizes the capacity of servers without a configured capacity: one build per
+// CPUsPerBuild CPUs, limited by the memory available at start divided by MemoryPerBuild
+type AutoCapacityConfig struct {
+	CPUsPerBuild   int               `yaml:"cpus_per_build,omitempty"`   // Default 2
+	MemoryPerBuild protocol.ByteSize `yaml:"memory_per_build,omitempty"` // Default 2GB
+}
+
+// FairShareConfig controls which waiting client gets the next free build slot
+type FairShareConfig struct {
+	Policy  string         `yaml:"policy,omitempty"`  // round_robin (default) or weighted
+	Weights map[string]int `yaml:"weights,omitempty"` // Client address -> relative share for weighted (default 1)
+}
+
+// ClientConfig contains client-specific configuration
+type ClientConfig struct {
+	ID            string               `yaml:"id,omitempty"` // Identity sent to servers (default: hostname and a random suffix)
+	Discovery     DiscoveryConfig      `yaml:"discovery"`
+	Timeouts      TimeoutConfig        `yaml:"timeouts"`
+	History       HistoryConfig        `yaml:"history"`
+	Scheduling    string               `yaml:"scheduling,omitempty"`     // How a free server is picked: first_available (default), least_loaded or fastest_transfer
+	ListenPort    int                  `yaml:"listen_port,omitempty"`    // Port servers with connect_to dial (0 = disabled)
+	Relay         RelayConnection      `yaml:"relay,omitempty"`          // Relay to reach the servers of a tenant through
+	SSH           []SSHServer          `yaml:"ssh,omitempty"`            // Servers reached through SSH
+	Transfer      ClientTransferConfig `yaml:"transfer,omitempty"`       // Chunked upload of large projects
+	Quotas        QuotaConfig          `yaml:"quotas,omitempty"`         // Build limits per user and team
+	Events        EventStreamConfig    `yaml:"events,omitempty"`         // JSON lines of build lifecycle events for external consumers
+	StatsD        StatsDConfig         `yaml:"statsd,omitempty"`         // Build metrics pushed to a StatsD/DogStatsD agent
+	LocalFallback bool                 `yaml:"local_fallback,omitempty"` // Run builds on this machine while no build server is connected
+}
+
+// Scheduling strategies for client.scheduling
+const (
+	SchedulingFirstAvailable  = "first_available"  // Any free server that can run the build
+	SchedulingLeastLoaded     = "least_loaded"     // The free server with the lowest reported CPU load
+	SchedulingFastestTransfer = "fastest_transfer" // The free server the project files reach first (probed bandwidth and latency)
+)
+
+// HistoryConfig controls how finished builds and their logs are kept
+type HistoryConfig struct {
+	Dir        string `yaml:"dir"`                 // Directory to persist history in (empty = memory only)
+	MaxEntries int    `yaml:"max_entries"`         // Number of builds to keep
+	Snapshots  bool   `yaml:"snapshots,omitempty"` // Archive the project files of every build so it can be replayed exactly
+}
+
+// WebConfig contains web interface configuration
+type WebConfig struct {
+	Port        int          `yaml:"port"`
+	BrowseRoots []string     `yaml:"browse_roots,omitempty"` // Directories the web interface may browse and build (default: environment and project directories)
+	CORS        CORSConfig   `yaml:"cors,omitempty"`         // Cross-origin access to the API for dashboards and IDE plugins
+	BasePath    string       `yaml:"base_path,omitempty"`    // Path prefix behind a reverse proxy, e.g. "/boltbuild/"
+	BindAddress string       `yaml:"bind_address,omitempty"` // Interface to listen on, e.g. 127.0.0.1 (default: all)
+	UnixSocket  string       `yaml:"unix_socket,omitempty"`  // Listen on this Unix domain socket instead of the TCP port
+	TLS         WebTLSConfig `yaml:"tls,omitempty"`          // HTTPS for the dashboard and API
+	Auth        AuthConfig   `yaml:"auth,omitempty"`         // Login for the dashboard and API
+}
+
+// LoggingConfig contains logging configuration
+type LoggingConfig struct {
+	Level         string       `yaml:"level"`                    // "info", "debug"
+	Syslog        SyslogConfig `yaml:"syslog,omitempty"`         // Also send records to a syslog server
+	EventLog      bool         `yaml:"event_log,omitempty"`      // Also write startup, shutdown, build and login failures to the Windows Event Log
+	EventSource   string       `yaml:"event_source,omitempty"`   // Event Log source name (default "boltbuild")
+	ProtocolTrace string       `yaml:"protocol_trace,omitempty"` // File receiving every client/server message, file contents elided (empty = disabled)
+}
+
+// DiscoveryConfig contains server discovery settings
+type DiscoveryConfig struct {
+	Ports          []int         `yaml:"ports"`
+	Servers        []string      `yaml:"servers,omitempty"` // Static servers: host:port, ws://host:port/path or wss://host/path
+	ScanInterval   time.Duration `yaml:"scan_interval"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	NetworkRange   NetworkRange  `yaml:"network_range"`
+}
+
+// NetworkRange defines the IP range for server discovery
+type NetworkRange struct {
+	Auto    bool   `yaml:"auto"`     // Auto-detect local network
+	Subnet  string `yaml:"subnet"`   // e.g., "192.168.1"
+	StartIP int    `yaml:"start_ip"` // Start IP in range (1-254)
+	EndIP   int    `yaml:"end_ip"`   // End IP in range (1-254)
+}
+
+// TimeoutConfig contains various timeout settings
+type TimeoutConfig struct {
+	Build       time.Duration `yaml:"build"`
+	Reconnect   time.Duration `yaml:"reconnect"`
+	HealthCheck time.Duration `yaml:"health_check"`
+}
+
+// BuildConfig contains build system configurations
+type BuildConfig struct {
+	Environments     map[string]BuildEnvironment `yaml:"environments"`
+	TempDir          string                      `yaml:"temp_dir"`
+	TempDeletion     bool                        `yaml:"temp_deletion"`
+	TempCleanup      TempCleanupConfig           `yaml:"temp_cleanup,omitempty"` // Removal of directories preserved with temp_deletion off
+	MaxWorkspaceSize protocol.ByteSize           `yaml:"max_workspace_size"`     // Maximum size of a build's project directory on the server (0 = unlimited)
+	Artifacts        ArtifactLimits              `yaml:"artifacts"`
+}
+
+// ArtifactLimits restricts the size of output files returned to the client
+type ArtifactLimits struct {
+	MaxFileSize  protocol.ByteSize `yaml:"max_file_size"`  // Per-file limit (0 = unlimited)
+	MaxTotalSize protocol.ByteSize `yaml:"max_total_size"` // Limit for all artifacts of a build (0 = unlimited)
+	OnLimit      string            `yaml:"on_limit"`       // "skip" (default), "truncate" or "fail"
+}
+
+// Artifact limit behaviors
+const (
+	ArtifactLimitSkip     = "skip"
+	ArtifactLimitTruncate = "truncate"
+	ArtifactLimitFail     = "fail"
+)
+
+// BuildEnvironment defines build settings for a specific language/environment
+type BuildEnvironment struct {
+	Name                 string                     `yaml:"name"`
+	Command              string                     `yaml:"command"`
+	Args                 []string                   `yaml:"args,omitempty"`                   // Argument list run as is, instead of a command split on whitespace
+	Shell                bool                       `yaml:"shell,omitempty"`                  // Run the command through sh -c (cmd /C on Windows servers) for quotes, pipes and &&
+	Commands             []CommandStep              `yaml:"commands,omitempty"`               // Commands run one after the other instead of command, with per-step settings
+	PlatformCommands     map[string]PlatformCommand `yaml:"platforms,omitempty"`              // Command variants by server platform, e.g. windows or linux/arm64
+	Image                string                     `yaml:"image,omitempty"`                  // Container image the build runs in (servers need a container runtime)
+	WSL                  string                     `yaml:"wsl,omitempty"`                    // WSL distribution the build runs in (Windows servers)
+	Requirements         protocol.Requirements      `yaml:"requirements,omitempty"`           // Resources a server needs to be considered
+	Speculative          bool                       `yaml:"speculative,omitempty"`            // Run on two idle servers, first success wins
+	Provenance           string                     `yaml:"provenance,omitempty"`             // "slsa" or a server-side generator command (e.g. an SBOM tool)
+	PersistentWorkspace  bool                       `yaml:"persistent_workspace,omitempty"`   // Build in a workspace the server keeps per client and project
+	PreservePaths        []string                   `yaml:"preserve_paths,omitempty"`         // Intermediate directories kept in the persistent workspace; other outputs are removed before each build
+	Parameters           BuildParameters            `yaml:"parameters,omitempty"`             // Per-build values substituted into the command as {{name}}
+	AllowCommandOverride bool                       `yaml:"allow_command_override,omitempty"` // Upload builds may replace the command (remote make/ninja execution)
+	ProjectDir           string                     `yaml:"project_dir"`
+	ProjectDirs          []SourceRoot               `yaml:"project_dirs,omitempty"` // Multiple source roots instead of a single project_dir
+	SharedPaths          []string                   `yaml:"shared_paths,omitempty"` // Paths always transferred with a monorepo subpath build
+	ExecutionDir         string                     `yaml:"execution_dir"`
+	OutputPaths          []string                   `yaml:"output_paths"`
+	Transfer             TransferConfig             `yaml:"transfer,omitempty"`             // Which project files are sent to the server
+	AlwaysCollectPaths   []string                   `yaml:"always_collect_paths,omitempty"` // Log/report files returned even when the build fails
+	DebugBundle          protocol.DebugBundleConfig `yaml:"debug_bundle,omitempty"`         // Triage archive returned when the build fails
+	EnvVars              map[string]string          `yaml:"env_vars"`
+	PostBuildScript      string                     `yaml:"post_build_script"` // Script/executable run on the client as an on_success hook
+	Hooks                HooksConfig                `yaml:"hooks,omitempty"`   // Lifecycle hooks specific to this environment
+	Access               EnvironmentAccess          `yaml:"access,omitempty"`  // Who may build this environment (default: anyone)
+	Plugin               string                     `yaml:"-"`                 // Name of the plugin providing this environment
+}
+
+// SourceRoot maps a local directory into the remote build workspace
+type SourceRoot struct {
+	Path   string `yaml:"path"`   // Local directory
+	Target string `yaml:"target"` // Location inside the remote workspace ("" = workspace root)
+}
+
+// SourceRoots returns the directories to transfer, defaulting to projectDir mapped to the workspace root.
+// With project_dirs, a projectDir other than the environment's own (e.g. a named project's dir)
+// replaces the first root, the other roots are kept.
+func (env *BuildEnvironment) SourceRoots(projectDir string) []SourceRoot {
+	if len(env.ProjectDirs) == 0 {
+		return []SourceRoot{{Path: projectDir, Target: ""}}
+	}
+	if projectDir == "" || projectDir == env.PrimaryDir() {
+		return env.ProjectDirs
+	}
+	roots := append([]SourceRoot{}, env.ProjectDirs...)
+	roots[0].Path = projectDir
+	return roots
+}
+
+// PrimaryDir returns the local directory used for post-build scripts and hooks
+func (env *BuildEnvironment) PrimaryDir() string {
+	if env.ProjectDir == "" && len(env.ProjectDirs) > 0 {
+		return env.ProjectDirs[0].Path
+	}
+	return env.ProjectDir
+}
+
+// Default returns a configuration with sensible defaults
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port: 8080,
+			AutoCapacity: AutoCapacityConfig{
+				CPUsPerBuild:   2,
+				MemoryPerBuild: 2 * protocol.GB,
+			},
+			BuildEnv: BuildEnvConfig{
+				Secrets: []string{"*TOKEN*", "*SECRET*", "*PASSWORD*", "*PASSWD*", "*CREDENTIAL*", "*API_KEY*", "*PRIVATE_KEY*", "*ACCESS_KEY*"},
+			},
+			ContentCache: ContentCacheConfig{
+				MaxSize: 1 * protocol.GB,
+			},
+		},
+		Client: ClientConfig{
+			Discovery: DiscoveryConfig{
+				Ports:          []int{8080, 8081, 8082, 8083, 8084, 8085},
+				ScanInterval:   10 * time.Second,
+				ConnectTimeout: 2 * time.Second,
+				NetworkRange: NetworkRange{
+					Auto:    true,
+					Subnet:  "",
+					StartIP: 1,
+					EndIP:   254,
+				},
+			},
+			Timeouts: TimeoutConfig{
+				Build:       120 * time.Second,
+				Reconnect:   10 * time.Second,
+				HealthCheck: 10 * time.Second,
+			},
+			History: HistoryConfig{
+				Dir:        "",
+				MaxEntries: 200,
+			},
+			Transfer: ClientTransferConfig{
+				ChunkThreshold: 32 * protocol.MB,
+				ChunkSize:      4 * protocol.MB,
+				Streams:        4,
+			},
+			Events: EventStreamConfig{
+				ProgressInterval: 5 * time.Second,
+			},
+			StatsD: StatsDConfig{
+				Port:     8125,
+				Prefix:   "boltbuild.",
+				Interval: 10 * time.Second,
+			},
+		},
+		Web: WebConfig{
+			Port: 8081,
+			Auth: AuthConfig{SessionTTL: 12 * time.Hour},
+		},
+		Build: BuildConfig{
+			TempDir:      "",   // Will use system temp dir if empty
+			TempDeletion: true, // Default to deleting temp directories
+			TempCleanup:  TempCleanupConfig{Interval: 10 * time.Minute},
+			Environments: map[string]BuildEnvironment{},
+			Artifacts: ArtifactLimits{
+				OnLimit: ArtifactLimitSkip,
+			},
+		},
+		Relay: RelayConfig{
+			Port: 7070,
+		},
+		TLS: TLSConfig{
+			Validity:       30 * 24 * time.Hour,
+			RenewBefore:    7 * 24 * time.Hour,
+			ReloadInterval: time.Minute,
+		},
+		Logging: LoggingConfig{
+			Level: "info", // Default to info level (only show connections)
+		},
+	}
+}
+
+// Load loads configuration from a YAML file
+func Load(filename string) (*Config, error) {
+	// Start with default config
+	config := Default()
+
+	// Check if config file exists
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		// Create default config file
+		if err := Save(config, filename); err != nil {
+			return nil, fmt.Errorf("failed to create default config file: %v", err)
+		}
+		return config, nil
+	}
+
+	// Read config file
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	// Parse YAML
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+
+	// Validate and set defaults for missing fields
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	return config, nil
+}
+
+// Save saves configuration to a YAML file
+func Save(config *Config, filename string) error {
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	// Marshal to YAML
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+
+	// Write to file
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	return nil
+}
+
+// Validate checks if the configuration is valid
+func (c *Config) Validate() error {
+	// Validate server config
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		return fmt.Errorf("invalid server port: %d", c.Server.Port)
+	}
+	if c.Server.Capacity < 0 {
+		return fmt.Errorf("invalid server capacity: %d", c.Server.Capacity)
+	}
+	if c.Server.AutoCapacity.CPUsPerBuild <= 0 || c.Server.AutoCapacity.MemoryPerBuild <= 0 {
+		return fmt.Errorf("invalid server auto capacity: %d CPUs and %s memory per build", c.Server.AutoCapacity.CPUsPerBuild, c.Server.AutoCapacity.MemoryPerBuild)
+	}
+	if err := validateBuildPriority(c.Server.BuildNice, c.Server.BuildIOPriority); err != nil {
+		return err
+	}
+	if err := c.Server.BuildEnv.validate(); err != nil {
+		return err
+	}
+	if err := validCPUPinning(c.Server.CPUPinning); err != nil {
+		return err
+	}
+	if c.Server.Instances < 0 {
+		return fmt.Errorf("invalid server instances: %d", c.Server.Instances)
+	}
+	switch c.Server.ContainerRuntime {
+	case "", ContainerRuntimeAuto, ContainerRuntimeDocker, ContainerRuntimePodman, ContainerRuntimeNone:
+	default:
+		return fmt.Errorf("invalid container runtime: %s (use auto, docker, podman or none)", c.Server.ContainerRuntime)
+	}
+	if c.Server.AdminPort < 0 || c.Server.AdminPort > 65535 || (c.Server.AdminPort != 0 && c.Server.AdminPort == c.Server.Port) {
+		return fmt.Errorf("invalid server admin port: %d", c.Server.AdminPort)
+	}
+	for _, addr := range c.Server.ConnectTo {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid server connect_to address %s: %v", addr, err)
+		}
+	}
+	if err := validateAnnounceTo(c.Server.AnnounceTo); err != nil {
+		return err
+	}
+	if port := c.Server.WebSocket.Port; port < 0 || port > 65535 || (port != 0 && (port == c.Server.Port || port == c.Server.AdminPort)) {
+		return fmt.Errorf("invalid server websocket port: %d", port)
+	}
+	if path := c.Server.WebSocket.Path; path != "" && !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("server websocket path must start with /: %s", path)
+	}
+	if c.Client.ListenPort < 0 || c.Client.ListenPort > 65535 {
+		return fmt.Errorf("invalid client listen port: %d", c.Client.ListenPort)
+	}
+	for _, relay := range []RelayConnection{c.Server.Relay, c.Client.Relay} {
+		if relay.Address == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(relay.Address); err != nil {
+			return fmt.Errorf("invalid relay address %s: %v", relay.Address, err)
+		}
+		if relay.Tenant == "" || relay.Token == "" {
+			return fmt.Errorf("relay %s requires a tenant and token", relay.Address)
+		}
+	}
+	if c.Server.MinFreeDisk < 0 {
+		return fmt.Errorf("invalid server min free disk: %d", c.Server.MinFreeDisk)
+	}
+	if c.Server.ContentCache.MaxSize < 0 {
+		return fmt.Errorf("invalid server content cache size: %d", c.Server.ContentCache.MaxSize)
+	}
+	if c.Client.Transfer.ChunkThreshold < 0 || c.Client.Transfer.ChunkSize <= 0 {
+		return fmt.Errorf("invalid client transfer chunk settings")
+	}
+	if c.Client.Transfer.Streams <= 0 {
+		return fmt.Errorf("client transfer streams must be positive: %d", c.Client.Transfer.Streams)
+	}
+	for name, limit := range map[string]BandwidthConfig{"client": c.Client.Transfer.BandwidthConfig, "server": c.Server.Transfer} {
+		if limit.MaxBandwidth < 0 {
+			return fmt.Errorf("invalid %s max_bandwidth: %d", name, limit.MaxBandwidth)
+		}
+		if limit.LimitHours != "" {
+			if _, _, err := ParseLimitHours(limit.LimitHours); err != nil {
+				return fmt.Errorf("%s transfer: %v", name, err)
+			}
+		}
+	}
+	for _, target := range c.Client.SSH {
+		if target.Host == "" {
+			return fmt.Errorf("ssh server requires a host")
+		}
+		if target.Port < 0 || target.Port > 65535 || target.SSHPort < 0 || target.SSHPort > 65535 {
+			return fmt.Errorf("invalid port for ssh server %s", target.Host)
+		}
+	}
+	if c.Relay.Port <= 0 || c.Relay.Port > 65535 {
+		return fmt.Errorf("invalid relay port: %d", c.Relay.Port)
+	}
+	if _, err := NewAccessPolicy(c.Server.Access); err != nil {
+		return fmt.Errorf("invalid server access: %v", err)
+	}
+	switch c.Server.FairShare.Policy {
+	case "", FairShareRoundRobin, FairShareWeighted:
+	default:
+		return fmt.Errorf("invalid fair share policy: %s (use round_robin or weighted)", c.Server.FairShare.Policy)
+	}
+	for client, weight := range c.Server.FairShare.Weights {
+		if weight <= 0 {
+			return fmt.Errorf("invalid fair share weight for %s: %d", client, weight)
+		}
+	}
+
+	// Validate TLS config
+	if c.TLS.Enabled {
+		if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+			return fmt.Errorf("tls.cert_file and tls.key_file must be set together")
+		}
+		if c.TLS.AutoRotate && (c.TLS.CertFile == "" || c.TLS.CAFile == "" || c.TLS.CAKeyFile == "") {
+			return fmt.Errorf("tls.auto_rotate requires cert_file, key_file, ca_file and ca_key_file")
+		}
+		if c.TLS.ReloadInterval <= 0 || c.TLS.Validity <= c.TLS.RenewBefore {
+			return fmt.Errorf("invalid TLS timing: reload_interval must be positive and validity longer than renew_before")
+		}
+	}
+
+	// Validate web config
+	if c.Web.Port <= 0 || c.Web.Port > 65535 {
+		return fmt.Errorf("invalid web port: %d", c.Web.Port)
+	}
+	if err := c.Web.CORS.validate(); err != nil {
+		return err
+	}
+	if base := c.Web.BasePath; base != "" && (!strings.HasPrefix(base, "/") || strings.ContainsAny(base, "?#") || strings.Contains(base, "//")) {
+		return fmt.Errorf("invalid web base path %q: expected an absolute path like /boltbuild/", base)
+	}
+	if addr := c.Web.BindAddress; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err == nil || strings.ContainsAny(addr, "/[] ") {
+			return fmt.Errorf("invalid web bind address %q: expected a host or IP address without port", addr)
+		}
+	}
+	if c.Web.UnixSocket != "" && c.Web.BindAddress != "" {
+		return fmt.Errorf("web.unix_socket and web.bind_address are exclusive")
+	}
+	if err := c.Web.TLS.validate(); err != nil {
+		return err
+	}
+	if err := c.Web.Auth.validate(); err != nil {
+		return err
+	}
+	if c.Web.TLS.Enabled && c.Web.UnixSocket != "" {
+		return fmt.Errorf("web.tls is not supported with web.unix_socket, terminate TLS in the proxy")
+	}
+	if port := c.Web.TLS.RedirectPort; c.Web.TLS.Enabled && port == c.Web.Port {
+		return fmt.Errorf("web.tls.redirect_port must differ from web.port")
+	}
+
+	// Validate client discovery ports
+	if len(c.Client.Discovery.Ports) == 0 {
+		return fmt.Errorf("no discovery ports specified")
+	}
+	for _, port := range c.Client.Discovery.Ports {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid discovery port: %d", port)
+		}
+	}
+	for _, addr := range c.Client.Discovery.Servers {
+		if IsWebSocketURL(addr) {
+			if target, err := url.Parse(addr); err != nil || target.Host == "" {
+				return fmt.Errorf("invalid discovery server URL: %s", addr)
+			}
+		} else if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid discovery server %s (use host:port, ws:// or wss://): %v", addr, err)
+		}
+	}
+
+	// Validate network range
+	if !c.Client.Discovery.NetworkRange.Auto {
+		if c.Client.Discovery.NetworkRange.Subnet == "" {
+			return fmt.Errorf("subnet must be specified when auto-detection is disabled")
+		}
+		if c.Client.Discovery.NetworkRange.StartIP < 1 || c.Client.Discovery.NetworkRange.StartIP > 254 {
+			return fmt.Errorf("invalid start IP: %d", c.Client.Discovery.NetworkRange.StartIP)
+		}
+		if c.Client.Discovery.NetworkRange.EndIP < 1 || c.Client.Discovery.NetworkRange.EndIP > 254 {
+			return fmt.Errorf("invalid end IP: %d", c.Client.Discovery.NetworkRange.EndIP)
+		}
+		if c.Client.Discovery.NetworkRange.StartIP > c.Client.Discovery.NetworkRange.EndIP {
+			return fmt.Errorf("start IP cannot be greater than end IP")
+		}
+	}
+
+	// Validate timeouts
+	if c.Client.Timeouts.Build <= 0 {
+		return fmt.Errorf("invalid build timeout: %v", c.Client.Timeouts.Build)
+	}
+	if c.Client.Timeouts.Reconnect <= 0 {
+		return fmt.Errorf("invalid reconnect timeout: %v", c.Client.Timeouts.Reconnect)
+	}
+	if c.Client.Timeouts.HealthCheck <= 0 {
+		return fmt.Errorf("invalid health check timeout: %v", c.Client.Timeouts.HealthCheck)
+	}
+	switch c.Client.Scheduling {
+	case "", SchedulingFirstAvailable, SchedulingLeastLoaded, SchedulingFastestTransfer:
+	default:
+		return fmt.Errorf("invalid scheduling strategy: %s (use first_available, least_loaded or fastest_transfer)", c.Client.Scheduling)
+	}
+
+	// Validate history
+	if err := c.Federation.validate(); err != nil {
+		return err
+	}
+	if err := c.Client.Quotas.validate(); err != nil {
+		return err
+	}
+	if err := c.Client.Events.validate(); err != nil {
+		return err
+	}
+	if err := c.Client.StatsD.validate(); err != nil {
+		return err
+	}
+	if c.Client.History.MaxEntries < 0 {
+		return fmt.Errorf("invalid history max entries: %d", c.Client.History.MaxEntries)
+	}
+
+	// Validate build limits
+	if c.Build.TempCleanup.MaxAge < 0 || c.Build.TempCleanup.MaxTotalSize < 0 {
+		return fmt.Errorf("invalid temp cleanup limits")
+	}
+	if c.Build.TempCleanup.Enabled() && c.Build.TempCleanup.Interval <= 0 {
+		return fmt.Errorf("invalid temp cleanup interval: %v", c.Build.TempCleanup.Interval)
+	}
+	if c.Build.MaxWorkspaceSize < 0 {
+		return fmt.Errorf("invalid max workspace size: %d", c.Build.MaxWorkspaceSize)
+	}
+	if c.Build.Artifacts.MaxFileSize < 0 || c.Build.Artifacts.MaxTotalSize < 0 {
+		return fmt.Errorf("invalid artifact size limits")
+	}
+	switch c.Build.Artifacts.OnLimit {
+	case "":
+		c.Build.Artifacts.OnLimit = ArtifactLimitSkip
+	case ArtifactLimitSkip, ArtifactLimitTruncate, ArtifactLimitFail:
+	default:
+		return fmt.Errorf("invalid artifact on_limit behavior: %s", c.Build.Artifacts.OnLimit)
+	}
+
+	// Validate hooks
+	if err := c.Hooks.validate(); err != nil {
+		return err
+	}
+
+	// Validate plugins
+	pluginNames := make(map[string]bool, len(c.Plugins))
+	for i, plugin := range c.Plugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("name not specified for plugin %d", i+1)
+		}
+		if pluginNames[plugin.Name] {
+			return fmt.Errorf("duplicate plugin name: %s", plugin.Name)
+		}
+		pluginNames[plugin.Name] = true
+		if plugin.Command == "" {
+			return fmt.Errorf("command not specified for plugin %s", plugin.Name)
+		}
+	}
+
+	// Validate projects (plugin environments are only known at runtime)
+	for name, project := range c.Projects {
+		if project.Dir == "" {
+			return fmt.Errorf("directory not specified for project %s", name)
+		}
+		if project.Environment == "" {
+			return fmt.Errorf("environment not specified for project %s", name)
+		}
+		if _, exists := c.Build.Environments[project.Environment]; !exists && len(c.Plugins) == 0 {
+			return fmt.Errorf("project %s uses unknown environment %s", name, project.Environment)
+		}
+	}
+
+	// Validate build environments (only if they exist)
+	for name, env := range c.Build.Environments {
+		if env.Name == "" {
+			return fmt.Errorf("name not specified for environment %s", name)
+		}
+		if err := env.validateCommand(); err != nil {
+			return fmt.Errorf("environment %s: %v", name, err)
+		}
+		if err := env.validatePlatformCommands(); err != nil {
+			return fmt.Errorf("environment %s: %v", name, err)
+		}
+		if env.ProjectDir == "" && len(env.ProjectDirs) == 0 {
+			return fmt.Errorf("project directory not specified for environment %s", name)
+		}
+		for _, root := range env.ProjectDirs {
+			if root.Path == "" {
+				return fmt.Errorf("source root without path in environment %s", name)
+			}
+			target := filepath.ToSlash(filepath.Clean(root.Target))
+			if filepath.IsAbs(root.Target) || target == ".." || strings.HasPrefix(target, "../") {
+				return fmt.Errorf("source root target %s must stay inside the workspace in environment %s", root.Target, name)
+			}
+		}
+		for _, shared := range env.SharedPaths {
+			if _, ok := ProjectRelativePath(shared); !ok {
+				return fmt.Errorf("shared path %s must be inside the project directory in environment %s", shared, name)
+			}
+		}
+		if env.ExecutionDir == "" {
+			return fmt.Errorf("execution directory not specified for environment %s", name)
+		}
+		if env.Image != "" && env.WSL != "" {
+			return fmt.Errorf("environment %s cannot use both image and wsl", name)
+		}
+		if err := env.Hooks.validate(); err != nil {
+			return fmt.Errorf("environment %s: %v", name, err)
+		}
+		if err := env.Parameters.validate(env.CommandLine()); err != nil {
+			return fmt.Errorf("environment %s: %v", name, err)
+		}
+		if err := env.Access.validate(c.Web.Auth.APIKeys); err != nil {
+			return fmt.Errorf("environment %s: %v", name, err)
+		}
+	}
+
+	if err := c.Logging.Syslog.validate(); err != nil {
+		return err
+	}
+	if err := c.Faults.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBuildEnvironment returns the build environment for a given language
+func (c *Config) GetBuildEnvironment(language string) (*BuildEnvironment, bool) {
+	env, exists := c.Build.Environments[language]
+	return &env, exists
+}
+
+// GetTempDir returns the configured temp directory or system default
+func (c *Config) GetTempDir() string {
+	if c.Build.TempDir != "" {
+		return c.Build.TempDir
+	}
+	return os.TempDir()
+}
@@ -0,0 +1,9 @@
+package config
+
+// Container runtime settings for server.container_runtime
+const (
+	ContainerRuntimeAuto   = "auto" // First usable of containerRuntimes (default)
+	ContainerRuntimeDocker = "docker"
+	ContainerRuntimePodman = "podman"
+	ContainerRuntimeNone   = "none" // Disable container builds
+)
@@ -0,0 +1,10 @@
+package config
+
+import (
+	"boltbuild/pkg/protocol"
+)
+
+// ContentCacheConfig controls the server's store of previously uploaded projects
+type ContentCacheConfig struct {
+	MaxSize protocol.ByteSize `yaml:"max_size"` // Disk space for cached projects, oldest are evicted (0 = disabled)
+}
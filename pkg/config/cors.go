@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CORSConfig lets dashboards and IDE plugins on other origins call the web API from a browser
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins,omitempty"` // e.g. "https://dash.example.com", or "*" for any (empty = same origin only)
+	AllowedMethods []string `yaml:"allowed_methods,omitempty"` // Default: GET, POST, PUT
+	AllowedHeaders []string `yaml:"allowed_headers,omitempty"` // Request headers besides the defaults (Content-Type, X-Boltbuild-User)
+}
+
+// validate checks that origins are "*" or scheme://host[:port] and methods are HTTP methods
+func (c CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") {
+			return fmt.Errorf("invalid CORS origin %q: expected scheme://host[:port] or *", origin)
+		}
+	}
+	for _, method := range c.AllowedMethods {
+		if method == "" || strings.ToUpper(method) != method || strings.ContainsAny(method, " ,") {
+			return fmt.Errorf("invalid CORS method %q: expected an upper case HTTP method", method)
+		}
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether requests from origin may read API responses
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+)
+
+// CPU pinning modes for server.cpu_pinning
+const (
+	CPUPinningNone  = "none"  // Builds may run on every CPU (default)
+	CPUPinningSlots = "slots" // The CPUs are split evenly between the build slots
+	CPUPinningNUMA  = "numa"  // Every build slot is bound to the CPUs of one NUMA node
+)
+
+// validCPUPinning checks a server.cpu_pinning value
+func validCPUPinning(mode string) error {
+	switch mode {
+	case "", CPUPinningNone, CPUPinningSlots, CPUPinningNUMA:
+		return nil
+	}
+	return fmt.Errorf("invalid server cpu pinning: %s (use %s, %s or %s)", mode, CPUPinningNone, CPUPinningSlots, CPUPinningNUMA)
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+)
+
+// minAPIKeyLength keeps keys from being guessable
+const minAPIKeyLength = 16
+
+// APIKeyConfig identifies a script or CI job calling the API
+type APIKeyConfig struct {
+	Name  string   `yaml:"name"`            // Shown in the audit log and matched by environment access lists
+	Key   string   `yaml:"key"`             // Secret sent in X-Boltbuild-API-Key or as a bearer token
+	Roles []string `yaml:"roles,omitempty"` // Roles of the key's requests
+}
+
+// EnvironmentAccess restricts who may build an environment (all empty = anyone)
+type EnvironmentAccess struct {
+	Users   []string `yaml:"users,omitempty"`    // Signed in users
+	Roles   []string `yaml:"roles,omitempty"`    // Users and API keys with one of these roles (see web.auth.roles)
+	APIKeys []string `yaml:"api_keys,omitempty"` // Names of web.auth.api_keys
+}
+
+// Restricted reports whether the environment is limited to some callers
+func (a EnvironmentAccess) Restricted() bool {
+	return len(a.Users) > 0 || len(a.Roles) > 0 || len(a.APIKeys) > 0
+}
+
+// validate checks that the access list names configured API keys
+func (a EnvironmentAccess) validate(keys []APIKeyConfig) error {
+	for _, name := range a.APIKeys {
+		found := false
+		for _, key := range keys {
+			found = found || key.Name == name
+		}
+		if !found {
+			return fmt.Errorf("access lists unknown API key %s", name)
+		}
+	}
+	return nil
+}
+
+// validateAPIKeys checks that keys are named uniquely and long enough
+func validateAPIKeys(keys []APIKeyConfig) error {
+	names := make(map[string]bool)
+	for i, key := range keys {
+		if key.Name == "" {
+			return fmt.Errorf("name not specified for web.auth.api_keys entry %d", i+1)
+		}
+		if names[key.Name] {
+			return fmt.Errorf("duplicate API key name %s", key.Name)
+		}
+		names[key.Name] = true
+		if len(key.Key) < minAPIKeyLength {
+			return fmt.Errorf("API key %s must be at least %d characters", key.Name, minAPIKeyLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Event stream sinks; file and tcp take a target after the colon
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkTCP    = "tcp"
+)
+
+// EventStreamConfig writes build lifecycle events as JSON lines for external consumers
+type EventStreamConfig struct {
+	Sink             string        `yaml:"sink,omitempty"`              // "stdout", "file:/path/events.jsonl" or "tcp:host:port" (empty = disabled)
+	ProgressInterval time.Duration `yaml:"progress_interval,omitempty"` // Minimum time between progress events of a build (default 5s)
+}
+
+// validate checks the sink syntax
+func (e EventStreamConfig) validate() error {
+	if e.ProgressInterval < 0 {
+		return fmt.Errorf("invalid event stream progress interval: %v", e.ProgressInterval)
+	}
+	if e.Sink == "" {
+		return nil
+	}
+	kind, target, _ := strings.Cut(e.Sink, ":")
+	switch kind {
+	case SinkStdout:
+		if target != "" {
+			return fmt.Errorf("invalid event stream sink %q: stdout takes no target", e.Sink)
+		}
+	case SinkFile:
+		if target == "" {
+			return fmt.Errorf("invalid event stream sink %q: expected file:/path", e.Sink)
+		}
+	case SinkTCP:
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			return fmt.Errorf("invalid event stream sink %q: expected tcp:host:port", e.Sink)
+		}
+	default:
+		return fmt.Errorf("invalid event stream sink %q (use stdout, file:/path or tcp:host:port)", e.Sink)
+	}
+	return nil
+}
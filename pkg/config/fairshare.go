@@ -0,0 +1,20 @@
+package config
+
+import (
+	"net"
+)
+
+// Fair-share policies for server.fair_share.policy
+const (
+	FairShareRoundRobin = "round_robin" // Free slots go to waiting clients in turn (default)
+	FairShareWeighted   = "weighted"    // Clients get slots in proportion to their configured weight (stride scheduling)
+)
+
+// ClientKey identifies a client for accounting; reconnects from the same host share it
+func ClientKey(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// FaultConfig injects failures into clients and servers to exercise retry, failover and
+// checksum handling before real outages do. It is intentionally missing from the example
+// configuration: never enable it outside of test farms.
+type FaultConfig struct {
+	Disconnect float64       `yaml:"disconnect,omitempty"` // Probability that sending a message drops the connection instead
+	Delay      float64       `yaml:"delay,omitempty"`      // Probability that a build result is held back
+	MaxDelay   time.Duration `yaml:"max_delay,omitempty"`  // Longest hold of a delayed result (default 5s)
+	Corrupt    float64       `yaml:"corrupt,omitempty"`    // Probability that a build result gets one artifact altered after its checksum
+	Seed       int64         `yaml:"seed,omitempty"`       // Makes the injected faults repeatable (0 = random)
+}
+
+// validate checks that the probabilities are between 0 and 1
+func (f FaultConfig) validate() error {
+	for name, rate := range map[string]float64{"disconnect": f.Disconnect, "delay": f.Delay, "corrupt": f.Corrupt} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("invalid fault %s probability: %v (use 0 to 1)", name, rate)
+		}
+	}
+	if f.MaxDelay < 0 {
+		return fmt.Errorf("invalid fault max delay: %v", f.MaxDelay)
+	}
+	return nil
+}
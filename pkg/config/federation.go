@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// FederationConfig lets this client's dashboard show the servers, queues and history of peer clients
+type FederationConfig struct {
+	Name    string           `yaml:"name,omitempty"`    // Site name of this client (default: client.id or hostname)
+	Peers   []FederationPeer `yaml:"peers,omitempty"`   // Other client coordinators
+	Timeout time.Duration    `yaml:"timeout,omitempty"` // Per peer request (default 5s)
+}
+
+// FederationPeer is another client whose web API is queried
+type FederationPeer struct {
+	Name    string `yaml:"name"`              // Site name, e.g. "berlin"
+	URL     string `yaml:"url"`               // Web interface, e.g. https://boltbuild.berlin.example.com/
+	APIKey  string `yaml:"api_key,omitempty"` // Sent as X-Boltbuild-API-Key when the peer requires a login
+	Forward bool   `yaml:"forward,omitempty"` // Trusted to run builds when every local server is busy
+}
+
+// validate checks that peers are named and reachable by HTTP(S)
+func (c FederationConfig) validate() error {
+	names := make(map[string]bool)
+	for i, peer := range c.Peers {
+		if peer.Name == "" {
+			return fmt.Errorf("name not specified for federation peer %d", i+1)
+		}
+		if names[peer.Name] || peer.Name == c.Name {
+			return fmt.Errorf("duplicate federation site name %s", peer.Name)
+		}
+		names[peer.Name] = true
+		if !strings.HasPrefix(peer.URL, "http://") && !strings.HasPrefix(peer.URL, "https://") {
+			return fmt.Errorf("invalid URL for federation peer %s: %q", peer.Name, peer.URL)
+		}
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("invalid federation timeout: %v", c.Timeout)
+	}
+	return nil
+}
+
+// Enabled reports whether peers are configured
+func (c FederationConfig) Enabled() bool {
+	return len(c.Peers) > 0
+}
+
+// SiteName returns the name this client appears under in the federated view
+func (c FederationConfig) SiteName(clientID string) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if clientID != "" {
+		return clientID
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
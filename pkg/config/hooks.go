@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lifecycle hook events
+const (
+	HookOnSubmit         = "on_submit"
+	HookOnDispatch       = "on_dispatch"
+	HookOnSuccess        = "on_success"
+	HookOnFailure        = "on_failure"
+	HookOnArtifactsSaved = "on_artifacts_saved"
+)
+
+// HooksConfig lists the hooks to run for each lifecycle event
+type HooksConfig struct {
+	OnSubmit         []HookConfig `yaml:"on_submit,omitempty"`          // Before a server is reserved (no server fields yet); a failing hook aborts the build
+	OnDispatch       []HookConfig `yaml:"on_dispatch,omitempty"`        // After the request was sent to a server
+	OnSuccess        []HookConfig `yaml:"on_success,omitempty"`         // After a successful build
+	OnFailure        []HookConfig `yaml:"on_failure,omitempty"`         // After a failed or timed out build
+	OnArtifactsSaved []HookConfig `yaml:"on_artifacts_saved,omitempty"` // After output files were written to disk
+}
+
+// HookConfig is a single hook: either a shell command or a webhook URL
+type HookConfig struct {
+	Command string        `yaml:"command"` // Shell command, receives the event as JSON on stdin
+	URL     string        `yaml:"url"`     // Webhook, receives the event as JSON POST body
+	Timeout time.Duration `yaml:"timeout"`
+
+	Vars map[string]string `yaml:"-"` // Extra environment variables for the command, set by post_build_script
+}
+
+// ForEvent returns the hooks registered for an event
+func (h HooksConfig) ForEvent(event string) []HookConfig {
+	switch event {
+	case HookOnSubmit:
+		return h.OnSubmit
+	case HookOnDispatch:
+		return h.OnDispatch
+	case HookOnSuccess:
+		return h.OnSuccess
+	case HookOnFailure:
+		return h.OnFailure
+	case HookOnArtifactsSaved:
+		return h.OnArtifactsSaved
+	}
+	return nil
+}
+
+// validate checks that every hook has exactly one target
+func (h HooksConfig) validate() error {
+	for _, event := range []string{HookOnSubmit, HookOnDispatch, HookOnSuccess, HookOnFailure, HookOnArtifactsSaved} {
+		for _, hook := range h.ForEvent(event) {
+			if (hook.Command == "") == (hook.URL == "") {
+				return fmt.Errorf("%s hook must specify either command or url", event)
+			}
+		}
+	}
+	return nil
+}
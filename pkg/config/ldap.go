@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LDAPConfig signs users in by binding to an LDAP directory or Active Directory with their password
+type LDAPConfig struct {
+	URL                string `yaml:"url"`                            // ldaps://dc.example.com or ldap://ldap.example.com:389
+	StartTLS           bool   `yaml:"start_tls,omitempty"`            // Upgrade ldap:// connections to TLS
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"` // Accept any directory certificate
+	UserDNTemplate     string `yaml:"user_dn_template,omitempty"`     // Bind directly as e.g. "uid=%s,ou=people,dc=example,dc=com" or "%s@example.com"
+	BindDN             string `yaml:"bind_dn,omitempty"`              // Or search the user with this service account first
+	BindPassword       string `yaml:"bind_password,omitempty"`
+	BaseDN             string `yaml:"base_dn,omitempty"`         // Where users are searched, e.g. "dc=example,dc=com"
+	UserFilter         string `yaml:"user_filter,omitempty"`     // Default: (uid=%s); Active Directory: (sAMAccountName=%s)
+	GroupAttribute     string `yaml:"group_attribute,omitempty"` // User attribute listing group DNs (default: memberOf)
+}
+
+// validate checks that users can be found
+func (c LDAPConfig) validate() error {
+	if !strings.HasPrefix(c.URL, "ldap://") && !strings.HasPrefix(c.URL, "ldaps://") {
+		return fmt.Errorf("invalid web.auth.ldap.url %q: expected ldap:// or ldaps://", c.URL)
+	}
+	if (c.UserDNTemplate == "") == (c.BaseDN == "") {
+		return fmt.Errorf("web.auth.ldap requires either user_dn_template or base_dn")
+	}
+	if c.UserDNTemplate != "" && !strings.Contains(c.UserDNTemplate, "%s") {
+		return fmt.Errorf("web.auth.ldap.user_dn_template must contain %%s for the user name")
+	}
+	if c.UserFilter != "" && !strings.Contains(c.UserFilter, "%s") {
+		return fmt.Errorf("web.auth.ldap.user_filter must contain %%s for the user name")
+	}
+	return nil
+}
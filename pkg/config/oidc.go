@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OIDCConfig signs dashboard users in with an OpenID Connect provider such as Keycloak, Azure AD or Google
+type OIDCConfig struct {
+	Issuer        string   `yaml:"issuer"`                   // e.g. https://keycloak.example.com/realms/dev
+	ClientID      string   `yaml:"client_id"`                // Client registered at the provider
+	ClientSecret  string   `yaml:"client_secret"`            // Its secret
+	RedirectURL   string   `yaml:"redirect_url,omitempty"`   // Registered callback (default: <dashboard>/auth/callback)
+	Scopes        []string `yaml:"scopes,omitempty"`         // Default: openid profile email
+	UsernameClaim string   `yaml:"username_claim,omitempty"` // Claim naming the user (default: preferred_username, then email, then sub)
+	GroupsClaim   string   `yaml:"groups_claim,omitempty"`   // Claim listing the user's groups (default: groups)
+	AllowedGroups []string `yaml:"allowed_groups,omitempty"` // Only members of these groups may sign in (empty = anyone the provider accepts)
+}
+
+// validate checks the provider settings
+func (c OIDCConfig) validate() error {
+	if !strings.HasPrefix(c.Issuer, "https://") && !strings.HasPrefix(c.Issuer, "http://localhost") {
+		return fmt.Errorf("invalid web.auth.oidc.issuer %q: expected an https URL", c.Issuer)
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("web.auth.oidc requires client_id and client_secret")
+	}
+	if c.RedirectURL != "" && !strings.HasSuffix(c.RedirectURL, "/auth/callback") {
+		return fmt.Errorf("invalid web.auth.oidc.redirect_url %q: must end with /auth/callback", c.RedirectURL)
+	}
+	return nil
+}
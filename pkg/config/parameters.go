@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Parameter types
+const (
+	ParameterString = "string"
+	ParameterChoice = "choice"
+)
+
+// BuildParameter is a value chosen per build and substituted into the command as {{name}}
+type BuildParameter struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Choices []string `json:"choices,omitempty"`
+	Default string   `json:"default,omitempty"`
+}
+
+// BuildParameters keeps parameters in declaration order so forms render predictably.
+// In YAML each parameter is either a list of choices, a type name or a full mapping:
+//
+//	parameters:
+//	  configuration: [Debug, Release]
+//	  target: string
+//	  jobs: {type: string, default: "4"}
+type BuildParameters []BuildParameter
+
+// ParameterPlaceholder matches {{name}} in command templates
+var ParameterPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// UnmarshalYAML reads parameters from a mapping while preserving their order
+func (p *BuildParameters) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("parameters must be a mapping (line %d)", value.Line)
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		param := BuildParameter{Name: value.Content[i].Value}
+		spec := value.Content[i+1]
+
+		switch spec.Kind {
+		case yaml.SequenceNode:
+			param.Type = ParameterChoice
+			if err := spec.Decode(&param.Choices); err != nil {
+				return fmt.Errorf("invalid choices for parameter %s: %v", param.Name, err)
+			}
+		case yaml.ScalarNode:
+			param.Type = spec.Value
+		case yaml.MappingNode:
+			var full struct {
+				Type    string   `yaml:"type"`
+				Choices []string `yaml:"choices"`
+				Default string   `yaml:"default"`
+			}
+			if err := spec.Decode(&full); err != nil {
+				return fmt.Errorf("invalid parameter %s: %v", param.Name, err)
+			}
+			param.Type, param.Choices, param.Default = full.Type, full.Choices, full.Default
+			if param.Type == "" && len(param.Choices) > 0 {
+				param.Type = ParameterChoice
+			}
+		default:
+			return fmt.Errorf("invalid parameter %s (line %d)", param.Name, spec.Line)
+		}
+
+		// The first choice is the default unless one is given
+		if param.Type == ParameterChoice && param.Default == "" && len(param.Choices) > 0 {
+			param.Default = param.Choices[0]
+		}
+		*p = append(*p, param)
+	}
+
+	return nil
+}
+
+// MarshalYAML writes parameters back in their full mapping form
+func (p BuildParameters) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, param := range p {
+		spec := &yaml.Node{}
+		full := map[string]interface{}{"type": param.Type}
+		if len(param.Choices) > 0 {
+			full["choices"] = param.Choices
+		}
+		if param.Default != "" {
+			full["default"] = param.Default
+		}
+		if err := spec.Encode(full); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: param.Name}, spec)
+	}
+	return node, nil
+}
+
+// validate checks parameter declarations and that the command only uses declared parameters
+func (p BuildParameters) validate(command string) error {
+	declared := make(map[string]bool)
+	for _, param := range p {
+		if declared[param.Name] {
+			return fmt.Errorf("duplicate parameter %s", param.Name)
+		}
+		declared[param.Name] = true
+
+		switch param.Type {
+		case ParameterString:
+		case ParameterChoice:
+			if len(param.Choices) == 0 {
+				return fmt.Errorf("parameter %s has no choices", param.Name)
+			}
+			if !buildutil.ContainsString(param.Choices, param.Default) {
+				return fmt.Errorf("default %s of parameter %s is not one of its choices", param.Default, param.Name)
+			}
+		default:
+			return fmt.Errorf("invalid type %s for parameter %s", param.Type, param.Name)
+		}
+	}
+
+	for _, match := range ParameterPlaceholder.FindAllStringSubmatch(command, -1) {
+		if !declared[match[1]] {
+			return fmt.Errorf("command uses undeclared parameter %s", match[1])
+		}
+	}
+	return nil
+}
+
+// Resolve combines submitted values with defaults and checks them against the declarations
+func (p BuildParameters) Resolve(values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	for _, param := range p {
+		value, given := values[param.Name]
+		if !given || value == "" {
+			value = param.Default
+		}
+		if param.Type == ParameterChoice && !buildutil.ContainsString(param.Choices, value) {
+			return nil, fmt.Errorf("invalid value %q for parameter %s (choices: %s)", value, param.Name, strings.Join(param.Choices, ", "))
+		}
+		resolved[param.Name] = value
+	}
+
+	for name := range values {
+		if _, declared := resolved[name]; !declared {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// platformPattern matches platform names: an operating system, optionally with an architecture
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9]+)?$`)
+
+// PlatformCommand replaces the command of an environment on servers of one platform
+type PlatformCommand struct {
+	Command  string        `yaml:"command,omitempty"`
+	Args     []string      `yaml:"args,omitempty"`
+	Shell    bool          `yaml:"shell,omitempty"`
+	Commands []CommandStep `yaml:"commands,omitempty"`
+}
+
+// commandLine returns the variant's command as one line
+func (p PlatformCommand) commandLine() string {
+	env := BuildEnvironment{Command: p.Command, Args: p.Args, Commands: p.Commands}
+	return env.CommandLine()
+}
+
+// validatePlatforms checks platform names such as "windows" or "linux/arm64"
+func validatePlatforms(platforms []string) error {
+	for _, platform := range platforms {
+		if !platformPattern.MatchString(platform) {
+			return fmt.Errorf("invalid platform %q (use an OS like windows or OS/arch like linux/arm64)", platform)
+		}
+	}
+	return nil
+}
+
+// validatePlatformCommands checks the platform variants of an environment like its command
+func (env *BuildEnvironment) validatePlatformCommands() error {
+	for _, platform := range sortedPlatforms(env.PlatformCommands) {
+		if err := validatePlatforms([]string{platform}); err != nil {
+			return err
+		}
+		variant := env.PlatformCommands[platform]
+		variantEnv := BuildEnvironment{Command: variant.Command, Args: variant.Args, Shell: variant.Shell, Commands: variant.Commands}
+		if err := variantEnv.validateCommand(); err != nil {
+			return fmt.Errorf("platform %s: %v", platform, err)
+		}
+		if err := env.Parameters.validate(variant.commandLine()); err != nil {
+			return fmt.Errorf("platform %s: %v", platform, err)
+		}
+	}
+	if err := validatePlatforms(env.Requirements.Platforms); err != nil {
+		return fmt.Errorf("requirements: %v", err)
+	}
+	for i, step := range env.Commands {
+		if err := validatePlatforms(step.Platforms); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// sortedPlatforms lists the platforms of an environment's variants in a stable order
+func sortedPlatforms(variants map[string]PlatformCommand) []string {
+	platforms := make([]string, 0, len(variants))
+	for platform := range variants {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"time"
+)
+
+// defaultPluginRefreshInterval is how long listed environments are cached when a plugin sets no refresh_interval
+const defaultPluginRefreshInterval = time.Minute
+
+// PluginConfig declares an external executable that provides build environments.
+//
+// The executable is invoked as:
+//
+//	<command> [args...] list                       -> JSON array of environment names
+//	<command> [args...] describe <env>             -> JSON environment definition
+//	<command> [args...] prepare <env> <project_dir> -> optional JSON overrides, non-zero exit aborts the build
+type PluginConfig struct {
+	Name            string        `yaml:"name"`
+	Command         string        `yaml:"command"`
+	Args            []string      `yaml:"args"`
+	Timeout         time.Duration `yaml:"timeout"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"` // How long listed environments are cached
+}
+
+// RefreshAfter returns how long the plugin's listed environments stay cached
+func (plugin PluginConfig) RefreshAfter() time.Duration {
+	if plugin.RefreshInterval > 0 {
+		return plugin.RefreshInterval
+	}
+	return defaultPluginRefreshInterval
+}
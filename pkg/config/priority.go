@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+)
+
+// IO priorities for server.build_io_priority
+const (
+	IOPriorityNormal = "normal" // Derived from the nice level by the kernel (default)
+	IOPriorityLow    = "low"    // Lowest best-effort level
+	IOPriorityIdle   = "idle"   // Disk access only when no other process needs the disk
+)
+
+// validateBuildPriority checks server.build_nice and server.build_io_priority
+func validateBuildPriority(nice int, ioPriority string) error {
+	if nice < 0 || nice > 19 {
+		return fmt.Errorf("invalid server build nice: %d (use 0 to 19)", nice)
+	}
+	switch ioPriority {
+	case "", IOPriorityNormal, IOPriorityLow, IOPriorityIdle:
+		return nil
+	}
+	return fmt.Errorf("invalid server build io priority: %s (use %s, %s or %s)", ioPriority, IOPriorityNormal, IOPriorityLow, IOPriorityIdle)
+}
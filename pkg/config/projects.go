@@ -0,0 +1,8 @@
+package config
+
+// ProjectConfig binds a name to a local directory and its default build environment
+type ProjectConfig struct {
+	Dir         string            `yaml:"dir"`
+	Environment string            `yaml:"environment"`      // Default environment for builds of this project
+	Labels      map[string]string `yaml:"labels,omitempty"` // Free-form metadata shown in the web interface
+}
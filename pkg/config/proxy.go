@@ -0,0 +1,11 @@
+package config
+
+import (
+	"strings"
+)
+
+// Prefix returns the path prefix the web interface is served under behind a reverse proxy,
+// without a trailing slash ("" = served at the root)
+func (c WebConfig) Prefix() string {
+	return strings.TrimRight(c.BasePath, "/")
+}
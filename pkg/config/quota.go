@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+)
+
+// QuotaLimits caps the builds of a user or team (0 = unlimited)
+type QuotaLimits struct {
+	BuildsPerHour      int `yaml:"builds_per_hour,omitempty" json:"builds_per_hour,omitempty"`             // Builds started in the last hour
+	BuildsPerDay       int `yaml:"builds_per_day,omitempty" json:"builds_per_day,omitempty"`               // Builds started in the last 24 hours
+	BuildMinutesPerDay int `yaml:"build_minutes_per_day,omitempty" json:"build_minutes_per_day,omitempty"` // Total duration of the builds of the last 24 hours
+}
+
+// Limited reports whether any limit is set
+func (l QuotaLimits) Limited() bool {
+	return l.BuildsPerHour > 0 || l.BuildsPerDay > 0 || l.BuildMinutesPerDay > 0
+}
+
+// QuotaConfig limits the builds submitted through the web interface, API and CLI per user and team
+type QuotaConfig struct {
+	Default QuotaLimits            `yaml:"default,omitempty"` // Every user without an entry in users
+	Users   map[string]QuotaLimits `yaml:"users,omitempty"`   // Per user, replacing default
+	Teams   map[string]QuotaLimits `yaml:"teams,omitempty"`   // Shared by the members of a group (OIDC or LDAP)
+}
+
+// validate rejects negative limits
+func (c QuotaConfig) validate() error {
+	check := func(scope string, limits QuotaLimits) error {
+		if limits.BuildsPerHour < 0 || limits.BuildsPerDay < 0 || limits.BuildMinutesPerDay < 0 {
+			return fmt.Errorf("negative limit in client.quotas.%s", scope)
+		}
+		return nil
+	}
+	if err := check("default", c.Default); err != nil {
+		return err
+	}
+	for user, limits := range c.Users {
+		if err := check("users."+user, limits); err != nil {
+			return err
+		}
+	}
+	for team, limits := range c.Teams {
+		if err := check("teams."+team, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
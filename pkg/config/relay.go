@@ -0,0 +1,14 @@
+package config
+
+// RelayConfig configures the "boltbuild relay" component
+type RelayConfig struct {
+	Port    int               `yaml:"port"`
+	Tenants map[string]string `yaml:"tenants"` // Tenant name -> token; clients only see servers of their own tenant
+}
+
+// RelayConnection is how a client or server reaches a relay
+type RelayConnection struct {
+	Address string `yaml:"address"` // host:port of the relay
+	Tenant  string `yaml:"tenant"`
+	Token   string `yaml:"token"`
+}
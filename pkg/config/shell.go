@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+)
+
+// CommandLine returns the command of an environment as one line: the command, the args list
+// quoted for display, or the commands joined with &&. Environments defining only platform
+// variants show the first one.
+func (env *BuildEnvironment) CommandLine() string {
+	if env.Command == "" && len(env.Args) == 0 && len(env.Commands) == 0 && len(env.PlatformCommands) > 0 {
+		return env.PlatformCommands[sortedPlatforms(env.PlatformCommands)[0]].commandLine()
+	}
+	if len(env.Commands) > 0 {
+		lines := make([]string, len(env.Commands))
+		for i, step := range env.Commands {
+			lines[i] = step.commandLine()
+		}
+		return strings.Join(lines, " && ")
+	}
+	if len(env.Args) > 0 {
+		return buildutil.QuoteArgs(env.Args)
+	}
+	return env.Command
+}
+
+// validateCommand checks that an environment has exactly one of command, args and commands
+func (env *BuildEnvironment) validateCommand() error {
+	set := 0
+	for _, given := range []bool{env.Command != "", len(env.Args) > 0, len(env.Commands) > 0} {
+		if given {
+			set++
+		}
+	}
+	if set == 0 && len(env.PlatformCommands) == 0 {
+		return fmt.Errorf("command not specified")
+	}
+	if set > 1 {
+		return fmt.Errorf("only one of command, args and commands can be set")
+	}
+	if env.Shell && len(env.Args) > 0 {
+		return fmt.Errorf("shell runs a command line, use command instead of args")
+	}
+	for i, step := range env.Commands {
+		if err := step.validate(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UsesShell reports whether a command line of the environment runs through the shell
+func (env *BuildEnvironment) UsesShell() bool {
+	for _, step := range env.Commands {
+		if step.Shell {
+			return true
+		}
+	}
+	for _, variant := range env.PlatformCommands {
+		variantEnv := BuildEnvironment{Shell: variant.Shell, Commands: variant.Commands}
+		if variantEnv.UsesShell() {
+			return true
+		}
+	}
+	return env.Shell
+}
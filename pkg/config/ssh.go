@@ -0,0 +1,10 @@
+package config
+
+// SSHServer is a build server reached through SSH instead of a direct connection
+type SSHServer struct {
+	Host         string   `yaml:"host"`                    // [user@]host as for ssh
+	Port         int      `yaml:"port,omitempty"`          // Build server port on the remote machine (default: server.port)
+	SSHPort      int      `yaml:"ssh_port,omitempty"`      // SSH port (default: ssh's own configuration)
+	IdentityFile string   `yaml:"identity_file,omitempty"` // Private key (default: ssh agent and ssh's configuration)
+	Options      []string `yaml:"options,omitempty"`       // Extra ssh options such as "ProxyJump=bastion"
+}
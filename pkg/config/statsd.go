@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatsDConfig pushes build metrics to a StatsD or DogStatsD agent over UDP
+type StatsDConfig struct {
+	Host      string            `yaml:"host,omitempty"`      // Agent host (empty = disabled)
+	Port      int               `yaml:"port,omitempty"`      // Agent UDP port (default 8125)
+	Prefix    string            `yaml:"prefix,omitempty"`    // Prepended to every metric name (default "boltbuild.")
+	Tags      map[string]string `yaml:"tags,omitempty"`      // Added to every metric, e.g. site: berlin (DogStatsD only)
+	DogStatsD bool              `yaml:"dogstatsd,omitempty"` // Send environment and result as tags; plain StatsD puts them into the metric name
+	Interval  time.Duration     `yaml:"interval,omitempty"`  // How often gauges (running builds, servers) are sent (default 10s)
+}
+
+// validate checks the agent address and the interval
+func (s StatsDConfig) validate() error {
+	if s.Host == "" {
+		return nil
+	}
+	if s.Port <= 0 || s.Port > 65535 {
+		return fmt.Errorf("invalid statsd port: %d", s.Port)
+	}
+	if s.Interval <= 0 {
+		return fmt.Errorf("invalid statsd interval: %v", s.Interval)
+	}
+	if len(s.Tags) > 0 && !s.DogStatsD {
+		return fmt.Errorf("statsd tags require dogstatsd: true")
+	}
+	return nil
+}
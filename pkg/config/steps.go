@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"gopkg.in/yaml.v3"
+)
+
+// CommandStep is one entry of an environment's commands list. In YAML a step is either a
+// command line or a mapping with per-step settings:
+//
+//	commands:
+//	  - cmake -B build
+//	  - command: cmake --build build -j8
+//	    timeout: 20m
+//	  - {name: tests, command: ctest --test-dir build, continue_on_error: true}
+type CommandStep struct {
+	Name            string            `yaml:"name,omitempty"`              // Shown in the log and results (default: the command)
+	Command         string            `yaml:"command,omitempty"`           // Command line, split on whitespace unless shell is set
+	Args            []string          `yaml:"args,omitempty"`              // Argument list instead of command
+	Shell           bool              `yaml:"shell,omitempty"`             // Run through the platform shell (also when the environment sets shell)
+	Dir             string            `yaml:"dir,omitempty"`               // Directory relative to the project (default: execution_dir)
+	EnvVars         map[string]string `yaml:"env_vars,omitempty"`          // Added to and overriding the environment's env_vars
+	Timeout         time.Duration     `yaml:"timeout,omitempty"`           // Limit of this step (default: none besides the build timeout)
+	ContinueOnError bool              `yaml:"continue_on_error,omitempty"` // A failure is reported as a warning and the next step runs
+	Platforms       []string          `yaml:"platforms,omitempty"`         // Run only on these server platforms, e.g. [windows] or [linux/arm64]
+}
+
+// UnmarshalYAML reads a step from a command line or a mapping
+func (s *CommandStep) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		s.Command = value.Value
+		return nil
+	}
+	type plain CommandStep
+	return value.Decode((*plain)(s))
+}
+
+// commandLine returns the step's command as one line
+func (s CommandStep) commandLine() string {
+	if len(s.Args) > 0 {
+		return buildutil.QuoteArgs(s.Args)
+	}
+	return s.Command
+}
+
+// validate checks that a step has something to run
+func (s CommandStep) validate(index int) error {
+	if (s.Command == "") == (len(s.Args) == 0) {
+		return fmt.Errorf("step %d needs either command or args", index+1)
+	}
+	if s.Shell && len(s.Args) > 0 {
+		return fmt.Errorf("step %d: shell runs a command line, use command instead of args", index+1)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("step %d: invalid timeout %v", index+1, s.Timeout)
+	}
+	dir := filepath.ToSlash(filepath.Clean(s.Dir))
+	if filepath.IsAbs(s.Dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return fmt.Errorf("step %d: dir %s must stay inside the workspace", index+1, s.Dir)
+	}
+	return nil
+}
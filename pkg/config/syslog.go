@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// SyslogFacilities are the facility codes of RFC 5424 by name
+var SyslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogConfig ships log records to a syslog server in addition to the standard error output
+type SyslogConfig struct {
+	Address  string `yaml:"address,omitempty"`  // host:port of the syslog server (empty = disabled)
+	Network  string `yaml:"network,omitempty"`  // udp (default) or tcp
+	Facility string `yaml:"facility,omitempty"` // e.g. daemon (default), user, local0 ... local7
+	AppName  string `yaml:"app_name,omitempty"` // APP-NAME of the records (default "boltbuild")
+}
+
+// validate checks the address, network and facility
+func (s SyslogConfig) validate() error {
+	if s.Address == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return fmt.Errorf("invalid syslog address %q: expected host:port", s.Address)
+	}
+	switch s.Network {
+	case "", "udp", "tcp":
+	default:
+		return fmt.Errorf("invalid syslog network: %s (use udp or tcp)", s.Network)
+	}
+	if _, exists := SyslogFacilities[s.Facility]; s.Facility != "" && !exists {
+		return fmt.Errorf("invalid syslog facility: %s", s.Facility)
+	}
+	return nil
+}
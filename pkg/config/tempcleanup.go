@@ -0,0 +1,19 @@
+package config
+
+import (
+	"time"
+
+	"boltbuild/pkg/protocol"
+)
+
+// TempCleanupConfig bounds the temp directories servers preserve with temp_deletion off
+type TempCleanupConfig struct {
+	Interval     time.Duration     `yaml:"interval,omitempty"`       // How often preserved directories are checked
+	MaxAge       time.Duration     `yaml:"max_age,omitempty"`        // Directories untouched for longer are removed (0 = keep)
+	MaxTotalSize protocol.ByteSize `yaml:"max_total_size,omitempty"` // Oldest directories are removed beyond this (0 = unlimited)
+}
+
+// Enabled reports whether preserved directories are cleaned up at all
+func (c TempCleanupConfig) Enabled() bool {
+	return c.MaxAge > 0 || c.MaxTotalSize > 0
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+
+	"boltbuild/pkg/protocol"
+)
+
+// BandwidthConfig limits the bytes per second written to the network
+type BandwidthConfig struct {
+	MaxBandwidth protocol.ByteSize `yaml:"max_bandwidth,omitempty"` // Per second across all connections (0 = unlimited)
+	LimitHours   string            `yaml:"limit_hours,omitempty"`   // Local time range the limit applies in, e.g. "08:00-18:00" (default: always)
+}
+
+// ParseLimitHours parses "HH:MM-HH:MM" into minutes after midnight; the range may wrap past midnight
+func ParseLimitHours(value string) (start, end int, err error) {
+	var h1, m1, h2, m2 int
+	if _, err := fmt.Sscanf(value, "%d:%d-%d:%d", &h1, &m1, &h2, &m2); err != nil {
+		return 0, 0, fmt.Errorf("invalid limit hours %q (use HH:MM-HH:MM)", value)
+	}
+	if h1 < 0 || h1 > 24 || h2 < 0 || h2 > 24 || m1 < 0 || m1 > 59 || m2 < 0 || m2 > 59 {
+		return 0, 0, fmt.Errorf("invalid limit hours %q (use HH:MM-HH:MM)", value)
+	}
+	return h1*60 + m1, h2*60 + m2, nil
+}
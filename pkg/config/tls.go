@@ -0,0 +1,19 @@
+package config
+
+import (
+	"time"
+)
+
+// TLSConfig encrypts the connections between clients and servers
+type TLSConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	CertFile           string        `yaml:"cert_file,omitempty"`            // Server certificate (PEM)
+	KeyFile            string        `yaml:"key_file,omitempty"`             // Server private key (PEM)
+	CAFile             string        `yaml:"ca_file,omitempty"`              // CA clients trust for server certificates (empty = system roots)
+	CAKeyFile          string        `yaml:"ca_key_file,omitempty"`          // CA key used by auto_rotate to issue server certificates
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify,omitempty"` // Clients accept any server certificate
+	AutoRotate         bool          `yaml:"auto_rotate,omitempty"`          // Servers issue themselves a new certificate before expiry
+	Validity           time.Duration `yaml:"validity,omitempty"`             // Lifetime of auto-rotated certificates
+	RenewBefore        time.Duration `yaml:"renew_before,omitempty"`         // Rotate this long before the certificate expires
+	ReloadInterval     time.Duration `yaml:"reload_interval,omitempty"`      // How often certificate files are checked for changes
+}
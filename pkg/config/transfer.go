@@ -0,0 +1,116 @@
+package config
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"boltbuild/pkg/protocol"
+)
+
+// defaultMaxTransferFileSize is used when an environment does not set transfer.max_file_size
+const defaultMaxTransferFileSize = 1 * protocol.MB
+
+// defaultTransferExcludes skip binaries unless an environment configures its own excludes
+var defaultTransferExcludes = []string{"*.exe", "*.dll", "*.so", "*.dylib", "*.o", "*.obj"}
+
+// TransferConfig selects which project files are sent to the build server
+type TransferConfig struct {
+	Include     []string          `yaml:"include,omitempty"`       // Only transfer files matching these patterns (default: everything)
+	Exclude     []string          `yaml:"exclude,omitempty"`       // Never transfer files or directories matching these patterns
+	MaxFileSize protocol.ByteSize `yaml:"max_file_size,omitempty"` // Skip files larger than this (default: 1MB)
+}
+
+// excluded reports whether a path matches the effective exclude patterns
+func (t TransferConfig) excluded(relPath string) bool {
+	if len(t.Exclude) == 0 {
+		// Default excludes compare extensions case-insensitively
+		return matchAnyPattern(defaultTransferExcludes, strings.ToLower(relPath))
+	}
+	return matchAnyPattern(t.Exclude, relPath)
+}
+
+// FileSizeLimit returns the effective per-file size limit
+func (t TransferConfig) FileSizeLimit() protocol.ByteSize {
+	if t.MaxFileSize <= 0 {
+		return defaultMaxTransferFileSize
+	}
+	return t.MaxFileSize
+}
+
+// SkipDir reports whether a directory (relative, slash separated) is excluded entirely
+func (t TransferConfig) SkipDir(relPath string) bool {
+	return t.excluded(relPath)
+}
+
+// IncludeFile reports whether a file (relative, slash separated) should be transferred
+func (t TransferConfig) IncludeFile(relPath string) bool {
+	if t.excluded(relPath) {
+		return false
+	}
+	return len(t.Include) == 0 || matchAnyPattern(t.Include, relPath)
+}
+
+// matchAnyPattern reports whether a path matches one of the patterns
+func matchAnyPattern(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if matchPathPattern(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathPattern matches a slash separated relative path against a glob pattern.
+// Patterns without a slash match the base name at any depth (like .gitignore),
+// "**" matches any number of directories and a trailing "/" is ignored.
+func matchPathPattern(pattern, relPath string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(strings.ReplaceAll(pattern, "\\", "/"), "./"), "/")
+	relPath = strings.TrimPrefix(relPath, "./")
+	if pattern == "" {
+		return false
+	}
+
+	if !strings.Contains(pattern, "/") {
+		matched, err := path.Match(pattern, path.Base(relPath))
+		return err == nil && matched
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+// matchSegments matches path segments, expanding "**" to zero or more segments
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try every possible number of swallowed segments
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], segments[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		segments = segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// ProjectRelativePath cleans a path relative to the project directory into slash form and
+// reports whether it stays inside the project directory
+func ProjectRelativePath(p string) (string, bool) {
+	cleaned := path.Clean(filepath.ToSlash(p))
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" || path.IsAbs(cleaned) {
+		return cleaned, false
+	}
+	return cleaned, cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
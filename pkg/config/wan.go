@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Components checked by ValidateWAN
+const (
+	WANRoleServer = "server"
+	WANRoleClient = "client"
+	WANRoleRelay  = "relay"
+)
+
+// wanMinTokenLength is the shortest relay token accepted in WAN mode
+const wanMinTokenLength = 16
+
+// ValidateWAN enforces the WAN profile for the component about to start: every connection is
+// TLS-encrypted and verified, servers and clients only meet at the relay (the authenticated
+// registry) with strong tenant tokens, and nothing listens for plaintext or unauthenticated peers
+func (c *Config) ValidateWAN(role string) error {
+	if !c.TLS.Enabled {
+		return fmt.Errorf("tls.enabled is required")
+	}
+	if c.TLS.InsecureSkipVerify {
+		return fmt.Errorf("tls.insecure_skip_verify is not allowed")
+	}
+
+	switch role {
+	case WANRoleServer:
+		if len(c.Server.ConnectTo) > 0 {
+			return fmt.Errorf("server.connect_to is not allowed, servers register at the relay")
+		}
+		if len(c.Server.AnnounceTo) > 0 {
+			return fmt.Errorf("server.announce_to is not allowed, servers register at the relay")
+		}
+		if c.Server.WebSocket.Port != 0 {
+			return fmt.Errorf("server.websocket is not allowed, servers register at the relay")
+		}
+		return validateWANRelayConnection("server.relay", c.Server.Relay)
+	case WANRoleClient:
+		if c.Client.ListenPort != 0 {
+			return fmt.Errorf("client.listen_port is not allowed, clients reach servers through the relay")
+		}
+		if len(c.Client.SSH) > 0 {
+			return fmt.Errorf("client.ssh is not allowed, clients reach servers through the relay")
+		}
+		if len(c.Client.Discovery.Servers) > 0 {
+			return fmt.Errorf("client.discovery.servers is not allowed, clients reach servers through the relay")
+		}
+		return validateWANRelayConnection("client.relay", c.Client.Relay)
+	case WANRoleRelay:
+		if c.TLS.CertFile == "" {
+			return fmt.Errorf("the relay needs tls.cert_file and tls.key_file")
+		}
+		for tenant, token := range c.Relay.Tenants {
+			if len(token) < wanMinTokenLength {
+				return fmt.Errorf("token of tenant %s is shorter than %d characters", tenant, wanMinTokenLength)
+			}
+		}
+	}
+	return nil
+}
+
+// validateWANRelayConnection checks the relay settings of a server or client
+func validateWANRelayConnection(name string, relay RelayConnection) error {
+	if relay.Address == "" {
+		return fmt.Errorf("%s.address is required", name)
+	}
+	if len(relay.Token) < wanMinTokenLength {
+		return fmt.Errorf("%s.token must be at least %d characters", name, wanMinTokenLength)
+	}
+	return nil
+}
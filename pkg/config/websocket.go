@@ -0,0 +1,16 @@
+package config
+
+import (
+	"strings"
+)
+
+// WebSocketConfig enables the WebSocket endpoint of a server
+type WebSocketConfig struct {
+	Port int    `yaml:"port,omitempty"` // HTTP port accepting WebSocket connections (0 = disabled)
+	Path string `yaml:"path,omitempty"` // Endpoint path (default: /boltbuild)
+}
+
+// IsWebSocketURL reports whether a server address selects the WebSocket transport
+func IsWebSocketURL(addr string) bool {
+	return strings.HasPrefix(addr, "ws://") || strings.HasPrefix(addr, "wss://")
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// defaultWebTLSDir holds the certificates of self_signed when no paths are configured
+const defaultWebTLSDir = "boltbuild-web-tls"
+
+// WebTLSConfig serves the web interface over HTTPS
+type WebTLSConfig struct {
+	Enabled      bool       `yaml:"enabled"`
+	CertFile     string     `yaml:"cert_file,omitempty"`     // Certificate (PEM), reloaded when it changes
+	KeyFile      string     `yaml:"key_file,omitempty"`      // Private key (PEM)
+	SelfSigned   bool       `yaml:"self_signed,omitempty"`   // Issue the certificate from a local CA, renewing it before expiry
+	CAFile       string     `yaml:"ca_file,omitempty"`       // CA of self_signed, to import into browsers
+	CAKeyFile    string     `yaml:"ca_key_file,omitempty"`   // Key of the self_signed CA
+	RedirectPort int        `yaml:"redirect_port,omitempty"` // Plain HTTP port redirecting to HTTPS (0 = none)
+	ACME         ACMEConfig `yaml:"acme,omitempty"`          // Certificates from Let's Encrypt or another ACME CA
+}
+
+// validate checks that a certificate is configured or issued
+func (c WebTLSConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") || (c.CAFile == "") != (c.CAKeyFile == "") {
+		return fmt.Errorf("web.tls: cert_file and key_file, and ca_file and ca_key_file, must be set together")
+	}
+	if err := c.ACME.validate(); err != nil {
+		return err
+	}
+	sources := 0
+	for _, configured := range []bool{c.CertFile != "" && !c.SelfSigned, c.SelfSigned, c.ACME.Enabled()} {
+		if configured {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("web.tls requires exactly one of cert_file and key_file, self_signed or acme")
+	}
+	if c.RedirectPort < 0 || c.RedirectPort > 65535 {
+		return fmt.Errorf("invalid web.tls.redirect_port: %d", c.RedirectPort)
+	}
+	return nil
+}
+
+// CertificateConfig returns the certificate settings, with the default paths of self_signed
+// and the renewal timing of the build connections' TLS settings
+func (c WebTLSConfig) CertificateConfig(timing TLSConfig) TLSConfig {
+	config := TLSConfig{
+		Enabled:        true,
+		CertFile:       c.CertFile,
+		KeyFile:        c.KeyFile,
+		CAFile:         c.CAFile,
+		CAKeyFile:      c.CAKeyFile,
+		AutoRotate:     c.SelfSigned,
+		Validity:       timing.Validity,
+		RenewBefore:    timing.RenewBefore,
+		ReloadInterval: timing.ReloadInterval,
+	}
+	if c.SelfSigned {
+		if config.CertFile == "" {
+			config.CertFile = filepath.Join(defaultWebTLSDir, "web.crt")
+			config.KeyFile = filepath.Join(defaultWebTLSDir, "web.key")
+		}
+		if config.CAFile == "" {
+			config.CAFile = filepath.Join(defaultWebTLSDir, "ca.crt")
+			config.CAKeyFile = filepath.Join(defaultWebTLSDir, "ca.key")
+		}
+	}
+	return config
+}
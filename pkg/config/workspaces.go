@@ -0,0 +1,7 @@
+package config
+
+// WorkspacesConfig enables persistent per-project workspaces on a server
+type WorkspacesConfig struct {
+	Enabled bool   `yaml:"enabled"`       // Keep workspaces of environments with persistent_workspace across builds
+	Dir     string `yaml:"dir,omitempty"` // Where workspaces are kept (default: boltbuild-workspaces in the temp directory)
+}
@@ -0,0 +1,11 @@
+package protocol
+
+// AnnouncePath is the client web API endpoint servers announce themselves at. It needs no
+// login: the client only dials back the caller's own IP, where the usual handshake applies.
+const AnnouncePath = "/api/v1/servers/announce"
+
+// ServerAnnouncement is sent by a freshly started server to the clients in server.announce_to
+type ServerAnnouncement struct {
+	ID   string `json:"id"`
+	Port int    `json:"port"` // Build protocol port on the announcing host
+}
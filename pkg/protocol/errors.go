@@ -0,0 +1,64 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors of build submissions. They are wrapped with details, test for them with errors.Is; the
+// web API names them in the ErrorCodeHeader of refused requests.
+var (
+	ErrNoServers       = errors.New("no available servers") // Every server able to run the build is busy
+	ErrServerBusy      = errors.New("server busy")          // The selected server is running another build
+	ErrVersionMismatch = errors.New("version mismatch")     // Client and server run different versions
+	ErrQuotaExceeded   = errors.New("build quota exceeded") // The user or team used up a build quota
+)
+
+// ErrorCodeHeader names the error of a refused web API request, e.g. "no_servers"
+const ErrorCodeHeader = "X-Boltbuild-Error"
+
+// errorCodes are the web API names of the submission errors
+var errorCodes = map[string]error{
+	"no_servers":       ErrNoServers,
+	"server_busy":      ErrServerBusy,
+	"version_mismatch": ErrVersionMismatch,
+	"quota_exceeded":   ErrQuotaExceeded,
+}
+
+// ErrorCode returns the web API name of the submission error err wraps ("" if none)
+func ErrorCode(err error) string {
+	for code, target := range errorCodes {
+		if errors.Is(err, target) {
+			return code
+		}
+	}
+	return ""
+}
+
+// CodeError returns the submission error of a web API name (nil if unknown)
+func CodeError(code string) error {
+	return errorCodes[code]
+}
+
+// BuildError is a build that ran and failed
+type BuildError struct {
+	ID       string
+	ExitCode int // Of the build command; 0 if the build failed after it (e.g. artifact limits), -1 if it did not finish
+	Message  string
+}
+
+// Error describes the failure
+func (e *BuildError) Error() string {
+	if e.ExitCode > 0 {
+		return fmt.Sprintf("build %s failed with exit code %d: %s", e.ID, e.ExitCode, e.Message)
+	}
+	return fmt.Sprintf("build %s failed: %s", e.ID, e.Message)
+}
+
+// Err returns nil for a successful build and a *BuildError otherwise
+func (r *BuildResponse) Err() error {
+	if r.Success {
+		return nil
+	}
+	return &BuildError{ID: r.ID, ExitCode: r.ExitCode, Message: r.Error}
+}
@@ -0,0 +1,239 @@
+// Package protocol defines the messages exchanged between boltbuild clients and servers and the
+// JSON types of the client's web API. Every connection starts with the server sending its
+// ServerInfo; afterwards both sides exchange newline-delimited JSON Messages.
+//
+// Fields are only ever added, so programs built against this package keep working with newer
+// clients and servers.
+package protocol
+
+import "time"
+
+// APIKeyHeader carries the API key of web API requests to clients that require a login
+const APIKeyHeader = "X-Boltbuild-API-Key"
+
+// BuildRequest represents a compilation request sent from client to server
+type BuildRequest struct {
+	ID                 string                    `json:"id"`
+	Environment        string                    `json:"environment"`              // Environment name for reference
+	Project            string                    `json:"project,omitempty"`        // named project the build belongs to
+	Command            string                    `json:"command"`                  // Complete build command
+	Args               []string                  `json:"args,omitempty"`           // argument list run instead of splitting command on whitespace
+	Shell              bool                      `json:"shell,omitempty"`          // run command through sh -c (cmd /C on Windows servers)
+	Steps              []BuildStep               `json:"steps,omitempty"`          // commands run one after the other instead of command
+	Platforms          map[string]CommandVariant `json:"platforms,omitempty"`      // replacements of the command by server platform ("windows", "linux/arm64")
+	ProjectDir         string                    `json:"project_dir"`              // Project directory
+	ExecutionDir       string                    `json:"execution_dir"`            // Execution directory (relative to project_dir)
+	OutputPaths        []string                  `json:"output_paths"`             // Output file patterns
+	AlwaysCollectPaths []string                  `json:"always_collect_paths"`     // Patterns collected even when the build fails
+	EnvVars            map[string]string         `json:"env_vars"`                 // Environment variables
+	Files              map[string]string         `json:"files"`                    // filename -> file content
+	ProjectName        string                    `json:"project_name"`             // unique project identifier
+	Compression        string                    `json:"compression"`              // requested artifact compression (negotiated from ServerInfo)
+	Image              string                    `json:"image,omitempty"`          // container image the build runs in
+	WSL                string                    `json:"wsl,omitempty"`            // WSL distribution the build runs in
+	Requirements       Requirements              `json:"requirements"`             // resources the server must provide
+	Provenance         string                    `json:"provenance,omitempty"`     // "slsa" or a generator command run after a successful build
+	Upload             *ChunkedUpload            `json:"upload,omitempty"`         // files follow in chunk messages instead
+	Workspace          string                    `json:"workspace,omitempty"`      // persistent workspace to build in (servers with workspaces enabled)
+	PreservePaths      []string                  `json:"preserve_paths,omitempty"` // workspace directories kept between builds besides the project
+	Clean              bool                      `json:"clean,omitempty"`          // start the persistent workspace over
+	ContentHash        string                    `json:"content_hash,omitempty"`   // project hash; files are left out when the server may hold them
+	DebugBundle        *DebugBundleConfig        `json:"debug_bundle,omitempty"`   // collect a triage archive if the build fails
+}
+
+// BuildResponse represents the compilation result sent back from server
+type BuildResponse struct {
+	ID          string            `json:"id"`
+	Success     bool              `json:"success"`
+	Output      string            `json:"output"`
+	Error       string            `json:"error,omitempty"`
+	Duration    time.Duration     `json:"duration"`
+	OutputFiles map[string]string `json:"output_files,omitempty"` // compiled files: filename -> base64 content
+	Warnings    []string          `json:"warnings,omitempty"`     // non-fatal problems, e.g. skipped artifacts
+	Compression string            `json:"compression,omitempty"`  // compression applied to output_files before base64
+	Checksums   map[string]string `json:"checksums,omitempty"`    // filename -> hex SHA-256 of the uncompressed content
+	Incremental bool              `json:"incremental,omitempty"`  // built on the state a previous build left in a persistent workspace
+	ExitCode    int               `json:"exit_code,omitempty"`    // of the build command; -1 if it did not finish (see BuildError)
+	Steps       []StepResult      `json:"steps,omitempty"`        // one per step run, for requests with steps
+}
+
+// BuildStep is one command of a multi-step build
+type BuildStep struct {
+	Name            string            `json:"name,omitempty"`
+	Command         string            `json:"command,omitempty"`
+	Args            []string          `json:"args,omitempty"`              // argument list run instead of splitting command
+	Shell           bool              `json:"shell,omitempty"`             // run command through the platform shell
+	Dir             string            `json:"dir,omitempty"`               // relative to project_dir (default: execution_dir)
+	EnvVars         map[string]string `json:"env_vars,omitempty"`          // added to and overriding the request's
+	Timeout         time.Duration     `json:"timeout,omitempty"`           // 0 = no limit besides the build's
+	ContinueOnError bool              `json:"continue_on_error,omitempty"` // a failure is reported as a warning and the next step runs
+	Platforms       []string          `json:"platforms,omitempty"`         // run only on these server platforms ("windows", "linux/arm64")
+}
+
+// CommandVariant replaces the command of a build on servers of one platform
+type CommandVariant struct {
+	Command string      `json:"command,omitempty"`
+	Args    []string    `json:"args,omitempty"`
+	Shell   bool        `json:"shell,omitempty"`
+	Steps   []BuildStep `json:"steps,omitempty"`
+}
+
+// StepResult is the outcome of one step of a multi-step build
+type StepResult struct {
+	Name     string        `json:"name"`
+	Success  bool          `json:"success"`
+	Skipped  bool          `json:"skipped,omitempty"` // not meant for the server's platform
+	Error    string        `json:"error,omitempty"`
+	ExitCode int           `json:"exit_code,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Output   string        `json:"output,omitempty"` // also part of the build output
+}
+
+// Message types exchanged between client and server after the initial ServerInfo
+const (
+	MessageHello   = "hello"   // client -> server: Client (sent once after receiving ServerInfo)
+	MessageBuild   = "build"   // client -> server: Request
+	MessageCancel  = "cancel"  // client -> server: BuildID
+	MessageLog     = "log"     // server -> client: BuildID, Data (build output as it is produced)
+	MessageResult  = "result"  // server -> client: Response
+	MessageStatus  = "status"  // server -> client: Load (sent periodically)
+	MessagePing    = "ping"    // client -> server: BuildID as probe ID, Data as payload
+	MessagePong    = "pong"    // server -> client: BuildID of the answered probe
+	MessageError   = "error"   // server -> client: Data (why the server closes the connection)
+	MessageChunk   = "chunk"   // client -> server: BuildID, Chunk (part of a chunked upload, on any connection)
+	MessageResend  = "resend"  // server -> client: BuildID (the project of a content hash is not cached, send the files)
+	MessageBrowse  = "browse"  // client -> server: BuildID, Browse (path in the workspace of a failed build)
+	MessageListing = "listing" // server -> client: BuildID, Browse (directory entries or file content)
+
+	MessagePreflight       = "preflight"        // client -> server: BuildID, Preflight (can the server run this build?)
+	MessagePreflightResult = "preflight_result" // server -> client: BuildID, Preflight with the decision
+	MessageLogLevel        = "log_level"        // client -> server: Data (log level to switch to, "info" or "debug")
+)
+
+// Message is the envelope for all traffic on a client/server connection
+type Message struct {
+	Type      string           `json:"type"`
+	BuildID   string           `json:"build_id,omitempty"`
+	Data      string           `json:"data,omitempty"`
+	Request   *BuildRequest    `json:"request,omitempty"`
+	Response  *BuildResponse   `json:"response,omitempty"`
+	Load      *ServerLoad      `json:"load,omitempty"`
+	Client    *ClientInfo      `json:"client,omitempty"`
+	Chunk     *UploadChunk     `json:"chunk,omitempty"`
+	Browse    *WorkspaceBrowse `json:"browse,omitempty"`
+	Preflight *PreflightCheck  `json:"preflight,omitempty"`
+}
+
+// ClientInfo identifies a client to the servers it connects to
+type ClientInfo struct {
+	ID       string `json:"id"`
+	Hostname string `json:"hostname"`
+	Version  string `json:"version"`
+	Address  string `json:"address,omitempty"` // Remote address as seen by the server
+}
+
+// ServerInfo represents server registration information
+type ServerInfo struct {
+	ID            string          `json:"id"`
+	Address       string          `json:"address"`
+	Port          int             `json:"port"`
+	Capacity      int             `json:"capacity"`
+	Version       string          `json:"version"`
+	OS            string          `json:"os,omitempty"`             // GOOS of the server, e.g. linux or windows
+	Arch          string          `json:"arch,omitempty"`           // GOARCH of the server, e.g. amd64 or arm64
+	OSVersion     string          `json:"os_version,omitempty"`     // operating system release, for display
+	Compression   []string        `json:"compression,omitempty"`    // supported artifact compression algorithms
+	Containers    string          `json:"containers,omitempty"`     // container runtime for environments with an image ("" = none)
+	WSL           []string        `json:"wsl,omitempty"`            // WSL distributions available on Windows servers
+	ContentCache  bool            `json:"content_cache,omitempty"`  // keeps projects so unchanged ones are not sent again
+	ChunkedUpload bool            `json:"chunked_upload,omitempty"` // accepts requests uploaded in chunks
+	Resources     ServerResources `json:"resources"`                // hardware for requirement matching
+	Load          ServerLoad      `json:"load"`                     // utilization, updated by status messages
+
+	Environments map[string]AdvertisedEnvironment `json:"environments,omitempty"` // the server's own build.environments
+	Tools        map[string]string                `json:"tools,omitempty"`        // installed build tools and their versions
+}
+
+// ServerStatusInfo represents server status for web interface
+type ServerStatusInfo struct {
+	ID         string          `json:"id"`
+	Address    string          `json:"address"`
+	Port       int             `json:"port"`
+	Capacity   int             `json:"capacity"`
+	Available  bool            `json:"available"`
+	Version    string          `json:"version"`
+	OS         string          `json:"os,omitempty"`
+	Arch       string          `json:"arch,omitempty"`
+	OSVersion  string          `json:"os_version,omitempty"`
+	Containers string          `json:"containers,omitempty"`
+	WSL        []string        `json:"wsl,omitempty"`
+	Resources  ServerResources `json:"resources"`
+	Load       ServerLoad      `json:"load"`
+	Network    NetworkStats    `json:"network"`
+
+	Environments []string          `json:"environments,omitempty"`
+	Tools        map[string]string `json:"tools,omitempty"`
+}
+
+// ChunkedUpload replaces the files of a build request whose project is sent in chunks
+type ChunkedUpload struct {
+	Chunks int    `json:"chunks"`
+	Size   int64  `json:"size"`   // Bytes of the reassembled file map
+	SHA256 string `json:"sha256"` // Checksum of the reassembled file map
+}
+
+// UploadChunk is one part of a chunked upload
+type UploadChunk struct {
+	Index int    `json:"index"`
+	Data  []byte `json:"data"`
+}
+
+// DebugBundleConfig makes the server collect what is needed to triage a failed build
+type DebugBundleConfig struct {
+	Enabled bool     `yaml:"enabled" json:"enabled"`
+	LogTail ByteSize `yaml:"log_tail,omitempty" json:"log_tail,omitempty"` // End of the build log included (default: 64KB)
+	Paths   []string `yaml:"paths,omitempty" json:"paths,omitempty"`       // Generated files to include, e.g. "./build/config.log"
+	Tools   []string `yaml:"tools,omitempty" json:"tools,omitempty"`       // Version commands run on the server, e.g. "gcc --version"
+}
+
+// WorkspaceEntry is a file or directory in a browsed workspace
+type WorkspaceEntry struct {
+	Name    string    `json:"name"`
+	Dir     bool      `json:"dir,omitempty"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// WorkspaceBrowse asks for (client -> server) and returns (server -> client) a directory listing
+// or file of the workspace a failed build left on the server
+type WorkspaceBrowse struct {
+	RequestID string           `json:"request_id"`
+	Path      string           `json:"path"` // Workspace-relative, "" for the root
+	Entries   []WorkspaceEntry `json:"entries,omitempty"`
+	File      bool             `json:"file,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	Binary    bool             `json:"binary,omitempty"`    // Content left out
+	Truncated bool             `json:"truncated,omitempty"` // Content cut at maxInspectFileSize
+	Size      int64            `json:"size,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// PreflightCheck asks a server whether it can run a build before its files are sent;
+// the server answers with Accepted and, when rejecting, Reason
+type PreflightCheck struct {
+	Environment  string       `json:"environment"`
+	Tool         string       `json:"tool,omitempty"` // Executable the command starts
+	Image        string       `json:"image,omitempty"`
+	WSL          string       `json:"wsl,omitempty"`
+	Requirements Requirements `json:"requirements"`
+	Size         int64        `json:"size"` // Estimated size of the project files in bytes
+
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AdvertisedEnvironment is an environment a server declares in its own build.environments
+type AdvertisedEnvironment struct {
+	Language string `json:"language,omitempty"`
+	Command  string `json:"command"`
+}
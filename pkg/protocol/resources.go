@@ -0,0 +1,140 @@
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a size in bytes that can be written as "512MB", "2GB" etc. in YAML
+type ByteSize int64
+
+// Byte size units (binary multiples)
+const (
+	KB ByteSize = 1 << (10 * (iota + 1))
+	MB
+	GB
+	TB
+)
+
+// ParseByteSize parses a human readable size such as "10MB" or "1.5GB"
+func ParseByteSize(value string) (ByteSize, error) {
+	str := strings.ToUpper(strings.TrimSpace(value))
+	if str == "" {
+		return 0, nil
+	}
+
+	multiplier := ByteSize(1)
+	for _, unit := range []struct {
+		suffix string
+		size   ByteSize
+	}{
+		{"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB}, {"B", 1},
+	} {
+		if strings.HasSuffix(str, unit.suffix) {
+			multiplier = unit.size
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(str, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+	return ByteSize(number * float64(multiplier)), nil
+}
+
+// String formats the size using the largest fitting unit
+func (b ByteSize) String() string {
+	switch {
+	case b >= TB:
+		return fmt.Sprintf("%.1fTB", float64(b)/float64(TB))
+	case b >= GB:
+		return fmt.Sprintf("%.1fGB", float64(b)/float64(GB))
+	case b >= MB:
+		return fmt.Sprintf("%.1fMB", float64(b)/float64(MB))
+	case b >= KB:
+		return fmt.Sprintf("%.1fKB", float64(b)/float64(KB))
+	default:
+		return fmt.Sprintf("%dB", int64(b))
+	}
+}
+
+// UnmarshalYAML accepts both plain byte counts and strings with units
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// MarshalYAML writes the size in its human readable form
+func (b ByteSize) MarshalYAML() (interface{}, error) {
+	if b == 0 {
+		return 0, nil
+	}
+	return b.String(), nil
+}
+
+// ServerResources describes the hardware of a build server (zero values are unknown)
+type ServerResources struct {
+	Memory   ByteSize `json:"memory"`    // Total physical memory
+	FreeDisk ByteSize `json:"free_disk"` // Free space in the build temp directory
+	GPUs     int      `json:"gpus"`      // Number of detected GPUs
+}
+
+// ServerLoad is the live utilization a server reports periodically (zero values are unknown)
+type ServerLoad struct {
+	CPULoad      float64   `json:"cpu_load"` // 1-minute load average per CPU (CPU busy fraction on Windows)
+	FreeMemory   ByteSize  `json:"free_memory"`
+	FreeDisk     ByteSize  `json:"free_disk"`
+	TempUsage    ByteSize  `json:"temp_usage"`         // Used by build directories and persistent workspaces
+	CacheSize    ByteSize  `json:"cache_size"`         // Used by the content cache
+	LowDisk      bool      `json:"low_disk,omitempty"` // Below server.min_free_disk, no new builds are scheduled
+	ActiveBuilds int       `json:"active_builds"`
+	QueuedBuilds int       `json:"queued_builds,omitempty"` // Waiting for a free slot
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Requirements are the resources a build environment needs on its server
+type Requirements struct {
+	MinMemory ByteSize `yaml:"min_memory,omitempty" json:"min_memory,omitempty"`
+	MinDisk   ByteSize `yaml:"min_disk,omitempty" json:"min_disk,omitempty"` // Free space in the server's temp directory
+	GPU       bool     `yaml:"gpu,omitempty" json:"gpu,omitempty"`
+	Platforms []string `yaml:"platforms,omitempty" json:"platforms,omitempty"` // Server OS or OS/arch, e.g. windows or linux/arm64 (checked against ServerInfo)
+}
+
+// Check reports the first requirement the resources do not satisfy
+func (r Requirements) Check(resources ServerResources) error {
+	if r.MinMemory > 0 && resources.Memory < r.MinMemory {
+		return fmt.Errorf("requires %s memory, server has %s", r.MinMemory, resources.Memory)
+	}
+	if r.MinDisk > 0 && resources.FreeDisk < r.MinDisk {
+		return fmt.Errorf("requires %s free disk, server has %s", r.MinDisk, resources.FreeDisk)
+	}
+	if r.GPU && resources.GPUs == 0 {
+		return fmt.Errorf("requires a GPU, server has none")
+	}
+	return nil
+}
+
+// NetworkStats is the measured connection quality from the client to a server
+type NetworkStats struct {
+	Latency    time.Duration `json:"latency"`   // Round trip of an empty probe
+	Bandwidth  float64       `json:"bandwidth"` // Upload bytes per second (0 = not measured)
+	MeasuredAt time.Time     `json:"measured_at"`
+}
+
+// TransferTime estimates how long sending size bytes to the server takes
+func (n NetworkStats) TransferTime(size int64) time.Duration {
+	if n.Bandwidth <= 0 {
+		return time.Duration(1<<63 - 1) // Unmeasured servers come last
+	}
+	return n.Latency + time.Duration(float64(size)/n.Bandwidth*float64(time.Second))
+}
@@ -0,0 +1,6 @@
+package protocol
+
+// Version information
+const (
+	Version = "1.0.0"
+)
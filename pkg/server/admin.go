@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+	"github.com/gorilla/mux"
+)
+
+// ConnectedClient describes a client connection in the server status
+type ConnectedClient struct {
+	protocol.ClientInfo
+	ConnectedAt   time.Time   `json:"connected_at"`
+	RunningBuilds []string    `json:"running_builds"` // Includes builds waiting for a slot
+	Usage         ClientUsage `json:"usage"`          // Accounting of all connections from the client's host
+}
+
+// ServerAdminStatus is the state reported by the server's admin endpoint
+type ServerAdminStatus struct {
+	ID       string              `json:"id"`
+	Version  string              `json:"version"`
+	Capacity int                 `json:"capacity"`
+	Load     protocol.ServerLoad `json:"load"`
+	Clients  []ConnectedClient   `json:"clients"`
+	Temp     TempCleanupStats    `json:"temp_cleanup"`
+}
+
+// connectedClients lists the connected clients sorted by connection time
+func (s *Server) connectedClients() []ConnectedClient {
+	s.clientsMux.RLock()
+	connections := make([]*ClientConnection, 0, len(s.clients))
+	for _, client := range s.clients {
+		connections = append(connections, client)
+	}
+	s.clientsMux.RUnlock()
+
+	clients := make([]ConnectedClient, 0, len(connections))
+	for _, connection := range connections {
+		connection.infoMux.Lock()
+		info := connection.info
+		connection.infoMux.Unlock()
+		info.Address = connection.addr
+
+		connection.buildsMux.Lock()
+		builds := make([]string, 0, len(connection.builds))
+		for id := range connection.builds {
+			builds = append(builds, id)
+		}
+		connection.buildsMux.Unlock()
+		sort.Strings(builds)
+
+		clients = append(clients, ConnectedClient{
+			ClientInfo:    info,
+			ConnectedAt:   connection.connectedAt,
+			RunningBuilds: builds,
+			Usage:         s.slots.clientUsage(config.ClientKey(connection.addr)),
+		})
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ConnectedAt.Before(clients[j].ConnectedAt) })
+	return clients
+}
+
+// StartAdmin serves the server status over HTTP on the admin port
+func (s *Server) StartAdmin(port int) error {
+	r := mux.NewRouter()
+	r.HandleFunc("/status", s.handleAdminStatus).Methods("GET")
+	r.HandleFunc("/clients", s.handleAdminClients).Methods("GET")
+
+	logging.Infof("Server admin endpoint listening on port %d", port)
+	return http.ListenAndServe(fmt.Sprintf(":%d", port), r)
+}
+
+// handleAdminStatus returns the server identity, load and connected clients
+func (s *Server) handleAdminStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ServerAdminStatus{
+		ID:       s.id,
+		Version:  protocol.Version,
+		Capacity: s.capacity,
+		Load:     s.measureLoad(),
+		Clients:  s.connectedClients(),
+		Temp:     s.tempCleanup.Stats(),
+	})
+}
+
+// handleAdminClients returns the connected clients
+func (s *Server) handleAdminClients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.connectedClients())
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// Announcement retries
+const (
+	announceRetryInterval = 5 * time.Second
+	announceAttempts      = 12 // Give up after about a minute; the client's scan still finds the server
+)
+
+// announceURL returns the announce endpoint of a client given as host:web_port or URL
+func announceURL(client string) string {
+	if !strings.HasPrefix(client, "http://") && !strings.HasPrefix(client, "https://") {
+		client = "http://" + client
+	}
+	return strings.TrimRight(client, "/") + protocol.AnnouncePath
+}
+
+// announce tells the configured clients that this server is up so they connect right away
+// instead of at their next discovery scan
+func (s *Server) announce(clients []string) {
+	announcement, _ := json.Marshal(protocol.ServerAnnouncement{ID: s.id, Port: s.port})
+	for _, client := range clients {
+		go func(client string) {
+			httpClient := &http.Client{Timeout: s.config.Client.Discovery.ConnectTimeout + 5*time.Second}
+			for attempt := 1; attempt <= announceAttempts; attempt++ {
+				resp, err := httpClient.Post(announceURL(client), "application/json", bytes.NewReader(announcement))
+				if err == nil {
+					resp.Body.Close()
+					if resp.StatusCode == http.StatusAccepted {
+						logging.Infof("Announced server to client %s", client)
+						return
+					}
+					err = fmt.Errorf("%s", resp.Status)
+				}
+				logging.Debugf("Failed to announce server to client %s: %v", client, err)
+				time.Sleep(announceRetryInterval)
+			}
+			logging.Infof("Giving up announcing server to client %s, it will find the server when scanning", client)
+		}(client)
+	}
+}
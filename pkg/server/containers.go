@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// containerWorkspace is where the build workspace is mounted inside a container
+const containerWorkspace = "/workspace"
+
+// containerRuntimes lists the supported runtimes in auto-detection order
+var containerRuntimes = []string{config.ContainerRuntimeDocker, config.ContainerRuntimePodman}
+
+// containerRuntime is a usable container CLI
+type containerRuntime struct {
+	Name     string // "docker" or "podman"
+	Path     string // Executable
+	Rootless bool   // Container root is mapped to the server user
+}
+
+// detectContainerRuntime finds the configured (or first usable) container runtime, nil without one
+func detectContainerRuntime(setting string) *containerRuntime {
+	candidates := containerRuntimes
+	switch setting {
+	case config.ContainerRuntimeNone:
+		return nil
+	case "", config.ContainerRuntimeAuto:
+	default:
+		candidates = []string{setting}
+	}
+
+	for _, name := range candidates {
+		runtime, err := probeContainerRuntime(name)
+		if err == nil {
+			return runtime
+		}
+		logging.Debugf("Container runtime %s not usable: %v", name, err)
+	}
+	return nil
+}
+
+// probeContainerRuntime checks that a runtime answers and whether it runs rootless
+func probeContainerRuntime(name string) (*containerRuntime, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The CLI alone is not enough, the daemon (or podman's storage) must answer as well
+	var format string
+	switch name {
+	case config.ContainerRuntimePodman:
+		format = "{{.Host.Security.Rootless}}"
+	default:
+		format = "{{json .SecurityOptions}}"
+	}
+	out, err := exec.Command(path, "info", "--format", format).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	runtime := &containerRuntime{Name: name, Path: path}
+	if name == config.ContainerRuntimePodman {
+		runtime.Rootless = strings.TrimSpace(string(out)) == "true"
+	} else {
+		runtime.Rootless = strings.Contains(string(out), "rootless")
+	}
+	return runtime, nil
+}
+
+// userArgs makes files written in the workspace belong to the server user
+func (r *containerRuntime) userArgs() []string {
+	switch {
+	case r.Name == config.ContainerRuntimePodman && r.Rootless:
+		// Rootless podman maps the server user to the same ID inside the container
+		return []string{"--userns=keep-id"}
+	case r.Rootless:
+		// Container root already is the server user in rootless Docker
+		return nil
+	}
+
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 {
+		return []string{"--user", fmt.Sprintf("%d:%d", uid, gid), "-e", "HOME=/tmp"}
+	}
+	return nil
+}
+
+// imageRef qualifies short image names for podman, which would otherwise prompt for a registry
+// (docker resolves them against Docker Hub)
+func (r *containerRuntime) imageRef(image string) string {
+	if r.Name != config.ContainerRuntimePodman {
+		return image
+	}
+	first, rest, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image // Already names a registry
+	}
+	if !found {
+		return "docker.io/library/" + image
+	}
+	return "docker.io/" + first + "/" + rest
+}
+
+// containerName is the name of the container running a build, used to remove it on cancellation
+func containerName(buildID string) string {
+	return "boltbuild-" + buildID
+}
+
+// containerCommand runs a build command inside the requested image with the workspace mounted.
+// The image is pulled by the runtime if it is not present yet.
+func (s *Server) containerCommand(ctx context.Context, request protocol.BuildRequest, projectDir, executionDir string, cmdParts []string) (*exec.Cmd, error) {
+	if s.containerRuntime == nil {
+		return nil, fmt.Errorf("environment %s requires image %s but this server has no container runtime", request.Environment, request.Image)
+	}
+
+	relDir, err := filepath.Rel(projectDir, executionDir)
+	if err != nil || relDir == ".." || strings.HasPrefix(relDir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("execution directory %s is outside the workspace", executionDir)
+	}
+
+	name := containerName(request.ID)
+	args := []string{"run", "--rm", "--name", name,
+		"-v", projectDir + ":" + containerWorkspace,
+		"-w", path.Join(containerWorkspace, filepath.ToSlash(relDir)),
+	}
+
+	// Run as the server user so the artifacts can be collected and removed
+	args = append(args, s.containerRuntime.userArgs()...)
+
+	keys := make([]string, 0, len(request.EnvVars))
+	for key := range request.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key+"="+request.EnvVars[key])
+	}
+
+	args = append(args, s.containerRuntime.imageRef(request.Image))
+	args = append(args, cmdParts...)
+
+	logging.Debugf("%s container build: %s %v", request.Environment, s.containerRuntime.Name, s.config.Server.BuildEnv.ScrubArgs(args))
+
+	cmd := exec.CommandContext(ctx, s.containerRuntime.Path, args...)
+	cmd.Dir = executionDir
+	cmd.WaitDelay = cancelWaitDelay
+
+	// Killing the CLI leaves the container running, so remove it first
+	runtime := s.containerRuntime.Path
+	cmd.Cancel = func() error {
+		if err := exec.Command(runtime, "rm", "-f", name).Run(); err != nil {
+			logging.Debugf("Failed to remove container %s: %v", name, err)
+		}
+		return cmd.Process.Kill()
+	}
+	return cmd, nil
+}
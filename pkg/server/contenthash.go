@@ -0,0 +1,180 @@
+package server
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// contentCacheDir is where servers keep the projects of previous builds, below the temp directory
+const contentCacheDir = "boltbuild-content"
+
+// contentCache keeps the files of uploaded projects by hash so unchanged projects are not sent again
+type contentCache struct {
+	dir     string
+	maxSize int64
+	mux     sync.Mutex
+}
+
+// newContentCache creates the cache of a server in its temp directory, nil when disabled
+func newContentCache(cfg config.ContentCacheConfig, tempDir string) *contentCache {
+	if cfg.MaxSize <= 0 {
+		return nil
+	}
+	return &contentCache{dir: filepath.Join(tempDir, contentCacheDir), maxSize: int64(cfg.MaxSize)}
+}
+
+// path is the file holding a cached project
+func (cc *contentCache) path(hash string) string {
+	return filepath.Join(cc.dir, hash+".json.gz")
+}
+
+// validHash rejects anything but a hex SHA-256 before it is used in a path
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// load returns the files of a cached project
+func (cc *contentCache) load(hash string) (map[string]string, bool) {
+	if !validHash(hash) {
+		return nil, false
+	}
+	cc.mux.Lock()
+	defer cc.mux.Unlock()
+
+	file, err := os.Open(cc.path(hash))
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, false
+	}
+	var files map[string]string
+	if err := json.NewDecoder(reader).Decode(&files); err != nil {
+		logging.Debugf("Dropping unreadable cached project %s: %v", hash, err)
+		os.Remove(cc.path(hash))
+		return nil, false
+	}
+
+	// Recently used projects are evicted last
+	now := time.Now()
+	os.Chtimes(cc.path(hash), now, now)
+	return files, true
+}
+
+// store caches the files of a project after checking they match the hash
+func (cc *contentCache) store(hash string, files map[string]string) {
+	if !validHash(hash) || buildutil.ProjectHash(files) != hash {
+		logging.Debugf("Not caching project: content hash does not match")
+		return
+	}
+	cc.mux.Lock()
+	defer cc.mux.Unlock()
+
+	if _, err := os.Stat(cc.path(hash)); err == nil {
+		now := time.Now()
+		os.Chtimes(cc.path(hash), now, now)
+		return
+	}
+	if err := cc.write(hash, files); err != nil {
+		logging.Debugf("Failed to cache project %s: %v", hash, err)
+		return
+	}
+	cc.evict()
+}
+
+// write saves a project atomically; mux must be held
+func (cc *contentCache) write(hash string, files map[string]string) error {
+	if err := os.MkdirAll(cc.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(cc.dir, hash+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(writer).Encode(files); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cc.path(hash))
+}
+
+// evict removes the least recently used projects until the cache fits; mux must be held
+func (cc *contentCache) evict() {
+	entries, err := os.ReadDir(cc.dir)
+	if err != nil {
+		return
+	}
+	var cached []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json.gz") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			cached = append(cached, info)
+			total += info.Size()
+		}
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].ModTime().Before(cached[j].ModTime()) })
+	for _, info := range cached {
+		if total <= cc.maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(cc.dir, info.Name())); err == nil {
+			total -= info.Size()
+			logging.Debugf("Evicted cached project %s", strings.TrimSuffix(info.Name(), ".json.gz"))
+		}
+	}
+}
+
+// resolveContent fills in the files of a request sent by content hash, or asks the client for them
+// when the project is not cached; requests with files are cached for the next build
+func (s *Server) resolveContent(clientConn *ClientConnection, request *protocol.BuildRequest) bool {
+	if request.ContentHash == "" || s.content == nil {
+		return true
+	}
+	if request.Files != nil {
+		go s.content.store(request.ContentHash, request.Files)
+		return true
+	}
+
+	files, cached := s.content.load(request.ContentHash)
+	if !cached {
+		if err := clientConn.send(protocol.Message{Type: protocol.MessageResend, BuildID: request.ID}); err != nil {
+			logging.Debugf("Failed to request the files of build %s: %v", request.ID, err)
+		}
+		return false
+	}
+	request.Files = files
+	logging.Debugf("Build %s reuses cached project %s (%d files)", request.ID, request.ContentHash[:12], len(files))
+	return true
+}
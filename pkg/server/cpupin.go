@@ -0,0 +1,176 @@
+package server
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// cpuPinning hands every running build the CPU set of a free build slot, so parallel builds
+// on big machines do not evict each other's caches
+type cpuPinning struct {
+	sets  [][]int // CPU set per build slot
+	inUse []bool
+	mux   sync.Mutex
+}
+
+// newCPUPinning divides the CPUs of this machine (instance index of count, see
+// server.instances) between capacity build slots; nil when pinning is off or not possible
+func newCPUPinning(mode string, capacity, index, count int) *cpuPinning {
+	if mode == "" || mode == config.CPUPinningNone {
+		return nil
+	}
+	if !cpuPinningSupported {
+		logging.Infof("Warning: CPU pinning is only supported on Linux, builds run unpinned")
+		return nil
+	}
+
+	var groups [][]int
+	if mode == config.CPUPinningNUMA {
+		nodes, err := numaNodes()
+		if err != nil || len(nodes) == 0 {
+			logging.Infof("Warning: NUMA nodes not found (%v), splitting the CPUs between build slots instead", err)
+			mode = config.CPUPinningSlots
+		} else {
+			// Instances take every count-th node, as long as there are enough nodes
+			for i, node := range nodes {
+				if len(nodes) < count || i%count == index {
+					groups = append(groups, node)
+				}
+			}
+		}
+	}
+	if mode == config.CPUPinningSlots {
+		cpus := make([]int, runtime.NumCPU())
+		for i := range cpus {
+			cpus[i] = i
+		}
+		parts := splitCPUs(cpus, count)
+		groups = [][]int{parts[index%len(parts)]}
+	}
+
+	// Slots share the groups round-robin; a group larger than its slots is split between them
+	pinning := &cpuPinning{inUse: make([]bool, capacity)}
+	for slot := 0; slot < capacity; slot++ {
+		group := groups[slot%len(groups)]
+		slotsInGroup := capacity / len(groups)
+		if slot%len(groups) < capacity%len(groups) {
+			slotsInGroup++
+		}
+		parts := splitCPUs(group, slotsInGroup)
+		pinning.sets = append(pinning.sets, parts[(slot/len(groups))%len(parts)])
+	}
+	for slot, set := range pinning.sets {
+		logging.Debugf("Build slot %d pinned to CPUs %s", slot, formatCPUList(set))
+	}
+	return pinning
+}
+
+// pinBuild binds a started build command to the CPU set of a free slot until the returned
+// function is called. Container builds are left to the container runtime.
+func (s *Server) pinBuild(request protocol.BuildRequest, pid int) (release func()) {
+	if request.Image != "" {
+		return func() {}
+	}
+	cpus, release := s.pinning.acquire()
+	if cpus == nil {
+		return release
+	}
+	if err := setAffinity(pid, cpus); err != nil {
+		logging.Debugf("Warning: Failed to pin build %s to CPUs %s: %v", request.ID, formatCPUList(cpus), err)
+	} else {
+		logging.Debugf("Build %s pinned to CPUs %s", request.ID, formatCPUList(cpus))
+	}
+	return release
+}
+
+// splitCPUs divides a CPU list into at most parts contiguous, nearly equal sets
+func splitCPUs(cpus []int, parts int) [][]int {
+	if parts > len(cpus) {
+		parts = len(cpus)
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	var sets [][]int
+	start := 0
+	for i := 0; i < parts; i++ {
+		size := len(cpus) / parts
+		if i < len(cpus)%parts {
+			size++
+		}
+		sets = append(sets, cpus[start:start+size])
+		start += size
+	}
+	return sets
+}
+
+// acquire reserves the CPU set of a free slot; release returns it
+func (p *cpuPinning) acquire() (cpus []int, release func()) {
+	if p == nil {
+		return nil, func() {}
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for slot, used := range p.inUse {
+		if !used {
+			p.inUse[slot] = true
+			return p.sets[slot], func() {
+				p.mux.Lock()
+				p.inUse[slot] = false
+				p.mux.Unlock()
+			}
+		}
+	}
+	// More builds than slots (e.g. builds of persistent workspaces): run unpinned
+	return nil, func() {}
+}
+
+// parseCPUList reads the kernel's CPU list format, e.g. "0-3,8-11"
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q", list)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// formatCPUList writes CPUs in the kernel's list format
+func formatCPUList(cpus []int) string {
+	var parts []string
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(cpus[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
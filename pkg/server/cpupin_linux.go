@@ -0,0 +1,62 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// cpuPinningSupported is true where builds can be bound to CPU sets
+const cpuPinningSupported = true
+
+// numaNodes returns the CPUs of every NUMA node, ordered by node number
+func numaNodes() ([][]int, error) {
+	paths, err := filepath.Glob("/sys/devices/system/node/node[0-9]*/cpulist")
+	if err != nil {
+		return nil, err
+	}
+	number := func(path string) int {
+		n, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(path)), "node"))
+		return n
+	}
+	sort.Slice(paths, func(i, j int) bool { return number(paths[i]) < number(paths[j]) })
+
+	var nodes [][]int
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cpus, err := parseCPUList(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if len(cpus) > 0 { // Memory-only nodes have no CPUs
+			nodes = append(nodes, cpus)
+		}
+	}
+	return nodes, nil
+}
+
+// setAffinity binds a started process to a CPU set; the processes it starts inherit it
+func setAffinity(pid int, cpus []int) error {
+	maxCPU := 0
+	for _, cpu := range cpus {
+		if cpu > maxCPU {
+			maxCPU = cpu
+		}
+	}
+	mask := make([]uint64, maxCPU/64+1)
+	for _, cpu := range cpus {
+		mask[cpu/64] |= 1 << (uint(cpu) % 64)
+	}
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(pid), uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0])))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
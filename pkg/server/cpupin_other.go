@@ -0,0 +1,20 @@
+//go:build !linux
+
+package server
+
+import (
+	"fmt"
+)
+
+// cpuPinningSupported is true where builds can be bound to CPU sets
+const cpuPinningSupported = false
+
+// numaNodes is only implemented on Linux
+func numaNodes() ([][]int, error) {
+	return nil, fmt.Errorf("NUMA nodes are only detected on Linux")
+}
+
+// setAffinity is only implemented on Linux
+func setAffinity(pid int, cpus []int) error {
+	return fmt.Errorf("CPU pinning is only supported on Linux")
+}
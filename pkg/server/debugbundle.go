@@ -0,0 +1,218 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// Debug bundle settings
+const (
+	defaultDebugLogTail = 64 * protocol.KB
+	maxDebugBundleFile  = 1 * protocol.MB  // Larger workspace files are cut
+	maxDebugBundleFiles = 16 * protocol.MB // Workspace files beyond this are left out
+	debugToolTimeout    = 10 * time.Second
+)
+
+// debugBundleInfo is the summary file at the root of a debug bundle
+type debugBundleInfo struct {
+	BuildID      string            `json:"build_id"`
+	Environment  string            `json:"environment"`
+	Command      string            `json:"command"`
+	ExecutionDir string            `json:"execution_dir,omitempty"`
+	Image        string            `json:"image,omitempty"`
+	WSL          string            `json:"wsl,omitempty"`
+	EnvVars      map[string]string `json:"env_vars,omitempty"`
+	Path         string            `json:"path"` // PATH of the server, where the command was looked up
+	Error        string            `json:"error"`
+	Duration     time.Duration     `json:"duration"`
+	ServerID     string            `json:"server_id"`
+	Platform     string            `json:"platform"`
+	Version      string            `json:"boltbuild_version"`
+	Files        []string          `json:"files,omitempty"`    // Workspace files included below files/
+	Warnings     []string          `json:"warnings,omitempty"` // What could not be collected
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// debugBundleWriter adds files to a gzipped tar archive
+type debugBundleWriter struct {
+	tar *tar.Writer
+	err error
+}
+
+// add writes one file into the archive, keeping the first error
+func (b *debugBundleWriter) add(name string, content []byte) {
+	if b.err != nil {
+		return
+	}
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: time.Now()}
+	if b.err = b.tar.WriteHeader(header); b.err == nil {
+		_, b.err = b.tar.Write(content)
+	}
+}
+
+// createDebugBundle packs the command, environment, tool versions, log tail and the
+// requested workspace files of a failed build into a tar.gz archive
+func (s *Server) createDebugBundle(ctx context.Context, request protocol.BuildRequest, projectDir string, response *protocol.BuildResponse) ([]byte, error) {
+	config := request.DebugBundle
+	info := debugBundleInfo{
+		BuildID:      request.ID,
+		Environment:  request.Environment,
+		Command:      request.Command,
+		ExecutionDir: request.ExecutionDir,
+		Image:        request.Image,
+		WSL:          request.WSL,
+		EnvVars:      s.config.Server.BuildEnv.Scrub(request.EnvVars),
+		Path:         os.Getenv("PATH"),
+		Error:        response.Error,
+		Duration:     response.Duration,
+		ServerID:     s.id,
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		Version:      protocol.Version,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	var buffer bytes.Buffer
+	compressed := gzip.NewWriter(&buffer)
+	bundle := &debugBundleWriter{tar: tar.NewWriter(compressed)}
+
+	// End of the log, where the failure usually is
+	tail := config.LogTail
+	if tail <= 0 {
+		tail = defaultDebugLogTail
+	}
+	log := response.Output
+	if int64(len(log)) > int64(tail) {
+		log = log[len(log)-int(tail):]
+	}
+	bundle.add("build-log-tail.txt", []byte(log))
+
+	if len(config.Tools) > 0 {
+		bundle.add("tool-versions.txt", []byte(s.debugToolVersions(ctx, config.Tools, projectDir)))
+	}
+
+	if len(config.Paths) > 0 {
+		files, err := s.findFiles(projectDir)
+		if err != nil {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("failed to list workspace: %v", err))
+		}
+		var total int64
+		for _, file := range files {
+			relativePath, err := filepath.Rel(projectDir, file)
+			if err != nil {
+				continue
+			}
+			normalizedPath := "./" + filepath.ToSlash(relativePath)
+			if strings.HasPrefix(normalizedPath, "./"+buildutil.BuildMetadataDir+"/") || !s.isOutputFileNormalized(normalizedPath, config.Paths) {
+				continue
+			}
+			if total >= int64(maxDebugBundleFiles) {
+				info.Warnings = append(info.Warnings, fmt.Sprintf("%s left out: bundle file limit of %s reached", normalizedPath, protocol.ByteSize(maxDebugBundleFiles)))
+				continue
+			}
+			content, err := s.readFilePrefix(file, int64(maxDebugBundleFile))
+			if err != nil {
+				info.Warnings = append(info.Warnings, fmt.Sprintf("failed to read %s: %v", normalizedPath, err))
+				continue
+			}
+			if stat, err := os.Stat(file); err == nil && stat.Size() > int64(len(content)) {
+				info.Warnings = append(info.Warnings, fmt.Sprintf("%s cut to %s", normalizedPath, protocol.ByteSize(len(content))))
+			}
+			total += int64(len(content))
+			info.Files = append(info.Files, normalizedPath)
+			bundle.add("files/"+filepath.ToSlash(relativePath), content)
+		}
+		sort.Strings(info.Files)
+	}
+
+	summary, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	bundle.add("build.json", summary)
+
+	if bundle.err != nil {
+		return nil, bundle.err
+	}
+	if err := bundle.tar.Close(); err != nil {
+		return nil, err
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// debugToolVersions runs the configured version commands and returns their combined output
+func (s *Server) debugToolVersions(ctx context.Context, tools []string, projectDir string) string {
+	var report strings.Builder
+	for _, tool := range tools {
+		parts := strings.Fields(tool)
+		if len(parts) == 0 {
+			continue
+		}
+		toolCtx, cancel := context.WithTimeout(ctx, debugToolTimeout)
+		cmd := exec.CommandContext(toolCtx, parts[0], parts[1:]...)
+		cmd.Dir = projectDir
+		cmd.WaitDelay = cancelWaitDelay
+		output, err := cmd.CombinedOutput()
+		cancel()
+
+		fmt.Fprintf(&report, "$ %s\n%s", tool, output)
+		if err != nil {
+			fmt.Fprintf(&report, "(%v)\n", err)
+		}
+		report.WriteString("\n")
+	}
+	return report.String()
+}
+
+// attachDebugBundle adds the debug bundle of a failed build to the response artifacts.
+// Cancelled builds get none; collection failures are reported as warnings.
+func (s *Server) attachDebugBundle(ctx context.Context, request protocol.BuildRequest, projectDir string, response *protocol.BuildResponse) {
+	if request.DebugBundle == nil || response.Success || ctx.Err() != nil {
+		return
+	}
+
+	// The build context is done for timed out builds, so tools get a fresh one
+	bundle, err := s.createDebugBundle(context.Background(), request, projectDir, response)
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(projectDir, buildutil.BuildMetadataDir), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(projectDir, filepath.FromSlash(buildutil.DebugBundlePath)), bundle, 0644)
+	}
+	var encoded string
+	if err == nil {
+		encoded, err = buildutil.EncodeArtifact(bundle, response.Compression)
+	}
+	if err != nil {
+		logging.Infof("Build %s: debug bundle failed: %v", request.ID, err)
+		response.Warnings = append(response.Warnings, fmt.Sprintf("debug bundle failed: %v", err))
+		return
+	}
+
+	if response.OutputFiles == nil {
+		response.OutputFiles = make(map[string]string)
+	}
+	if response.Checksums == nil {
+		response.Checksums = make(map[string]string)
+	}
+	response.OutputFiles["./"+buildutil.DebugBundlePath] = encoded
+	response.Checksums["./"+buildutil.DebugBundlePath] = buildutil.ArtifactChecksum(bundle)
+	logging.Debugf("Attached %s debug bundle to build %s", protocol.ByteSize(len(bundle)), request.ID)
+}
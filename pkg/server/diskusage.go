@@ -0,0 +1,77 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// diskUsageInterval is how long measured directory sizes are reused; walking large
+// workspaces for every load report would cost more than the report is worth
+const diskUsageInterval = 30 * time.Second
+
+// diskUsage caches how much of the temp directory a server's builds and caches use
+type diskUsage struct {
+	temp     protocol.ByteSize // Build directories and persistent workspaces
+	cache    protocol.ByteSize // Projects kept by the content cache
+	measured time.Time
+	low      bool // Last low disk state, to log changes once
+	mux      sync.Mutex
+}
+
+// diskUsage returns the space used by builds and the content cache, measured at most every diskUsageInterval
+func (s *Server) diskUsage() (temp, cache protocol.ByteSize) {
+	s.usage.mux.Lock()
+	defer s.usage.mux.Unlock()
+
+	if time.Since(s.usage.measured) < diskUsageInterval {
+		return s.usage.temp, s.usage.cache
+	}
+
+	tempDir := s.config.GetTempDir()
+	temp = 0
+	if entries, err := os.ReadDir(tempDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), buildutil.ProjectDirPrefix) {
+				size, _ := s.directorySize(filepath.Join(tempDir, entry.Name()))
+				temp += size
+			}
+		}
+	}
+	if s.workspaces != nil {
+		size, _ := s.directorySize(s.workspaces.dir)
+		temp += size
+	}
+	cache = 0
+	if s.content != nil {
+		cache, _ = s.directorySize(s.content.dir)
+	}
+
+	s.usage.temp, s.usage.cache, s.usage.measured = temp, cache, time.Now()
+	return temp, cache
+}
+
+// checkLowDisk reports whether the free space fell below server.min_free_disk; such servers
+// keep running their builds but clients do not schedule new ones on them
+func (s *Server) checkLowDisk(load protocol.ServerLoad) bool {
+	threshold := s.config.Server.MinFreeDisk
+	low := threshold > 0 && load.FreeDisk > 0 && load.FreeDisk < threshold
+
+	s.usage.mux.Lock()
+	defer s.usage.mux.Unlock()
+	if low != s.usage.low {
+		if low {
+			logging.Infof("Low on disk space: %s free, minimum %s; clients stop scheduling builds here", load.FreeDisk, threshold)
+		} else {
+			logging.Infof("Disk space recovered: %s free, builds are scheduled again", load.FreeDisk)
+		}
+		s.usage.low = low
+	}
+	return low
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+)
+
+// ClientUsage is the per-client accounting of a server
+type ClientUsage struct {
+	Running   int           `json:"running"`
+	Queued    int           `json:"queued"`
+	Completed int           `json:"completed"`
+	BuildTime time.Duration `json:"build_time"` // Total time spent in finished builds
+	QueueTime time.Duration `json:"queue_time"` // Total time builds waited for a slot
+}
+
+// slotWaiter is a build waiting for a free slot
+type slotWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// buildSlots limits concurrent builds to the server capacity and hands free slots
+// to the clients waiting for them according to the fair-share policy
+type buildSlots struct {
+	capacity int
+	policy   string
+	weights  map[string]int // Client host -> weight (default 1)
+	running  int
+	waiting  map[string][]*slotWaiter // Client host -> builds in arrival order
+	turns    []string                 // Clients in the order they get their next turn
+	passes   map[string]float64       // Weighted: slots granted divided by weight, lowest goes next
+	usage    map[string]*ClientUsage
+	mux      sync.Mutex
+}
+
+// newBuildSlots creates the slot scheduler for a server
+func newBuildSlots(capacity int, cfg config.FairShareConfig) *buildSlots {
+	return &buildSlots{
+		capacity: capacity,
+		policy:   cfg.Policy,
+		weights:  cfg.Weights,
+		waiting:  make(map[string][]*slotWaiter),
+		passes:   make(map[string]float64),
+		usage:    make(map[string]*ClientUsage),
+	}
+}
+
+// usageOf returns the accounting entry of a client; callers hold the lock
+func (b *buildSlots) usageOf(client string) *ClientUsage {
+	usage, exists := b.usage[client]
+	if !exists {
+		usage = &ClientUsage{}
+		b.usage[client] = usage
+	}
+	return usage
+}
+
+// weight returns the configured share of a client
+func (b *buildSlots) weight(client string) int {
+	if weight := b.weights[client]; weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// acquire waits until the client may start a build, or returns the context error if the build
+// is cancelled while queued
+func (b *buildSlots) acquire(ctx context.Context, client string) error {
+	start := time.Now()
+	waiter := &slotWaiter{ready: make(chan struct{})}
+
+	b.mux.Lock()
+	b.usageOf(client).Queued++
+	if len(b.waiting[client]) == 0 {
+		// Idle time earns no credit: start level with the clients already waiting
+		var lowest float64
+		for i, waiting := range b.turns {
+			if i == 0 || b.passes[waiting] < lowest {
+				lowest = b.passes[waiting]
+			}
+		}
+		if len(b.turns) > 0 && b.passes[client] < lowest {
+			b.passes[client] = lowest
+		}
+		b.turns = append(b.turns, client)
+	}
+	b.waiting[client] = append(b.waiting[client], waiter)
+	b.grant()
+	b.mux.Unlock()
+
+	select {
+	case <-waiter.ready:
+	case <-ctx.Done():
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		if waiter.granted {
+			// The slot arrived together with the cancellation
+			b.finish(client)
+			b.grant()
+		} else {
+			b.remove(client, waiter)
+			b.usageOf(client).Queued--
+		}
+		return ctx.Err()
+	}
+
+	if wait := time.Since(start); wait > time.Second {
+		logging.Debugf("Build of client %s waited %v for a slot", client, wait.Round(time.Millisecond))
+	}
+	b.mux.Lock()
+	b.usageOf(client).QueueTime += time.Since(start)
+	b.mux.Unlock()
+	return nil
+}
+
+// release frees the slot of a finished build
+func (b *buildSlots) release(client string, duration time.Duration) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	usage := b.usageOf(client)
+	usage.Completed++
+	usage.BuildTime += duration
+	b.finish(client)
+	b.grant()
+}
+
+// finish returns a slot without counting a build; callers hold the lock
+func (b *buildSlots) finish(client string) {
+	b.running--
+	b.usageOf(client).Running--
+}
+
+// remove drops a cancelled waiter; callers hold the lock
+func (b *buildSlots) remove(client string, waiter *slotWaiter) {
+	queue := b.waiting[client]
+	for i, queued := range queue {
+		if queued == waiter {
+			b.waiting[client] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(b.waiting[client]) == 0 {
+		// A client without waiting builds gets a fresh turn at the end when it queues again
+		delete(b.waiting, client)
+		for i, turn := range b.turns {
+			if turn == client {
+				b.turns = append(b.turns[:i:i], b.turns[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// grant hands free slots to waiting builds; callers hold the lock
+func (b *buildSlots) grant() {
+	for b.running < b.capacity {
+		client := b.nextClient()
+		if client == "" {
+			return
+		}
+
+		waiter := b.waiting[client][0]
+		b.remove(client, waiter)
+		usage := b.usageOf(client)
+		usage.Queued--
+		usage.Running++
+		b.running++
+		waiter.granted = true
+		close(waiter.ready)
+	}
+}
+
+// nextClient picks the client whose build starts next and moves it to the end of the turns;
+// callers hold the lock
+func (b *buildSlots) nextClient() string {
+	best := -1
+	for i, client := range b.turns {
+		if len(b.waiting[client]) == 0 {
+			continue
+		}
+		if best < 0 {
+			best = i
+			if b.policy != config.FairShareWeighted {
+				break // Round robin: the first waiting client in turn order
+			}
+			continue
+		}
+		// Weighted: the lowest pass, earlier turns win ties
+		if b.passes[client] < b.passes[b.turns[best]] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+
+	client := b.turns[best]
+	b.passes[client] += 1 / float64(b.weight(client))
+	b.turns = append(append(b.turns[:best:best], b.turns[best+1:]...), client)
+	return client
+}
+
+// counts returns the number of running and queued builds
+func (b *buildSlots) counts() (running, queued int) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for _, usage := range b.usage {
+		queued += usage.Queued
+	}
+	return b.running, queued
+}
+
+// clientUsage returns the accounting of one client
+func (b *buildSlots) clientUsage(client string) ClientUsage {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return *b.usageOf(client)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// Workspace inspection settings
+const (
+	maxInspectedWorkspaces = 100     // Failed builds whose workspaces can be browsed, oldest are forgotten
+	maxInspectFileSize     = 1 << 20 // Larger files are shown truncated
+)
+
+// inspectableWorkspace is the preserved workspace of a failed build
+type inspectableWorkspace struct {
+	dir     string
+	owner   string // Only the client that ran the build may browse it
+	created time.Time
+}
+
+// inspectableWorkspaces remembers which failed builds left a workspace behind
+type inspectableWorkspaces struct {
+	workspaces map[string]inspectableWorkspace
+	mux        sync.Mutex
+}
+
+// newInspectableWorkspaces creates an empty registry
+func newInspectableWorkspaces() *inspectableWorkspaces {
+	return &inspectableWorkspaces{workspaces: make(map[string]inspectableWorkspace)}
+}
+
+// add registers the workspace of a failed build, forgetting the oldest beyond the limit
+func (iw *inspectableWorkspaces) add(buildID, dir, owner string) {
+	iw.mux.Lock()
+	defer iw.mux.Unlock()
+	iw.workspaces[buildID] = inspectableWorkspace{dir: dir, owner: owner, created: time.Now()}
+	for len(iw.workspaces) > maxInspectedWorkspaces {
+		oldest := ""
+		for id, workspace := range iw.workspaces {
+			if oldest == "" || workspace.created.Before(iw.workspaces[oldest].created) {
+				oldest = id
+			}
+		}
+		delete(iw.workspaces, oldest)
+	}
+}
+
+// get returns the workspace of a build if the owner may browse it
+func (iw *inspectableWorkspaces) get(buildID, owner string) (inspectableWorkspace, bool) {
+	iw.mux.Lock()
+	defer iw.mux.Unlock()
+	workspace, exists := iw.workspaces[buildID]
+	return workspace, exists && workspace.owner == owner
+}
+
+// clientOwner identifies the client of a connection for workspace ownership
+func clientOwner(clientConn *ClientConnection) string {
+	clientConn.infoMux.Lock()
+	owner := clientConn.info.ID
+	clientConn.infoMux.Unlock()
+	if owner == "" {
+		owner = config.ClientKey(clientConn.addr)
+	}
+	return owner
+}
+
+// rememberFailedWorkspace makes the workspace of a failed build browsable when it is kept
+func (s *Server) rememberFailedWorkspace(clientConn *ClientConnection, request protocol.BuildRequest, workspace string, response protocol.BuildResponse) {
+	if response.Success || response.Error == "build cancelled" {
+		return
+	}
+	dir := workspace
+	if dir == "" {
+		if s.config.Build.TempDeletion {
+			return
+		}
+		dir = filepath.Join(s.config.GetTempDir(), request.ProjectName)
+	}
+	s.inspectable.add(request.ID, dir, clientOwner(clientConn))
+}
+
+// browseWorkspace answers a browse request of a client, read-only and confined to the workspace
+func (s *Server) browseWorkspace(clientConn *ClientConnection, buildID string, browse protocol.WorkspaceBrowse) {
+	result := protocol.WorkspaceBrowse{RequestID: browse.RequestID, Path: browse.Path}
+	if err := s.readWorkspace(clientConn, buildID, &result); err != nil {
+		result.Error = err.Error()
+	}
+	if err := clientConn.send(protocol.Message{Type: protocol.MessageListing, BuildID: buildID, Browse: &result}); err != nil {
+		logging.Debugf("Failed to send workspace listing to %s: %v", clientConn.addr, err)
+	}
+}
+
+// readWorkspace fills in the listing or file a browse request points at
+func (s *Server) readWorkspace(clientConn *ClientConnection, buildID string, result *protocol.WorkspaceBrowse) error {
+	workspace, exists := s.inspectable.get(buildID, clientOwner(clientConn))
+	if !exists {
+		return fmt.Errorf("no preserved workspace for build %s", buildID)
+	}
+
+	// Symlinks may not lead out of the workspace
+	root, err := filepath.EvalSymlinks(workspace.dir)
+	if err != nil {
+		return fmt.Errorf("workspace of build %s no longer exists", buildID)
+	}
+	result.Path = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(result.Path)), "/")
+	target, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(result.Path)))
+	if err != nil {
+		return fmt.Errorf("%s not found", result.Path)
+	}
+	if rel, err := filepath.Rel(root, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside the workspace", result.Path)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return readWorkspaceFile(target, info, result)
+	}
+
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		result.Entries = append(result.Entries, protocol.WorkspaceEntry{Name: entry.Name(), Dir: entry.IsDir(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		if result.Entries[i].Dir != result.Entries[j].Dir {
+			return result.Entries[i].Dir
+		}
+		return result.Entries[i].Name < result.Entries[j].Name
+	})
+	return nil
+}
+
+// readWorkspaceFile returns the start of a text file
+func readWorkspaceFile(target string, info os.FileInfo, result *protocol.WorkspaceBrowse) error {
+	result.File = true
+	result.Size = info.Size()
+	file, err := os.Open(target)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxInspectFileSize))
+	if err != nil {
+		return err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		result.Binary = true
+		return nil
+	}
+	result.Content = string(content)
+	result.Truncated = info.Size() > int64(len(content))
+	return nil
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// instancePorts picks the listening port of every server instance: server.port for the first,
+// then free ports of client.discovery.ports, so clients scanning the usual ports find them all
+func instancePorts(cfg *config.Config, count int) ([]int, error) {
+	ports := []int{cfg.Server.Port}
+	reserved := map[int]bool{
+		cfg.Server.Port:           true,
+		cfg.Server.AdminPort:      true,
+		cfg.Server.WebSocket.Port: true,
+	}
+	for _, port := range cfg.Client.Discovery.Ports {
+		if len(ports) == count {
+			break
+		}
+		if reserved[port] || !portFree(port) {
+			continue
+		}
+		reserved[port] = true
+		ports = append(ports, port)
+	}
+	if len(ports) < count {
+		return nil, fmt.Errorf("%d server instances need %d ports, but only %d of client.discovery.ports %v are free",
+			count, count, len(ports)-1, cfg.Client.Discovery.Ports)
+	}
+	return ports, nil
+}
+
+// portFree reports whether nothing listens on a TCP port yet
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// instanceCapacities splits the capacity between the instances, the first ones taking the remainder
+func instanceCapacities(capacity, count int) []int {
+	capacities := make([]int, count)
+	for i := range capacities {
+		capacities[i] = capacity / count
+		if i < capacity%count {
+			capacities[i]++
+		}
+	}
+	return capacities
+}
+
+// instanceResources is the share of the machine's hardware advertised by one instance: memory and
+// free disk in proportion to its capacity, GPUs dealt out whole like the capacity
+func instanceResources(machine protocol.ServerResources, capacity, totalCapacity, instance, count int) protocol.ServerResources {
+	share := float64(capacity) / float64(totalCapacity)
+	return protocol.ServerResources{
+		Memory:   protocol.ByteSize(float64(machine.Memory) * share),
+		FreeDisk: protocol.ByteSize(float64(machine.FreeDisk) * share),
+		GPUs:     instanceCapacities(machine.GPUs, count)[instance],
+	}
+}
+
+// NewInstances creates the logical servers of one machine. Each has its own port, ID,
+// share of the capacity and share of the memory, disk and GPUs it advertises; they share the
+// detected tools, the access rules, the bandwidth limit and the on-disk stores (content cache,
+// workspaces, temp cleanup), so two instances never build in the same workspace at once.
+func NewInstances(cfg *config.Config, count int) ([]*Server, error) {
+	if count <= 1 {
+		return []*Server{New(cfg)}, nil
+	}
+	if cfg.Server.Capacity < count {
+		return nil, fmt.Errorf("capacity %d cannot be split between %d server instances", cfg.Server.Capacity, count)
+	}
+	ports, err := instancePorts(cfg, count)
+	if err != nil {
+		return nil, err
+	}
+
+	baseID := generateServerID()
+	capacities := instanceCapacities(cfg.Server.Capacity, count)
+	servers := make([]*Server, count)
+	for i := range servers {
+		// servers[0] is still nil while the first instance is created, so it detects the machine
+		servers[i] = newServer(cfg, fmt.Sprintf("%s-%d", baseID, i+1), ports[i], capacities[i], i, count, servers[0])
+	}
+
+	machine := servers[0].resources
+	for i, server := range servers {
+		server.resources = instanceResources(machine, capacities[i], cfg.Server.Capacity, i, count)
+		server.diskShare = float64(capacities[i]) / float64(cfg.Server.Capacity)
+		logging.Debugf("Server instance %s: capacity %d, %s memory, %d GPUs", server.id, server.capacity, server.resources.Memory, server.resources.GPUs)
+	}
+	return servers, nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// osVersion caches the operating system release of this machine
+var osVersion struct {
+	once    sync.Once
+	version string
+}
+
+// serverOSVersion describes the operating system release, e.g. "Ubuntu 22.04.4 LTS (kernel
+// 6.5.0)", "Microsoft Windows [Version 10.0.22631]" or "macOS 14.4"; empty when unknown
+func serverOSVersion() string {
+	osVersion.once.Do(func() {
+		switch runtime.GOOS {
+		case "linux":
+			name := "Linux"
+			if data, err := os.ReadFile("/etc/os-release"); err == nil {
+				for _, line := range strings.Split(string(data), "\n") {
+					if value, found := strings.CutPrefix(line, "PRETTY_NAME="); found {
+						name = strings.Trim(value, `"`)
+					}
+				}
+			}
+			kernel, _ := os.ReadFile("/proc/sys/kernel/osrelease")
+			osVersion.version = strings.TrimSpace(fmt.Sprintf("%s (kernel %s)", name, strings.TrimSpace(string(kernel))))
+		case "windows":
+			if output, err := exec.Command("cmd", "/C", "ver").Output(); err == nil {
+				osVersion.version = strings.TrimSpace(string(output))
+			}
+		case "darwin":
+			if output, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+				osVersion.version = "macOS " + strings.TrimSpace(string(output))
+			}
+		}
+	})
+	return osVersion.version
+}
+
+// buildPlatform returns the platform a build runs on: the server's, or Linux on the server's
+// architecture for container and WSL builds
+func buildPlatform(request protocol.BuildRequest) string {
+	if request.Image != "" || request.WSL != "" {
+		return "linux/" + runtime.GOARCH
+	}
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// resolvePlatformCommand replaces the command of a request with the variant for the platform
+// it runs on, the OS/architecture variant before the OS one. Environments defining only
+// variants cannot run on other platforms.
+func resolvePlatformCommand(request protocol.BuildRequest) (protocol.BuildRequest, error) {
+	if len(request.Platforms) == 0 {
+		return request, nil
+	}
+	platform := buildPlatform(request)
+	goos, _, _ := strings.Cut(platform, "/")
+	for _, key := range []string{platform, goos} {
+		if variant, exists := request.Platforms[key]; exists {
+			logging.Debugf("Build %s uses the %s command of environment %s", request.ID, key, request.Environment)
+			request.Command, request.Args, request.Shell, request.Steps = variant.Command, variant.Args, variant.Shell, variant.Steps
+			break
+		}
+	}
+	request.Platforms = nil
+	if request.Command == "" && len(request.Args) == 0 && len(request.Steps) == 0 {
+		return request, fmt.Errorf("environment %s has no command for platform %s", request.Environment, platform)
+	}
+	return request, nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"fmt"
+	"os/exec"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// checkPreflight decides whether this server can take a build
+func (s *Server) checkPreflight(clientConn *ClientConnection, check protocol.PreflightCheck) error {
+	clientConn.infoMux.Lock()
+	identified := clientConn.info.ID != ""
+	clientConn.infoMux.Unlock()
+	if !identified && s.access.RequiresClientID() {
+		return fmt.Errorf("this server only accepts builds from identified clients")
+	}
+
+	switch {
+	case check.Image != "" && s.containerRuntime == nil:
+		return fmt.Errorf("image %s requires container support, which this server lacks", check.Image)
+	case check.WSL != "" && !buildutil.ContainsString(s.wslDistributions, check.WSL):
+		return fmt.Errorf("WSL distribution %s is not installed", check.WSL)
+	case check.Image == "" && check.WSL == "" && buildutil.ContainsString(buildutil.ProbedTools(), check.Tool):
+		// Only well-known build tools are looked up, the command may start with a shell builtin
+		if _, err := exec.LookPath(check.Tool); err != nil {
+			return fmt.Errorf("%s: not found", check.Tool)
+		}
+	}
+	if err := check.Requirements.Check(s.resources); err != nil {
+		return err
+	}
+
+	if maxSize := s.config.Build.MaxWorkspaceSize; maxSize > 0 && protocol.ByteSize(check.Size) > maxSize {
+		return fmt.Errorf("project files of %s exceed the workspace size limit of %s", protocol.ByteSize(check.Size), maxSize)
+	}
+	load := s.measureLoad()
+	if load.LowDisk {
+		return fmt.Errorf("server is low on disk space (%s free)", load.FreeDisk)
+	}
+	if load.FreeDisk > 0 && protocol.ByteSize(check.Size) > load.FreeDisk {
+		return fmt.Errorf("project files of %s do not fit into %s of free disk space", protocol.ByteSize(check.Size), load.FreeDisk)
+	}
+	return nil
+}
+
+// answerPreflight sends the server's decision on a pre-flight check
+func (s *Server) answerPreflight(clientConn *ClientConnection, buildID string, check protocol.PreflightCheck) {
+	check.Accepted = true
+	if err := s.checkPreflight(clientConn, check); err != nil {
+		check.Accepted, check.Reason = false, err.Error()
+		logging.Infof("Rejected build %s for %s in pre-flight check: %v", buildID, check.Environment, err)
+	}
+	if err := clientConn.send(protocol.Message{Type: protocol.MessagePreflightResult, BuildID: buildID, Preflight: &check}); err != nil {
+		logging.Debugf("Failed to answer pre-flight check of build %s: %v", buildID, err)
+	}
+}
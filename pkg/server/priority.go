@@ -0,0 +1,29 @@
+package server
+
+import (
+	"os/exec"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// prioritizeBuild lowers the priority of a build command that is about to start (Windows
+// priority classes are set at creation)
+func (s *Server) prioritizeBuild(request protocol.BuildRequest, cmd *exec.Cmd) {
+	if request.Image == "" && s.config.Server.BuildNice > 0 {
+		setPriorityClass(cmd, s.config.Server.BuildNice)
+	}
+}
+
+// prioritizeStartedBuild lowers the CPU and IO priority of a started build command; the processes
+// it starts inherit them. Container builds are left to the container runtime.
+func (s *Server) prioritizeStartedBuild(request protocol.BuildRequest, pid int) {
+	nice, ioPriority := s.config.Server.BuildNice, s.config.Server.BuildIOPriority
+	if request.Image != "" || (nice == 0 && (ioPriority == "" || ioPriority == config.IOPriorityNormal)) {
+		return
+	}
+	if err := setProcessPriority(pid, nice, ioPriority); err != nil {
+		logging.Debugf("Warning: Failed to lower the priority of build %s: %v", request.ID, err)
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"syscall"
+
+	"boltbuild/pkg/config"
+)
+
+// I/O scheduling classes of ioprio_set(2)
+const (
+	ioprioWhoProcess    = 1
+	ioprioClassBE       = 2
+	ioprioClassIdle     = 3
+	ioprioClassShift    = 13
+	ioprioLowestBELevel = 7
+)
+
+// setIOPriority moves a process into the idle IO class or the lowest best-effort level
+func setIOPriority(pid int, ioPriority string) error {
+	prio := ioprioClassBE<<ioprioClassShift | ioprioLowestBELevel
+	if ioPriority == config.IOPriorityIdle {
+		prio = ioprioClassIdle << ioprioClassShift
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
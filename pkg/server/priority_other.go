@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package server
+
+import (
+	"fmt"
+)
+
+// setIOPriority is only implemented on Linux
+func setIOPriority(pid int, ioPriority string) error {
+	return fmt.Errorf("IO priorities are only supported on Linux")
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package server
+
+import (
+	"os/exec"
+	"syscall"
+
+	"boltbuild/pkg/config"
+)
+
+// setPriorityClass is only needed on Windows, niceness is set after the start
+func setPriorityClass(cmd *exec.Cmd, nice int) {}
+
+// setProcessPriority sets the nice level and IO priority of a started process
+func setProcessPriority(pid, nice int, ioPriority string) error {
+	if nice > 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice); err != nil {
+			return err
+		}
+	}
+	if ioPriority != "" && ioPriority != config.IOPriorityNormal {
+		return setIOPriority(pid, ioPriority)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Windows priority classes used for niced builds
+const (
+	belowNormalPriorityClass = 0x00004000
+	idlePriorityClass        = 0x00000040
+)
+
+// setPriorityClass starts a build below normal priority (nice 1 to 9) or in the idle class
+// (10 to 19), which also lowers its IO priority
+func setPriorityClass(cmd *exec.Cmd, nice int) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if nice >= 10 {
+		cmd.SysProcAttr.CreationFlags |= idlePriorityClass
+	} else {
+		cmd.SysProcAttr.CreationFlags |= belowNormalPriorityClass
+	}
+}
+
+// setProcessPriority has nothing left to do on Windows, the class was set at creation
+func setProcessPriority(pid, nice int, ioPriority string) error {
+	return nil
+}
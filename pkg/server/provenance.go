@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// ProvenanceSLSA selects the built-in generator for environment provenance settings;
+// any other value is a command whose standard output is the document (e.g. "syft dir:. -o spdx-json")
+const ProvenanceSLSA = "slsa"
+
+// provenanceTimeout bounds how long a provenance generator may run
+const provenanceTimeout = 5 * time.Minute
+
+// provenanceSubject is an artifact covered by an in-toto statement
+type provenanceSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// provenanceStatement is an in-toto statement carrying an SLSA v1 provenance predicate
+type provenanceStatement struct {
+	Type          string              `json:"_type"`
+	Subject       []provenanceSubject `json:"subject"`
+	PredicateType string              `json:"predicateType"`
+	Predicate     struct {
+		BuildDefinition struct {
+			BuildType            string                 `json:"buildType"`
+			ExternalParameters   map[string]interface{} `json:"externalParameters"`
+			ResolvedDependencies []provenanceSubject    `json:"resolvedDependencies,omitempty"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder struct {
+				ID      string            `json:"id"`
+				Version map[string]string `json:"version"`
+			} `json:"builder"`
+			Metadata struct {
+				InvocationID string    `json:"invocationId"`
+				StartedOn    time.Time `json:"startedOn"`
+				FinishedOn   time.Time `json:"finishedOn"`
+			} `json:"metadata"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// sortedSubjects converts path -> SHA-256 checksums into subjects, leaving out boltbuild's metadata files
+func sortedSubjects(checksums map[string]string) []provenanceSubject {
+	subjects := make([]provenanceSubject, 0, len(checksums))
+	for path, sum := range checksums {
+		if strings.HasPrefix(strings.TrimPrefix(path, "./"), buildutil.BuildMetadataDir+"/") {
+			continue
+		}
+		subjects = append(subjects, provenanceSubject{Name: path, Digest: map[string]string{"sha256": sum}})
+	}
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i].Name < subjects[j].Name })
+	return subjects
+}
+
+// slsaProvenance describes which inputs, command and server produced the collected artifacts
+func (s *Server) slsaProvenance(request protocol.BuildRequest, response *protocol.BuildResponse, started time.Time) ([]byte, error) {
+	var statement provenanceStatement
+	statement.Type = "https://in-toto.io/Statement/v1"
+	statement.PredicateType = "https://slsa.dev/provenance/v1"
+	statement.Subject = sortedSubjects(response.Checksums)
+
+	definition := &statement.Predicate.BuildDefinition
+	definition.BuildType = "https://github.com/baris-kurt/boltbuild/build@v1"
+	definition.ExternalParameters = map[string]interface{}{
+		"environment":   request.Environment,
+		"command":       request.Command,
+		"execution_dir": request.ExecutionDir,
+	}
+	if request.Image != "" {
+		definition.ExternalParameters["image"] = request.Image
+	}
+	if request.WSL != "" {
+		definition.ExternalParameters["wsl"] = request.WSL
+	}
+	inputs := make(map[string]string, len(request.Files))
+	for name, content := range request.Files {
+		inputs[name] = buildutil.ArtifactChecksum([]byte(content))
+	}
+	definition.ResolvedDependencies = sortedSubjects(inputs)
+
+	details := &statement.Predicate.RunDetails
+	details.Builder.ID = "boltbuild-server://" + s.id
+	details.Builder.Version = map[string]string{"boltbuild": protocol.Version, "os": runtime.GOOS + "/" + runtime.GOARCH}
+	details.Metadata.InvocationID = request.ID
+	details.Metadata.StartedOn = started.UTC()
+	details.Metadata.FinishedOn = time.Now().UTC()
+
+	return json.MarshalIndent(statement, "", "  ")
+}
+
+// runProvenanceCommand runs an external generator in the workspace and returns its standard output
+func (s *Server) runProvenanceCommand(ctx context.Context, request protocol.BuildRequest, projectDir string) ([]byte, error) {
+	parts := strings.Fields(request.Provenance)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty provenance command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, provenanceTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	cmd.Dir = projectDir
+	cmd.Env = append(os.Environ(), "BOLTBUILD_BUILD_ID="+request.ID, "BOLTBUILD_ENVIRONMENT="+request.Environment)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = cancelWaitDelay
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// attachProvenance generates the provenance document of a successful build and adds it to the
+// response artifacts. Generator failures are reported as warnings and do not fail the build.
+func (s *Server) attachProvenance(ctx context.Context, request protocol.BuildRequest, projectDir string, response *protocol.BuildResponse, started time.Time) {
+	if request.Provenance == "" || !response.Success {
+		return
+	}
+
+	var document []byte
+	var err error
+	if request.Provenance == ProvenanceSLSA {
+		document, err = s.slsaProvenance(request, response, started)
+	} else {
+		document, err = s.runProvenanceCommand(ctx, request, projectDir)
+	}
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(projectDir, buildutil.BuildMetadataDir), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(projectDir, filepath.FromSlash(buildutil.ProvenancePath)), document, 0644)
+	}
+	var encoded string
+	if err == nil {
+		encoded, err = buildutil.EncodeArtifact(document, response.Compression)
+	}
+	if err != nil {
+		logging.Infof("Build %s: provenance generation failed: %v", request.ID, err)
+		response.Warnings = append(response.Warnings, fmt.Sprintf("provenance generation failed: %v", err))
+		return
+	}
+
+	if response.OutputFiles == nil {
+		response.OutputFiles = make(map[string]string)
+	}
+	if response.Checksums == nil {
+		response.Checksums = make(map[string]string)
+	}
+	response.OutputFiles["./"+buildutil.ProvenancePath] = encoded
+	response.Checksums["./"+buildutil.ProvenancePath] = buildutil.ArtifactChecksum(document)
+	logging.Debugf("Attached %d bytes of provenance to build %s", len(document), request.ID)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/relay"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+)
+
+// connectRelay registers the server at a relay and opens a data connection for every tunnel
+// a client requests, reconnecting whenever the relay connection is lost
+func (s *Server) connectRelay(cfg config.RelayConnection) {
+	for {
+		conn, decoder, err := relay.DialRelay(cfg, relay.RelayMessage{Role: relay.RelayRoleServer, ServerID: s.id}, s.config.Client.Discovery.ConnectTimeout, s.config.TLS)
+		if err == nil {
+			if err = relay.ExpectWelcome(decoder); err == nil {
+				logging.Infof("Registered at relay %s as tenant %s", cfg.Address, cfg.Tenant)
+				for {
+					var msg relay.RelayMessage
+					if err = decoder.Decode(&msg); err != nil {
+						break
+					}
+					if msg.Type == relay.RelayMessageOpen {
+						go s.openRelaySession(cfg, msg.Session)
+					}
+				}
+			}
+			conn.Close()
+		}
+		logging.Infof("Relay %s unavailable: %v", cfg.Address, err)
+		time.Sleep(transport.ReverseRetryInterval)
+	}
+}
+
+// openRelaySession answers a tunnel request; the tunnel then carries a normal client connection
+func (s *Server) openRelaySession(cfg config.RelayConnection, session string) {
+	conn, _, err := relay.DialRelay(cfg, relay.RelayMessage{Role: relay.RelayRoleServerData, Session: session}, s.config.Client.Discovery.ConnectTimeout, s.config.TLS)
+	if err != nil {
+		logging.Debugf("Failed to open relay session %s: %v", session, err)
+		return
+	}
+	s.handleClientConnection(conn)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// loadReportInterval is how often servers send their load to connected clients
+const loadReportInterval = 5 * time.Second
+
+// detectResources measures the resources of this machine
+func detectResources(tempDir string) protocol.ServerResources {
+	resources := protocol.ServerResources{
+		Memory: totalMemory(),
+		GPUs:   gpuCount(),
+	}
+	if free, err := freeDiskSpace(tempDir); err == nil {
+		resources.FreeDisk = free
+	} else {
+		logging.Debugf("Failed to determine free disk space of %s: %v", tempDir, err)
+	}
+	return resources
+}
+
+// AutoCapacity derives the number of concurrent builds from the CPUs and the memory available
+// now, for servers without a configured capacity; it is evaluated on every start
+func AutoCapacity(cfg config.AutoCapacityConfig) int {
+	cpus := runtime.NumCPU()
+	capacity := cpus / cfg.CPUsPerBuild
+	memory := freeMemory()
+	if memory > 0 {
+		if byMemory := int(memory / cfg.MemoryPerBuild); byMemory < capacity {
+			capacity = byMemory
+		}
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	logging.Infof("Server capacity %d detected from %d CPUs and %s available memory", capacity, cpus, memory)
+	return capacity
+}
+
+// measureLoad samples the current utilization of this machine
+func measureLoad(tempDir string, activeBuilds, queuedBuilds int) protocol.ServerLoad {
+	load := protocol.ServerLoad{
+		CPULoad:      cpuLoad(),
+		FreeMemory:   freeMemory(),
+		ActiveBuilds: activeBuilds,
+		QueuedBuilds: queuedBuilds,
+		UpdatedAt:    time.Now(),
+	}
+	if free, err := freeDiskSpace(tempDir); err == nil {
+		load.FreeDisk = free
+	}
+	return load
+}
+
+// meminfoValue reads a field of /proc/meminfo (Linux only)
+func meminfoValue(field string) (protocol.ByteSize, bool) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == field+":" {
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			return protocol.ByteSize(kb) * protocol.KB, true
+		}
+	}
+	return 0, false
+}
+
+// cpuLoad returns the 1-minute load average per CPU from /proc/loadavg, sysctl or the platform API
+func cpuLoad() float64 {
+	var text string
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		text = string(data)
+	} else if out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output(); err == nil {
+		text = strings.Trim(strings.TrimSpace(string(out)), "{ }") // macOS/BSD: "{ 1.52 1.38 1.30 }"
+	} else {
+		return platformCPULoad()
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return load / float64(runtime.NumCPU())
+}
+
+// freeMemory returns the memory available for new processes
+func freeMemory() protocol.ByteSize {
+	if available, ok := meminfoValue("MemAvailable"); ok {
+		return available
+	}
+	return platformFreeMemory()
+}
+
+// totalMemory returns the physical memory from /proc/meminfo, sysctl or the platform API
+func totalMemory() protocol.ByteSize {
+	if total, ok := meminfoValue("MemTotal"); ok {
+		return total
+	}
+
+	// macOS and BSD
+	if out, err := exec.Command("sysctl", "-n", "hw.memsize").Output(); err == nil {
+		if size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			return protocol.ByteSize(size)
+		}
+	}
+	if out, err := exec.Command("sysctl", "-n", "hw.physmem").Output(); err == nil {
+		if size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			return protocol.ByteSize(size)
+		}
+	}
+
+	return platformMemory()
+}
+
+// gpuCount counts NVIDIA GPUs through nvidia-smi
+func gpuCount() int {
+	out, err := exec.Command("nvidia-smi", "--list-gpus").Output()
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "GPU ") {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package server
+
+import (
+	"syscall"
+
+	"boltbuild/pkg/protocol"
+)
+
+// freeDiskSpace returns the space available to unprivileged users on the filesystem of dir
+func freeDiskSpace(dir string) (protocol.ByteSize, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return protocol.ByteSize(stat.Bavail) * protocol.ByteSize(stat.Bsize), nil
+}
+
+// platformMemory is unknown where neither /proc/meminfo nor sysctl answered
+func platformMemory() protocol.ByteSize {
+	return 0
+}
+
+// platformCPULoad is unknown where neither /proc/loadavg nor sysctl answered
+func platformCPULoad() float64 {
+	return 0
+}
+
+// platformFreeMemory is unknown without /proc/meminfo
+func platformFreeMemory() protocol.ByteSize {
+	return 0
+}
@@ -0,0 +1,100 @@
+//go:build windows
+
+package server
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"boltbuild/pkg/protocol"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGetDiskFreeSpaceExW  = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetSystemTimes       = kernel32.NewProc("GetSystemTimes")
+)
+
+// freeDiskSpace returns the space available to the server user on the volume of dir
+func freeDiskSpace(dir string) (protocol.ByteSize, error) {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	result, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&available)), uintptr(unsafe.Pointer(&total)), uintptr(unsafe.Pointer(&free)))
+	if result == 0 {
+		return 0, err
+	}
+	return protocol.ByteSize(available), nil
+}
+
+// memoryStatusEx mirrors the Win32 MEMORYSTATUSEX structure
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// memoryStatus calls GlobalMemoryStatusEx
+func memoryStatus() (memoryStatusEx, bool) {
+	status := memoryStatusEx{Length: uint32(unsafe.Sizeof(memoryStatusEx{}))}
+	result, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	return status, result != 0
+}
+
+// platformMemory returns the physical memory reported by GlobalMemoryStatusEx
+func platformMemory() protocol.ByteSize {
+	status, ok := memoryStatus()
+	if !ok {
+		return 0
+	}
+	return protocol.ByteSize(status.TotalPhys)
+}
+
+// platformFreeMemory returns the available physical memory reported by GlobalMemoryStatusEx
+func platformFreeMemory() protocol.ByteSize {
+	status, ok := memoryStatus()
+	if !ok {
+		return 0
+	}
+	return protocol.ByteSize(status.AvailPhys)
+}
+
+// cpuTimes is the previous GetSystemTimes sample; Windows has no load average,
+// so the busy fraction since the last report is used instead
+var cpuTimes struct {
+	idle, total uint64
+	mux         sync.Mutex
+}
+
+// platformCPULoad returns the fraction of CPU time spent busy since the previous call
+func platformCPULoad() float64 {
+	var idle, kernel, user syscall.Filetime
+	result, _, _ := procGetSystemTimes.Call(uintptr(unsafe.Pointer(&idle)), uintptr(unsafe.Pointer(&kernel)), uintptr(unsafe.Pointer(&user)))
+	if result == 0 {
+		return 0
+	}
+
+	idleTime := uint64(idle.HighDateTime)<<32 | uint64(idle.LowDateTime)
+	totalTime := (uint64(kernel.HighDateTime)<<32 | uint64(kernel.LowDateTime)) +
+		(uint64(user.HighDateTime)<<32 | uint64(user.LowDateTime)) // Kernel time includes idle time
+
+	cpuTimes.mux.Lock()
+	defer cpuTimes.mux.Unlock()
+	deltaIdle, deltaTotal := idleTime-cpuTimes.idle, totalTime-cpuTimes.total
+	cpuTimes.idle, cpuTimes.total = idleTime, totalTime
+	if deltaTotal == 0 {
+		return 0
+	}
+	return 1 - float64(deltaIdle)/float64(deltaTotal)
+}
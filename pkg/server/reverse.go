@@ -0,0 +1,35 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+)
+
+// dialClients keeps outbound connections to the configured clients for servers that cannot
+// accept inbound connections. The server still acts as the server of the protocol (and of TLS).
+func (s *Server) dialClients(addresses []string, tlsConfig *tls.Config) {
+	for _, addr := range addresses {
+		go s.dialClient(addr, tlsConfig)
+	}
+}
+
+// dialClient connects to one client and reconnects whenever the connection ends
+func (s *Server) dialClient(addr string, tlsConfig *tls.Config) {
+	for {
+		conn, err := net.DialTimeout("tcp", addr, s.config.Client.Discovery.ConnectTimeout)
+		if err != nil {
+			logging.Debugf("Failed to reach client %s: %v", addr, err)
+		} else {
+			if tlsConfig != nil {
+				conn = tls.Server(conn, tlsConfig)
+			}
+			logging.Infof("Connected to client %s", addr)
+			s.handleClientConnection(conn)
+		}
+		time.Sleep(transport.ReverseRetryInterval)
+	}
+}
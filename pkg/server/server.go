@@ -0,0 +1,1110 @@
+// Package server runs boltbuild build servers. A Server accepts client connections, receives
+// uploaded projects and runs their builds:
+//
+//	srv := server.New(cfg, server.WithPort(9090), server.WithCapacity(4))
+//	err := srv.Start()
+//
+// NewInstances creates every logical server the configuration declares for one machine.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// Server represents a build server that accepts client connections
+type Server struct {
+	config      *config.Config
+	id          string
+	port        int
+	capacity    int
+	clients     map[string]*ClientConnection
+	clientsMux  sync.RWMutex
+	slots       *buildSlots            // Limits concurrent builds and shares them fairly between clients
+	access      *config.AccessPolicy   // Which machines and clients may connect and build
+	uploads     *transport.UploadStore // Chunked uploads being reassembled
+	bandwidth   *transport.TokenBucket // Limit for results and logs sent to clients (nil = unlimited)
+	content     *contentCache          // Projects of previous builds by content hash (nil = disabled)
+	workspaces  *workspaceStore        // Persistent per-project workspaces (nil = disabled)
+	inspectable *inspectableWorkspaces // Workspaces of failed builds clients may browse
+	tempCleanup *tempCleanup           // Removal of preserved temp directories
+	usage       diskUsage              // Cached sizes reported with the load
+	pinning     *cpuPinning            // CPU sets of the build slots (nil = builds run unpinned)
+	secondary   bool                   // Further instance of a machine: temp cleanup and the WebSocket endpoint run in the first
+
+	containerRuntime *containerRuntime        // Container CLI for builds with an image (nil = not supported)
+	wslDistributions []string                 // WSL distributions on Windows servers
+	tools            map[string]string        // Installed build tools and their versions
+	resources        protocol.ServerResources // Hardware reported to clients for requirement matching (this instance's share)
+	diskShare        float64                  // Fraction of the machine's free disk this instance reports
+	faults           *transport.FaultInjector // Injected failures for resilience tests (nil = none)
+}
+
+// uploadExpiryInterval is how often abandoned chunked uploads are dropped
+const uploadExpiryInterval = time.Minute
+
+// cancelWaitDelay bounds how long a cancelled build may hold its output open
+// (child processes of the killed command may keep the pipes alive)
+const cancelWaitDelay = 2 * time.Second
+
+// ClientConnection represents a connection from a client
+type ClientConnection struct {
+	conn        net.Conn
+	addr        string
+	connectedAt time.Time
+	info        protocol.ClientInfo // Sent by the client after connecting (empty for old clients)
+	infoMux     sync.Mutex
+	encoder     *json.Encoder
+	writeMux    sync.Mutex
+	builds      map[string]context.CancelFunc // Running builds of this client
+	ctx         context.Context               // Done when the client disconnects
+	buildsMux   sync.Mutex
+	faults      *transport.FaultInjector
+}
+
+// send writes a message to the client; safe for concurrent use
+func (cc *ClientConnection) send(msg protocol.Message) error {
+	cc.writeMux.Lock()
+	defer cc.writeMux.Unlock()
+	if cc.faults.Disconnect(cc.conn, cc.addr) {
+		return transport.ErrFaultInjected
+	}
+	return cc.encoder.Encode(msg)
+}
+
+// trackBuild registers a build of this client and returns its context, done when the build is
+// cancelled or the client disconnects
+func (cc *ClientConnection) trackBuild(id string) context.Context {
+	ctx, cancel := context.WithCancel(cc.ctx)
+	cc.buildsMux.Lock()
+	cc.builds[id] = cancel
+	cc.buildsMux.Unlock()
+	return ctx
+}
+
+// untrackBuild forgets a finished build
+func (cc *ClientConnection) untrackBuild(id string) {
+	cc.buildsMux.Lock()
+	defer cc.buildsMux.Unlock()
+	if cancel, exists := cc.builds[id]; exists {
+		cancel()
+		delete(cc.builds, id)
+	}
+}
+
+// cancelBuild stops a running build of this client
+func (cc *ClientConnection) cancelBuild(id string) bool {
+	cc.buildsMux.Lock()
+	defer cc.buildsMux.Unlock()
+
+	cancel, exists := cc.builds[id]
+	if exists {
+		cancel()
+	}
+	return exists
+}
+
+// measureLoad samples the current load of this server
+func (s *Server) measureLoad() protocol.ServerLoad {
+	running, queued := s.slots.counts()
+	load := measureLoad(s.config.GetTempDir(), running, queued)
+	load.TempUsage, load.CacheSize = s.diskUsage()
+	load.LowDisk = s.checkLowDisk(load)
+	return load
+}
+
+// expireUploads periodically drops chunked uploads whose build request never arrived
+func (s *Server) expireUploads() {
+	ticker := time.NewTicker(uploadExpiryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.uploads.Expire()
+	}
+}
+
+// reportLoad periodically sends the server load to a client until done is closed
+func (s *Server) reportLoad(client *ClientConnection, done <-chan struct{}) {
+	ticker := time.NewTicker(loadReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			load := s.measureLoad()
+			if err := client.send(protocol.Message{Type: protocol.MessageStatus, Load: &load}); err != nil {
+				logging.Debugf("Failed to report load to %s: %v", client.addr, err)
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// outputStreamer collects build output and forwards every chunk as it is written
+type outputStreamer struct {
+	buf     bytes.Buffer
+	onWrite func(chunk string)
+	mux     sync.Mutex
+}
+
+// Write implements io.Writer
+func (o *outputStreamer) Write(p []byte) (int, error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	o.buf.Write(p)
+	if o.onWrite != nil {
+		o.onWrite(string(p))
+	}
+	return len(p), nil
+}
+
+// String returns the complete output so far
+func (o *outputStreamer) String() string {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	return o.buf.String()
+}
+
+// Option overrides a setting New otherwise takes from the configuration
+type Option func(*serverSettings)
+
+// serverSettings are the settings an Option can change
+type serverSettings struct {
+	id       string
+	port     int
+	capacity int
+}
+
+// WithID sets the server ID instead of generating one from the hostname
+func WithID(id string) Option {
+	return func(s *serverSettings) { s.id = id }
+}
+
+// WithPort sets the port the server listens on instead of server.port
+func WithPort(port int) Option {
+	return func(s *serverSettings) { s.port = port }
+}
+
+// WithCapacity sets the number of concurrent builds instead of server.capacity
+func WithCapacity(capacity int) Option {
+	return func(s *serverSettings) { s.capacity = capacity }
+}
+
+// New creates a build server for config; options override single settings
+func New(cfg *config.Config, options ...Option) *Server {
+	settings := serverSettings{port: cfg.Server.Port, capacity: cfg.Server.Capacity}
+	for _, option := range options {
+		option(&settings)
+	}
+	if settings.id == "" {
+		settings.id = generateServerID()
+	}
+	return newServer(cfg, settings.id, settings.port, settings.capacity, 0, 1, nil)
+}
+
+// newServer creates instance number instance of count on one machine with its own ID, port
+// and capacity. The first instance detects the machine (hardware, tools, container runtime) and
+// creates the on-disk stores; further instances share them with first.
+func newServer(cfg *config.Config, id string, port, capacity, instance, count int, first *Server) *Server {
+	server := &Server{
+		config:    cfg,
+		id:        id,
+		port:      port,
+		capacity:  capacity,
+		clients:   make(map[string]*ClientConnection),
+		slots:     newBuildSlots(capacity, cfg.Server.FairShare),
+		uploads:   transport.NewUploadStore(cfg.Build.MaxWorkspaceSize),
+		pinning:   newCPUPinning(cfg.Server.CPUPinning, capacity, instance, count),
+		secondary: instance > 0,
+		diskShare: 1,
+	}
+	if first != nil {
+		server.shareMachine(first)
+		return server
+	}
+
+	server.faults = transport.NewFaultInjector(cfg.Faults)
+	server.bandwidth = transport.NewTokenBucket(cfg.Server.Transfer)
+	server.content = newContentCache(cfg.Server.ContentCache, cfg.GetTempDir())
+	server.workspaces = newWorkspaceStore(cfg.Server.Workspaces, cfg.GetTempDir())
+	server.inspectable = newInspectableWorkspaces()
+	server.tempCleanup = newTempCleanup(cfg.Build.TempCleanup)
+
+	// The configuration was validated, so the access rules parse
+	server.access, _ = config.NewAccessPolicy(cfg.Server.Access)
+
+	if server.containerRuntime = detectContainerRuntime(cfg.Server.ContainerRuntime); server.containerRuntime != nil {
+		mode := ""
+		if server.containerRuntime.Rootless {
+			mode = " (rootless)"
+		}
+		logging.Infof("Container builds enabled using %s%s", server.containerRuntime.Name, mode)
+	} else if runtime := cfg.Server.ContainerRuntime; runtime != "" && runtime != config.ContainerRuntimeAuto && runtime != config.ContainerRuntimeNone {
+		logging.Infof("Warning: Container runtime %s is not usable, container builds are disabled", runtime)
+	}
+	server.resources = detectResources(cfg.GetTempDir())
+	logging.Debugf("Server resources: %s memory, %s free disk, %d GPUs", server.resources.Memory, server.resources.FreeDisk, server.resources.GPUs)
+
+	if server.wslDistributions = detectWSLDistributions(); len(server.wslDistributions) > 0 {
+		logging.Infof("WSL builds enabled for %s", strings.Join(server.wslDistributions, ", "))
+	}
+	server.tools = detectToolVersions()
+	logging.Debugf("Server build tools: %v", server.tools)
+	return server
+}
+
+// shareMachine takes over the machine-wide state of the first instance of the machine
+func (s *Server) shareMachine(first *Server) {
+	s.access = first.access
+	s.faults = first.faults
+	s.bandwidth = first.bandwidth
+	s.content = first.content
+	s.workspaces = first.workspaces
+	s.inspectable = first.inspectable
+	s.tempCleanup = first.tempCleanup
+	s.containerRuntime = first.containerRuntime
+	s.wslDistributions = first.wslDistributions
+	s.tools = first.tools
+	s.resources = first.resources
+}
+
+// containerRuntimeName returns the container runtime advertised to clients ("" = none)
+func (s *Server) containerRuntimeName() string {
+	if s.containerRuntime == nil {
+		return ""
+	}
+	return s.containerRuntime.Name
+}
+
+// ID returns the ID the server reports to clients
+func (s *Server) ID() string {
+	return s.id
+}
+
+// Port returns the port the server listens on
+func (s *Server) Port() int {
+	return s.port
+}
+
+// Capacity returns the number of builds the server runs at once
+func (s *Server) Capacity() int {
+	return s.capacity
+}
+
+// Start begins listening for client connections
+func (s *Server) Start() error {
+	go s.expireUploads()
+	if !s.secondary {
+		go s.runTempCleanup(s.config.GetTempDir())
+	}
+
+	// In WAN mode the relay is the only way in, nothing listens for direct connections
+	if s.config.WANMode {
+		logging.Infof("Build server %s started in WAN mode, registering at relay %s", s.id, s.config.Server.Relay.Address)
+		s.connectRelay(s.config.Server.Relay)
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to start server: %v", err)
+	}
+	defer listener.Close()
+
+	var tlsConfig *tls.Config
+	if s.config.TLS.Enabled {
+		if s.config.TLS.CertFile == "" {
+			return fmt.Errorf("TLS is enabled but no tls.cert_file is configured")
+		}
+		certificates, err := transport.NewCertificateStore(s.config.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go certificates.Watch(done)
+		tlsConfig = certificates.ServerTLSConfig()
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	// Clients behind HTTP-only proxies and firewalls reach the WebSocket endpoint
+	if s.config.Server.WebSocket.Port != 0 && !s.secondary {
+		go s.startWebSocket(s.config.Server.WebSocket, tlsConfig)
+	}
+
+	// Servers behind firewalls connect to their clients themselves
+	s.dialClients(s.config.Server.ConnectTo, tlsConfig)
+	if s.config.Server.Relay.Address != "" {
+		go s.connectRelay(s.config.Server.Relay)
+	}
+
+	logging.Infof("Build server %s started on port %d, waiting for clients...", s.id, s.port)
+	s.announce(s.config.Server.AnnounceTo)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logging.Debugf("Failed to accept connection: %v", err)
+			continue
+		}
+
+		go s.handleClientConnection(conn)
+	}
+}
+
+// handleClientConnection manages a single client connection
+func (s *Server) handleClientConnection(conn net.Conn) {
+	defer conn.Close()
+	conn = transport.Throttle(conn, s.bandwidth)
+	clientAddr := conn.RemoteAddr().String()
+	conn = logging.TraceConn(conn, clientAddr)
+
+	// Refuse machines outside the allowed networks before revealing anything
+	if err := s.access.CheckAddress(clientAddr); err != nil {
+		logging.Infof("Refused connection from %s: %v", clientAddr, err)
+		return
+	}
+
+	// Register client; everything done for it stops when it disconnects
+	ctx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	clientConn := &ClientConnection{
+		conn:        conn,
+		addr:        clientAddr,
+		connectedAt: time.Now(),
+		encoder:     json.NewEncoder(conn),
+		builds:      make(map[string]context.CancelFunc),
+		ctx:         ctx,
+		faults:      s.faults,
+	}
+
+	s.clientsMux.Lock()
+	s.clients[clientAddr] = clientConn
+	s.clientsMux.Unlock()
+
+	logging.Infof("Client connected from %s", clientAddr)
+
+	// Send server info to client
+	// Free disk space changes between connections, memory and GPUs do not
+	load := s.measureLoad()
+	resources := s.resources
+	if load.FreeDisk > 0 {
+		resources.FreeDisk = protocol.ByteSize(float64(load.FreeDisk) * s.diskShare)
+	}
+
+	serverInfo := protocol.ServerInfo{
+		ID:            s.id,
+		Address:       s.getLocalIP(),
+		Port:          s.port,
+		Capacity:      s.capacity,
+		Version:       protocol.Version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		OSVersion:     serverOSVersion(),
+		Compression:   buildutil.SupportedCompressions,
+		Containers:    s.containerRuntimeName(),
+		WSL:           s.wslDistributions,
+		ContentCache:  s.content != nil,
+		ChunkedUpload: true,
+		Resources:     resources,
+		Load:          load,
+		Environments:  s.environments(),
+		Tools:         s.tools,
+	}
+
+	if err := clientConn.encoder.Encode(serverInfo); err != nil {
+		logging.Debugf("Failed to send server info to %s: %v", clientAddr, err)
+		return
+	}
+
+	// Report the load until the client disconnects
+	done := make(chan struct{})
+	defer close(done)
+	go s.reportLoad(clientConn, done)
+
+	// Process messages from this client; builds run in the background so cancel requests are seen
+	decoder := json.NewDecoder(conn)
+loop:
+	for {
+		var msg protocol.Message
+		if err := decoder.Decode(&msg); err != nil {
+			logging.Infof("Client %s disconnected: %v", clientAddr, err)
+			break
+		}
+
+		switch msg.Type {
+		case protocol.MessageHello:
+			if msg.Client == nil {
+				continue
+			}
+			info := *msg.Client
+			info.Address = clientAddr
+			if err := s.access.CheckClient(info); err != nil {
+				logging.Infof("Refused client %s at %s: %v", info.ID, clientAddr, err)
+				clientConn.send(protocol.Message{Type: protocol.MessageError, Data: err.Error()})
+				break loop
+			}
+			clientConn.infoMux.Lock()
+			clientConn.info = info
+			clientConn.infoMux.Unlock()
+			logging.Infof("Client %s at %s identified as %s (version %s)", info.ID, clientAddr, info.Hostname, info.Version)
+			if info.Version != protocol.Version {
+				logging.Infof("WARNING: Version mismatch with client %s! Server: %s, Client: %s", info.ID, protocol.Version, info.Version)
+			}
+		case protocol.MessageBuild:
+			if msg.Request == nil {
+				logging.Debugf("Ignoring build message without request from %s", clientAddr)
+				continue
+			}
+			clientConn.infoMux.Lock()
+			identified := clientConn.info.ID != ""
+			clientConn.infoMux.Unlock()
+			if !identified && s.access.RequiresClientID() {
+				response := protocol.BuildResponse{ID: msg.Request.ID, Error: "this server only accepts builds from identified clients"}
+				clientConn.send(protocol.Message{Type: protocol.MessageResult, BuildID: msg.Request.ID, Response: &response})
+				continue
+			}
+			logging.Debugf("Received build request %s for %s from %s", msg.Request.ID, msg.Request.Environment, clientAddr)
+			// Cancellable from now on, also while its upload is still arriving
+			buildCtx := clientConn.trackBuild(msg.Request.ID)
+			go func(request protocol.BuildRequest) {
+				defer clientConn.untrackBuild(request.ID)
+
+				// Chunked uploads arrive separately, possibly over other connections
+				if request.Upload != nil {
+					if err := s.uploads.Assemble(buildCtx, &request); err != nil {
+						response := protocol.BuildResponse{ID: request.ID, Error: err.Error()}
+						clientConn.send(protocol.Message{Type: protocol.MessageResult, BuildID: request.ID, Response: &response})
+						return
+					}
+				}
+				if !s.resolveContent(clientConn, &request) {
+					return
+				}
+				s.runClientBuild(buildCtx, clientConn, request)
+			}(*msg.Request)
+		case protocol.MessageBrowse:
+			if msg.Browse != nil {
+				go s.browseWorkspace(clientConn, msg.BuildID, *msg.Browse)
+			}
+		case protocol.MessagePreflight:
+			if msg.Preflight != nil {
+				go s.answerPreflight(clientConn, msg.BuildID, *msg.Preflight)
+			}
+		case protocol.MessageChunk:
+			if msg.Chunk == nil || msg.Chunk.Index < 0 {
+				continue
+			}
+			clientConn.infoMux.Lock()
+			identified := clientConn.info.ID != ""
+			clientConn.infoMux.Unlock()
+			if !identified && s.access.RequiresClientID() {
+				logging.Debugf("Ignoring upload chunk of build %s from unidentified client %s", msg.BuildID, clientAddr)
+				continue
+			}
+			if err := s.uploads.Add(msg.BuildID, *msg.Chunk); err != nil {
+				logging.Infof("Refused upload chunk of build %s from %s: %v", msg.BuildID, clientAddr, err)
+			}
+		case protocol.MessageCancel:
+			if clientConn.cancelBuild(msg.BuildID) {
+				logging.Infof("Cancelling build %s on request of %s", msg.BuildID, clientAddr)
+			}
+		case protocol.MessageLogLevel:
+			if err := logging.SetLevel(msg.Data); err != nil {
+				logging.Infof("Ignoring log level change from %s: %v", clientAddr, err)
+			} else {
+				logging.Infof("Log level set to %s on request of %s", msg.Data, clientAddr)
+			}
+		case protocol.MessagePing:
+			// Network probe: answer without the payload
+			if err := clientConn.send(protocol.Message{Type: protocol.MessagePong, BuildID: msg.BuildID}); err != nil {
+				logging.Debugf("Failed to answer probe from %s: %v", clientAddr, err)
+			}
+		default:
+			logging.Debugf("Ignoring unknown message type %q from %s", msg.Type, clientAddr)
+		}
+	}
+
+	// Builds of a disconnected client are no longer wanted
+	disconnect()
+
+	// Remove client on disconnect
+	s.clientsMux.Lock()
+	delete(s.clients, clientAddr)
+	s.clientsMux.Unlock()
+
+	usage := s.slots.clientUsage(config.ClientKey(clientAddr))
+	logging.Infof("Client %s totals: %d builds, %v building, %v queued", config.ClientKey(clientAddr), usage.Completed,
+		usage.BuildTime.Round(time.Second), usage.QueueTime.Round(time.Second))
+}
+
+// runClientBuild executes a build for a client, streaming its output and sending the result
+func (s *Server) runClientBuild(ctx context.Context, clientConn *ClientConnection, request protocol.BuildRequest) {
+	// Builds of one persistent workspace run one after the other
+	workspace := s.workspaceFor(clientConn, request)
+	if workspace != "" {
+		unlock := s.workspaces.lock(workspace)
+		defer unlock()
+	}
+
+	// Wait for a build slot; the client may cancel the build while it is queued
+	client := config.ClientKey(clientConn.addr)
+	if err := s.slots.acquire(ctx, client); err != nil {
+		response := protocol.BuildResponse{ID: request.ID, Error: "build cancelled"}
+		if err := clientConn.send(protocol.Message{Type: protocol.MessageResult, BuildID: request.ID, Response: &response}); err != nil {
+			logging.Debugf("Failed to send response to %s: %v", clientConn.addr, err)
+		}
+		return
+	}
+
+	response := s.processBuildRequest(ctx, request, workspace, func(chunk string) {
+		if err := clientConn.send(protocol.Message{Type: protocol.MessageLog, BuildID: request.ID, Data: chunk}); err != nil {
+			logging.Debugf("Failed to stream output of build %s to %s: %v", request.ID, clientConn.addr, err)
+		}
+	})
+	s.slots.release(client, response.Duration)
+	s.rememberFailedWorkspace(clientConn, request, workspace, response)
+	s.faults.Corrupt(&response)
+	s.faults.Delay(request.ID)
+
+	if err := clientConn.send(protocol.Message{Type: protocol.MessageResult, BuildID: request.ID, Response: &response}); err != nil {
+		logging.Debugf("Failed to send response to %s: %v", clientConn.addr, err)
+	}
+}
+
+// RunBuild executes a build request in this process, without a client connection, once a build
+// slot is free. started is called when the build leaves the queue and output receives the build
+// output while the command runs. Clients use it to build on their own machine.
+func (s *Server) RunBuild(ctx context.Context, request protocol.BuildRequest, started func(), output func(chunk string)) (protocol.BuildResponse, error) {
+	if err := s.slots.acquire(ctx, s.id); err != nil {
+		return protocol.BuildResponse{}, err
+	}
+	started()
+	response := s.processBuildRequest(ctx, request, "", output)
+	s.slots.release(s.id, response.Duration)
+	return response, nil
+}
+
+// processBuildRequest executes a build request and returns the result, in the persistent
+// workspace if one is given and in a fresh temp directory otherwise.
+// onOutput receives the build output while the command runs.
+func (s *Server) processBuildRequest(ctx context.Context, request protocol.BuildRequest, workspace string, onOutput func(chunk string)) protocol.BuildResponse {
+	start := time.Now()
+
+	response := protocol.BuildResponse{
+		ID:          request.ID,
+		Compression: request.Compression,
+	}
+
+	if workspace != "" {
+		// Persistent workspaces keep everything but changed and removed project files
+		sync, err := s.syncWorkspace(workspace, request)
+		if err != nil {
+			response.Success = false
+			response.Error = fmt.Sprintf("Failed to sync workspace: %v", err)
+			response.Duration = time.Since(start)
+			return response
+		}
+		response.Incremental = sync.Incremental
+		logging.Debugf("Workspace %s synced: %d files written, %d removed, %d unchanged (incremental: %v)",
+			workspace, sync.Written, sync.Removed, len(request.Files)-sync.Written, sync.Incremental)
+		return s.executeBuild(ctx, request, workspace, response, start, onOutput)
+	}
+
+	// Create temporary project directory
+	projectDir, err := s.createProjectDirectory(request)
+	if err != nil {
+		response.Success = false
+		response.Error = fmt.Sprintf("Failed to create project directory: %v", err)
+		response.Duration = time.Since(start)
+		return response
+	}
+
+	// Clean up temporary directory based on configuration
+	defer s.tempCleanup.acquire(projectDir)()
+	defer func() {
+		if s.config.Build.TempDeletion {
+			os.RemoveAll(projectDir)
+		} else {
+			logging.Debugf("Temporary directory preserved: %s", projectDir)
+		}
+	}()
+
+	// Write files to project directory
+	if err := s.writeProjectFiles(projectDir, request.Files); err != nil {
+		response.Success = false
+		response.Error = fmt.Sprintf("Failed to write project files: %v", err)
+		response.Duration = time.Since(start)
+		return response
+	}
+
+	return s.executeBuild(ctx, request, projectDir, response, start, onOutput)
+}
+
+// executeBuild runs the build command in a prepared project directory and collects the results
+func (s *Server) executeBuild(ctx context.Context, request protocol.BuildRequest, projectDir string, response protocol.BuildResponse, start time.Time, onOutput func(chunk string)) protocol.BuildResponse {
+	// Environments may define commands per server platform
+	request, err := resolvePlatformCommand(request)
+	if err != nil {
+		response.Success = false
+		response.Error = err.Error()
+		response.Duration = time.Since(start)
+		return response
+	}
+
+	output := &outputStreamer{onWrite: onOutput}
+	var failedStep string
+	if len(request.Steps) > 0 {
+		failedStep, err = s.runSteps(ctx, request, projectDir, output, &response)
+	} else {
+		// Execute build command based on language
+		cmd, cmdErr := s.buildCommand(ctx, request, projectDir)
+		if cmdErr != nil {
+			response.Success = false
+			response.Error = cmdErr.Error()
+			response.Duration = time.Since(start)
+			return response
+		}
+		err = s.runCommand(request, cmd, output)
+	}
+	response.Output = output.String()
+	response.Duration = time.Since(start)
+
+	var exitErr *exec.ExitError
+	if ctx.Err() != nil {
+		response.Success = false
+		response.Error = "build cancelled"
+		response.ExitCode = -1
+	} else if errors.As(err, &exitErr) {
+		response.Success = false
+		response.Error = err.Error()
+		response.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		response.Success = false
+		response.Error = err.Error()
+		response.ExitCode = -1
+	} else if err := s.checkWorkspaceSize(projectDir); err != nil {
+		// Build produced more data than the workspace may hold
+		response.Success = false
+		response.Error = err.Error()
+	} else {
+		response.Success = true
+	}
+	if failedStep != "" && ctx.Err() == nil {
+		response.Error = fmt.Sprintf("step %s: %s", failedStep, response.Error)
+	}
+
+	// Collect compiled output files (and always-collected files even on failure)
+	if patterns, collect := s.artifactPatterns(request, response.Success); collect {
+		outputFiles, checksums, warnings, err := s.collectOutputFiles(projectDir, request, patterns)
+		response.Warnings = append(response.Warnings, warnings...)
+		if err != nil {
+			logging.Debugf("Warning: Failed to collect output files: %v", err)
+			if errors.Is(err, errArtifactLimit) {
+				response.Success = false
+				response.Error = err.Error()
+			}
+		} else {
+			response.OutputFiles = outputFiles
+			response.Checksums = checksums
+		}
+	}
+
+	// Describe how the artifacts were produced if the environment asks for it
+	s.attachProvenance(ctx, request, projectDir, &response, start)
+
+	// Give failed builds what is needed to triage them remotely
+	s.attachDebugBundle(ctx, request, projectDir, &response)
+
+	// Keep the complete build log (and one per step) in the workspace; clients get it as the output
+	if err := s.writeBuildLogs(projectDir, response); err != nil {
+		logging.Debugf("Warning: Failed to write build log: %v", err)
+	}
+
+	logging.Debugf("Build %s completed in %v, success: %v (files: %d, output: %d)", request.ID, response.Duration, response.Success, len(request.Files), len(response.OutputFiles))
+	if !response.Success {
+		logging.ReportEventf(logging.EventError, "Build %s (%s) failed: %s", request.ID, request.Environment, response.Error)
+	}
+	return response
+}
+
+// runCommand runs a build command to completion at the configured priority and CPU pinning
+func (s *Server) runCommand(request protocol.BuildRequest, cmd *exec.Cmd, output *outputStreamer) error {
+	cmd.Stdout = output
+	cmd.Stderr = output
+	s.prioritizeBuild(request, cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	s.prioritizeStartedBuild(request, cmd.Process.Pid)
+	release := s.pinBuild(request, cmd.Process.Pid)
+	defer release()
+	return cmd.Wait()
+}
+
+// buildCommand creates the appropriate build command based on request configuration
+func (s *Server) buildCommand(ctx context.Context, request protocol.BuildRequest, projectDir string) (*exec.Cmd, error) {
+	// Parse the command string from the request
+	cmdParts := commandArgs(request)
+	if len(cmdParts) == 0 || cmdParts[0] == "" {
+		return nil, fmt.Errorf("empty command in build request")
+	}
+
+	// Determine execution directory
+	executionDir := request.ExecutionDir
+	if executionDir == "" {
+		executionDir = projectDir // Fallback to project directory
+	} else if !filepath.IsAbs(executionDir) {
+		// If relative path, make it relative to project directory
+		executionDir = filepath.Join(projectDir, executionDir)
+	}
+
+	// Create execution directory if it doesn't exist
+	if err := os.MkdirAll(executionDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create execution directory: %v", err)
+	}
+
+	// Environments with an image run inside a container
+	if request.Image != "" {
+		return s.containerCommand(ctx, request, projectDir, executionDir, cmdParts)
+	}
+
+	// Environments with a WSL distribution run inside it on Windows servers
+	if request.WSL != "" {
+		return s.wslCommand(ctx, request, executionDir, cmdParts)
+	}
+
+	// Command will be executed in the execution directory
+	logging.Debugf("%s build command: %s (execution dir: %s, env: %v)", request.Environment, buildutil.QuoteArgs(cmdParts), executionDir, s.config.Server.BuildEnv.Scrub(request.EnvVars))
+
+	// Create command
+	cmd := nativeCommand(ctx, request, cmdParts)
+	cmd.Dir = executionDir
+	cmd.WaitDelay = cancelWaitDelay
+
+	// The allowed host variables plus the environment variables of the request
+	cmd.Env = s.config.Server.BuildEnv.Environ(request.EnvVars)
+
+	return cmd, nil
+}
+
+// createProjectDirectory creates a temporary directory for the build
+func (s *Server) createProjectDirectory(request protocol.BuildRequest) (string, error) {
+	// Create a temporary directory for project files
+	tempDir := s.config.GetTempDir()
+	projectDir := filepath.Join(tempDir, request.ProjectName)
+
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return "", err
+	}
+
+	return projectDir, nil
+}
+
+// writeProjectFiles writes all project files to the temporary directory
+func (s *Server) writeProjectFiles(projectDir string, files map[string]string) error {
+	maxSize := s.config.Build.MaxWorkspaceSize
+	var written protocol.ByteSize
+
+	for relativePath, content := range files {
+		// Enforce workspace size cap before touching the disk
+		written += protocol.ByteSize(len(content))
+		if maxSize > 0 && written > maxSize {
+			return fmt.Errorf("project files exceed workspace size limit of %s", maxSize)
+		}
+
+		// Normalize path separators for the current OS
+		normalizedRelPath := filepath.FromSlash(relativePath)
+		fullPath := filepath.Join(projectDir, normalizedRelPath)
+
+		// Create directory if it doesn't exist
+		dir := filepath.Dir(fullPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		// Write file
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkWorkspaceSize verifies that the project directory stays within the configured size limit
+func (s *Server) checkWorkspaceSize(projectDir string) error {
+	maxSize := s.config.Build.MaxWorkspaceSize
+	if maxSize <= 0 {
+		return nil
+	}
+
+	size, err := s.directorySize(projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to determine workspace size: %v", err)
+	}
+	if size > maxSize {
+		return fmt.Errorf("workspace size %s exceeds limit of %s", size, maxSize)
+	}
+	return nil
+}
+
+// directorySize returns the total size of all files below a directory
+func (s *Server) directorySize(dir string) (protocol.ByteSize, error) {
+	var size protocol.ByteSize
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += protocol.ByteSize(info.Size())
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+// writeBuildLogs writes the combined build output and the output of every step that ran to the
+// workspace. The response already carries them, so they are not returned as artifacts again.
+func (s *Server) writeBuildLogs(projectDir string, response protocol.BuildResponse) error {
+	logPath := filepath.Join(projectDir, filepath.FromSlash(buildutil.BuildLogPath))
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(logPath, []byte(response.Output), 0644); err != nil {
+		return err
+	}
+
+	// Persistent workspaces may still hold the step logs of a previous build
+	stepDir := filepath.Join(projectDir, filepath.FromSlash(buildutil.StepLogDir))
+	if err := os.RemoveAll(stepDir); err != nil {
+		return err
+	}
+	for i, step := range response.Steps {
+		if step.Skipped {
+			continue
+		}
+		if err := os.MkdirAll(stepDir, 0755); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%d-%s.log", i+1, buildutil.SafeName(step.Name))
+		if err := os.WriteFile(filepath.Join(stepDir, name), []byte(step.Output), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// artifactPatterns returns the file patterns to collect for a finished build.
+// Successful builds return their output paths plus always_collect_paths, failed builds only the latter.
+func (s *Server) artifactPatterns(request protocol.BuildRequest, success bool) ([]string, bool) {
+	if !success {
+		return request.AlwaysCollectPaths, len(request.AlwaysCollectPaths) > 0
+	}
+	if len(request.OutputPaths) == 0 {
+		// No output patterns means every file is an output
+		return nil, true
+	}
+	patterns := append([]string{}, request.OutputPaths...)
+	return append(patterns, request.AlwaysCollectPaths...), true
+}
+
+// errArtifactLimit is returned when artifacts exceed the configured limits and on_limit is "fail"
+var errArtifactLimit = errors.New("artifact size limit exceeded")
+
+// collectOutputFiles collects compiled output files and returns them as base64 along with their checksums
+func (s *Server) collectOutputFiles(projectDir string, request protocol.BuildRequest, patterns []string) (map[string]string, map[string]string, []string, error) {
+	outputFiles := make(map[string]string)
+	checksums := make(map[string]string)
+	limits := s.config.Build.Artifacts
+	var warnings []string
+	var total protocol.ByteSize
+
+	files, err := s.findFiles(projectDir)
+	if err != nil {
+		logging.Debugf("Error finding files in project directory %s: %v", projectDir, err)
+		return nil, nil, nil, err
+	}
+
+	logging.Debugf("Found %d files in project directory %s for environment %s", len(files), projectDir, request.Environment)
+
+	for _, file := range files {
+		relativePath, err := filepath.Rel(projectDir, file)
+		if err != nil {
+			logging.Debugf("Warning: Failed to get relative path for %s: %v", file, err)
+			continue
+		}
+		// Normalize to use forward slashes and prefix with ./
+		normalizedPath := "./" + filepath.ToSlash(relativePath)
+		if strings.HasPrefix(normalizedPath, "./"+buildutil.BuildMetadataDir+"/") {
+			// boltbuild's own files (build logs, workspace manifest) are not build outputs
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			logging.Debugf("Warning: Failed to stat file %s: %v", file, err)
+			continue
+		}
+
+		logging.Debugf("Checking file: %s (size: %d)", normalizedPath, info.Size())
+
+		if !s.isOutputFileNormalized(normalizedPath, patterns) {
+			logging.Debugf("Skipped file (not output): %s", normalizedPath)
+			continue
+		}
+
+		// Apply per-file and total artifact size limits
+		size := protocol.ByteSize(info.Size())
+		allowed := size
+		if limits.MaxFileSize > 0 && allowed > limits.MaxFileSize {
+			allowed = limits.MaxFileSize
+		}
+		if limits.MaxTotalSize > 0 && total+allowed > limits.MaxTotalSize {
+			allowed = limits.MaxTotalSize - total
+		}
+
+		if allowed < size {
+			switch limits.OnLimit {
+			case config.ArtifactLimitFail:
+				return nil, nil, warnings, fmt.Errorf("%w: %s is %s", errArtifactLimit, normalizedPath, size)
+			case config.ArtifactLimitTruncate:
+				warnings = append(warnings, fmt.Sprintf("artifact %s truncated from %s to %s", normalizedPath, size, allowed))
+			default:
+				warnings = append(warnings, fmt.Sprintf("artifact %s skipped: size %s exceeds limit", normalizedPath, size))
+				continue
+			}
+		}
+
+		content, err := s.readFilePrefix(file, int64(allowed))
+		if err != nil {
+			logging.Debugf("Warning: Failed to read output file %s: %v", file, err)
+			continue
+		}
+
+		encoded, err := buildutil.EncodeArtifact(content, request.Compression)
+		if err != nil {
+			logging.Debugf("Warning: Failed to encode output file %s: %v", file, err)
+			continue
+		}
+
+		total += protocol.ByteSize(len(content))
+		outputFiles[normalizedPath] = encoded
+		checksums[normalizedPath] = buildutil.ArtifactChecksum(content)
+		logging.Debugf("Added output file: %s (size: %d bytes)", normalizedPath, len(content))
+	}
+
+	for _, warning := range warnings {
+		logging.Infof("Build %s: %s", request.ID, warning)
+	}
+
+	logging.Debugf("Collected %d output files for build %s", len(outputFiles), request.ID)
+	return outputFiles, checksums, warnings, nil
+}
+
+// readFilePrefix reads at most limit bytes from the beginning of a file
+func (s *Server) readFilePrefix(path string, limit int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(io.LimitReader(file, limit))
+}
+
+// findFiles recursively finds all files in a directory
+func (s *Server) findFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// isOutputFileNormalized matches output patterns against the normalized relative path (./...)
+func (s *Server) isOutputFileNormalized(normalizedPath string, outputPaths []string) bool {
+	if len(outputPaths) == 0 {
+		return true
+	}
+	for _, pattern := range outputPaths {
+		// Always use forward slashes for pattern and path
+		patternNorm := filepath.ToSlash(pattern)
+		matched, err := filepath.Match(patternNorm, normalizedPath)
+		if err == nil && matched {
+			return true
+		}
+		// Also check basename for patterns like "main.*"
+		matched, err = filepath.Match(patternNorm, filepath.Base(normalizedPath))
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// generateServerID generates a unique server ID using computer name
+func generateServerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		// Fallback to random ID if hostname is not available
+		bytes := make([]byte, 8)
+		rand.Read(bytes)
+		return fmt.Sprintf("server-%s", hex.EncodeToString(bytes))
+	}
+	return fmt.Sprintf("server-%s", hostname)
+}
+
+// getLocalIP returns the local IP address of the server
+func (s *Server) getLocalIP() string {
+	conn, err := net.Dial("udp", "8.8.8.8:80")
+	if err != nil {
+		return "localhost"
+	}
+	defer conn.Close()
+
+	localAddr := conn.LocalAddr().(*net.UDPAddr)
+	return localAddr.IP.String()
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/protocol"
+)
+
+// commandArgs returns the argument list of a build: the args list as given, the command run by
+// sh -c in shell mode (containers and WSL distributions are Linux), or the command split on
+// whitespace
+func commandArgs(request protocol.BuildRequest) []string {
+	switch {
+	case len(request.Args) > 0:
+		return request.Args
+	case request.Shell:
+		return []string{"sh", "-c", request.Command}
+	default:
+		return strings.Fields(request.Command)
+	}
+}
+
+// nativeCommand creates a build command running directly on the server, through the
+// platform's shell in shell mode
+func nativeCommand(ctx context.Context, request protocol.BuildRequest, cmdParts []string) *exec.Cmd {
+	if request.Shell && len(request.Args) == 0 {
+		return buildutil.ShellCommand(ctx, request.Command)
+	}
+	return exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+}
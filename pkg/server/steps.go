@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// stepName names a step in the log and its result
+func stepName(step protocol.BuildStep, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	if len(step.Args) > 0 {
+		return buildutil.QuoteArgs(step.Args)
+	}
+	if step.Command != "" {
+		return step.Command
+	}
+	return fmt.Sprintf("step %d", index+1)
+}
+
+// runSteps runs the steps of a build one after the other in the same workspace. Every step's
+// output goes to the build output after a header line and into its own result. The first
+// failing step ends the build unless it may continue on error; its name is returned with the error.
+func (s *Server) runSteps(ctx context.Context, request protocol.BuildRequest, projectDir string, output *outputStreamer, response *protocol.BuildResponse) (string, error) {
+	platform := buildPlatform(request)
+	for i, step := range request.Steps {
+		name := stepName(step, i)
+		if !buildutil.MatchesPlatform(step.Platforms, platform) {
+			fmt.Fprintf(output, "==> [%d/%d] %s (skipped on %s)\n", i+1, len(request.Steps), name, platform)
+			response.Steps = append(response.Steps, protocol.StepResult{Name: name, Success: true, Skipped: true})
+			continue
+		}
+		fmt.Fprintf(output, "==> [%d/%d] %s\n", i+1, len(request.Steps), name)
+
+		stepCtx, cancel := ctx, context.CancelFunc(func() {})
+		if step.Timeout > 0 {
+			stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		}
+		started := time.Now()
+		stepOutput := &outputStreamer{onWrite: func(chunk string) { output.Write([]byte(chunk)) }}
+		cmd, err := s.buildCommand(stepCtx, buildutil.StepRequest(request, step), projectDir)
+		if err == nil {
+			err = s.runCommand(request, cmd, stepOutput)
+		}
+		timedOut := stepCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+		cancel()
+
+		result := protocol.StepResult{Name: name, Success: err == nil, Duration: time.Since(started), Output: stepOutput.String()}
+		if err != nil {
+			result.Error, result.ExitCode = err.Error(), -1
+			var exitErr *exec.ExitError
+			if timedOut {
+				result.Error = fmt.Sprintf("timed out after %v", step.Timeout)
+				err = errors.New(result.Error)
+			} else if errors.As(err, &exitErr) {
+				result.ExitCode = exitErr.ExitCode()
+			}
+		}
+		response.Steps = append(response.Steps, result)
+		logging.Debugf("Build %s step %s finished in %v, success: %v", request.ID, name, result.Duration, result.Success)
+
+		if err == nil {
+			continue
+		}
+		if step.ContinueOnError && ctx.Err() == nil {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("step %s failed: %s", name, result.Error))
+			continue
+		}
+		return name, err
+	}
+	return "", nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// TempCleanupStats reports the temp directory cleanup in the server status
+type TempCleanupStats struct {
+	Runs           int               `json:"runs"`
+	Removed        int               `json:"removed"`         // Directories removed since the server started
+	ReclaimedBytes protocol.ByteSize `json:"reclaimed_bytes"` // Disk space they held
+	Preserved      int               `json:"preserved"`       // Directories left after the last run
+	PreservedBytes protocol.ByteSize `json:"preserved_bytes"`
+	LastRun        time.Time         `json:"last_run,omitempty"`
+}
+
+// tempCleanup removes preserved build directories that are too old or exceed the size budget
+type tempCleanup struct {
+	config config.TempCleanupConfig
+	inUse  map[string]int // Directories of running builds, never removed
+	stats  TempCleanupStats
+	mux    sync.Mutex
+}
+
+// newTempCleanup creates the cleanup of a server
+func newTempCleanup(cfg config.TempCleanupConfig) *tempCleanup {
+	return &tempCleanup{config: cfg, inUse: make(map[string]int)}
+}
+
+// acquire marks a build directory as in use and returns the function releasing it
+func (t *tempCleanup) acquire(dir string) func() {
+	t.mux.Lock()
+	t.inUse[dir]++
+	t.mux.Unlock()
+	return func() {
+		t.mux.Lock()
+		if t.inUse[dir]--; t.inUse[dir] <= 0 {
+			delete(t.inUse, dir)
+		}
+		t.mux.Unlock()
+	}
+}
+
+// Stats returns the cleanup counters
+func (t *tempCleanup) Stats() TempCleanupStats {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.stats
+}
+
+// runTempCleanup removes preserved directories periodically until the server exits
+func (s *Server) runTempCleanup(tempDir string) {
+	t := s.tempCleanup
+	if !t.config.Enabled() {
+		return
+	}
+	logging.Infof("Cleaning up preserved temp directories in %s every %v (max age: %v, max total size: %s)",
+		tempDir, t.config.Interval, t.config.MaxAge, t.config.MaxTotalSize)
+	for {
+		s.collectTempDirs(tempDir)
+		time.Sleep(t.config.Interval)
+	}
+}
+
+// preservedDir is a build directory found in the temp directory
+type preservedDir struct {
+	path    string
+	size    protocol.ByteSize
+	modTime time.Time
+}
+
+// collectTempDirs runs one cleanup pass: first by age, then oldest first until the size budget fits
+func (s *Server) collectTempDirs(tempDir string) {
+	t := s.tempCleanup
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		logging.Debugf("Temp cleanup: failed to read %s: %v", tempDir, err)
+		return
+	}
+
+	var dirs []preservedDir
+	var total protocol.ByteSize
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), buildutil.ProjectDirPrefix) {
+			continue
+		}
+		path := filepath.Join(tempDir, entry.Name())
+		t.mux.Lock()
+		_, busy := t.inUse[path]
+		t.mux.Unlock()
+		if busy {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		size, err := s.directorySize(path)
+		if err != nil {
+			continue
+		}
+		dirs = append(dirs, preservedDir{path: path, size: size, modTime: info.ModTime()})
+		total += size
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].modTime.Before(dirs[j].modTime) })
+
+	removed := 0
+	var reclaimed protocol.ByteSize
+	remaining := dirs[:0]
+	for _, dir := range dirs {
+		tooOld := t.config.MaxAge > 0 && time.Since(dir.modTime) > t.config.MaxAge
+		overBudget := t.config.MaxTotalSize > 0 && total > t.config.MaxTotalSize
+		if !tooOld && !overBudget {
+			remaining = append(remaining, dir)
+			continue
+		}
+		if err := os.RemoveAll(dir.path); err != nil {
+			logging.Debugf("Temp cleanup: failed to remove %s: %v", dir.path, err)
+			remaining = append(remaining, dir)
+			continue
+		}
+		total -= dir.size
+		removed++
+		reclaimed += dir.size
+		logging.Debugf("Temp cleanup: removed %s (%s, last modified %s)", dir.path, dir.size, dir.modTime.Format(time.RFC3339))
+	}
+	if removed > 0 {
+		logging.Infof("Temp cleanup reclaimed %s from %d preserved build directories, %d left (%s)", reclaimed, removed, len(remaining), total)
+	}
+
+	t.mux.Lock()
+	t.stats.Runs++
+	t.stats.Removed += removed
+	t.stats.ReclaimedBytes += reclaimed
+	t.stats.Preserved = len(remaining)
+	t.stats.PreservedBytes = total
+	t.stats.LastRun = time.Now()
+	t.mux.Unlock()
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/pkg/protocol"
+)
+
+// toolVersionTimeout bounds each "<tool> --version" call during server startup
+const toolVersionTimeout = 5 * time.Second
+
+// detectToolVersions returns the first line of "<tool> --version" for every installed probed tool
+func detectToolVersions() map[string]string {
+	versions := make(map[string]string)
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+	for _, tool := range buildutil.ProbedTools() {
+		if _, err := exec.LookPath(tool); err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(tool string) {
+			defer wg.Done()
+			arg := "--version"
+			if tool == "go" {
+				arg = "version"
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), toolVersionTimeout)
+			defer cancel()
+			output, _ := exec.CommandContext(ctx, tool, arg).CombinedOutput()
+			version := strings.TrimSpace(strings.SplitN(strings.TrimSpace(string(output)), "\n", 2)[0])
+			if len(version) > 120 {
+				version = version[:120]
+			}
+			if version == "" {
+				version = "unknown"
+			}
+			mux.Lock()
+			versions[tool] = version
+			mux.Unlock()
+		}(tool)
+	}
+	wg.Wait()
+	return versions
+}
+
+// environments returns the environments of the server's own configuration
+func (s *Server) environments() map[string]protocol.AdvertisedEnvironment {
+	if len(s.config.Build.Environments) == 0 {
+		return nil
+	}
+	envs := make(map[string]protocol.AdvertisedEnvironment)
+	for name, env := range s.config.Build.Environments {
+		envs[name] = protocol.AdvertisedEnvironment{Language: env.Name, Command: env.CommandLine()}
+	}
+	return envs
+}
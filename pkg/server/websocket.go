@@ -0,0 +1,49 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"boltbuild/internal/logging"
+	"boltbuild/internal/transport"
+	"boltbuild/pkg/config"
+)
+
+// startWebSocket serves the build protocol over WebSocket on its own HTTP port, so servers
+// can sit behind HTTP-only reverse proxies. With TLS enabled the endpoint is HTTPS (wss://).
+func (s *Server) startWebSocket(cfg config.WebSocketConfig, tlsConfig *tls.Config) {
+	path := cfg.Path
+	if path == "" {
+		path = transport.WebSocketDefaultPath
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, s.handleWebSocket)
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           mux,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logging.Infof("WebSocket endpoint listening on port %d at %s", cfg.Port, path)
+
+	var err error
+	if tlsConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	logging.Infof("Warning: WebSocket endpoint stopped: %v", err)
+}
+
+// handleWebSocket upgrades a request and handles it like a direct client connection
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn := transport.AcceptWebSocket(w, r)
+	if conn == nil {
+		return
+	}
+	// Access checks see the proxy's address when there is one; forwarded headers are not trusted
+	s.handleClientConnection(conn)
+}
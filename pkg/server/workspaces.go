@@ -0,0 +1,184 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/config"
+	"boltbuild/pkg/protocol"
+)
+
+// workspaceManifestPath records which project files were synced into a persistent workspace
+const workspaceManifestPath = buildutil.BuildMetadataDir + "/workspace.json"
+
+// workspacesDir is the default location of persistent workspaces, below the temp directory
+const workspacesDir = "boltbuild-workspaces"
+
+// workspaceStore manages the persistent workspaces of a server
+type workspaceStore struct {
+	dir   string
+	locks map[string]*sync.Mutex
+	mux   sync.Mutex
+}
+
+// newWorkspaceStore creates the workspace store of a server, by default in its temp directory,
+// nil when disabled
+func newWorkspaceStore(cfg config.WorkspacesConfig, tempDir string) *workspaceStore {
+	if !cfg.Enabled {
+		return nil
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(tempDir, workspacesDir)
+	}
+	return &workspaceStore{dir: dir, locks: make(map[string]*sync.Mutex)}
+}
+
+// path is the workspace of a client's project; clients only ever reach their own workspaces
+func (w *workspaceStore) path(owner, name string) string {
+	return filepath.Join(w.dir, buildutil.SafeName(owner), buildutil.SafeName(name))
+}
+
+// lock serializes builds in one workspace and returns the unlock function
+func (w *workspaceStore) lock(path string) func() {
+	w.mux.Lock()
+	lock, exists := w.locks[path]
+	if !exists {
+		lock = &sync.Mutex{}
+		w.locks[path] = lock
+	}
+	w.mux.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// workspaceFor resolves the persistent workspace a request asks for, "" for a fresh temp directory
+func (s *Server) workspaceFor(clientConn *ClientConnection, request protocol.BuildRequest) string {
+	if request.Workspace == "" || s.workspaces == nil {
+		return ""
+	}
+	return s.workspaces.path(clientOwner(clientConn), request.Workspace)
+}
+
+// workspaceSync describes how a persistent workspace was prepared for a build
+type workspaceSync struct {
+	Written     int  // Project files new or changed since the last build
+	Removed     int  // Files deleted: gone from the project, or stale outputs outside preserve_paths
+	Incremental bool // The workspace held the state of a previous build
+}
+
+// preserved reports whether a workspace-relative path lies in one of the preserved directories
+func preserved(relativePath string, preservePaths []string) bool {
+	for _, path := range preservePaths {
+		path = strings.Trim(filepath.ToSlash(path), "/")
+		if relativePath == path || strings.HasPrefix(relativePath, path+"/") {
+			return true
+		}
+	}
+	return strings.HasPrefix(relativePath, buildutil.BuildMetadataDir+"/")
+}
+
+// syncWorkspace brings a persistent workspace up to date with the project files: only changed
+// files are written, so unchanged sources keep their timestamps for incremental builds, and
+// files removed from the project since the last sync are deleted. Build outputs are kept, or
+// with preserve_paths only the intermediate directories listed there. Clean requests start over.
+func (s *Server) syncWorkspace(projectDir string, request protocol.BuildRequest) (workspaceSync, error) {
+	var result workspaceSync
+	if request.Clean {
+		if err := os.RemoveAll(projectDir); err != nil {
+			return result, fmt.Errorf("failed to clean workspace: %v", err)
+		}
+	}
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return result, err
+	}
+
+	manifestPath := filepath.Join(projectDir, filepath.FromSlash(workspaceManifestPath))
+	previous := make(map[string]string)
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		result.Incremental = json.Unmarshal(data, &previous) == nil
+	}
+
+	maxSize := s.config.Build.MaxWorkspaceSize
+	var total protocol.ByteSize
+	current := make(map[string]string, len(request.Files))
+	changed := make(map[string]string)
+	for name, content := range request.Files {
+		total += protocol.ByteSize(len(content))
+		if maxSize > 0 && total > maxSize {
+			return result, fmt.Errorf("project files exceed workspace size limit of %s", maxSize)
+		}
+
+		// The manifest and the removal of stale files only ever see paths inside the workspace
+		relativePath, err := buildutil.CleanUploadPath(name)
+		if err != nil {
+			return result, err
+		}
+		sum := sha256.Sum256([]byte(content))
+		current[relativePath] = hex.EncodeToString(sum[:])
+		fullPath := filepath.Join(projectDir, filepath.FromSlash(relativePath))
+		if _, statErr := os.Stat(fullPath); statErr == nil && previous[relativePath] == current[relativePath] {
+			continue
+		}
+		changed[relativePath] = content
+	}
+
+	if err := s.writeProjectFiles(projectDir, changed); err != nil {
+		return result, err
+	}
+	result.Written = len(changed)
+
+	for name := range previous {
+		relativePath, err := buildutil.CleanUploadPath(name)
+		if err != nil {
+			logging.Infof("Warning: Not removing %s from workspace %s: %v", name, projectDir, err)
+			continue
+		}
+		if _, exists := current[relativePath]; exists {
+			continue
+		}
+		fullPath := filepath.Join(projectDir, filepath.FromSlash(relativePath))
+		if err := os.Remove(fullPath); err == nil {
+			result.Removed++
+		}
+	}
+
+	// Only the listed intermediate directories survive besides the project itself
+	if len(request.PreservePaths) > 0 {
+		filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			relativePath, err := filepath.Rel(projectDir, path)
+			if err != nil {
+				return nil
+			}
+			relativePath = filepath.ToSlash(relativePath)
+			if _, exists := current[relativePath]; exists || preserved(relativePath, request.PreservePaths) {
+				return nil
+			}
+			if os.Remove(path) == nil {
+				result.Removed++
+			}
+			return nil
+		})
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return result, err
+	}
+	return result, os.WriteFile(manifestPath, data, 0644)
+}
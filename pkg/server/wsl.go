@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"boltbuild/internal/buildutil"
+	"boltbuild/internal/logging"
+	"boltbuild/pkg/protocol"
+)
+
+// detectWSLDistributions lists the WSL distributions installed on a Windows server
+func detectWSLDistributions() []string {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	path, err := exec.LookPath("wsl.exe")
+	if err != nil {
+		return nil
+	}
+
+	out, err := exec.Command(path, "--list", "--quiet").Output()
+	if err != nil {
+		logging.Debugf("Failed to list WSL distributions: %v", err)
+		return nil
+	}
+
+	var distributions []string
+	for _, line := range strings.Split(decodeWSLOutput(out), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			distributions = append(distributions, name)
+		}
+	}
+	return distributions
+}
+
+// decodeWSLOutput converts wsl.exe output, which is UTF-16LE, to a string
+func decodeWSLOutput(out []byte) string {
+	if len(out) < 2 || out[1] != 0 {
+		return string(out) // Plain output (WSL_UTF8=1)
+	}
+	units := make([]uint16, len(out)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(out[2*i:])
+	}
+	return strings.TrimPrefix(string(utf16.Decode(units)), "\ufeff")
+}
+
+// wslPath translates a Windows path to its location inside WSL (C:\build\x -> /mnt/c/build/x)
+func wslPath(path string) (string, error) {
+	volume := filepath.VolumeName(path)
+	if len(volume) != 2 || volume[1] != ':' {
+		return "", fmt.Errorf("cannot translate %s to a WSL path", path)
+	}
+	rest := strings.ReplaceAll(path[len(volume):], `\`, "/")
+	return "/mnt/" + strings.ToLower(volume[:1]) + rest, nil
+}
+
+// wslCommand runs a build command inside the requested WSL distribution
+func (s *Server) wslCommand(ctx context.Context, request protocol.BuildRequest, executionDir string, cmdParts []string) (*exec.Cmd, error) {
+	if !buildutil.ContainsString(s.wslDistributions, request.WSL) {
+		return nil, fmt.Errorf("environment %s requires WSL distribution %s which is not installed on this server", request.Environment, request.WSL)
+	}
+
+	dir, err := wslPath(executionDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Environment variables are passed through env(1), WSLENV would need every name listed
+	args := []string{"--distribution", request.WSL, "--cd", dir, "--exec", "env"}
+	keys := make([]string, 0, len(request.EnvVars))
+	for key := range request.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key+"="+request.EnvVars[key])
+	}
+	args = append(args, cmdParts...)
+
+	logging.Debugf("%s WSL build: wsl.exe %v", request.Environment, s.config.Server.BuildEnv.ScrubArgs(args))
+
+	cmd := exec.CommandContext(ctx, "wsl.exe", args...)
+	cmd.Dir = executionDir
+	cmd.WaitDelay = cancelWaitDelay
+	return cmd, nil
+}